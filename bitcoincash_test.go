@@ -0,0 +1,107 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestBitcoinCashChain(t *testing.T) {
+	h := newHarness(t, walletUser("bch-user", currencies.BitcoinCash, currencies.Main, "bch-address", "bch-change"))
+	defer h.Close()
+	token := h.login("bch-user")
+
+	if _, ok := h.bchMain.UsersData()["bch-address"]; !ok {
+		t.Errorf("bch main UsersData: bch-address is not sent")
+	}
+	if _, ok := h.btcMain.UsersData()["bch-address"]; ok {
+		t.Errorf("btc main UsersData: bitcoin cash address is sent to bitcoin")
+	}
+
+	h.bchMain.AddSpendableOut(&btcpb.AddSpOut{
+		UserID:      "bch-user",
+		TxID:        "txid-bch-funding",
+		TxOutAmount: 100000,
+		Address:     "bch-address",
+		TxStatus:    store.TxStatusInBlockConfirmedIncoming,
+	})
+	h.bchMain.NewTransaction(&btcpb.BTCTransaction{
+		UserID:      "bch-user",
+		TxID:        "txid-bch",
+		TxAddress:   []string{"bch-address"},
+		TxStatus:    store.TxStatusAppearedInMempoolOutcoming,
+		TxOutAmount: 50000,
+		TxFee:       1000,
+		Rbf:         true,
+		TxInputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "bch-address", Amount: 100000},
+		},
+		TxOutputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "bch-receiver", Amount: 50000},
+			{Address: "bch-change", Amount: 49000},
+		},
+		WalletsInput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "bch-user", Address: "bch-address", Amount: 100000},
+		},
+		WalletsOutput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "bch-user", Address: "bch-change", Amount: 49000, TxOutIndex: 1},
+		},
+	})
+	h.bchMain.AddSpendableOut(&btcpb.AddSpOut{
+		UserID:      "bch-user",
+		TxID:        "txid-bch",
+		TxOutID:     1,
+		TxOutAmount: 49000,
+		Address:     "bch-change",
+		TxStatus:    store.TxStatusAppearedInMempoolIncoming,
+	})
+	h.bchMain.DeleteSpendableOut(&btcpb.ReqDeleteSpOut{
+		UserID:    "bch-user",
+		TxID:      "txid-bch-funding",
+		Address:   "bch-address",
+		SpendTxID: "txid-bch",
+	})
+	h.waitFor("pending bch tx", func() bool {
+		spent, _ := h.userStore.GetTxSpentOutputs(currencies.BitcoinCash, currencies.Main, "bch-user", "txid-bch")
+		own, _ := h.userStore.GetTxSpendableOutputs(currencies.BitcoinCash, currencies.Main, "bch-user", "txid-bch")
+		return len(spent) == 1 && len(own) == 1
+	})
+	if _, err := h.userStore.FindUserTransaction("bch-user", currencies.Bitcoin, currencies.Main, "txid-bch"); err == nil {
+		t.Errorf("bitcoin cash tx is stored as bitcoin one")
+	}
+
+	h.waitForTxNotification("bch transaction notification", "txid-bch", func(n *store.WsTxNotify) bool {
+		return n.CurrencyID == currencies.BitcoinCash
+	})
+
+	// bitcoin cash nodes don't relay replacements, only a child tx may speed it up
+	w := h.do(http.MethodGet, "/api/v1/transaction/feebump/145/0/txid-bch", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET feebump: %d %s", w.Code, w.Body.String())
+	}
+	resp := struct {
+		FeeBump store.FeeBump `json:"feebump"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal: %s", err.Error())
+	}
+	if resp.FeeBump.Replacement != nil {
+		t.Errorf("replacement on bitcoin cash: %+v", resp.FeeBump.Replacement)
+	}
+	if c := resp.FeeBump.CPFP; c == nil || c.Inputs[0].TxID != "txid-bch" {
+		t.Errorf("cpfp: got %+v", c)
+	}
+
+	if versions := h.nodeVersions(); versions["bch"]["main"].Branch != "mock" {
+		t.Errorf("bch node version: got %+v", versions)
+	}
+}
@@ -6,51 +6,73 @@ See LICENSE for details
 package btc
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"google.golang.org/grpc"
-	mgo "gopkg.in/mgo.v2"
 
+	"github.com/Multy-io/Multy-back/chain"
 	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/feeestimator"
+	"github.com/Multy-io/Multy-back/logger"
 	pb "github.com/Multy-io/Multy-back/node-streamer/btc"
 	"github.com/Multy-io/Multy-back/store"
 	nsq "github.com/bitly/go-nsq"
-	"github.com/jekabolt/slf"
 )
 
-// BTCConn is a main struct of package
+// BTCConn is a main struct of package, it connects node services of all networks of a bitcoin-like chain
 type BTCConn struct {
-	NsqProducer      *nsq.Producer // a producer for sending data to clients
-	CliTest          pb.NodeCommuunicationsClient
-	CliMain          pb.NodeCommuunicationsClient
-	WatchAddressTest chan pb.WatchAddress
-	WatchAddressMain chan pb.WatchAddress
+	Params      Params
+	NsqProducer *nsq.Producer // a producer for sending data to clients
 
-	BtcMempool     sync.Map
-	BtcMempoolTest sync.Map
-
-	VersionMain store.NodeVersion
-	VersionTest store.NodeVersion
+	nodes map[int]*node
 
 	Resync sync.Map
+
+	// raw txs are checked against spendable outputs of the user
+	userStore store.UserStore
 }
 
-var log = slf.WithContext("btc")
+// node is a node service of a single network, calls go to the active one of its endpoints
+type node struct {
+	cli   *failoverClient
+	watch chan pb.WatchAddress
+
+	// fee estimation from mempool of the network
+	fees *feeestimator.Estimator
+
+	// node service streams supervisor
+	streams *chain.Supervisor
+
+	// health of node service endpoints, streams are reopened when the active one changes
+	endpoints *chain.Failover
+
+	// TLS certificates of the connections, nil if they are plaintext
+	certs *chain.Certificates
+}
 
-//InitHandlers init nsq mongo and ws connection to node
-// return main client , test client , err
-func InitHandlers(dbConf *store.Conf, coinTypes []store.CoinType, nsqAddr string) (*BTCConn, error) {
+var log = logger.WithContext("btc")
+
+// defaultConfirmations is a depth after which txs are confirmed if config has none
+const defaultConfirmations = 6
+
+// localConfirmations is the default depth on regtest, a single block mined on demand confirms txs
+const localConfirmations = 1
+
+// InitHandlers init nsq and grpc connections to node services of the chain,
+// every network of the chain in config gets its own node
+func InitHandlers(params Params, userStore store.UserStore, coinTypes []store.CoinType, nsqAddr string) (*BTCConn, error) {
 	//declare pacakge struct
 	cli := &BTCConn{
-		BtcMempool:     sync.Map{},
-		BtcMempoolTest: sync.Map{},
-		Resync:         sync.Map{},
+		Params:    params,
+		nodes:     map[int]*node{},
+		Resync:    sync.Map{},
+		userStore: userStore,
 	}
 
-	cli.WatchAddressMain = make(chan pb.WatchAddress)
-	cli.WatchAddressTest = make(chan pb.WatchAddress)
-
 	config := nsq.NewConfig()
 	p, err := nsq.NewProducer(nsqAddr, config)
 	if err != nil {
@@ -58,93 +80,140 @@ func InitHandlers(dbConf *store.Conf, coinTypes []store.CoinType, nsqAddr string
 	}
 
 	cli.NsqProducer = p
-	log.Infof("InitHandlers: nsq.NewProducer: √")
-
-	addr := []string{dbConf.Address}
+	log.Infof("InitHandlers: %s: nsq.NewProducer: √", params.Name)
 
-	mongoDBDial := &mgo.DialInfo{
-		Addrs:    addr,
-		Username: dbConf.Username,
-		Password: dbConf.Password,
+	for _, ct := range coinTypes {
+		if ct.СurrencyID != params.CurrencyID {
+			continue
+		}
+		if ct.Confirmations <= 0 {
+			ct.Confirmations = defaultConfirmations
+			if ct.NetworkID == currencies.Regtest {
+				ct.Confirmations = localConfirmations
+			}
+		}
+		n, err := cli.initNode(ct)
+		if err != nil {
+			return cli, fmt.Errorf("initNode: %s", err.Error())
+		}
+		cli.nodes[ct.NetworkID] = n
+		log.Infof("InitHandlers: %s: initGrpcClient: netID:%d √ %v", params.Name, ct.NetworkID, n.endpoints.ActiveEndpoint())
+	}
+	if len(cli.nodes) == 0 {
+		return cli, fmt.Errorf("no %s node services in config", params.Name)
 	}
 
-	db, err := mgo.DialWithInfo(mongoDBDial)
-	if err != nil {
-		return nil, err
+	return cli, nil
+}
+
+// initNode connects to node service endpoints of the network, picks a healthy one
+// and starts streams from it
+func (b *BTCConn) initNode(ct store.CoinType) (*node, error) {
+	name := fmt.Sprintf("%s %s", b.Params.Name, networkName(ct.NetworkID))
+	maxLag := ct.MaxLag
+	if maxLag <= 0 {
+		maxLag = b.Params.MaxLag
 	}
+	var certs *chain.Certificates
+	if ct.TLS != nil {
+		loaded, err := chain.LoadCertificates(*ct.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("chain.LoadCertificates: %s", err.Error())
+		}
+		certs = loaded
+	}
+	cli, err := newFailoverClient(name, ct.Endpoints(), int64(maxLag), certs)
 	if err != nil {
-		log.Errorf("RunProcess: can't connect to DB: %s", err.Error())
-		return cli, fmt.Errorf("mgo.Dial: %s", err.Error())
+		return nil, fmt.Errorf("initGrpcClient: %s", err.Error())
 	}
-	log.Infof("InitHandlers: mgo.Dial: √")
-
-	usersData = db.DB(dbConf.DBUsers).C(store.TableUsers) // all db tables
-	exRate = db.DB(dbConf.DBStockExchangeRate).C("TableStockExchangeRate")
 
-	// main
-	txsData = db.DB(dbConf.DBTx).C(dbConf.TableTxsDataBTCMain)
-	spendableOutputs = db.DB(dbConf.DBTx).C(dbConf.TableSpendableOutputsBTCMain)
-	spentOutputs = db.DB(dbConf.DBTx).C(dbConf.TableSpentOutputsBTCMain)
+	n := &node{
+		cli:       cli,
+		watch:     make(chan pb.WatchAddress),
+		fees:      feeestimator.New(b.Params.CurrencyID, ct.NetworkID, feeestimator.NewMempool(feeestimator.DefaultBlocks), b.userStore, b.Params.feeStrategies()...).WithParams(b.Params.Blocks),
+		streams:   chain.NewSupervisor(name),
+		endpoints: cli.failover,
+		certs:     certs,
+	}
+	if err := n.fees.Restore(); err != nil {
+		log.Errorf("initNode: fees.Restore: %s", err.Error())
+	}
 
-	// test
-	txsDataTest = db.DB(dbConf.DBTx).C(dbConf.TableTxsDataBTCTest)
-	spendableOutputsTest = db.DB(dbConf.DBTx).C(dbConf.TableSpendableOutputsBTCTest)
-	spentOutputsTest = db.DB(dbConf.DBTx).C(dbConf.TableSpentOutputsBTCTest)
+	n.endpoints.Check()
+	n.endpoints.OnSwitch(n.streams.Reconnect)
+	n.streams.OnDisconnect(n.endpoints.Trigger)
+	n.endpoints.Run(time.Duration(ct.ProbeInterval)*time.Second, n.streams.Done())
 
-	restoreState = db.DB(dbConf.DBRestoreState).C(dbConf.TableState)
+	setGRPCHandlers(cli, n.streams, b.userStore, b.NsqProducer, b.Params.CurrencyID, ct.NetworkID, ct.Confirmations, n.watch, n.fees, &b.Resync)
+	return n, nil
+}
 
-	// setup main net
-	urlMain, err := fethCoinType(coinTypes, currencies.Bitcoin, currencies.Main)
-	if err != nil {
-		return cli, fmt.Errorf("fethCoinType: %s", err.Error())
+// Versions returns versions of active node services by names of their networks
+func (b *BTCConn) Versions() map[string]store.NodeVersion {
+	versions := map[string]store.NodeVersion{}
+	for networkID, n := range b.nodes {
+		versions[networkName(networkID)] = n.endpoints.ActiveEndpoint().Version
 	}
+	return versions
+}
 
-	cliMain, err := initGrpcClient(urlMain)
-	if err != nil {
-		return cli, fmt.Errorf("initGrpcClient: %s", err.Error())
+// Endpoints returns urls of active node services by names of their networks
+func (b *BTCConn) Endpoints() map[string]string {
+	endpoints := map[string]string{}
+	for networkID, n := range b.nodes {
+		endpoints[networkName(networkID)] = n.endpoints.ActiveEndpoint().URL
 	}
+	return endpoints
+}
 
-	setGRPCHandlers(cliMain, cli.NsqProducer, currencies.Main, cli.WatchAddressMain, cli.BtcMempool, cli.Resync)
-
-	cli.CliMain = cliMain
-	log.Infof("InitHandlers: initGrpcClient: Main: √")
-
-	// setup testnet
-	urlTest, err := fethCoinType(coinTypes, currencies.Bitcoin, currencies.Test)
-	if err != nil {
-		return cli, fmt.Errorf("fethCoinType: %s", err.Error())
+// ReloadCertificates re-reads TLS certificates of node services of all networks,
+// broken connections are redialed with them right away. Networks failed to reload
+// keep certificates loaded before and don't stop others from reloading.
+func (b *BTCConn) ReloadCertificates() error {
+	failed := []string{}
+	for networkID, n := range b.nodes {
+		if n.certs == nil {
+			continue
+		}
+		if err := n.certs.Reload(); err != nil {
+			log.Errorf("ReloadCertificates: %s %s: %s", b.Params.Name, networkName(networkID), err.Error())
+			failed = append(failed, fmt.Sprintf("%s %s: %s", b.Params.Name, networkName(networkID), err.Error()))
+			continue
+		}
+		n.cli.redial()
 	}
-	cliTest, err := initGrpcClient(urlTest)
-	if err != nil {
-		return cli, fmt.Errorf("initGrpcClient: %s", err.Error())
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
 	}
-	setGRPCHandlers(cliTest, cli.NsqProducer, currencies.Test, cli.WatchAddressTest, cli.BtcMempoolTest, cli.Resync)
+	return nil
+}
 
-	cli.CliTest = cliTest
-	log.Infof("InitHandlers: initGrpcClient: Test: √")
+// Fees returns fee estimator of the network, nil if the network is not configured
+func (b *BTCConn) Fees(networkID int) *feeestimator.Estimator {
+	if n, ok := b.nodes[networkID]; ok {
+		return n.fees
+	}
+	return nil
+}
 
-	return cli, nil
+func networkName(networkID int) string {
+	switch networkID {
+	case currencies.Main:
+		return "main"
+	case currencies.Regtest:
+		return "regtest"
+	}
+	return "test"
 }
 
-func initGrpcClient(url string) (pb.NodeCommuunicationsClient, error) {
-	conn, err := grpc.Dial(url, grpc.WithInsecure())
+// initGrpcClient dials node service, connections are secured with TLS if certs are given
+func initGrpcClient(url string, certs *chain.Certificates) (*grpc.ClientConn, error) {
+	conn, err := grpc.Dial(url, chain.TransportOption(certs))
 	if err != nil {
 		log.Errorf("initGrpcClient: grpc.Dial: %s", err.Error())
 		return nil, err
 	}
-
-	// Create a new  client
-	client := pb.NewNodeCommuunicationsClient(conn)
-	return client, nil
-}
-
-func fethCoinType(coinTypes []store.CoinType, currencyID, networkID int) (string, error) {
-	for _, ct := range coinTypes {
-		if ct.СurrencyID == currencyID && ct.NetworkID == networkID {
-			return ct.GRPCUrl, nil
-		}
-	}
-	return "", fmt.Errorf("fethCoinType: no such coin in config")
+	return conn, nil
 }
 
 // // BtcTransaction stuct for ws notifications
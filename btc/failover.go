@@ -0,0 +1,125 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package btc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/Multy-io/Multy-back/chain"
+	pb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// failoverClient sends every call to the node service endpoint which is active now
+type failoverClient struct {
+	conns    []*grpc.ClientConn
+	clients  []pb.NodeCommuunicationsClient
+	failover *chain.Failover
+}
+
+func newFailoverClient(name string, urls []string, maxLag int64, certs *chain.Certificates) (*failoverClient, error) {
+	f := &failoverClient{}
+	for _, url := range urls {
+		conn, err := initGrpcClient(url, certs)
+		if err != nil {
+			return nil, err
+		}
+		f.conns = append(f.conns, conn)
+		f.clients = append(f.clients, pb.NewNodeCommuunicationsClient(conn))
+	}
+	f.failover = chain.NewFailover(name, urls, maxLag, f.probe)
+	return f, nil
+}
+
+// redial makes broken connections to all endpoints reconnect right away
+func (f *failoverClient) redial() {
+	for _, conn := range f.conns {
+		conn.ResetConnectBackoff()
+	}
+}
+
+func (f *failoverClient) probe(ctx context.Context, endpoint int) (store.NodeVersion, int64, error) {
+	cli := f.clients[endpoint]
+	sv, err := cli.ServiceInfo(ctx, &pb.Empty{})
+	if err != nil {
+		return store.NodeVersion{}, 0, err
+	}
+	bh, err := cli.EventGetBlockHeight(ctx, &pb.Empty{})
+	if err != nil {
+		return store.NodeVersion{}, 0, err
+	}
+	version := store.NodeVersion{
+		Branch: sv.GetBranch(),
+		Commit: sv.GetCommit(),
+	}
+	return version, bh.GetHeight(), nil
+}
+
+func (f *failoverClient) active() pb.NodeCommuunicationsClient {
+	return f.clients[f.failover.Active()]
+}
+
+func (f *failoverClient) ServiceInfo(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (*pb.ServiceVersion, error) {
+	return f.active().ServiceInfo(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventInitialAdd(ctx context.Context, in *pb.UsersData, opts ...grpc.CallOption) (*pb.ReplyInfo, error) {
+	return f.active().EventInitialAdd(ctx, in, opts...)
+}
+
+func (f *failoverClient) SyncState(ctx context.Context, in *pb.BlockHeight, opts ...grpc.CallOption) (*pb.ReplyInfo, error) {
+	return f.active().SyncState(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventAddNewAddress(ctx context.Context, in *pb.WatchAddress, opts ...grpc.CallOption) (*pb.ReplyInfo, error) {
+	return f.active().EventAddNewAddress(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventGetBlockHeight(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (*pb.BlockHeight, error) {
+	return f.active().EventGetBlockHeight(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventGetAllMempool(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (pb.NodeCommuunications_EventGetAllMempoolClient, error) {
+	return f.active().EventGetAllMempool(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventAddMempoolRecord(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (pb.NodeCommuunications_EventAddMempoolRecordClient, error) {
+	return f.active().EventAddMempoolRecord(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventDeleteMempool(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (pb.NodeCommuunications_EventDeleteMempoolClient, error) {
+	return f.active().EventDeleteMempool(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventResyncAddress(ctx context.Context, in *pb.AddressToResync, opts ...grpc.CallOption) (*pb.ReplyInfo, error) {
+	return f.active().EventResyncAddress(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventSendRawTx(ctx context.Context, in *pb.RawTx, opts ...grpc.CallOption) (*pb.ReplyInfo, error) {
+	return f.active().EventSendRawTx(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventDeleteSpendableOut(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (pb.NodeCommuunications_EventDeleteSpendableOutClient, error) {
+	return f.active().EventDeleteSpendableOut(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventNewBlock(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (pb.NodeCommuunications_EventNewBlockClient, error) {
+	return f.active().EventNewBlock(ctx, in, opts...)
+}
+
+func (f *failoverClient) EventAddSpendableOut(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (pb.NodeCommuunications_EventAddSpendableOutClient, error) {
+	return f.active().EventAddSpendableOut(ctx, in, opts...)
+}
+
+func (f *failoverClient) NewTx(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (pb.NodeCommuunications_NewTxClient, error) {
+	return f.active().NewTx(ctx, in, opts...)
+}
+
+func (f *failoverClient) ResyncAddress(ctx context.Context, in *pb.Empty, opts ...grpc.CallOption) (pb.NodeCommuunications_ResyncAddressClient, error) {
+	return f.active().ResyncAddress(ctx, in, opts...)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package btc
+
+import (
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// sizes of p2pkh txs parts in bytes, signed inputs are not known before the client signs them
+const (
+	txOverheadSize = 10
+	txInputSize    = 148
+	txOutputSize   = 34
+
+	// incrementalRelayFee is how much in satoshi per byte a replacement pays over the replaced tx
+	incrementalRelayFee = 1
+)
+
+func txSize(inputs, outputs int) int {
+	return txOverheadSize + inputs*txInputSize + outputs*txOutputSize
+}
+
+// FeeBump suggests a replacement of the pending tx of the user if it signals rbf and nodes
+// of the chain relay replacements, and a child tx spending its outputs so both are mined at the fast rate
+func (c *Chain) FeeBump(userID, txID string) (store.FeeBump, error) {
+	userStore := c.conn.userStore
+	tx, err := userStore.FindUserTransaction(userID, c.CurrencyID(), c.networkID, txID)
+	if err != nil {
+		return store.FeeBump{}, err
+	}
+	if !store.IsMempoolStatus(tx.TxStatus) {
+		return store.FeeBump{}, chain.ErrTxNotPending
+	}
+	rates, err := c.fees.Estimate()
+	if err != nil {
+		return store.FeeBump{}, err
+	}
+
+	spent, err := userStore.GetTxSpentOutputs(c.CurrencyID(), c.networkID, userID, txID)
+	if err != nil {
+		return store.FeeBump{}, err
+	}
+	own, err := userStore.GetTxSpendableOutputs(c.CurrencyID(), c.networkID, userID, txID)
+	if err != nil {
+		return store.FeeBump{}, err
+	}
+
+	size := txSize(len(tx.TxInputs), len(tx.TxOutputs))
+	fee := tx.TxFee
+	if fee <= 0 {
+		fee = sumAmounts(tx.TxInputs) - sumAmounts(tx.TxOutputs)
+	}
+	bump := store.FeeBump{
+		TxID:       txID,
+		RBF:        tx.RBF,
+		Size:       size,
+		Fee:        fee,
+		FeeRate:    int(fee) / size,
+		TargetRate: rates.Fast,
+	}
+	if tx.RBF && c.conn.Params.RBF {
+		bump.Replacement = replacement(tx, spent, own, fee, rates.Fast, c.conn.Params.DustLimit)
+	}
+	bump.CPFP = childPaysForParent(own, size, fee, rates.Fast, c.conn.Params.MinFeeRate, c.conn.Params.DustLimit)
+	return bump, nil
+}
+
+// replacement spends the same inputs to the same outputs paying the extra fee from the change,
+// it pays at least the target rate and more than the replaced tx by the incremental relay fee
+func replacement(tx store.MultyTX, spent []store.SpentOutput, own []store.SpendableOutputs, fee int64, rate int, dustLimit int64) *store.FeeBumpTx {
+	if len(spent) == 0 {
+		return nil
+	}
+	inputs := []store.SpendableOutputs{}
+	for _, out := range spent {
+		if out.Output == nil {
+			// spent before outputs were kept, the client can't sign the input
+			return nil
+		}
+		inputs = append(inputs, *out.Output)
+	}
+
+	size := txSize(len(inputs), len(tx.TxOutputs))
+	newFee := int64(rate * size)
+	if min := fee + int64(incrementalRelayFee*size); newFee < min {
+		newFee = min
+	}
+
+	outputs := append([]store.AddresAmount{}, tx.TxOutputs...)
+	change := -1
+	for i, out := range outputs {
+		for _, o := range own {
+			if o.Address == out.Address {
+				change = i
+			}
+		}
+	}
+	if change < 0 {
+		return nil
+	}
+	outputs[change].Amount -= newFee - fee
+	if outputs[change].Amount < dustLimit {
+		return nil
+	}
+	return &store.FeeBumpTx{
+		Inputs:  inputs,
+		Outputs: outputs,
+		Size:    size,
+		Fee:     newFee,
+		FeeRate: int(newFee) / size,
+	}
+}
+
+// childPaysForParent spends outputs of the user so the parent and the child together pay the target rate,
+// the child alone pays at least the min rate
+func childPaysForParent(own []store.SpendableOutputs, parentSize int, parentFee int64, rate, minRate int, dustLimit int64) *store.FeeBumpTx {
+	if len(own) == 0 {
+		return nil
+	}
+	var amount int64
+	for _, out := range own {
+		amount += out.TxOutAmount
+	}
+
+	size := txSize(len(own), 1)
+	fee := int64(rate*(parentSize+size)) - parentFee
+	if min := int64(minRate * size); fee < min {
+		fee = min
+	}
+	if amount-fee < dustLimit {
+		return nil
+	}
+	return &store.FeeBumpTx{
+		Inputs: own,
+		Outputs: []store.AddresAmount{{
+			Address: own[0].Address,
+			Amount:  amount - fee,
+		}},
+		Size:    size,
+		Fee:     fee,
+		FeeRate: int(fee) / size,
+	}
+}
+
+func sumAmounts(amounts []store.AddresAmount) int64 {
+	var sum int64
+	for _, a := range amounts {
+		sum += a.Amount
+	}
+	return sum
+}
@@ -1,5 +1,5 @@
 /*
-Copyright 2019 Idealnaya rabota LLC
+Copyright 2018 Idealnaya rabota LLC
 Licensed under Multy.io license.
 See LICENSE for details
 */
@@ -7,39 +7,41 @@ package btc
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"sync"
 	"time"
 
-	"gopkg.in/mgo.v2"
-
-	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/feeestimator"
 	pb "github.com/Multy-io/Multy-back/node-streamer/btc"
 	"github.com/Multy-io/Multy-back/store"
 	nsq "github.com/bitly/go-nsq"
-	"gopkg.in/mgo.v2/bson"
 )
 
-func setGRPCHandlers(cli pb.NodeCommuunicationsClient, nsqProducer *nsq.Producer, networtkID int, wa chan pb.WatchAddress, mempool sync.Map, resync sync.Map) {
+func setGRPCHandlers(cli pb.NodeCommuunicationsClient, streams *chain.Supervisor, userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID, confirmations int, wa chan pb.WatchAddress, fees *feeestimator.Estimator, resync *sync.Map) {
 
 	mempoolCh := make(chan interface{})
-	// initial fill mempool respectively network id
-	go func() {
+	// fill mempool respectively network id on start and after node service is reconnected
+	streams.RunSnapshot("EventGetAllMempool", func(ctx context.Context, alive func()) error {
 		// 		clientDeadline := time.Now().Add(time.Duration(100) * time.Second)
 		//      c, _ := context.WithDeadline(context.Background(), clientDeadline)
-		stream, err := cli.EventGetAllMempool(context.Background(), &pb.Empty{})
+		stream, err := cli.EventGetAllMempool(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
+			return fmt.Errorf("cli.EventGetAllMempool: %s", err.Error())
 		}
+		alive()
 
 		for {
 			mpRec, err := stream.Recv()
 			if err == io.EOF {
-				break
+				// whole mempool is received
+				return nil
 			}
 			if err != nil {
-				log.Errorf("setGRPCHandlers: client.EventGetAllMempool: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
 			mempoolCh <- store.MempoolRecord{
 				Category: int(mpRec.Category),
@@ -47,462 +49,188 @@ func setGRPCHandlers(cli pb.NodeCommuunicationsClient, nsqProducer *nsq.Producer
 			}
 
 		}
-	}()
+	})
 
 	// add transaction on every new tx on node
-	go func() {
-		stream, err := cli.EventAddMempoolRecord(context.Background(), &pb.Empty{})
+	streams.Run("EventAddMempoolRecord", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventAddMempoolRecord(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventAddMempoolRecord: %s", err.Error())
-			// return nil, err
+			return fmt.Errorf("cli.EventAddMempoolRecord: %s", err.Error())
 		}
+		alive()
 
 		for {
 			mpRec, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("setGRPCHandlers: client.EventAddMempoolRecord:stream.Recv: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
 			mempoolCh <- store.MempoolRecord{
 				Category: int(mpRec.Category),
 				HashTX:   mpRec.HashTX,
 			}
-
-			if err != nil {
-				log.Errorf("initGrpcClient: mpRates.Insert: %s", err.Error())
-			}
 		}
-	}()
+	})
 
-	go func() {
-		stream, err := cli.EventNewBlock(context.Background(), &pb.Empty{})
+	streams.Run("EventNewBlock", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventNewBlock(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventNewBlock: %s", err.Error())
-			// return nil, err
+			return fmt.Errorf("cli.EventNewBlock: %s", err.Error())
 		}
+		alive()
 
 		for {
 			h, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("setGRPCHandlers: client.EventNewBlock:stream.Recv: %s", err.Error())
-			}
-
-			query := bson.M{"currencyid": currencies.Bitcoin, "networkid": networtkID}
-			update := bson.M{
-				"$set": bson.M{
-					"blockheight": h.GetHeight(),
-				},
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
-			err = restoreState.Update(query, update)
-			if err == mgo.ErrNotFound {
-				restoreState.Insert(store.LastState{
-					BlockHeight: h.GetHeight(),
-					CurrencyID:  currencies.Bitcoin,
-					NetworkID:   networtkID,
-				})
+			block := store.Block{Height: h.GetHeight(), Hash: h.GetHash(), PrevHash: h.GetPrevHash()}
+			if !rollbackOrphaned(userStore, nsqProducer, currencyID, networtkID, block) {
+				continue
 			}
 
+			err = userStore.SetLastSyncBlockState(networtkID, currencyID, block)
 			if err != nil {
 				log.Errorf("initGrpcClient: cli.EventNewBlock: %s", err.Error())
 			}
+
+			updateConfirmations(userStore, nsqProducer, currencyID, networtkID, h.GetHeight(), confirmations)
+			fees.NewBlock()
 		}
-	}()
+	})
 
 	//deleting mempool record on block
-	go func() {
-		stream, err := cli.EventDeleteMempool(context.Background(), &pb.Empty{})
+	streams.Run("EventDeleteMempool", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventDeleteMempool(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
-			// return nil, err
+			return fmt.Errorf("cli.EventDeleteMempool: %s", err.Error())
 		}
+		alive()
 
 		for {
 			mpRec, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("initGrpcClient: cli.EventDeleteMempool:stream.Recv: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
 			mempoolCh <- mpRec.Hash
-
-			if err != nil {
-				log.Errorf("setGRPCHandlers:mpRates.Remove: %s", err.Error())
-			} else {
-				// log.Debugf("Tx removed: %s", mpRec.Hash)
-			}
 		}
 
-	}()
+	})
 
 	// new spendable output
-	go func() {
-		stream, err := cli.EventAddSpendableOut(context.Background(), &pb.Empty{})
+	streams.Run("EventAddSpendableOut", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventAddSpendableOut(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
-		}
-
-		spOutputs := &mgo.Collection{}
-		spend := &mgo.Collection{}
-		switch networtkID {
-		case currencies.Main:
-			spOutputs = spendableOutputs
-			spend = spentOutputs
-		case currencies.Test:
-			spOutputs = spendableOutputsTest
-			spend = spentOutputsTest
-		default:
-			log.Errorf("setGRPCHandlers: wrong networkID:")
+			return fmt.Errorf("cli.EventAddSpendableOut: %s", err.Error())
 		}
+		alive()
 
 		for {
 			gSpOut, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("initGrpcClient: cli.EventAddSpendableOut:stream.Recv: %s", err.Error())
-			}
-
-			query := bson.M{"userid": gSpOut.UserID, "txid": gSpOut.TxID, "address": gSpOut.Address}
-			err = spend.Find(query).One(nil)
-
-			if err == mgo.ErrNotFound {
-				user := store.User{}
-				sel := bson.M{"wallets.addresses.address": gSpOut.Address}
-				err = usersData.Find(sel).One(&user)
-				if err != nil && err != mgo.ErrNotFound {
-					log.Errorf("SetWsHandlers: cli.On newIncomingTx: %s", err)
-					return
-				}
-				spOut := generatedSpOutsToStore(gSpOut)
-
-				log.Infof("Add spendable output : %v", gSpOut.String())
-
-				exRates, err := GetLatestExchangeRate()
-				if err != nil {
-					log.Errorf("initGrpcClient: GetLatestExchangeRate: %s", err.Error())
-				}
-				spOut.StockExchangeRate = exRates
-
-				query := bson.M{"userid": spOut.UserID, "txid": spOut.TxID, "address": spOut.Address}
-				err = spOutputs.Find(query).One(nil)
-				if err == mgo.ErrNotFound {
-					//insertion
-					err := spOutputs.Insert(spOut)
-					if err != nil {
-						log.Errorf("Create spOutputs:txsData.Insert: %s", err.Error())
-					}
-					continue
-				}
-				if err != nil && err != mgo.ErrNotFound {
-					log.Errorf("Create spOutputs:spOutputs.Find %s", err.Error())
-					continue
-				}
-
-				update := bson.M{
-					"$set": bson.M{
-						"txstatus": spOut.TxStatus,
-					},
-				}
-				err = spOutputs.Update(query, update)
-				if err != nil {
-					log.Errorf("CreateSpendableOutputs:spendableOutputs.Update: %s", err.Error())
-				}
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
+			addSpendableOutput(userStore, currencyID, networtkID, gSpOut)
 		}
 
-	}()
+	})
 
 	// delete spendable output
-	go func() {
-		stream, err := cli.EventDeleteSpendableOut(context.Background(), &pb.Empty{})
+	streams.Run("EventDeleteSpendableOut", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventDeleteSpendableOut(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
-		}
-		spOutputs := &mgo.Collection{}
-		spend := &mgo.Collection{}
-		switch networtkID {
-		case currencies.Main:
-			spOutputs = spendableOutputs
-			spend = spentOutputs
-		case currencies.Test:
-			spOutputs = spendableOutputsTest
-			spend = spentOutputsTest
-		default:
-			log.Errorf("setGRPCHandlers: wrong networkID:")
+			return fmt.Errorf("cli.EventDeleteSpendableOut: %s", err.Error())
 		}
+		alive()
+
 		for {
 			del, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("initGrpcClient: cli.EventDeleteMempool:stream.Recv: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
-			i := 0
-			for {
-				//insert to spend collection
-				var once sync.Once
-
-				once.Do(func() {
-					err := spend.Insert(del)
-					if err != nil {
-						log.Errorf("DeleteSpendableOutputs:spend.Insert: %s", err)
-					}
-				})
-
-				query := bson.M{"userid": del.UserID, "txid": del.TxID, "address": del.Address}
-				log.Infof("-------- query delete %v\n", query)
-				err = spOutputs.Remove(query)
-				if err != nil {
-					log.Errorf("DeleteSpendableOutputs:spendableOutputs.Remove: %s", err.Error())
-				} else {
-					log.Infof("delete success √: %v", query)
-					break
-				}
-				i++
-				if i == 10 {
-					break
-				}
-				time.Sleep(time.Second * 3)
-			}
-			log.Debugf("DeleteSpendableOutputs:spendableOutputs.Remove: %s", err)
+			deleteSpendableOutput(userStore, nsqProducer, currencyID, networtkID, del)
 		}
-	}()
+	})
 
 	// add to transaction history record and send ws notification on tx
-	go func() {
-		stream, err := cli.NewTx(context.Background(), &pb.Empty{})
+	streams.Run("NewTx", func(ctx context.Context, alive func()) error {
+		stream, err := cli.NewTx(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
+			return fmt.Errorf("cli.NewTx: %s", err.Error())
 		}
+		alive()
 
 		for {
 			gTx, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("initGrpcClient: cli.NewTx:stream.Recv: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 			tx := generatedTxDataToStore(gTx)
 
-			setExchangeRates(&tx, gTx.Resync, tx.MempoolTime)
-			setUserID(&tx)
-			// setTxInfo(&tx)
-			user := store.User{}
-			// set wallet index and address index in input
-			for i := 0; i < len(tx.WalletsInput); i++ {
-				sel := bson.M{"wallets.addresses.address": tx.WalletsInput[i].Address.Address}
-				err := usersData.Find(sel).One(&user)
-				if err == mgo.ErrNotFound {
-					continue
-				} else if err != nil && err != mgo.ErrNotFound {
-					log.Errorf("initGrpcClient: cli.On newIncomingTx: %s", err)
-				}
-				for _, wallet := range user.Wallets {
-					for _, addr := range wallet.Adresses {
-						if addr.Address == tx.WalletsInput[i].Address.Address {
-							tx.WalletsInput[i].WalletIndex = wallet.WalletIndex
-							tx.WalletsInput[i].Address.AddressIndex = addr.AddressIndex
-						}
-					}
-				}
-			}
-
-			for i := 0; i < len(tx.WalletsOutput); i++ {
-				sel := bson.M{"wallets.addresses.address": tx.WalletsOutput[i].Address.Address}
-				err := usersData.Find(sel).One(&user)
-				if err == mgo.ErrNotFound {
-					continue
-				} else if err != nil && err != mgo.ErrNotFound {
-					log.Errorf("initGrpcClient: cli.On newIncomingTx: %s", err)
-				}
-
-				for _, wallet := range user.Wallets {
-					for _, addr := range wallet.Adresses {
-						if addr.Address == tx.WalletsOutput[i].Address.Address {
-							tx.WalletsOutput[i].WalletIndex = wallet.WalletIndex
-							tx.WalletsOutput[i].Address.AddressIndex = addr.AddressIndex
-						}
-					}
-				}
-			}
+			setExchangeRates(userStore, &tx, gTx.Resync, tx.MempoolTime)
+			setUserID(userStore, &tx, currencyID, networtkID)
+			setTxInfo(userStore, &tx, currencyID, networtkID)
 
 			log.Infof("New tx history in: %v out: %v", tx.WalletsInput, tx.WalletsOutput)
 
-			err = saveMultyTransaction(tx, networtkID, gTx.Resync)
+			err = saveMultyTransaction(userStore, tx, currencyID, networtkID)
 			if err != nil {
 				log.Errorf("initGrpcClient: saveMultyTransaction: %s", err)
 			}
-			updateWalletAndAddressDate(tx, networtkID)
+			updateWalletAndAddressDate(userStore, tx)
 			if !gTx.Resync {
-				sendNotifyToClients(tx, nsqProducer, networtkID)
+				sendNotifyToClients(tx, nsqProducer, currencyID, networtkID)
 			}
 		}
-	}()
+	})
 
 	// Resync tx history and spendable outputs
-	go func() {
-		spOutputs := &mgo.Collection{}
-		spend := &mgo.Collection{}
-		switch networtkID {
-		case currencies.Main:
-			spOutputs = spendableOutputs
-			spend = spentOutputs
-		case currencies.Test:
-			spOutputs = spendableOutputsTest
-			spend = spentOutputsTest
-		default:
-			log.Errorf("setGRPCHandlers: wrong networkID:")
-		}
-
-		stream, err := cli.ResyncAddress(context.Background(), &pb.Empty{})
+	streams.Run("ResyncAddress", func(ctx context.Context, alive func()) error {
+		stream, err := cli.ResyncAddress(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
+			return fmt.Errorf("cli.ResyncAddress: %s", err.Error())
 		}
+		alive()
 
 		for {
 			rTxs, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("initGrpcClient: cli.NewTx:stream.Recv: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
 			// tx history
 			for _, gTx := range rTxs.Txs {
 				tx := generatedTxDataToStore(gTx)
-				setExchangeRates(&tx, gTx.Resync, tx.MempoolTime)
-				setUserID(&tx)
-				user := store.User{}
-				// set wallet index and address index in input
-				for i := 0; i < len(tx.WalletsInput); i++ {
-					sel := bson.M{"wallets.addresses.address": tx.WalletsInput[i].Address.Address}
-					err := usersData.Find(sel).One(&user)
-					if err == mgo.ErrNotFound {
-						continue
-					} else if err != nil && err != mgo.ErrNotFound {
-						log.Errorf("initGrpcClient: cli.On newIncomingTx: %s", err)
-					}
-
-					for _, wallet := range user.Wallets {
-						for _, addr := range wallet.Adresses {
-							if addr.Address == tx.WalletsInput[i].Address.Address {
-								tx.WalletsInput[i].WalletIndex = wallet.WalletIndex
-								tx.WalletsInput[i].Address.AddressIndex = addr.AddressIndex
-							}
-						}
-					}
-				}
-				// set wallet index and address index in output
-				for i := 0; i < len(tx.WalletsOutput); i++ {
-					sel := bson.M{"wallets.addresses.address": tx.WalletsOutput[i].Address.Address}
-					err := usersData.Find(sel).One(&user)
-					if err == mgo.ErrNotFound {
-						continue
-					} else if err != nil && err != mgo.ErrNotFound {
-						log.Errorf("initGrpcClient: cli.On newIncomingTx: %s", err)
-					}
-
-					for _, wallet := range user.Wallets {
-						for _, addr := range wallet.Adresses {
-							if addr.Address == tx.WalletsOutput[i].Address.Address {
-								tx.WalletsOutput[i].WalletIndex = wallet.WalletIndex
-								tx.WalletsOutput[i].Address.AddressIndex = addr.AddressIndex
-							}
-						}
-					}
-				}
-				err = saveMultyTransaction(tx, networtkID, gTx.Resync)
+				setExchangeRates(userStore, &tx, gTx.Resync, tx.MempoolTime)
+				setUserID(userStore, &tx, currencyID, networtkID)
+				setTxInfo(userStore, &tx, currencyID, networtkID)
+
+				err = saveMultyTransaction(userStore, tx, currencyID, networtkID)
 				if err != nil {
 					log.Errorf("initGrpcClient: saveMultyTransaction: %s", err)
 				}
-				updateWalletAndAddressDate(tx, networtkID)
+				updateWalletAndAddressDate(userStore, tx)
 			}
 
 			// sp outs
 			for _, gSpOut := range rTxs.SpOuts {
-				query := bson.M{"userid": gSpOut.UserID, "txid": gSpOut.TxID, "address": gSpOut.Address}
-				err = spend.Find(query).One(nil)
-				if err == mgo.ErrNotFound {
-					user := store.User{}
-					sel := bson.M{"wallets.addresses.address": gSpOut.Address}
-					err = usersData.Find(sel).One(&user)
-					if err != nil && err != mgo.ErrNotFound {
-						log.Errorf("SetWsHandlers: cli.On newIncomingTx: %s", err)
-						return
-					}
-					spOut := generatedSpOutsToStore(gSpOut)
-					log.Infof("Add spendable output : %v", gSpOut.String())
-					exRates, err := GetLatestExchangeRate()
-					if err != nil {
-						log.Errorf("initGrpcClient: GetLatestExchangeRate: %s", err.Error())
-					}
-					spOut.StockExchangeRate = exRates
-
-					query := bson.M{"userid": spOut.UserID, "txid": spOut.TxID, "address": spOut.Address}
-					err = spOutputs.Find(query).One(nil)
-					if err == mgo.ErrNotFound {
-						//insertion
-						err := spOutputs.Insert(spOut)
-						if err != nil {
-							log.Errorf("Create spOutputs:txsData.Insert: %s", err.Error())
-						}
-						continue
-					}
-					if err != nil && err != mgo.ErrNotFound {
-						log.Errorf("Create spOutputs:spOutputs.Find %s", err.Error())
-						continue
-					}
-					update := bson.M{
-						"$set": bson.M{
-							"txstatus": spOut.TxStatus,
-						},
-					}
-					err = spOutputs.Update(query, update)
-					if err != nil {
-						log.Errorf("CreateSpendableOutputs:spendableOutputs.Update: %s", err.Error())
-					}
-				}
+				addSpendableOutput(userStore, currencyID, networtkID, gSpOut)
 			}
 
 			// del sp outs
 			for _, del := range rTxs.SpOutDelete {
-				i := 0
-				for {
-					//insert to spend collection
-					err = spend.Insert(del)
-					if err != nil {
-						log.Errorf("DeleteSpendableOutputs:spend.Insert: %s", err)
-					}
-					query := bson.M{"userid": del.UserID, "txid": del.TxID, "address": del.Address}
-					log.Infof("-------- query delete %v\n", query)
-					err = spOutputs.Remove(query)
-					if err != nil {
-						log.Errorf("DeleteSpendableOutputs:spendableOutputs.Remove: %s", err.Error())
-					} else {
-						log.Infof("delete success √: %v", query)
-						break
-					}
-					i++
-					if i == 10 {
-						break
-					}
-					time.Sleep(time.Second * 3)
-				}
+				deleteSpendableOutput(userStore, nsqProducer, currencyID, networtkID, del)
 			}
 			if len(rTxs.Txs) > 0 {
 				resync.Delete(rTxs.Txs[0].TxAddress[0])
@@ -510,34 +238,48 @@ func setGRPCHandlers(cli pb.NodeCommuunicationsClient, nsqProducer *nsq.Producer
 
 		}
 
-	}()
+	})
+
+	// watch for channel and push to node,
+	// the address failed to be added is pushed again once the node service is back
+	var watched *pb.WatchAddress
+	streams.Run("EventAddNewAddress", func(ctx context.Context, alive func()) error {
+		if _, err := cli.ServiceInfo(ctx, &pb.Empty{}); err != nil {
+			return fmt.Errorf("cli.ServiceInfo: %s", err.Error())
+		}
+		alive()
 
-	// watch for channel and push to node
-	go func() {
 		for {
-			select {
-			case addr := <-wa:
-				a := addr
-				rp, err := cli.EventAddNewAddress(context.Background(), &a)
-				if err != nil {
-					log.Errorf("NewAddressNode: cli.EventAddNewAddress %s\n", err.Error())
+			if watched == nil {
+				select {
+				case addr := <-wa:
+					watched = &addr
+				case <-ctx.Done():
+					return ctx.Err()
 				}
-				log.Debugf("EventAddNewAddress Reply %s", rp)
-
-				rp, err = cli.EventResyncAddress(context.Background(), &pb.AddressToResync{
-					Address:      addr.GetAddress(),
-					UserID:       addr.GetUserID(),
-					WalletIndex:  addr.GetWalletIndex(),
-					AddressIndex: addr.GetWalletIndex(),
-				})
-				if err != nil {
-					log.Errorf("EventResyncAddress: cli.EventResyncAddress %s\n", err.Error())
-				}
-				log.Debugf("EventResyncAddress Reply %s", rp)
+			}
 
+			rp, err := cli.EventAddNewAddress(ctx, watched)
+			if err != nil {
+				return fmt.Errorf("cli.EventAddNewAddress: %s", err.Error())
+			}
+			log.Debugf("EventAddNewAddress Reply %s", rp)
+
+			rp, err = cli.EventResyncAddress(ctx, &pb.AddressToResync{
+				Address:      watched.GetAddress(),
+				UserID:       watched.GetUserID(),
+				WalletIndex:  watched.GetWalletIndex(),
+				AddressIndex: watched.GetWalletIndex(),
+			})
+			if err != nil {
+				return fmt.Errorf("cli.EventResyncAddress: %s", err.Error())
 			}
+			log.Debugf("EventResyncAddress Reply %s", rp)
+
+			watched = nil
+			alive()
 		}
-	}()
+	})
 
 	go func() {
 		for {
@@ -546,12 +288,75 @@ func setGRPCHandlers(cli pb.NodeCommuunicationsClient, nsqProducer *nsq.Producer
 			// 	log.Errorf("Not found type: %v", v)
 			case string:
 				// delete tx from pool
-				mempool.Delete(v)
+				fees.Mempool().Remove(v)
 			case store.MempoolRecord:
 				// add tx to pool
-				mempool.Store(v.HashTX, v.Category)
+				fees.Mempool().Add(v.HashTX, v.Category)
 			}
 		}
 	}()
 
 }
+
+// addSpendableOutput stores the output unless a tx spending it was seen already
+func addSpendableOutput(userStore store.UserStore, currencyID, networtkID int, gSpOut *pb.AddSpOut) {
+	spent, err := userStore.IsSpentOutput(currencyID, networtkID, gSpOut.UserID, gSpOut.TxID, gSpOut.Address)
+	if err != nil {
+		log.Errorf("addSpendableOutput: userStore.IsSpentOutput: %s", err.Error())
+		return
+	}
+	if spent {
+		return
+	}
+
+	spOut := generatedSpOutsToStore(gSpOut)
+	log.Infof("Add spendable output : %v", gSpOut.String())
+
+	exRates, err := userStore.GetLatestExchangeRate()
+	if err != nil {
+		log.Errorf("addSpendableOutput: GetLatestExchangeRate: %s", err.Error())
+	}
+	spOut.StockExchangeRate = exRates
+
+	err = userStore.AddSpendableOutput(currencyID, networtkID, spOut)
+	if err != nil {
+		log.Errorf("addSpendableOutput: userStore.AddSpendableOutput: %s", err.Error())
+	}
+}
+
+// deleteSpendableOutput marks the output as spent and removes it,
+// the output itself may come a bit later than its spending tx so removal is retried.
+// Output spent already by another pending tx means that tx is replaced by fee.
+func deleteSpendableOutput(userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID int, del *pb.ReqDeleteSpOut) {
+	spent, err := userStore.FindSpentOutput(currencyID, networtkID, del.UserID, del.TxID, del.Address)
+	if err == nil && spent.SpendTxID != "" && del.SpendTxID != "" {
+		if spent.SpendTxID != del.SpendTxID {
+			replaceTransaction(userStore, nsqProducer, currencyID, networtkID, spent.SpendTxID, del.SpendTxID)
+		}
+		// the output was removed when it was spent first
+		return
+	}
+	if err != nil && err != store.ErrNotFound {
+		log.Errorf("deleteSpendableOutput: userStore.FindSpentOutput: %s", err.Error())
+	}
+
+	err = userStore.AddSpentOutput(currencyID, networtkID, store.SpentOutput{
+		UserID:    del.UserID,
+		TxID:      del.TxID,
+		Address:   del.Address,
+		SpendTxID: del.SpendTxID,
+	})
+	if err != nil {
+		log.Errorf("deleteSpendableOutput: userStore.AddSpentOutput: %s", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		err = userStore.DeleteSpendableOutput(currencyID, networtkID, del.UserID, del.TxID, del.Address)
+		if err == nil {
+			log.Infof("delete success √: %v %v", del.TxID, del.Address)
+			return
+		}
+		log.Errorf("deleteSpendableOutput: userStore.DeleteSpendableOutput: %s", err.Error())
+		time.Sleep(time.Second * 3)
+	}
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TxHash returns the txid of the tx
+func (c *Chain) TxHash(rawTx string) (string, error) {
+	raw, err := hex.DecodeString(rawTx)
+	if err != nil {
+		return "", err
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return "", err
+	}
+	return tx.TxHash().String(), nil
+}
+
+func (c *Chain) TxSeen(txID string) (bool, error) {
+	if c.fees.Mempool().Has(txID) {
+		return true, nil
+	}
+	return c.conn.userStore.IsTxInBlock(c.CurrencyID(), c.networkID, txID)
+}
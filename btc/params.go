@@ -0,0 +1,131 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package btc
+
+import (
+	"time"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/feeestimator"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// Params describe a bitcoin-like chain served by node services of the btc protocol,
+// fee rates are in the smallest units of the coin per byte
+type Params struct {
+	CurrencyID int
+	// Name is a short name of the coin used in logs and node versions
+	Name string
+	// RBF tells whether nodes of the chain relay replacements of txs signaling it
+	RBF bool
+	// MinFeeRate is the lowest fee rate we suggest to the clients
+	MinFeeRate int
+	// MaxLag is how many blocks node service may be behind the others before traffic fails over
+	MaxLag int
+	// DustLimit is the smallest output in satoshi nodes of the chain relay
+	DustLimit int64
+	// FallbackRates are suggested while there is no mempool to estimate from
+	FallbackRates store.FeeRates
+	// Blocks describe blocks of the chain for mempool analytics
+	Blocks feeestimator.Params
+}
+
+var chainParams = map[int]Params{
+	currencies.Bitcoin: {
+		CurrencyID: currencies.Bitcoin,
+		Name:       "btc",
+		RBF:        true,
+		MinFeeRate: 2,
+		MaxLag:     2,
+		DustLimit:  546,
+		FallbackRates: store.FeeRates{
+			VerySlow: 2,
+			Slow:     2,
+			Medium:   3,
+			Fast:     5,
+			VeryFast: 10,
+		},
+		Blocks: feeestimator.Params{
+			BlockInterval: 10 * time.Minute,
+			BlockTxs:      2000,
+			Buckets:       []int{2, 5, 10, 20, 50, 100, 200, 500},
+		},
+	},
+	currencies.Litecoin: {
+		CurrencyID: currencies.Litecoin,
+		Name:       "ltc",
+		RBF:        true,
+		MinFeeRate: 10,
+		MaxLag:     6,
+		DustLimit:  5460,
+		FallbackRates: store.FeeRates{
+			VerySlow: 10,
+			Slow:     10,
+			Medium:   20,
+			Fast:     30,
+			VeryFast: 50,
+		},
+		Blocks: feeestimator.Params{
+			BlockInterval: 150 * time.Second,
+			BlockTxs:      500,
+			Buckets:       []int{10, 20, 50, 100, 200, 500},
+		},
+	},
+	// dash and bitcoin cash nodes don't replace txs in mempool
+	currencies.Dash: {
+		CurrencyID: currencies.Dash,
+		Name:       "dash",
+		MinFeeRate: 1,
+		MaxLag:     6,
+		DustLimit:  5460,
+		FallbackRates: store.FeeRates{
+			VerySlow: 1,
+			Slow:     1,
+			Medium:   2,
+			Fast:     5,
+			VeryFast: 10,
+		},
+		Blocks: feeestimator.Params{
+			BlockInterval: 150 * time.Second,
+			BlockTxs:      500,
+			Buckets:       []int{1, 2, 5, 10, 20, 50},
+		},
+	},
+	currencies.BitcoinCash: {
+		CurrencyID: currencies.BitcoinCash,
+		Name:       "bch",
+		MinFeeRate: 1,
+		MaxLag:     2,
+		DustLimit:  546,
+		FallbackRates: store.FeeRates{
+			VerySlow: 1,
+			Slow:     1,
+			Medium:   1,
+			Fast:     2,
+			VeryFast: 5,
+		},
+		Blocks: feeestimator.Params{
+			BlockInterval: 10 * time.Minute,
+			BlockTxs:      2000,
+			Buckets:       []int{1, 2, 5, 10, 20, 50},
+		},
+	},
+}
+
+// ParamsOf returns params of the bitcoin-like chain, false if the currency is not one of them
+func ParamsOf(currencyID int) (Params, bool) {
+	params, ok := chainParams[currencyID]
+	return params, ok
+}
+
+// feeStrategies estimate fee rates from mempool fee categories
+func (p Params) feeStrategies() []feeestimator.Strategy {
+	return []feeestimator.Strategy{
+		feeestimator.MempoolPercentile{Min: p.MinFeeRate},
+		feeestimator.RecentBlocks{Min: p.MinFeeRate},
+		feeestimator.Fallback{Rates: p.FallbackRates},
+	}
+}
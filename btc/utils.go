@@ -9,137 +9,33 @@ import (
 	"encoding/json"
 	"errors"
 	"strconv"
-	"time"
 
-	"github.com/Multy-io/Multy-back/currencies"
 	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
 	"github.com/Multy-io/Multy-back/store"
 	nsq "github.com/bitly/go-nsq"
-	_ "github.com/jekabolt/slflog"
-	mgo "gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
 )
 
-var (
-	exRate    *mgo.Collection
-	usersData *mgo.Collection
-
-	txsData          *mgo.Collection
-	spendableOutputs *mgo.Collection
-	spentOutputs     *mgo.Collection
-
-	txsDataTest          *mgo.Collection
-	spendableOutputsTest *mgo.Collection
-	spentOutputsTest     *mgo.Collection
-
-	restoreState *mgo.Collection
-)
-
-func updateWalletAndAddressDate(tx store.MultyTX, networkID int) error {
+func updateWalletAndAddressDate(userStore store.UserStore, tx store.MultyTX) error {
 	for _, wallet := range tx.WalletsInput {
-		sel := bson.M{"userID": wallet.UserId, "wallets.addresses.address": wallet.Address.Address}
-		user := store.User{}
-		err := usersData.Find(sel).One(&user)
-		update := bson.M{}
-
-		var ok bool
-
-		for i := range user.Wallets {
-			for j, addr := range user.Wallets[i].Adresses {
-				if addr.Address == wallet.Address.Address {
-					ok = true
-					update = bson.M{
-						"$set": bson.M{
-							"wallets." + strconv.Itoa(i) + ".lastActionTime":                                   time.Now().Unix(),
-							"wallets." + strconv.Itoa(i) + ".addresses." + strconv.Itoa(j) + ".lastActionTime": time.Now().Unix(),
-							"wallets." + strconv.Itoa(i) + ".status":                                           store.WalletStatusOK,
-						},
-					}
-					break
-				}
-			}
-		}
-		if ok {
-			err = usersData.Update(sel, update)
-			if err != nil {
-				return errors.New("updateWalletAndAddressDate:usersData.Update: " + err.Error())
-			}
+		err := userStore.UpdateAddressLastAction(wallet.UserId, wallet.Address.Address)
+		if err != nil && err != store.ErrNotFound {
+			return errors.New("updateWalletAndAddressDate:userStore.UpdateAddressLastAction: " + err.Error())
 		}
-
 	}
 	for _, wallet := range tx.WalletsOutput {
-		sel := bson.M{"userID": wallet.UserId, "wallets.addresses.address": wallet.Address.Address}
-		user := store.User{}
-		err := usersData.Find(sel).One(&user)
-		update := bson.M{}
-
-		var ok bool
-
-		for i := range user.Wallets {
-			for j, addr := range user.Wallets[i].Adresses {
-				if addr.Address == wallet.Address.Address {
-					ok = true
-					update = bson.M{
-						"$set": bson.M{
-							"wallets." + strconv.Itoa(i) + ".lastActionTime":                                   time.Now().Unix(),
-							"wallets." + strconv.Itoa(i) + ".addresses." + strconv.Itoa(j) + ".lastActionTime": time.Now().Unix(),
-							"wallets." + strconv.Itoa(i) + ".status":                                           store.WalletStatusOK,
-						},
-					}
-					break
-				}
-			}
-		}
-		if ok {
-			err = usersData.Update(sel, update)
-			if err != nil {
-				return errors.New("updateWalletAndAddressDate:usersData.Update: " + err.Error())
-			}
+		err := userStore.UpdateAddressLastAction(wallet.UserId, wallet.Address.Address)
+		if err != nil && err != store.ErrNotFound {
+			return errors.New("updateWalletAndAddressDate:userStore.UpdateAddressLastAction: " + err.Error())
 		}
-
 	}
 
 	return nil
 }
 
-func GetReSyncExchangeRate(time int64) ([]store.ExchangeRatesRecord, error) {
-	selCCCAGG := bson.M{
-		"stockexchange": "CCCAGG",
-		"timestamp":     bson.M{"$lt": time},
-	}
-	stocksCCCAGG := store.ExchangeRatesRecord{}
-	err := exRate.Find(selCCCAGG).Sort("-timestamp").One(&stocksCCCAGG)
-	if err != nil {
-		return nil, err
-	}
-	return []store.ExchangeRatesRecord{stocksCCCAGG}, nil
-}
-
-func GetLatestExchangeRate() ([]store.ExchangeRatesRecord, error) {
-	selGdax := bson.M{
-		"stockexchange": "Gdax",
-	}
-	selPoloniex := bson.M{
-		"stockexchange": "Poloniex",
-	}
-	stocksGdax := store.ExchangeRatesRecord{}
-	err := exRate.Find(selGdax).Sort("-timestamp").One(&stocksGdax)
-	if err != nil {
-		return nil, err
-	}
-
-	stocksPoloniex := store.ExchangeRatesRecord{}
-	err = exRate.Find(selPoloniex).Sort("-timestamp").One(&stocksPoloniex)
-	if err != nil {
-		return nil, err
-	}
-	return []store.ExchangeRatesRecord{stocksPoloniex, stocksGdax}, nil
-}
-
-func setExchangeRates(tx *store.MultyTX, isReSync bool, TxTime int64) {
+func setExchangeRates(userStore store.UserStore, tx *store.MultyTX, isReSync bool, TxTime int64) {
 	var err error
 	if isReSync {
-		rates, err := GetReSyncExchangeRate(TxTime)
+		rates, err := userStore.GetReSyncExchangeRate(TxTime)
 		if err != nil {
 			log.Errorf("processTransaction:ExchangeRates: %s", err.Error())
 		}
@@ -147,7 +43,7 @@ func setExchangeRates(tx *store.MultyTX, isReSync bool, TxTime int64) {
 		return
 	}
 	if !isReSync || err != nil {
-		rates, err := GetLatestExchangeRate()
+		rates, err := userStore.GetLatestExchangeRate()
 		if err != nil {
 			log.Errorf("processTransaction:ExchangeRates: %s", err.Error())
 		}
@@ -155,13 +51,13 @@ func setExchangeRates(tx *store.MultyTX, isReSync bool, TxTime int64) {
 	}
 }
 
-func sendNotifyToClients(tx store.MultyTX, nsqProducer *nsq.Producer, netid int) {
+func sendNotifyToClients(tx store.MultyTX, nsqProducer *nsq.Producer, currencyID, netid int) {
 
 	for _, walletOutput := range tx.WalletsOutput {
 		txMsq := store.TransactionWithUserID{
 			UserID: walletOutput.UserId,
 			NotificationMsg: &store.WsTxNotify{
-				CurrencyID:      currencies.Bitcoin,
+				CurrencyID:      currencyID,
 				NetworkID:       netid,
 				Address:         walletOutput.Address.Address,
 				Amount:          strconv.Itoa(int(tx.TxOutAmount)),
@@ -181,7 +77,7 @@ func sendNotifyToClients(tx store.MultyTX, nsqProducer *nsq.Producer, netid int)
 		txMsq := store.TransactionWithUserID{
 			UserID: walletInput.UserId,
 			NotificationMsg: &store.WsTxNotify{
-				CurrencyID:      currencies.Bitcoin,
+				CurrencyID:      currencyID,
 				NetworkID:       netid,
 				Address:         walletInput.Address.Address,
 				Amount:          strconv.Itoa(int(tx.TxOutAmount)),
@@ -201,7 +97,7 @@ func sendNotifyToClients(tx store.MultyTX, nsqProducer *nsq.Producer, netid int)
 			txMsq := store.TransactionWithUserID{
 				UserID: tx.UserId,
 				NotificationMsg: &store.WsTxNotify{
-					CurrencyID:      currencies.Bitcoin,
+					CurrencyID:      currencyID,
 					NetworkID:       netid,
 					Address:         tx.TxAddress[0],
 					Amount:          strconv.Itoa(int(tx.TxOutAmount)),
@@ -223,7 +119,7 @@ func sendNotifyToClients(tx store.MultyTX, nsqProducer *nsq.Producer, netid int)
 			txMsq := store.TransactionWithUserID{
 				UserID: tx.UserId,
 				NotificationMsg: &store.WsTxNotify{
-					CurrencyID:      currencies.Bitcoin,
+					CurrencyID:      currencyID,
 					NetworkID:       netid,
 					Address:         tx.TxAddress[0],
 					Amount:          strconv.Itoa(int(tx.TxOutAmount)),
@@ -316,6 +212,7 @@ func generatedTxDataToStore(gSpOut *btcpb.BTCTransaction) store.MultyTX {
 		TxOutputs:     outs,
 		WalletsInput:  wInputs,
 		WalletsOutput: wOutputs,
+		RBF:           gSpOut.Rbf,
 	}
 }
 
@@ -333,205 +230,127 @@ func generatedSpOutsToStore(gSpOut *btcpb.AddSpOut) store.SpendableOutputs {
 	}
 }
 
-func saveMultyTransaction(tx store.MultyTX, networtkID int, resync bool) error {
-
-	txStore := &mgo.Collection{}
-	switch networtkID {
-	case currencies.Main:
-		txStore = txsData
-	case currencies.Test:
-		txStore = txsDataTest
-	default:
-		return errors.New("saveMultyTransaction: wrong networkID")
+func saveMultyTransaction(userStore store.UserStore, tx store.MultyTX, currencyID, networtkID int) error {
+	err := userStore.SaveMultyTransaction(currencyID, networtkID, tx)
+	if err != nil {
+		log.Errorf("saveMultyTransaction:userStore.SaveMultyTransaction %s", err.Error())
 	}
+	return err
+}
 
-	// fetchedTxs := []store.MultyTX{}
-	// query := bson.M{"txid": tx.TxID}
-	// txStore.Find(query).All(&fetchedTxs)
-
-	// Doubling txs fix on -1
-	if tx.BlockHeight == -1 {
-		multyTX := store.MultyTX{}
-		if len(tx.WalletsInput) != 0 {
-			sel := bson.M{"userid": tx.WalletsInput[0].UserId, "txid": tx.TxID, "walletsoutput.walletindex": tx.WalletsInput[0].WalletIndex}
-			err := txStore.Find(sel).One(multyTX)
-			if err == nil && multyTX.BlockHeight > -1 {
-				return nil
-			}
-		}
-		if len(tx.WalletsOutput) > 0 {
-			sel := bson.M{"userid": tx.WalletsOutput[0].UserId, "txid": tx.TxID, "walletsoutput.walletindex": tx.WalletsOutput[0].WalletIndex}
-			err := txStore.Find(sel).One(multyTX)
-			if err == nil && multyTX.BlockHeight > -1 {
-				return nil
-			}
+func setUserID(userStore store.UserStore, tx *store.MultyTX, currencyID, networtkID int) {
+	ua := store.UserAddress{}
+	for _, address := range tx.TxAddress {
+		err := userStore.FindAddress(currencyID, networtkID, address, &ua)
+		if err != nil {
+			log.Errorf("setUserID: userStore.FindAddress: %s", err.Error())
 		}
+		tx.UserId = ua.UserID
 	}
+}
 
-	// Doubling txs fix on a asynchronous err
-	if len(tx.WalletsInput) != 0 {
-		sel := bson.M{"userid": tx.UserId, "txid": tx.TxID, "walletsoutput.walletindex": tx.WalletsInput[0].WalletIndex, "mempooltime": tx.MempoolTime}
-		err := txStore.Find(sel).One(nil)
-		if err == nil {
-			return nil
-		}
+// setTxInfo sets wallet index and address index in inputs and outputs
+func setTxInfo(userStore store.UserStore, tx *store.MultyTX, currencyID, networtkID int) {
+	for i := range tx.WalletsInput {
+		setWalletInfo(userStore, &tx.WalletsInput[i], currencyID, networtkID)
 	}
-	if len(tx.WalletsOutput) > 0 {
-		sel := bson.M{"userid": tx.UserId, "txid": tx.TxID, "walletsoutput.walletindex": tx.WalletsOutput[0].WalletIndex, "mempooltime": tx.MempoolTime}
-		err := txStore.Find(sel).One(nil)
-		if err == nil {
-			return nil
-		}
+	for i := range tx.WalletsOutput {
+		setWalletInfo(userStore, &tx.WalletsOutput[i], currencyID, networtkID)
 	}
+}
 
-	// This is splited transaction! That means that transaction's WalletsInputs and WalletsOutput have the same WalletIndex!
-	//Here we have outgoing transaction for exact wallet!
-	multyTX := store.MultyTX{}
-	if tx.WalletsInput != nil && len(tx.WalletsInput) > 0 {
+func setWalletInfo(userStore store.UserStore, wft *store.WalletForTx, currencyID, networtkID int) {
+	ua := store.UserAddress{}
+	err := userStore.FindAddress(currencyID, networtkID, wft.Address.Address, &ua)
+	if err == store.ErrNotFound {
+		return
+	} else if err != nil {
+		log.Errorf("setWalletInfo: userStore.FindAddress: %s", err)
+		return
+	}
+	wft.WalletIndex = ua.WalletIndex
+	wft.Address.AddressIndex = ua.AddressIndex
+}
 
-		sel := bson.M{"userid": tx.WalletsInput[0].UserId, "txid": tx.TxID, "txaddress": tx.TxAddress[0]}
+// updateConfirmations recomputes confirmations of txs in blocks from the new chain tip,
+// txs deep enough become confirmed and clients are notified
+func updateConfirmations(userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID int, height int64, depth int) {
+	txs := []store.MultyTX{}
+	err := userStore.GetInBlockTransactions(currencyID, networtkID, &txs)
+	if err != nil {
+		log.Errorf("updateConfirmations: userStore.GetInBlockTransactions: %s", err.Error())
+		return
+	}
 
-		err := txStore.Find(sel).One(&multyTX)
-		if err == mgo.ErrNotFound {
-			// initial insertion
-			err := txStore.Insert(tx)
-			return err
+	for _, tx := range txs {
+		confirmations := store.Confirmations(height, tx.BlockHeight)
+		status := tx.TxStatus
+		if confirmations >= depth {
+			status = store.ConfirmedStatus(status)
 		}
-		if err != nil && err != mgo.ErrNotFound {
-			// database error
-			return err
+		if status == tx.TxStatus && confirmations == tx.Confirmations {
+			continue
 		}
 
-		update := bson.M{
-			"$set": bson.M{
-				"txstatus":      tx.TxStatus,
-				"blockheight":   tx.BlockHeight,
-				"confirmations": tx.Confirmations,
-				"blocktime":     tx.BlockTime,
-				"walletsoutput": tx.WalletsOutput,
-				"walletsinput":  tx.WalletsInput,
-			},
-		}
-		err = txStore.Update(sel, update)
+		err = userStore.UpdateTransactionConfirmations(currencyID, networtkID, store.TxKey{TxID: tx.TxID, UserID: tx.UserId}, status, confirmations)
 		if err != nil {
-			log.Errorf("saveMultyTransaction:txsData.Update %s", err.Error())
-		}
-		return err
-
-		// sel := bson.M{"userid": tx.WalletsInput[0].UserId, "transactions.txid": tx.TxID, "transactions.walletsinput.walletindex": tx.WalletsInput[0].WalletIndex}
-		// sel := bson.M{"userid": tx.WalletsInput[0].UserId, "txid": tx.TxID, "walletsinput.walletindex": tx.WalletsInput[0].WalletIndex} // last
-		sel = bson.M{"userid": tx.WalletsInput[0].UserId, "txid": tx.TxID, "walletsoutput.walletindex": tx.WalletsInput[0].WalletIndex}
-		if tx.BlockHeight != -1 {
-			sel = bson.M{"userid": tx.WalletsInput[0].UserId, "txid": tx.TxID, "walletsinput.walletindex": tx.WalletsInput[0].WalletIndex}
-		}
-
-		err = txStore.Find(sel).One(&multyTX)
-		if err == mgo.ErrNotFound {
-			// initial insertion
-			err := txStore.Insert(tx)
-			return err
-		}
-		if err != nil && err != mgo.ErrNotFound {
-			// database error
-			return err
-		}
-
-		update = bson.M{
-			"$set": bson.M{
-				"txstatus":      tx.TxStatus,
-				"blockheight":   tx.BlockHeight,
-				"confirmations": tx.Confirmations,
-				"blocktime":     tx.BlockTime,
-				"walletsoutput": tx.WalletsOutput,
-				"walletsinput":  tx.WalletsInput,
-			},
-		}
-		err = txStore.Update(sel, update)
-		return err
-	} else if tx.WalletsOutput != nil && len(tx.WalletsOutput) > 0 {
-		// sel := bson.M{"userid": tx.WalletsOutput[0].UserId, "transactions.txid": tx.TxID, "transactions.walletsoutput.walletindex": tx.WalletsOutput[0].WalletIndex}
-		sel := bson.M{"userid": tx.WalletsOutput[0].UserId, "txid": tx.TxID, "txaddress": tx.TxAddress[0]}
-		err := txStore.Find(sel).One(&multyTX)
-		if err == mgo.ErrNotFound {
-			// initial insertion
-			err := txStore.Insert(tx)
-			return err
+			log.Errorf("updateConfirmations: userStore.UpdateTransactionConfirmations: %s", err.Error())
+			continue
 		}
-		if err != nil && err != mgo.ErrNotFound {
-			// database error
-			return err
+		if status != tx.TxStatus && len(tx.TxAddress) > 0 {
+			tx.TxStatus = status
+			tx.Confirmations = confirmations
+			sendNotifyToClients(tx, nsqProducer, currencyID, networtkID)
 		}
-
-		update := bson.M{
-			"$set": bson.M{
-				"txstatus":      tx.TxStatus,
-				"blockheight":   tx.BlockHeight,
-				"confirmations": tx.Confirmations,
-				"blocktime":     tx.BlockTime,
-				"walletsoutput": tx.WalletsOutput,
-				"walletsinput":  tx.WalletsInput,
-			},
-		}
-		err = txStore.Update(sel, update)
-		if err != nil {
-			log.Errorf("saveMultyTransaction:txsData.Update %s", err.Error())
-		}
-		return err
 	}
-	return nil
 }
 
-func setUserID(tx *store.MultyTX) {
-	user := store.User{}
-	for _, address := range tx.TxAddress {
-		query := bson.M{"wallets.addresses.address": address}
-		err := usersData.Find(query).One(&user)
-		if err != nil {
-			log.Errorf("setUserID: usersData.Find: %s", err.Error())
+// rollbackOrphaned returns txs of blocks orphaned by the new block back to mempool
+// and notifies clients of the reversed status. False is returned if the block doesn't
+// connect to recent blocks and has to be ignored.
+func rollbackOrphaned(userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID int, block store.Block) bool {
+	ls, err := userStore.FethLastSyncBlockState(networtkID, currencyID)
+	if err != nil {
+		if err != store.ErrNotFound {
+			log.Errorf("rollbackOrphaned: userStore.FethLastSyncBlockState: %s", err.Error())
 		}
-		tx.UserId = user.UserID
+		return true
 	}
-}
-
-func setTxInfo(tx *store.MultyTX) {
-	user := store.User{}
-	// set wallet index and address index in input
-	for _, in := range tx.WalletsInput {
-		sel := bson.M{"wallets.addresses.address": in.Address.Address}
-		err := usersData.Find(sel).One(&user)
-		if err == mgo.ErrNotFound {
-			continue
-		} else if err != nil && err != mgo.ErrNotFound {
-			log.Errorf("initGrpcClient: cli.On newIncomingTx: %s", err)
-		}
-
-		for _, wallet := range user.Wallets {
-			for i := 0; i < len(wallet.Adresses); i++ {
-				if wallet.Adresses[i].Address == in.Address.Address {
-					in.WalletIndex = wallet.WalletIndex
-					in.Address.AddressIndex = wallet.Adresses[i].AddressIndex
-				}
-			}
-		}
+	if !store.Connects(ls.Blocks, block) {
+		log.Warnf("rollbackOrphaned: block %d %s is seen already or doesn't connect to recent blocks, skipped", block.Height, block.Hash)
+		return false
+	}
+	fork := store.ForkHeight(ls.Blocks, block)
+	if fork == 0 {
+		return true
 	}
+	log.Warnf("rollbackOrphaned: reorg, block %d %s orphans blocks from %d", block.Height, block.Hash, fork)
 
-	// set wallet index and address index in output
-	for _, out := range tx.WalletsOutput {
-		sel := bson.M{"wallets.addresses.address": out.Address.Address}
-		err := usersData.Find(sel).One(&user)
-		if err == mgo.ErrNotFound {
-			continue
-		} else if err != nil && err != mgo.ErrNotFound {
-			log.Errorf("initGrpcClient: cli.On newIncomingTx: %s", err)
+	txs, err := userStore.RollbackTransactions(currencyID, networtkID, fork)
+	if err != nil {
+		log.Errorf("rollbackOrphaned: userStore.RollbackTransactions: %s", err.Error())
+		return true
+	}
+	for _, tx := range txs {
+		if len(tx.TxAddress) > 0 {
+			sendNotifyToClients(tx, nsqProducer, currencyID, networtkID)
 		}
+	}
+	return true
+}
 
-		for _, wallet := range user.Wallets {
-			for i := 0; i < len(wallet.Adresses); i++ {
-				if wallet.Adresses[i].Address == out.Address.Address {
-					out.WalletIndex = wallet.WalletIndex
-					out.Address.AddressIndex = wallet.Adresses[i].AddressIndex
-				}
-			}
+// replaceTransaction links the pending tx to the one replaced it by fee
+// and notifies clients the tx is not pending anymore
+func replaceTransaction(userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID int, txID, replacedBy string) {
+	log.Infof("replaceTransaction: %s is replaced by %s", txID, replacedBy)
+	txs, err := userStore.ReplaceTransaction(currencyID, networtkID, txID, replacedBy)
+	if err != nil {
+		log.Errorf("replaceTransaction: userStore.ReplaceTransaction: %s", err.Error())
+		return
+	}
+	for _, tx := range txs {
+		if len(tx.TxAddress) > 0 {
+			sendNotifyToClients(tx, nsqProducer, currencyID, networtkID)
 		}
 	}
 }
@@ -0,0 +1,122 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/store"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// opReturn starts scripts of outputs carrying data
+	opReturn = 0x6a
+)
+
+// ValidateRawTx decodes the tx and checks its inputs are spendable outputs of the user
+// or outputs spent by a pending tx of the user it may replace, outputs are not dust and fee rate is not far from the current estimation
+func (c *Chain) ValidateRawTx(userID, rawTx string) error {
+	raw, err := hex.DecodeString(rawTx)
+	if err != nil {
+		return chain.NewTxError(chain.TxErrDecode, "wrong hex: %s", err.Error())
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return chain.NewTxError(chain.TxErrDecode, "wrong tx: %s", err.Error())
+	}
+	if len(tx.TxIn) == 0 || len(tx.TxOut) == 0 {
+		return chain.NewTxError(chain.TxErrDecode, "tx has no inputs or outputs")
+	}
+
+	var in int64
+	for _, txIn := range tx.TxIn {
+		prev := txIn.PreviousOutPoint
+		out, err := c.conn.userStore.FindSpendableOutput(c.CurrencyID(), c.networkID, userID, prev.Hash.String(), int(prev.Index))
+		if err == store.ErrNotFound {
+			out, err = c.replacedOutput(userID, prev)
+		}
+		if err == store.ErrNotFound {
+			return chain.NewTxError(chain.TxErrUnknownInput, "%s:%d is not a spendable output", prev.Hash.String(), prev.Index)
+		}
+		if err != nil {
+			return err
+		}
+		in += out.TxOutAmount
+	}
+
+	var out int64
+	for i, txOut := range tx.TxOut {
+		if txOut.Value < c.conn.Params.DustLimit && !isNullData(txOut.PkScript) {
+			return chain.NewTxError(chain.TxErrDustOutput, "output %d of %d satoshi is below %d", i, txOut.Value, c.conn.Params.DustLimit)
+		}
+		out += txOut.Value
+	}
+	if out > in {
+		return chain.NewTxError(chain.TxErrFeeTooLow, "outputs %d exceed inputs %d", out, in)
+	}
+
+	rates, err := c.fees.Estimate()
+	if err != nil {
+		log.Errorf("ValidateRawTx: fees.Estimate: netID:%d %s", c.networkID, err.Error())
+		return nil
+	}
+	return chain.CheckFeeRate((in-out)/int64(vsize(tx)), rates)
+}
+
+// replacedOutput returns the output of the user spent by a pending tx which signals rbf,
+// the output may be spent again by a replacement of the tx
+func (c *Chain) replacedOutput(userID string, prev wire.OutPoint) (store.SpendableOutputs, error) {
+	if !c.conn.Params.RBF {
+		return store.SpendableOutputs{}, store.ErrNotFound
+	}
+	userStore := c.conn.userStore
+	user := store.User{}
+	if err := userStore.FindUserByID(userID, &user); err != nil {
+		return store.SpendableOutputs{}, err
+	}
+	for _, wallet := range user.Wallets {
+		if wallet.CurrencyID != c.CurrencyID() || wallet.NetworkID != c.networkID {
+			continue
+		}
+		for _, address := range wallet.Adresses {
+			spent, err := userStore.FindSpentOutput(c.CurrencyID(), c.networkID, userID, prev.Hash.String(), address.Address)
+			if err == store.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return store.SpendableOutputs{}, err
+			}
+			if spent.Output == nil || spent.Output.TxOutID != int(prev.Index) {
+				continue
+			}
+			tx, err := userStore.FindUserTransaction(userID, c.CurrencyID(), c.networkID, spent.SpendTxID)
+			if err == store.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return store.SpendableOutputs{}, err
+			}
+			if tx.RBF && store.IsMempoolStatus(tx.TxStatus) {
+				return *spent.Output, nil
+			}
+		}
+	}
+	return store.SpendableOutputs{}, store.ErrNotFound
+}
+
+// vsize is the size fee rates are counted for, witness data is discounted
+func vsize(tx *wire.MsgTx) int {
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	return (weight + 3) / 4
+}
+
+// isNullData tells whether the output only carries data and holds no coins
+func isNullData(script []byte) bool {
+	return len(script) > 0 && script[0] == opReturn
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Multy-io/Multy-back/logger"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+var log = logger.WithContext("chain")
+
+// Chain is a connection to the node service of a single currency and network.
+// REST and socket.io handlers work with chains through this interface instead of
+// picking node clients by currency and network id by hand.
+type Chain interface {
+	CurrencyID() int
+	NetworkID() int
+
+	// ServiceInfo returns the version of the node service
+	ServiceInfo() (store.ServiceInfo, error)
+	// InitialAdd sends all watched addresses of the chain to the node service
+	InitialAdd(usersData map[string]store.AddressExtended) error
+	// WatchAddress makes node service watch for the address and resync its history
+	WatchAddress(address, userID string, walletIndex, addressIndex int)
+	// ResyncAddress asks node service to resend the history of the address
+	ResyncAddress(address, userID string, walletIndex, addressIndex int) error
+	// ValidateRawTx checks hex encoded transaction of the user before it is broadcast,
+	// *TxError is returned if the transaction is rejected
+	ValidateRawTx(userID, rawTx string) error
+	// SendRawTx broadcasts hex encoded transaction and returns node service reply
+	SendRawTx(rawTx string) (string, error)
+	// TxHash returns the hash the chain identifies hex encoded transaction by
+	TxHash(rawTx string) (string, error)
+	// TxSeen reports whether the tx is in the mempool or in a block of the chain
+	TxSeen(txID string) (bool, error)
+	// BlockHeight returns the current height of the chain tip
+	BlockHeight() (int64, error)
+	// SyncState makes node service resend all events since the given block
+	SyncState(blockHeight int64) error
+	// FeeRates returns fee rates estimation for different confirmation speeds
+	FeeRates() (store.FeeRates, error)
+	// MempoolStats returns mempool size, fee histogram, trend of the last hours
+	// and confirmation time of the fee rate if it is positive
+	MempoolStats(hours, feeRate int) (store.MempoolStats, error)
+	// Streams returns supervisor of node service streams
+	Streams() *Supervisor
+	// Endpoints returns failover between node service endpoints of the network
+	Endpoints() *Failover
+}
+
+// UTXO is implemented by chains which balances are built from spendable outputs.
+type UTXO interface {
+	Chain
+	// IsSyncing reports whether address history is being resynced now
+	IsSyncing(address string) bool
+	// FeeBump suggests replace-by-fee and child-pays-for-parent txs for the pending tx of the user
+	FeeBump(userID, txID string) (store.FeeBump, error)
+}
+
+// Account is implemented by chains with account based balances.
+type Account interface {
+	Chain
+	// AddressBalance returns confirmed and pending balances of the address in the smallest units
+	AddressBalance(address string) (balance string, pending string, err error)
+	// AddressNonce returns the nonce of the address
+	AddressNonce(address string) (int64, error)
+	// Nonces returns the nonce the address should use next, its pending txs and gaps in their nonces
+	Nonces(address string) (store.AddressNonces, error)
+	// TokenBalances returns balances of the address in registered tokens
+	TokenBalances(address string) ([]store.TokenBalance, error)
+	// Multisig reads owners and required confirmations of the contract from the node service,
+	// owners are associated with wallets of users. ErrNotMultisig is returned if it has no owners.
+	Multisig(contract string) (store.Multisig, error)
+	// AddMultisig registers the multisig contract read by Multisig and makes node service watch for its events,
+	// owners and confirmations of the contract registered already are updated
+	AddMultisig(multisig store.Multisig) (store.Multisig, error)
+	// WatchMultisigs sends all registered multisig contracts to the node service
+	WatchMultisigs() error
+}
+
+// ErrNotMultisig is returned if the node service finds no owners of the contract
+var ErrNotMultisig = errors.New("not a multisig contract")
+
+// Key identifies a chain by currency and network
+type Key struct {
+	CurrencyID int
+	NetworkID  int
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("curID:%d netID:%d", k.CurrencyID, k.NetworkID)
+}
+
+// Registry holds all chains supported by the server
+type Registry struct {
+	m      sync.RWMutex
+	chains map[Key]Chain
+}
+
+// NewRegistry returns empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		chains: map[Key]Chain{},
+	}
+}
+
+// Register adds chain to the registry. Only one chain per currency and network is allowed.
+func (r *Registry) Register(c Chain) error {
+	key := Key{c.CurrencyID(), c.NetworkID()}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, ok := r.chains[key]; ok {
+		return fmt.Errorf("Register: chain already registered: %s", key)
+	}
+	r.chains[key] = c
+	log.Debugf("Register: %s", key)
+	return nil
+}
+
+// Get returns chain by currency and network
+func (r *Registry) Get(currencyID, networkID int) (Chain, error) {
+	key := Key{currencyID, networkID}
+
+	r.m.RLock()
+	defer r.m.RUnlock()
+	c, ok := r.chains[key]
+	if !ok {
+		return nil, fmt.Errorf("Get: no such chain: %s", key)
+	}
+	return c, nil
+}
+
+// All returns all registered chains ordered by currency and network
+func (r *Registry) All() []Chain {
+	r.m.RLock()
+	all := make([]Chain, 0, len(r.chains))
+	for _, c := range r.chains {
+		all = append(all, c)
+	}
+	r.m.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CurrencyID() != all[j].CurrencyID() {
+			return all[i].CurrencyID() < all[j].CurrencyID()
+		}
+		return all[i].NetworkID() < all[j].NetworkID()
+	})
+	return all
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package chain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+const (
+	defaultProbeInterval = 30 * time.Second
+	probeTimeout         = 5 * time.Second
+	// minTriggerInterval limits probes triggered by broken streams,
+	// they keep breaking with backoff while the node service is down
+	minTriggerInterval = time.Second
+)
+
+// EndpointHealth is a state of a single node service endpoint after the last probe
+type EndpointHealth struct {
+	URL       string            `json:"url"`
+	Active    bool              `json:"active"`
+	Healthy   bool              `json:"healthy"`
+	Height    int64             `json:"height"`
+	Version   store.NodeVersion `json:"version"`
+	LastError string            `json:"lasterror,omitempty"`
+	LastProbe int64             `json:"lastprobe"`
+}
+
+// ProbeFunc asks the node service at the endpoint for its version and the chain tip height
+type ProbeFunc func(ctx context.Context, endpoint int) (store.NodeVersion, int64, error)
+
+// Failover probes node services of a single network and picks the one traffic goes to.
+// The active endpoint is kept while it's healthy, otherwise the first healthy one in
+// config order takes its place. Node service is healthy if it replies and its chain tip
+// is at most maxLag blocks behind the highest tip among all endpoints.
+type Failover struct {
+	name   string
+	maxLag int64
+	probe  ProbeFunc
+
+	// check serializes probes of the periodic loop and explicit checks
+	check sync.Mutex
+
+	m           sync.Mutex
+	endpoints   []EndpointHealth
+	active      int
+	onSwitch    func()
+	triggering  bool
+	lastTrigger time.Time
+}
+
+// NewFailover returns failover over the endpoints, the first one is active until probed
+func NewFailover(name string, urls []string, maxLag int64, probe ProbeFunc) *Failover {
+	f := &Failover{
+		name:   name,
+		maxLag: maxLag,
+		probe:  probe,
+	}
+	for _, url := range urls {
+		f.endpoints = append(f.endpoints, EndpointHealth{URL: url})
+	}
+	f.endpoints[0].Active = true
+	return f
+}
+
+// OnSwitch sets hook called after traffic is moved to another endpoint
+func (f *Failover) OnSwitch(hook func()) {
+	f.m.Lock()
+	f.onSwitch = hook
+	f.m.Unlock()
+}
+
+// Active returns index of the endpoint traffic goes to
+func (f *Failover) Active() int {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.active
+}
+
+// ActiveEndpoint returns state of the endpoint traffic goes to
+func (f *Failover) ActiveEndpoint() EndpointHealth {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.endpoints[f.active]
+}
+
+// Health returns states of all endpoints in config order
+func (f *Failover) Health() []EndpointHealth {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return append([]EndpointHealth{}, f.endpoints...)
+}
+
+// Run probes endpoints every interval until done is closed
+func (f *Failover) Run(interval time.Duration, done <-chan struct{}) {
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	go func() {
+		for {
+			select {
+			case <-time.After(interval):
+				f.Check()
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// Trigger probes endpoints in background, e.g. when a stream of the active node service
+// breaks, so traffic is moved without waiting for the periodic probe. Triggers coming
+// while endpoints are probed or right after the last triggered probe are ignored.
+func (f *Failover) Trigger() {
+	f.m.Lock()
+	if f.triggering || time.Since(f.lastTrigger) < minTriggerInterval {
+		f.m.Unlock()
+		return
+	}
+	f.triggering = true
+	f.lastTrigger = time.Now()
+	f.m.Unlock()
+
+	go func() {
+		f.Check()
+
+		f.m.Lock()
+		f.triggering = false
+		f.m.Unlock()
+	}()
+}
+
+// Check probes all endpoints and moves traffic to a healthy one if the active is not
+func (f *Failover) Check() {
+	f.check.Lock()
+	defer f.check.Unlock()
+
+	f.m.Lock()
+	probed := append([]EndpointHealth{}, f.endpoints...)
+	f.m.Unlock()
+
+	var tip int64
+	for i := range probed {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		version, height, err := f.probe(ctx, i)
+		cancel()
+
+		probed[i].LastProbe = time.Now().Unix()
+		if err != nil {
+			probed[i].Healthy = false
+			probed[i].LastError = err.Error()
+			continue
+		}
+		probed[i].Healthy = true
+		probed[i].LastError = ""
+		probed[i].Height = height
+		probed[i].Version = version
+		if height > tip {
+			tip = height
+		}
+	}
+	for i := range probed {
+		if probed[i].Healthy && tip-probed[i].Height > f.maxLag {
+			probed[i].Healthy = false
+			probed[i].LastError = "lags behind the chain tip"
+		}
+	}
+
+	f.m.Lock()
+	from := f.active
+	to := from
+	if !probed[from].Healthy {
+		for i := range probed {
+			if probed[i].Healthy {
+				to = i
+				break
+			}
+		}
+	}
+	for i := range probed {
+		probed[i].Active = i == to
+	}
+	f.endpoints = probed
+	f.active = to
+	hook := f.onSwitch
+	f.m.Unlock()
+
+	if to == from {
+		if !probed[from].Healthy {
+			log.Errorf("Failover: %s: no healthy node services, %s: %s", f.name, probed[from].URL, probed[from].LastError)
+		}
+		return
+	}
+	log.Infof("Failover: %s: switched from %s (%s) to %s", f.name, probed[from].URL, probed[from].LastError, probed[to].URL)
+	if hook != nil {
+		hook()
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package chain
+
+import (
+	"time"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+const (
+	defaultRebroadcastWindow   = 600
+	defaultRebroadcastAttempts = 6
+)
+
+// RebroadcastConf configures resending of txs node services lost
+type RebroadcastConf struct {
+	// Window is how many seconds a tx may be unseen in mempool and blocks before it is sent again
+	Window int
+	// Attempts is how many times a tx is sent again before it is reported dropped
+	Attempts int
+}
+
+// Rebroadcaster keeps txs sent by users in the mempool of node services.
+// Node services forget txs on restart and nodes evict them under load,
+// a tx unseen for a window is sent again and reported dropped after all attempts.
+// Txs replaced by another tx of the user or rejected as conflicting are given up on silently.
+type Rebroadcaster struct {
+	chains    *Registry
+	userStore store.UserStore
+	window    time.Duration
+	attempts  int
+	dropped   func(store.OutboundTx)
+}
+
+// NewRebroadcaster returns rebroadcaster of the chains, dropped is called for every tx given up on
+func NewRebroadcaster(chains *Registry, userStore store.UserStore, conf RebroadcastConf, dropped func(store.OutboundTx)) *Rebroadcaster {
+	if conf.Window <= 0 {
+		conf.Window = defaultRebroadcastWindow
+	}
+	if conf.Attempts <= 0 {
+		conf.Attempts = defaultRebroadcastAttempts
+	}
+	return &Rebroadcaster{
+		chains:    chains,
+		userStore: userStore,
+		window:    time.Duration(conf.Window) * time.Second,
+		attempts:  conf.Attempts,
+		dropped:   dropped,
+	}
+}
+
+// Window returns how long a tx may be unseen before it is sent again
+func (r *Rebroadcaster) Window() time.Duration { return r.window }
+
+// Run checks pending txs twice a window, it never returns
+func (r *Rebroadcaster) Run() {
+	ticker := time.NewTicker(r.window / 2)
+	for now := range ticker.C {
+		r.Check(now)
+	}
+}
+
+// Check looks for pending txs of all chains at the moment
+func (r *Rebroadcaster) Check(now time.Time) {
+	for _, c := range r.chains.All() {
+		txs, err := r.userStore.GetOutboundTxs(c.CurrencyID(), c.NetworkID(), store.OutboundTxPending)
+		if err != nil {
+			log.Errorf("Check: userStore.GetOutboundTxs: curID:%d netID:%d %s", c.CurrencyID(), c.NetworkID(), err.Error())
+			continue
+		}
+		for _, tx := range txs {
+			if err := r.check(c, tx, now); err != nil {
+				log.Errorf("Check: curID:%d netID:%d txid:%s %s", c.CurrencyID(), c.NetworkID(), tx.TxID, err.Error())
+			}
+		}
+	}
+}
+
+func (r *Rebroadcaster) check(c Chain, tx store.OutboundTx, now time.Time) error {
+	mined, err := r.userStore.IsTxInBlock(c.CurrencyID(), c.NetworkID(), tx.TxID)
+	if err != nil {
+		return err
+	}
+	if mined {
+		tx.Status = store.OutboundTxMined
+		tx.LastSeen = now.Unix()
+		return r.userStore.UpdateOutboundTx(tx)
+	}
+
+	replaced, err := r.userStore.IsTxReplaced(c.CurrencyID(), c.NetworkID(), tx.TxID)
+	if err != nil {
+		return err
+	}
+	if replaced {
+		tx.Status = store.OutboundTxReplaced
+		log.Infof("check: tx replaced curID:%d netID:%d txid:%s", tx.CurrencyID, tx.NetworkID, tx.TxID)
+		return r.userStore.UpdateOutboundTx(tx)
+	}
+
+	seen, err := c.TxSeen(tx.TxID)
+	if err != nil {
+		return err
+	}
+	if seen {
+		tx.LastSeen = now.Unix()
+		return r.userStore.UpdateOutboundTx(tx)
+	}
+
+	last := tx.Submitted
+	if tx.LastSeen > last {
+		last = tx.LastSeen
+	}
+	if tx.LastBroadcast > last {
+		last = tx.LastBroadcast
+	}
+	if now.Sub(time.Unix(last, 0)) < r.window {
+		return nil
+	}
+
+	if tx.Broadcasts >= r.attempts {
+		tx.Status = store.OutboundTxDropped
+		if err := r.userStore.UpdateOutboundTx(tx); err != nil {
+			return err
+		}
+		log.Warnf("check: tx dropped curID:%d netID:%d txid:%s", tx.CurrencyID, tx.NetworkID, tx.TxID)
+		r.restoreInputs(c, tx)
+		r.dropped(tx)
+		return nil
+	}
+
+	resp, err := c.SendRawTx(tx.RawTx)
+	if err != nil {
+		return err
+	}
+	tx.Broadcasts++
+	tx.LastBroadcast = now.Unix()
+
+	txErr := NodeTxError(resp)
+	switch {
+	case txErr == nil:
+		log.Infof("check: tx sent again curID:%d netID:%d txid:%s reply:%s", tx.CurrencyID, tx.NetworkID, tx.TxID, resp)
+	case txErr.Known():
+		tx.LastSeen = now.Unix()
+	case txErr.Conflict():
+		// the tx is never mined, the user is not told it's dropped as
+		// most likely another tx of the user spent the same inputs or nonce
+		tx.Status = store.OutboundTxConflicted
+		log.Warnf("check: tx conflicted curID:%d netID:%d txid:%s reply:%s", tx.CurrencyID, tx.NetworkID, tx.TxID, txErr.Message)
+		if err := r.userStore.UpdateOutboundTx(tx); err != nil {
+			return err
+		}
+		r.restoreInputs(c, tx)
+		return nil
+	default:
+		log.Warnf("check: tx rejected curID:%d netID:%d txid:%s reply:%s", tx.CurrencyID, tx.NetworkID, tx.TxID, txErr.Message)
+	}
+	return r.userStore.UpdateOutboundTx(tx)
+}
+
+// restoreInputs makes outputs the tx given up on spent spendable again,
+// the tx won't spend them so the wallet may use them in another one
+func (r *Rebroadcaster) restoreInputs(c Chain, tx store.OutboundTx) {
+	if _, ok := c.(UTXO); !ok {
+		return
+	}
+	if err := r.userStore.RestoreSpentOutputs(tx.CurrencyID, tx.NetworkID, tx.TxID); err != nil {
+		log.Errorf("restoreInputs: userStore.RestoreSpentOutputs: %s", err.Error())
+	}
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package chain
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = time.Minute
+)
+
+// StreamHealth is a state of a single node service stream
+type StreamHealth struct {
+	Name       string `json:"name"`
+	Connected  bool   `json:"connected"`
+	Finished   bool   `json:"finished"`
+	Reconnects int    `json:"reconnects"`
+	LastError  string `json:"lasterror,omitempty"`
+	LastEvent  int64  `json:"lastevent"`
+}
+
+// Backoff is an exponential delay between stream reconnects
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// Duration returns delay before the given reconnect attempt, starting from zero
+func (b Backoff) Duration(attempt int) time.Duration {
+	d := b.Min
+	for i := 0; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// ServeFunc opens a stream and handles its events until the stream breaks.
+// It has to call alive once the stream is opened and on every received event.
+// Returning nil means the stream is finished and must not be reopened.
+type ServeFunc func(ctx context.Context, alive func()) error
+
+// Supervisor runs node service streams and reopens them with backoff when they break.
+// After the node service comes back OnReconnect hook is called once, it's the place
+// to send EventInitialAdd again since restarted node service knows nothing about users.
+// OnDisconnect hook is called every time a stream breaks.
+// Snapshot streams finished by then are served again as the node service state may differ.
+type Supervisor struct {
+	name    string
+	backoff Backoff
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	m sync.Mutex
+	// streams are served with a context of the current generation,
+	// cancelling it breaks them all to be reopened
+	gen          context.Context
+	genCancel    context.CancelFunc
+	streams      map[string]*StreamHealth
+	onReconnect  func() error
+	onDisconnect func()
+	broken       bool
+	// reconnected is closed and replaced when streams are reopened after a failure
+	reconnected chan struct{}
+}
+
+// NewSupervisor returns supervisor with default backoff
+func NewSupervisor(name string) *Supervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	gen, genCancel := context.WithCancel(ctx)
+	return &Supervisor{
+		name: name,
+		backoff: Backoff{
+			Min: defaultMinBackoff,
+			Max: defaultMaxBackoff,
+		},
+		ctx:         ctx,
+		cancel:      cancel,
+		gen:         gen,
+		genCancel:   genCancel,
+		streams:     map[string]*StreamHealth{},
+		reconnected: make(chan struct{}),
+	}
+}
+
+// SetBackoff changes delays between reconnects
+func (s *Supervisor) SetBackoff(b Backoff) {
+	s.m.Lock()
+	s.backoff = b
+	s.m.Unlock()
+}
+
+// OnReconnect sets hook called when streams are reopened after a failure
+func (s *Supervisor) OnReconnect(f func() error) {
+	s.m.Lock()
+	s.onReconnect = f
+	s.m.Unlock()
+}
+
+// OnDisconnect sets hook called when a stream breaks, e.g. to look for another node service
+func (s *Supervisor) OnDisconnect(f func()) {
+	s.m.Lock()
+	s.onDisconnect = f
+	s.m.Unlock()
+}
+
+// Run serves the stream in a new goroutine until it's finished or supervisor is stopped
+func (s *Supervisor) Run(name string, serve ServeFunc) {
+	s.run(name, serve, false)
+}
+
+// RunSnapshot serves the stream which finishes once the whole state is received,
+// e.g. the mempool. It's served again every time streams are reopened after a failure.
+func (s *Supervisor) RunSnapshot(name string, serve ServeFunc) {
+	s.run(name, serve, true)
+}
+
+func (s *Supervisor) run(name string, serve ServeFunc, snapshot bool) {
+	s.m.Lock()
+	s.streams[name] = &StreamHealth{Name: name}
+	s.m.Unlock()
+
+	go func() {
+		attempt := 0
+		for {
+			// reconnects since the stream is opened make the snapshot stale
+			reconnected := s.reconnectedChan()
+			err := serve(s.generation(), func() { s.alive(name, &attempt) })
+			if s.ctx.Err() != nil {
+				s.disconnected(name, s.ctx.Err())
+				return
+			}
+			if err == nil {
+				s.finished(name)
+				if !snapshot {
+					return
+				}
+				select {
+				case <-reconnected:
+					log.Infof("Supervisor: %s %s: taking snapshot again", s.name, name)
+					continue
+				case <-s.ctx.Done():
+					return
+				}
+			}
+
+			log.Errorf("Supervisor: %s %s: %s", s.name, name, err.Error())
+			s.disconnected(name, err)
+			if hook := s.disconnectHook(); hook != nil {
+				hook()
+			}
+
+			s.m.Lock()
+			delay := s.backoff.Duration(attempt)
+			s.m.Unlock()
+			attempt++
+
+			select {
+			case <-time.After(delay):
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Reconnect breaks all streams, they are reopened after the minimal backoff
+// and OnReconnect hook is called, e.g. when traffic is moved to another node service
+func (s *Supervisor) Reconnect() {
+	s.m.Lock()
+	s.genCancel()
+	s.gen, s.genCancel = context.WithCancel(s.ctx)
+	s.m.Unlock()
+}
+
+// Stop cancels all streams
+func (s *Supervisor) Stop() {
+	s.cancel()
+}
+
+// Done is closed when supervisor is stopped
+func (s *Supervisor) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Health returns states of all streams ordered by name
+func (s *Supervisor) Health() []StreamHealth {
+	s.m.Lock()
+	all := make([]StreamHealth, 0, len(s.streams))
+	for _, h := range s.streams {
+		all = append(all, *h)
+	}
+	s.m.Unlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// Healthy reports whether all running streams are connected
+func (s *Supervisor) Healthy() bool {
+	for _, h := range s.Health() {
+		if !h.Connected && !h.Finished {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Supervisor) reconnectedChan() <-chan struct{} {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.reconnected
+}
+
+func (s *Supervisor) disconnectHook() func() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.onDisconnect
+}
+
+func (s *Supervisor) generation() context.Context {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.gen
+}
+
+func (s *Supervisor) alive(name string, attempt *int) {
+	s.m.Lock()
+	h := s.streams[name]
+	wasConnected := h.Connected
+	h.Connected = true
+	h.LastEvent = time.Now().Unix()
+
+	var hook func() error
+	if !wasConnected {
+		if *attempt > 0 {
+			h.Reconnects++
+			log.Infof("Supervisor: %s %s: reconnected", s.name, name)
+		}
+		*attempt = 0
+		h.Finished = false
+		// the first reopened stream re-sends users data for all of them
+		if s.broken {
+			s.broken = false
+			close(s.reconnected)
+			s.reconnected = make(chan struct{})
+			hook = s.onReconnect
+		}
+	}
+	s.m.Unlock()
+
+	if hook != nil {
+		if err := hook(); err != nil {
+			log.Errorf("Supervisor: %s onReconnect: %s", s.name, err.Error())
+			s.m.Lock()
+			s.broken = true
+			s.m.Unlock()
+		}
+	}
+}
+
+func (s *Supervisor) disconnected(name string, err error) {
+	s.m.Lock()
+	h := s.streams[name]
+	h.Connected = false
+	h.LastError = err.Error()
+	s.broken = true
+	s.m.Unlock()
+}
+
+func (s *Supervisor) finished(name string) {
+	s.m.Lock()
+	h := s.streams[name]
+	h.Connected = false
+	h.Finished = true
+	s.m.Unlock()
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package chain
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// SyncTracker keeps catch-up progress of chains restored from the last synced block.
+// Current height is taken from the RestoreState collection which is updated by
+// the new block handlers.
+type SyncTracker struct {
+	m         sync.Mutex
+	userStore store.UserStore
+	progress  map[Key]*store.SyncProgress
+}
+
+// NewSyncTracker returns empty tracker
+func NewSyncTracker(userStore store.UserStore) *SyncTracker {
+	return &SyncTracker{
+		userStore: userStore,
+		progress:  map[Key]*store.SyncProgress{},
+	}
+}
+
+// Start begins tracking of the chain catching up from one height to another
+func (t *SyncTracker) Start(c Chain, from, target int64) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.progress[Key{c.CurrencyID(), c.NetworkID()}] = &store.SyncProgress{
+		CurrencyID:    c.CurrencyID(),
+		NetworkID:     c.NetworkID(),
+		FromHeight:    from,
+		TargetHeight:  target,
+		CurrentHeight: from,
+		Synced:        from >= target,
+	}
+}
+
+// Progress returns catch-up progress of all tracked chains
+func (t *SyncTracker) Progress() []store.SyncProgress {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	all := make([]store.SyncProgress, 0, len(t.progress))
+	for _, p := range t.progress {
+		if !p.Synced {
+			ls, err := t.userStore.FethLastSyncBlockState(p.NetworkID, p.CurrencyID)
+			if err != nil {
+				log.Errorf("Progress: userStore.FethLastSyncBlockState: curID:%d netID:%d %s", p.CurrencyID, p.NetworkID, err.Error())
+			}
+			if ls.BlockHeight > p.CurrentHeight {
+				p.CurrentHeight = ls.BlockHeight
+			}
+			// once caught up the chain stays synced
+			p.Synced = p.CurrentHeight >= p.TargetHeight
+		}
+		all = append(all, *p)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CurrencyID != all[j].CurrencyID {
+			return all[i].CurrencyID < all[j].CurrencyID
+		}
+		return all[i].NetworkID < all[j].NetworkID
+	})
+	return all
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package chain
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// Certificates are TLS certificates of node service connections read from files.
+// Connections made after Reload use the certificates read by it, established ones are kept.
+type Certificates struct {
+	conf store.NodeTLS
+
+	m      sync.Mutex
+	config *tls.Config
+}
+
+// LoadCertificates reads certificates the config points to
+func LoadCertificates(conf store.NodeTLS) (*Certificates, error) {
+	c := &Certificates{conf: conf}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload re-reads certificate files, certificates read before are kept on error
+func (c *Certificates) Reload() error {
+	config := &tls.Config{
+		ServerName: c.conf.ServerName,
+	}
+
+	if c.conf.CAFile != "" {
+		bundle, err := ioutil.ReadFile(c.conf.CAFile)
+		if err != nil {
+			return fmt.Errorf("Reload: ioutil.ReadFile: %s", err.Error())
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(bundle) {
+			return fmt.Errorf("Reload: no certificates in %s", c.conf.CAFile)
+		}
+		config.RootCAs = roots
+	}
+
+	if c.conf.CertFile != "" || c.conf.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.conf.CertFile, c.conf.KeyFile)
+		if err != nil {
+			return fmt.Errorf("Reload: tls.LoadX509KeyPair: %s", err.Error())
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	c.m.Lock()
+	c.config = config
+	c.m.Unlock()
+	return nil
+}
+
+func (c *Certificates) tlsConfig() *tls.Config {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.config.Clone()
+}
+
+// TransportOption returns dial option securing node service connections with the certificates,
+// connections are plaintext if there are none
+func TransportOption(certs *Certificates) grpc.DialOption {
+	if certs == nil {
+		return grpc.WithInsecure()
+	}
+	return grpc.WithTransportCredentials(&reloadingCredentials{certs: certs})
+}
+
+// reloadingCredentials make every handshake with the certificates loaded last
+type reloadingCredentials struct {
+	certs      *Certificates
+	serverName string
+}
+
+func (r *reloadingCredentials) current() credentials.TransportCredentials {
+	config := r.certs.tlsConfig()
+	if r.serverName != "" {
+		config.ServerName = r.serverName
+	}
+	return credentials.NewTLS(config)
+}
+
+func (r *reloadingCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return r.current().ClientHandshake(ctx, authority, conn)
+}
+
+func (r *reloadingCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("ServerHandshake: node service credentials are client only")
+}
+
+func (r *reloadingCredentials) Info() credentials.ProtocolInfo {
+	return r.current().Info()
+}
+
+func (r *reloadingCredentials) Clone() credentials.TransportCredentials {
+	return &reloadingCredentials{
+		certs:      r.certs,
+		serverName: r.serverName,
+	}
+}
+
+func (r *reloadingCredentials) OverrideServerName(serverName string) error {
+	r.serverName = serverName
+	return nil
+}
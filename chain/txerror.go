@@ -0,0 +1,117 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+const (
+	// lowFeePercent of the very slow estimation is the lowest fee rate accepted,
+	// estimation may go up since the client got it
+	lowFeePercent = 50
+	// absurdFeeFactor times the very fast estimation is the highest fee rate accepted
+	absurdFeeFactor = 10
+)
+
+// Reasons a raw transaction is rejected for, clients switch on them
+const (
+	TxErrDecode       = "decode"        // hex or tx encoding is malformed
+	TxErrWrongNetwork = "wrong_network" // tx is signed for another network
+	TxErrUnknownInput = "unknown_input" // input is not a spendable output of the user
+	TxErrDustOutput   = "dust_output"   // output is too small to be relayed
+	TxErrFeeTooLow    = "fee_too_low"   // tx would stay in mempool for too long
+	TxErrFeeTooHigh   = "fee_too_high"  // fee is absurd, most likely a client bug
+	TxErrNode         = "node_rejected" // node service refused to broadcast the tx
+)
+
+// ErrTxNotPending is returned if fee of a tx already mined or replaced is asked to be bumped
+var ErrTxNotPending = errors.New("tx is not pending")
+
+// TxError is the reason a raw transaction is not broadcast, it is returned to clients as is
+type TxError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *TxError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// NewTxError returns the rejection reason with formatted message
+func NewTxError(code, format string, args ...interface{}) *TxError {
+	return &TxError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// NodeTxError returns the error of node service reply if broadcast failed,
+// node services reply with a message containing "err:" then
+func NodeTxError(resp string) *TxError {
+	if !strings.Contains(resp, "err:") {
+		return nil
+	}
+	msg := strings.TrimSpace(resp[strings.Index(resp, "err:")+len("err:"):])
+	return &TxError{
+		Code:    TxErrNode,
+		Message: msg,
+	}
+}
+
+// conflictReplies are node rejections of a tx whose inputs or nonce are used by another tx,
+// such a tx is never mined whatever times it is sent
+var conflictReplies = []string{
+	"txn-mempool-conflict",
+	"missingorspent",
+	"missing inputs",
+	"already spent",
+	"nonce too low",
+	"replacement transaction underpriced",
+}
+
+// knownReplies are node rejections of a tx the node has already
+var knownReplies = []string{
+	"already known",
+	"txn-already-in-mempool",
+	"txn-already-known",
+	"already in block chain",
+}
+
+// Conflict tells that the node rejected the tx as it conflicts with another one
+func (e *TxError) Conflict() bool {
+	return replyContains(e.Message, conflictReplies)
+}
+
+// Known tells that the node rejected the tx as it has the tx already
+func (e *TxError) Known() bool {
+	return replyContains(e.Message, knownReplies)
+}
+
+func replyContains(msg string, replies []string) bool {
+	msg = strings.ToLower(msg)
+	for _, reply := range replies {
+		if strings.Contains(msg, reply) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFeeRate rejects fee rates too low to be mined soon or too high to be intended
+func CheckFeeRate(rate int64, rates store.FeeRates) error {
+	if min := int64(rates.VerySlow) * lowFeePercent / 100; rate < min {
+		return NewTxError(TxErrFeeTooLow, "fee rate %d is below %d", rate, min)
+	}
+	if max := int64(rates.VeryFast) * absurdFeeFactor; max > 0 && rate > max {
+		return NewTxError(TxErrFeeTooHigh, "fee rate %d is above %d", rate, max)
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Multy-io/Multy-back/logger"
 	"github.com/Multy-io/Multy-back/store"
 	"github.com/jekabolt/slf"
 )
@@ -54,7 +55,9 @@ type exchangeChart struct {
 	log slf.StructuredLogger
 }
 
-func newExchangeChart(db store.UserStore) (*exchangeChart, error) {
+// newExchangeChart connects to stock exchanges unless disableStocks is set,
+// rates stay empty in that case
+func newExchangeChart(db store.UserStore, disableStocks bool) (*exchangeChart, error) {
 	chart := &exchangeChart{
 		rates: &Rates{
 			exchangeBitfinex: &StockRate{
@@ -74,10 +77,15 @@ func newExchangeChart(db store.UserStore) (*exchangeChart, error) {
 			mDay:        &sync.Mutex{},
 		},
 		db:  db,
-		log: slf.WithContext("chart"),
+		log: logger.WithContext("chart"),
 	}
 	chart.log.Debug("new exchange chart")
 
+	if disableStocks {
+		chart.log.Info("exchange stocks are disabled")
+		return chart, nil
+	}
+
 	//moved to next release
 	//chart.getDayAPIBitstamp()
 
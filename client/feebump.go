@@ -0,0 +1,102 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/store"
+	"github.com/gin-gonic/gin"
+)
+
+// getFeeBump returns replacement and child-pays-for-parent suggestions for the stuck tx of the user
+func (restClient *RestClient) getFeeBump() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := getToken(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrHeaderError,
+			})
+			return
+		}
+
+		currencyID, err := strconv.Atoi(c.Param("currencyid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrDecodeCurIndexErr,
+			})
+			return
+		}
+
+		networkID, err := strconv.Atoi(c.Param("networkid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrDecodenetworkidErr,
+			})
+			return
+		}
+
+		user := store.User{}
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrUserNotFound,
+			})
+			return
+		}
+
+		ch, err := restClient.chains.Get(currencyID, networkID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+			})
+			return
+		}
+		utxo, ok := ch.(chain.UTXO)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrMethodNotImplennted,
+			})
+			return
+		}
+
+		bump, err := utxo.FeeBump(user.UserID, c.Param("txid"))
+		switch {
+		case err == store.ErrNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"message": msgErrTxNotFound,
+			})
+			return
+		case err == chain.ErrTxNotPending:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrTxNotPending,
+			})
+			return
+		case err != nil:
+			restClient.log.Errorf("getFeeBump: utxo.FeeBump: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"message": http.StatusText(http.StatusOK),
+			"feebump": bump,
+		})
+	}
+}
@@ -9,12 +9,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strconv"
 	"time"
 
 	firebase "firebase.google.com/go"
 	"firebase.google.com/go/messaging"
 	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/logger"
 	"github.com/Multy-io/Multy-back/store"
 	"github.com/gin-gonic/gin"
 	"github.com/jekabolt/slf"
@@ -41,8 +43,10 @@ type FirebaseClient struct {
 	// client *fcm.FcmClient
 	app *firebase.App
 
-	nsqConsumer *nsq.Consumer
-	nsqConfig   *nsq.Config
+	nsqConsumer             *nsq.Consumer
+	nsqConsumerMultisigSign *nsq.Consumer
+	nsqConsumerTxDropped    *nsq.Consumer
+	nsqConfig               *nsq.Config
 
 	log slf.StructuredLogger
 }
@@ -53,7 +57,7 @@ func InitFirebaseConn(conf *FirebaseConf, c *gin.Engine, nsqAddr string) (*Fireb
 		// client:    fcm.NewFcmClient(conf.ServerKey),
 		nsqConfig: nsq.NewConfig(),
 
-		log: slf.WithContext("firebase"),
+		log: logger.WithContext("firebase"),
 	}
 	fClient.log.Info("Firebase connection initialization")
 
@@ -84,6 +88,7 @@ func InitFirebaseConn(conf *FirebaseConf, c *gin.Engine, nsqAddr string) (*Fireb
 			// topic := "btcTransactionUpdate-003b1e5227ce5f45b22676dc4b55ea00e1410c5f3cf8ae972724fa5d93ecc4585e"
 
 			messageKeys := map[string]string{
+				"contract":        msg.NotificationMsg.Contract,
 				"score":           "1",
 				"time":            time.Now().Format(time.Kitchen),
 				"amount":          msg.NotificationMsg.Amount,
@@ -103,7 +108,7 @@ func InitFirebaseConn(conf *FirebaseConf, c *gin.Engine, nsqAddr string) (*Fireb
 								// Title: "You have a new transaction",
 								// Body:  msg.NotificationMsg.Amount + " " + currencies.CurrencyNames[msg.NotificationMsg.CurrencyID],
 								LocKey:  store.TopicNewIncoming,
-								LocArgs: []string{convertToHuman(msg.NotificationMsg.Amount, currencies.Dividers[msg.NotificationMsg.CurrencyID]), currencies.CurrencyNames[msg.NotificationMsg.CurrencyID]},
+								LocArgs: humanAmount(msg.NotificationMsg),
 							},
 						},
 					},
@@ -130,10 +135,139 @@ func InitFirebaseConn(conf *FirebaseConf, c *gin.Engine, nsqAddr string) (*Fireb
 	if err = nsqConsumer.ConnectToNSQD(nsqAddr); err != nil {
 		return nil, fmt.Errorf("connecting to nsq: %s", err.Error())
 	}
+	fClient.nsqConsumer = nsqConsumer
+
+	fClient.nsqConsumerMultisigSign, err = fClient.newConsumerMultisigSign(service, nsqAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	fClient.nsqConsumerTxDropped, err = fClient.newConsumerTxDropped(service, nsqAddr)
+	if err != nil {
+		return nil, err
+	}
 	fClient.log.Debugf("Firebase connection initialization done")
 	return fClient, nil
 }
 
+// newConsumerMultisigSign pushes owners of multisigs to confirm pending txs
+func (fClient *FirebaseClient) newConsumerMultisigSign(service *firebase.App, nsqAddr string) (*nsq.Consumer, error) {
+	nsqConsumer, err := nsq.NewConsumer(store.TopicMultisigSign, "firebase", fClient.nsqConfig)
+	if err != nil {
+		return nil, fmt.Errorf("new nsq consumer: %s", err.Error())
+	}
+
+	nsqConsumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
+		sign := store.MultisigSignNeeded{}
+		if err := json.Unmarshal(message.Body, &sign); err != nil {
+			return err
+		}
+
+		messageToSend := &messaging.Message{
+			Data: map[string]string{
+				"currencyid":  strconv.Itoa(sign.CurrencyID),
+				"networkid":   strconv.Itoa(sign.NetworkID),
+				"contract":    sign.Contract,
+				"owner":       sign.Owner,
+				"txindex":     strconv.FormatInt(sign.TxIndex, 10),
+				"walletindex": strconv.Itoa(sign.WalletIndex),
+			},
+			APNS: &messaging.APNSConfig{
+				Payload: &messaging.APNSPayload{
+					Aps: &messaging.Aps{
+						Alert: &messaging.ApsAlert{
+							LocKey:  store.TopicMultisigSign,
+							LocArgs: []string{sign.WalletName, strconv.Itoa(sign.Confirmed), strconv.Itoa(sign.Required)},
+						},
+					},
+				},
+			},
+			Topic: store.TopicTransaction + "-" + sign.UserID,
+		}
+
+		ctx := context.Background()
+		client, err := service.Messaging(ctx)
+		if err != nil {
+			fClient.log.Errorf("service.Messaging: %v", err.Error())
+			return nil
+		}
+		if _, err = client.Send(ctx, messageToSend); err != nil {
+			fClient.log.Errorf("client.Send : %v", err.Error())
+		}
+		fClient.log.Debugf("multisig push to user: %v", sign.UserID)
+		return nil
+	}))
+
+	if err = nsqConsumer.ConnectToNSQD(nsqAddr); err != nil {
+		return nil, fmt.Errorf("connecting to nsq: %s", err.Error())
+	}
+	return nsqConsumer, nil
+}
+
+// newConsumerTxDropped pushes users whose sent txs were dropped from mempool
+func (fClient *FirebaseClient) newConsumerTxDropped(service *firebase.App, nsqAddr string) (*nsq.Consumer, error) {
+	nsqConsumer, err := nsq.NewConsumer(store.TopicTxDropped, "firebase", fClient.nsqConfig)
+	if err != nil {
+		return nil, fmt.Errorf("new nsq consumer: %s", err.Error())
+	}
+
+	nsqConsumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
+		dropped := store.TxDropped{}
+		if err := json.Unmarshal(message.Body, &dropped); err != nil {
+			return err
+		}
+
+		messageToSend := &messaging.Message{
+			Data: map[string]string{
+				"currencyid": strconv.Itoa(dropped.CurrencyID),
+				"networkid":  strconv.Itoa(dropped.NetworkID),
+				"txid":       dropped.TxID,
+			},
+			APNS: &messaging.APNSConfig{
+				Payload: &messaging.APNSPayload{
+					Aps: &messaging.Aps{
+						Alert: &messaging.ApsAlert{
+							LocKey:  store.TopicTxDropped,
+							LocArgs: []string{currencies.CurrencyNames[dropped.CurrencyID], dropped.TxID},
+						},
+					},
+				},
+			},
+			Topic: store.TopicTransaction + "-" + dropped.UserID,
+		}
+
+		ctx := context.Background()
+		client, err := service.Messaging(ctx)
+		if err != nil {
+			fClient.log.Errorf("service.Messaging: %v", err.Error())
+			return nil
+		}
+		if _, err = client.Send(ctx, messageToSend); err != nil {
+			fClient.log.Errorf("client.Send : %v", err.Error())
+		}
+		fClient.log.Debugf("dropped tx push to user: %v", dropped.UserID)
+		return nil
+	}))
+
+	if err = nsqConsumer.ConnectToNSQD(nsqAddr); err != nil {
+		return nil, fmt.Errorf("connecting to nsq: %s", err.Error())
+	}
+	return nsqConsumer, nil
+}
+
+// humanAmount returns amount and name of the currency or the token of the incoming tx.
+// Decimals of tokens come from contracts, the amount is sent as is when they are unknown.
+func humanAmount(msg *store.WsTxNotify) []string {
+	if msg.Contract != "" {
+		if msg.Decimals <= 0 && msg.Symbol == "" {
+			return []string{msg.Amount, msg.Contract}
+		}
+		divider := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(msg.Decimals)), nil)
+		return []string{divideToHuman(msg.Amount, new(big.Float).SetInt(divider)), msg.Symbol}
+	}
+	return []string{convertToHuman(msg.Amount, currencies.Dividers[msg.CurrencyID]), currencies.CurrencyNames[msg.CurrencyID]}
+}
+
 func NewPushService(withCredentialsFile string) (*firebase.App, error) {
 	opt := option.WithCredentialsFile(withCredentialsFile)
 	return firebase.NewApp(context.Background(), nil, opt)
@@ -0,0 +1,53 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestHumanAmount(t *testing.T) {
+	cases := []struct {
+		name string
+		msg  store.WsTxNotify
+		want []string
+	}{
+		{
+			name: "ether",
+			msg:  store.WsTxNotify{CurrencyID: currencies.Ether, Amount: "1500000000000000000"},
+			want: []string{"1.5", currencies.CurrencyNames[currencies.Ether]},
+		},
+		{
+			name: "token",
+			msg:  store.WsTxNotify{Contract: "0xdai", Symbol: "DAI", Decimals: 18, Amount: "2500000000000000000"},
+			want: []string{"2.5", "DAI"},
+		},
+		{
+			name: "token with more than 18 decimals",
+			msg:  store.WsTxNotify{Contract: "0xyam", Symbol: "YAM", Decimals: 24, Amount: "3000000000000000000000000"},
+			want: []string{"3", "YAM"},
+		},
+		{
+			name: "token with unknown decimals",
+			msg:  store.WsTxNotify{Contract: "0xunknown", Amount: "12345"},
+			want: []string{"12345", "0xunknown"},
+		},
+		{
+			name: "amount which is not a number",
+			msg:  store.WsTxNotify{Contract: "0xdai", Symbol: "DAI", Decimals: 18, Amount: "unknown"},
+			want: []string{"unknown", "DAI"},
+		},
+	}
+	for _, c := range cases {
+		if got := humanAmount(&c.msg); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: humanAmount = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/Multy-io/Multy-back/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerIdempotencyKey = "Idempotency-Key"
+	// headerIdempotentReplay is set on responses repeated from the store
+	headerIdempotentReplay = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+	// idempotencyLease is how long the key is held by the request in progress,
+	// it's taken again after that as the request is lost
+	idempotencyLease = time.Minute
+)
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent makes requests with the Idempotency-Key header run once per user and key,
+// repeated requests get the stored response. Server errors, handler panics and requests
+// left without response are not stored, the request may be repeated. So may be the one
+// the backend crashed handling once idempotencyLease is over.
+func (restClient *RestClient) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(headerIdempotencyKey)
+		if key == "" {
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrIdempotencyKey,
+			})
+			return
+		}
+
+		token, err := getToken(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrHeaderError,
+			})
+			return
+		}
+		user := store.User{}
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrUserNotFound,
+			})
+			return
+		}
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrRequestBodyError,
+			})
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		err = restClient.userStore.ReserveIdempotencyKey(user.UserID, key, requestHash, idempotencyLease)
+		if err == store.ErrKeyExists {
+			restClient.replayResponse(c, user.UserID, key, requestHash)
+			return
+		}
+		if err != nil {
+			restClient.log.Errorf("idempotent: userStore.ReserveIdempotencyKey: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			if r := recover(); r != nil {
+				restClient.releaseIdempotencyKey(c, user.UserID, key)
+				panic(r)
+			}
+		}()
+		c.Next()
+
+		if !w.Written() || w.Status() >= http.StatusInternalServerError {
+			restClient.releaseIdempotencyKey(c, user.UserID, key)
+			return
+		}
+		err = restClient.userStore.SaveIdempotentResponse(user.UserID, key, w.Status(), w.body.Bytes())
+		if err != nil {
+			restClient.log.Errorf("idempotent: userStore.SaveIdempotentResponse: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+		}
+	}
+}
+
+// releaseIdempotencyKey lets the request with the key run again
+func (restClient *RestClient) releaseIdempotencyKey(c *gin.Context, userID, key string) {
+	err := restClient.userStore.ReleaseIdempotencyKey(userID, key)
+	if err != nil {
+		restClient.log.Errorf("idempotent: userStore.ReleaseIdempotencyKey: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+	}
+}
+
+// replayResponse repeats the stored response to the request with the key
+func (restClient *RestClient) replayResponse(c *gin.Context, userID, key, requestHash string) {
+	resp, err := restClient.userStore.FindIdempotentResponse(userID, key)
+	if err != nil {
+		restClient.log.Errorf("replayResponse: userStore.FindIdempotentResponse: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"message": msgErrServerError,
+		})
+		return
+	}
+	switch {
+	case resp.RequestHash != requestHash:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"code":    http.StatusUnprocessableEntity,
+			"message": msgErrIdempotencyKeyReused,
+		})
+	case resp.Code == 0:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"message": msgErrIdempotencyInProgress,
+		})
+	default:
+		c.Header(headerIdempotentReplay, "true")
+		c.Data(resp.Code, "application/json; charset=utf-8", resp.Body)
+		c.Abort()
+	}
+}
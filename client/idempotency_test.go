@@ -0,0 +1,56 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/logger"
+	"github.com/Multy-io/Multy-back/store"
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotentReleasesKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userStore := store.NewMemoryUserStore()
+	userStore.Insert(store.User{UserID: "user", Devices: []store.Device{{JWT: "token"}}})
+	restClient := &RestClient{userStore: userStore, log: logger.WithContext("idempotency-test")}
+
+	calls := 0
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(ioutil.Discard))
+	router.POST("/panic", restClient.idempotent(), func(c *gin.Context) {
+		calls++
+		panic("handler failed")
+	})
+	router.POST("/silent", restClient.idempotent(), func(c *gin.Context) {
+		calls++
+	})
+
+	do := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set(headerIdempotencyKey, "key"+path)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/panic", "/silent"} {
+		calls = 0
+		for i := 0; i < 2; i++ {
+			if w := do(path); w.Header().Get(headerIdempotentReplay) != "" || w.Code == http.StatusConflict {
+				t.Errorf("%s: attempt %d got %d replayed %q", path, i, w.Code, w.Header().Get(headerIdempotentReplay))
+			}
+		}
+		if calls != 2 {
+			t.Errorf("%s: handler ran %d times, want the key released after every attempt", path, calls)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/gin-gonic/gin"
+	"github.com/graarh/golang-socketio"
+	"github.com/jekabolt/slf"
+)
+
+const (
+	defaultMempoolHours = 24
+	maxMempoolHours     = 24 * 30
+
+	// updateMempoolStats is how often mempool stats are pushed to socket.io subscribers
+	updateMempoolStats = 30 * time.Second
+
+	MempoolSubscribe   = "event:mempool:subscribe"
+	MempoolUnsubscribe = "event:mempool:unsubscribe"
+
+	topicMempool = "mempool"
+)
+
+var errBadMempoolQuery = errors.New("malformed mempool query")
+
+// MempoolSubscription is a socket.io request for mempool stats of the chain
+type MempoolSubscription struct {
+	CurrencyID int `json:"currencyid"`
+	NetworkID  int `json:"networkid"`
+}
+
+func mempoolRoom(currencyID, networkID int) string {
+	return fmt.Sprintf("%s:%d:%d", topicMempool, currencyID, networkID)
+}
+
+// parseMempoolQuery returns hours of the trend and fee rate to estimate confirmation for,
+// zero fee rate means no estimation
+func parseMempoolQuery(hours, feeRate string) (int, int, error) {
+	h := defaultMempoolHours
+	if hours != "" {
+		var err error
+		h, err = strconv.Atoi(hours)
+		if err != nil || h <= 0 {
+			return 0, 0, errBadMempoolQuery
+		}
+		if h > maxMempoolHours {
+			h = maxMempoolHours
+		}
+	}
+	rate := 0
+	if feeRate != "" {
+		var err error
+		rate, err = strconv.Atoi(feeRate)
+		if err != nil || rate < 0 {
+			return 0, 0, errBadMempoolQuery
+		}
+	}
+	return h, rate, nil
+}
+
+// getMempoolStats returns mempool size, fee rate histogram and trend of the chain,
+// confirmation time is estimated for the feerate query param
+func (restClient *RestClient) getMempoolStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currencyID, err := strconv.Atoi(c.Param("currencyid"))
+		if err != nil {
+			restClient.log.Errorf("getMempoolStats: non int currency id: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrDecodeCurIndexErr,
+			})
+			return
+		}
+
+		networkID, err := strconv.Atoi(c.Param("networkid"))
+		if err != nil {
+			restClient.log.Errorf("getMempoolStats: non int networkid: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrDecodenetworkidErr,
+			})
+			return
+		}
+
+		hours, feeRate, err := parseMempoolQuery(c.Query("hours"), c.Query("feerate"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrMempoolQuery,
+			})
+			return
+		}
+
+		ch, err := restClient.chains.Get(currencyID, networkID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+			})
+			return
+		}
+
+		stats, err := ch.MempoolStats(hours, feeRate)
+		if err != nil {
+			restClient.log.Errorf("getMempoolStats: ch.MempoolStats: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"message": http.StatusText(http.StatusOK),
+			"mempool": stats,
+		})
+	}
+}
+
+// setMempoolHandlers lets socket.io clients subscribe to mempool stats of a chain,
+// subscribers get the stats right away and then periodically
+func setMempoolHandlers(server *gosocketio.Server, chains *chain.Registry, log slf.StructuredLogger) {
+	server.On(MempoolSubscribe, func(c *gosocketio.Channel, req MempoolSubscription) string {
+		ch, err := chains.Get(req.CurrencyID, req.NetworkID)
+		if err != nil {
+			return "err: no such curid or netid"
+		}
+		c.Join(mempoolRoom(req.CurrencyID, req.NetworkID))
+
+		stats, err := ch.MempoolStats(defaultMempoolHours, 0)
+		if err != nil {
+			log.Errorf("mempool subscribe: ch.MempoolStats: %s", err.Error())
+		} else {
+			c.Emit(topicMempool, stats)
+		}
+		return MempoolSubscribe + ":ok"
+	})
+
+	server.On(MempoolUnsubscribe, func(c *gosocketio.Channel, req MempoolSubscription) string {
+		c.Leave(mempoolRoom(req.CurrencyID, req.NetworkID))
+		return MempoolUnsubscribe + ":ok"
+	})
+
+	go func() {
+		for range time.Tick(updateMempoolStats) {
+			for _, ch := range chains.All() {
+				room := mempoolRoom(ch.CurrencyID(), ch.NetworkID())
+				if server.Amount(room) == 0 {
+					continue
+				}
+				stats, err := ch.MempoolStats(defaultMempoolHours, 0)
+				if err != nil {
+					log.Errorf("mempool update: ch.MempoolStats: curID:%d netID:%d %s", ch.CurrencyID(), ch.NetworkID(), err.Error())
+					continue
+				}
+				server.BroadcastTo(room, topicMempool, stats)
+			}
+		}
+	}()
+}
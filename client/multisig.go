@@ -0,0 +1,226 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/store"
+	"github.com/gin-gonic/gin"
+)
+
+// MultisigParams describes a deployed multisig contract,
+// its owners and confirmations are read from the chain
+type MultisigParams struct {
+	CurrencyID      int    `json:"currencyID"`
+	NetworkID       int    `json:"networkID"`
+	ContractAddress string `json:"contractAddress"`
+	TxOfCreation    string `json:"txOfCreation"`
+	WalletName      string `json:"walletName"`
+}
+
+// MultisigVerbose is a multisig with owners associated with wallets of the user and txs submitted to it
+type MultisigVerbose struct {
+	store.Multisig
+	Txs []store.MultisigTx `json:"txs"`
+}
+
+// addMultisig registers the multisig contract one of the owners of which is in wallets of the user
+func (restClient *RestClient) addMultisig() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := getToken(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrHeaderError,
+			})
+			return
+		}
+
+		params := MultisigParams{}
+		if err := decodeBody(c, &params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrRequestBodyError,
+			})
+			return
+		}
+		if params.ContractAddress == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrMultisigParams,
+			})
+			return
+		}
+
+		user := store.User{}
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrUserNotFound,
+			})
+			return
+		}
+
+		ch, err := restClient.chains.Get(params.CurrencyID, params.NetworkID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+			})
+			return
+		}
+		ac, ok := ch.(chain.Account)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrMethodNotImplennted,
+			})
+			return
+		}
+
+		multisig, err := ac.Multisig(params.ContractAddress)
+		if err == chain.ErrNotMultisig {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrMultisigParams,
+			})
+			return
+		}
+		if err != nil {
+			restClient.log.Errorf("addMultisig: ch.Multisig: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
+		if !ownedBy(multisig.Owners, user.UserID) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrNotMultisigOwner,
+			})
+			return
+		}
+
+		multisig.WalletName = params.WalletName
+		multisig.TxOfCreation = params.TxOfCreation
+		multisig, err = ac.AddMultisig(multisig)
+		if err != nil {
+			restClient.log.Errorf("addMultisig: ch.AddMultisig: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"code":     http.StatusCreated,
+			"message":  http.StatusText(http.StatusCreated),
+			"multisig": restClient.multisigVerbose(user.UserID, multisig),
+		})
+	}
+}
+
+// getMultisigs returns multisigs of the chain owned by wallets of the user with txs submitted to them
+func (restClient *RestClient) getMultisigs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := getToken(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrHeaderError,
+			})
+			return
+		}
+
+		currencyID, err := strconv.Atoi(c.Param("currencyid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrDecodeCurIndexErr,
+			})
+			return
+		}
+
+		networkID, err := strconv.Atoi(c.Param("networkid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrDecodenetworkidErr,
+			})
+			return
+		}
+
+		user := store.User{}
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrUserNotFound,
+			})
+			return
+		}
+
+		multisigs, err := restClient.userStore.GetUserMultisigs(currencyID, networkID, user.UserID)
+		if err != nil {
+			restClient.log.Errorf("getMultisigs: userStore.GetUserMultisigs: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
+
+		verbose := []MultisigVerbose{}
+		for _, multisig := range multisigs {
+			verbose = append(verbose, restClient.multisigVerbose(user.UserID, multisig))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":      http.StatusOK,
+			"message":   http.StatusText(http.StatusOK),
+			"multisigs": verbose,
+		})
+	}
+}
+
+// multisigVerbose associates owners of the multisig with wallets of the user only
+// and adds txs submitted to it
+func (restClient *RestClient) multisigVerbose(userID string, multisig store.Multisig) MultisigVerbose {
+	addresses := []string{}
+	for _, owner := range multisig.Owners {
+		addresses = append(addresses, owner.Address)
+	}
+	owners, err := restClient.userStore.FethUserAddresses(multisig.CurrencyID, multisig.NetworkID, userID, addresses)
+	if err != nil {
+		restClient.log.Errorf("multisigVerbose: userStore.FethUserAddresses: %s", err.Error())
+	} else {
+		multisig.Owners = owners
+	}
+
+	txs, err := restClient.userStore.GetMultisigTxs(multisig.CurrencyID, multisig.NetworkID, multisig.ContractAddress)
+	if err != nil {
+		restClient.log.Errorf("multisigVerbose: userStore.GetMultisigTxs: %s", err.Error())
+		txs = []store.MultisigTx{}
+	}
+	return MultisigVerbose{
+		Multisig: multisig,
+		Txs:      txs,
+	}
+}
+
+// ownedBy reports whether any of the owners is in wallets of the user
+func ownedBy(owners []store.AddressExtended, userID string) bool {
+	for _, ae := range owners {
+		if ae.Associated && ae.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
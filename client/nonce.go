@@ -0,0 +1,110 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/store"
+	"github.com/gin-gonic/gin"
+)
+
+// getNonces returns the nonce the address of the user should sign the next tx with,
+// its pending txs with speed up and cancel suggestions for stuck ones
+func (restClient *RestClient) getNonces() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := getToken(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrHeaderError,
+			})
+			return
+		}
+
+		currencyID, err := strconv.Atoi(c.Param("currencyid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrDecodeCurIndexErr,
+			})
+			return
+		}
+
+		networkID, err := strconv.Atoi(c.Param("networkid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrDecodenetworkidErr,
+			})
+			return
+		}
+
+		user := store.User{}
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrUserNotFound,
+			})
+			return
+		}
+
+		ch, err := restClient.chains.Get(currencyID, networkID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+			})
+			return
+		}
+		account, ok := ch.(chain.Account)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrMethodNotImplennted,
+			})
+			return
+		}
+
+		address := strings.ToLower(c.Param("address"))
+		ua := store.UserAddress{}
+		err = restClient.userStore.FindAddress(currencyID, networkID, address, &ua)
+		if err == store.ErrNotFound || (err == nil && ua.UserID != user.UserID) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"message": msgErrAddressNotFound,
+			})
+			return
+		}
+		if err != nil {
+			restClient.log.Errorf("getNonces: restClient.userStore.FindAddress: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
+
+		nonces, err := account.Nonces(address)
+		if err != nil {
+			restClient.log.Errorf("getNonces: account.Nonces: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"message": http.StatusText(http.StatusOK),
+			"nonces":  nonces,
+		})
+	}
+}
@@ -0,0 +1,30 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"time"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// saveOutboundTx keeps the tx the node service accepted, so it is sent again if the node loses it
+func saveOutboundTx(userStore store.UserStore, ch chain.Chain, userID, rawTx string) error {
+	txID, err := ch.TxHash(rawTx)
+	if err != nil {
+		return err
+	}
+	return userStore.SaveOutboundTx(store.OutboundTx{
+		CurrencyID: ch.CurrencyID(),
+		NetworkID:  ch.NetworkID(),
+		UserID:     userID,
+		TxID:       txID,
+		RawTx:      rawTx,
+		Status:     store.OutboundTxPending,
+		Submitted:  time.Now().Unix(),
+	})
+}
@@ -13,7 +13,6 @@ import (
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"gopkg.in/mgo.v2/bson"
 )
 
 // The jwt middleware.
@@ -95,9 +94,7 @@ func (restClient *RestClient) LoginHandler() gin.HandlerFunc {
 			// userID and deviceID existed in DB
 			if concreteDevice.DeviceID == loginVals.DeviceID {
 				restClient.log.Infof("update token for device %s", loginVals.DeviceID)
-				sel := bson.M{"userID": user.UserID, "devices.JWT": concreteDevice.JWT}
-				update := bson.M{"$set": bson.M{"devices.$.JWT": tokenString}}
-				err = restClient.userStore.Update(sel, update)
+				err = restClient.userStore.UpdateDeviceToken(user.UserID, concreteDevice.JWT, tokenString)
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{
 						"token":  "",
@@ -120,8 +117,7 @@ func (restClient *RestClient) LoginHandler() gin.HandlerFunc {
 		device := createDevice(loginVals.DeviceID, c.ClientIP(), tokenString, loginVals.PushToken, loginVals.AppVersion, loginVals.DeviceType)
 		user.Devices = append(user.Devices, device)
 
-		sel := bson.M{"userID": userID}
-		err = restClient.userStore.UpdateUser(sel, &user)
+		err = restClient.userStore.UpdateUser(user)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"token":  "",
@@ -6,32 +6,29 @@ See LICENSE for details
 package client
 
 import (
-	"context"
 	"crypto/hmac"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Multy-io/Multy-back/btc"
+	"github.com/Multy-io/Multy-back/chain"
 	"github.com/Multy-io/Multy-back/currencies"
 	"github.com/Multy-io/Multy-back/eth"
+	"github.com/Multy-io/Multy-back/logger"
 	"github.com/Multy-io/Multy-back/store"
 	"github.com/jekabolt/slf"
 
-	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
-	ethpb "github.com/Multy-io/Multy-back/node-streamer/eth"
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/gin-gonic/gin"
-	mgo "gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
 )
 
 const (
@@ -57,6 +54,17 @@ const (
 	msgErrAdressBalance         = "empty address or 3-rd party server error"
 	msgErrChainIsNotImplemented = "current chain is not implemented"
 	msgErrUserHaveNoTxs         = "user have no transactions"
+	msgErrHistoryFilter         = "wrong history filter"
+	msgErrSyncToken             = "wrong sync token"
+	msgErrMempoolQuery          = "wrong mempool query"
+	msgErrTxNotFound            = "transaction not found"
+	msgErrTxNotPending          = "transaction is not pending"
+	msgErrIdempotencyKey        = "wrong idempotency key"
+	msgErrIdempotencyKeyReused  = "idempotency key is used for another request"
+	msgErrIdempotencyInProgress = "request with the idempotency key is in progress"
+	msgErrMultisigParams        = "wrong multisig contract"
+	msgErrNotMultisigOwner      = "none of multisig owners is in user wallets"
+	msgErrAddressNotFound       = "address is not in user wallets"
 )
 
 type RestClient struct {
@@ -74,8 +82,10 @@ type RestClient struct {
 
 	donationAddresses []store.DonationInfo
 
-	BTC            *btc.BTCConn
-	ETH            *eth.ETHConn
+	UTXO           map[int]*btc.BTCConn
+	EVM            map[int]*eth.ETHConn
+	chains         *chain.Registry
+	syncTracker    *chain.SyncTracker
 	MultyVerison   store.ServerConfig
 	Secretkey      string
 	DeviceVersions store.Versions
@@ -89,18 +99,22 @@ func SetRestHandlers(
 	userDB store.UserStore,
 	r *gin.Engine,
 	donationAddresses []store.DonationInfo,
-	btc *btc.BTCConn,
-	eth *eth.ETHConn,
+	utxo map[int]*btc.BTCConn,
+	evm map[int]*eth.ETHConn,
+	chains *chain.Registry,
+	syncTracker *chain.SyncTracker,
 	mv store.ServerConfig,
 	secretkey string,
 	deviceVersions store.Versions,
 ) (*RestClient, error) {
 	restClient := &RestClient{
 		userStore:         userDB,
-		log:               slf.WithContext("rest-client"),
+		log:               logger.WithContext("rest-client"),
 		donationAddresses: donationAddresses,
-		BTC:               btc,
-		ETH:               eth,
+		UTXO:              utxo,
+		EVM:               evm,
+		chains:            chains,
+		syncTracker:       syncTracker,
 		MultyVerison:      mv,
 		Secretkey:         secretkey,
 		DeviceVersions:    deviceVersions,
@@ -109,6 +123,8 @@ func SetRestHandlers(
 
 	r.POST("/auth", restClient.LoginHandler())
 	r.GET("/server/config", restClient.getServerConfig())
+	r.GET("/server/sync", restClient.getSyncProgress())
+	r.GET("/server/streams", restClient.getStreamsHealth())
 
 	r.GET("/donations", restClient.donations())
 
@@ -119,11 +135,18 @@ func SetRestHandlers(
 		v1.DELETE("/wallet/:currencyid/:networkid/:walletindex", restClient.deleteWallet())
 		v1.POST("/address", restClient.addAddress())
 		v1.GET("/transaction/feerate/:currencyid/:networkid", restClient.getFeeRate())
+		v1.GET("/mempool/:currencyid/:networkid", restClient.getMempoolStats())
 		v1.GET("/outputs/spendable/:currencyid/:networkid/:addr", restClient.getSpendableOutputs())
-		v1.POST("/transaction/send", restClient.sendRawHDTransaction())
+		v1.POST("/transaction/send", restClient.idempotent(), restClient.sendRawHDTransaction())
+		v1.GET("/transaction/feebump/:currencyid/:networkid/:txid", restClient.getFeeBump())
+		v1.GET("/nonce/:currencyid/:networkid/:address", restClient.getNonces())
 		v1.GET("/wallet/:walletindex/verbose/:currencyid/:networkid", restClient.getWalletVerbose())
 		v1.GET("/wallets/verbose", restClient.getAllWalletsVerbose())
 		v1.GET("/wallets/transactions/:currencyid/:networkid/:walletindex", restClient.getWalletTransactionsHistory())
+		v1.GET("/wallets/tokens/:currencyid/:networkid/:walletindex", restClient.getWalletTokenHistory())
+		v1.POST("/multisig", restClient.addMultisig())
+		v1.GET("/multisig/:currencyid/:networkid", restClient.getMultisigs())
+		v1.GET("/sync", restClient.getChanges())
 		v1.POST("/wallet/name", restClient.changeWalletName())
 		v1.POST("/resync/wallet/:currencyid/:networkid/:walletindex", restClient.resyncWallet())
 		v1.GET("/exchange/changelly/list", restClient.changellyListCurrencies())
@@ -138,11 +161,9 @@ func initMiddlewareJWT(restClient *RestClient) {
 		Timeout:    time.Hour,
 		MaxRefresh: time.Hour,
 		Authenticator: func(userId, deviceId, pushToken string, deviceType int, c *gin.Context) (store.User, bool) {
-			query := bson.M{"userID": userId}
-
 			user := store.User{}
 
-			err := restClient.userStore.FindUser(query, &user)
+			err := restClient.userStore.FindUserByID(userId, &user)
 
 			if err != nil || len(user.UserID) == 0 {
 				return user, false
@@ -223,9 +244,8 @@ func getToken(c *gin.Context) (string, error) {
 
 func createCustomWallet(wp WalletParams, token string, restClient *RestClient, c *gin.Context) error {
 	user := store.User{}
-	query := bson.M{"devices.JWT": token}
 
-	err := restClient.userStore.FindUser(query, &user)
+	err := restClient.userStore.FindUserByToken(token, &user)
 	if err != nil {
 		restClient.log.Errorf("createCustomWallet: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 		err = errors.New(msgErrUserNotFound)
@@ -239,13 +259,11 @@ func createCustomWallet(wp WalletParams, token string, restClient *RestClient, c
 		}
 	}
 
-	sel := bson.M{"devices.JWT": token}
 	wallet := createWallet(wp.CurrencyID, wp.NetworkID, wp.Address, wp.AddressIndex, wp.WalletIndex, wp.WalletName)
-	update := bson.M{"$push": bson.M{"wallets": wallet}}
 
-	err = restClient.userStore.Update(sel, update)
+	err = restClient.userStore.AddWallet(user.UserID, wallet)
 	if err != nil {
-		restClient.log.Errorf("addWallet: restClient.userStore.Update: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+		restClient.log.Errorf("addWallet: restClient.userStore.AddWallet: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 		err := errors.New(msgErrServerError)
 		return err
 	}
@@ -262,48 +280,29 @@ func createCustomWallet(wp WalletParams, token string, restClient *RestClient, c
 
 func changeName(cn ChangeName, token string, restClient *RestClient, c *gin.Context) error {
 	user := store.User{}
-	query := bson.M{"devices.JWT": token}
 
-	if err := restClient.userStore.FindUser(query, &user); err != nil {
+	if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
 		restClient.log.Errorf("deleteWallet: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 		err := errors.New(msgErrUserNotFound)
 		return err
 	}
-	var position int
-
-	for i, wallet := range user.Wallets {
-		if wallet.NetworkID == cn.NetworkID && wallet.WalletIndex == cn.WalletIndex && wallet.CurrencyID == cn.CurrencyID {
-			position = i
-			break
-		}
-	}
-	sel := bson.M{"userID": user.UserID, "wallets.walletIndex": cn.WalletIndex, "wallets.networkID": cn.NetworkID}
-	update := bson.M{
-		"$set": bson.M{
-			"wallets." + strconv.Itoa(position) + ".walletName": cn.WalletName,
-		},
+	err := restClient.userStore.ChangeWalletName(user.UserID, cn.CurrencyID, cn.NetworkID, cn.WalletIndex, cn.WalletName)
+	if err == store.ErrNotFound {
+		return errors.New(msgErrNoWallet)
 	}
-
-	return restClient.userStore.Update(sel, update)
-
-	err := errors.New(msgErrNoWallet)
 	return err
-
 }
 
 func addAddressToWallet(address, token string, currencyID, networkid, walletIndex, addressIndex int, restClient *RestClient, c *gin.Context) error {
 	user := store.User{}
-	query := bson.M{"devices.JWT": token}
 
-	if err := restClient.userStore.FindUser(query, &user); err != nil {
+	if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
 		// restClient.log.Errorf("deleteWallet: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 		return errors.New(msgErrUserNotFound)
 	}
 
-	var position int
-	for i, wallet := range user.Wallets {
+	for _, wallet := range user.Wallets {
 		if wallet.NetworkID == networkid && wallet.CurrencyID == currencyID && wallet.WalletIndex == walletIndex {
-			position = i
 			for _, walletAddress := range wallet.Adresses {
 				if walletAddress.AddressIndex == addressIndex {
 					return errors.New(msgErrAddressIndex)
@@ -319,10 +318,8 @@ func addAddressToWallet(address, token string, currencyID, networkid, walletInde
 	}
 
 	//TODO: make no possibility to add eth address
-	sel := bson.M{"devices.JWT": token, "wallets.currencyID": currencyID, "wallets.networkID": networkid, "wallets.walletIndex": walletIndex}
-	update := bson.M{"$push": bson.M{"wallets." + strconv.Itoa(position) + ".addresses": addr}}
-	if err := restClient.userStore.Update(sel, update); err != nil {
-		restClient.log.Errorf("addAddressToWallet: restClient.userStore.Update: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+	if err := restClient.userStore.AddAddress(user.UserID, currencyID, networkid, walletIndex, addr); err != nil {
+		restClient.log.Errorf("addAddressToWallet: restClient.userStore.AddAddress: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 		return errors.New(msgErrServerError)
 	}
 
@@ -341,48 +338,11 @@ func AddWatchAndResync(currencyID, networkid, walletIndex, addressIndex int, use
 }
 
 func NewAddressNode(address, userid string, currencyID, networkID, walletIndex, addressIndex int, restClient *RestClient) error {
-
-	//add new re-sync to map
-	restClient.BTC.Resync.Store(address, true)
-
-	switch currencyID {
-	case currencies.Bitcoin:
-		if networkID == currencies.Main {
-			restClient.BTC.WatchAddressMain <- btcpb.WatchAddress{
-				Address:      address,
-				UserID:       userid,
-				WalletIndex:  int32(walletIndex),
-				AddressIndex: int32(addressIndex),
-			}
-		}
-
-		if networkID == currencies.Test {
-			restClient.BTC.WatchAddressTest <- btcpb.WatchAddress{
-				Address:      address,
-				UserID:       userid,
-				WalletIndex:  int32(walletIndex),
-				AddressIndex: int32(addressIndex),
-			}
-		}
-	case currencies.Ether:
-		if networkID == currencies.ETHMain {
-			restClient.ETH.WatchAddressMain <- ethpb.WatchAddress{
-				Address:      address,
-				UserID:       userid,
-				WalletIndex:  int32(walletIndex),
-				AddressIndex: int32(addressIndex),
-			}
-		}
-
-		if networkID == currencies.ETHTest {
-			restClient.ETH.WatchAddressTest <- ethpb.WatchAddress{
-				Address:      address,
-				UserID:       userid,
-				WalletIndex:  int32(walletIndex),
-				AddressIndex: int32(addressIndex),
-			}
-		}
+	ch, err := restClient.chains.Get(currencyID, networkID)
+	if err != nil {
+		return fmt.Errorf("NewAddressNode: chains.Get: %s", err.Error())
 	}
+	ch.WatchAddress(address, userid, walletIndex, addressIndex)
 	return nil
 }
 
@@ -498,9 +458,49 @@ func (restClient *RestClient) donations() gin.HandlerFunc {
 	}
 }
 
+func (restClient *RestClient) getSyncProgress() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"code":     http.StatusOK,
+			"message":  http.StatusText(http.StatusOK),
+			"progress": restClient.syncTracker.Progress(),
+		})
+	}
+}
+
+func (restClient *RestClient) getStreamsHealth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		health := []gin.H{}
+		for _, ch := range restClient.chains.All() {
+			health = append(health, gin.H{
+				"currencyid": ch.CurrencyID(),
+				"networkid":  ch.NetworkID(),
+				"healthy":    ch.Streams().Healthy(),
+				"streams":    ch.Streams().Health(),
+				"endpoints":  ch.Endpoints().Health(),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"message": http.StatusText(http.StatusOK),
+			"chains":  health,
+		})
+	}
+}
+
 func (restClient *RestClient) getServerConfig() gin.HandlerFunc {
-	restClient.BTC.CliMain.ServiceInfo(context.Background(), &btcpb.Empty{})
 	return func(c *gin.Context) {
+		nsVersions := map[string]map[string]store.NodeVersion{}
+		nsEndpoints := map[string]map[string]string{}
+		for _, conn := range restClient.UTXO {
+			nsVersions[conn.Params.Name] = conn.Versions()
+			nsEndpoints[conn.Params.Name] = conn.Endpoints()
+		}
+		for _, conn := range restClient.EVM {
+			nsVersions[conn.Params.Name] = conn.Versions()
+			nsEndpoints[conn.Params.Name] = conn.Endpoints()
+		}
+
 		resp := map[string]interface{}{
 			"stockexchanges": map[string][]string{
 				"poloniex": []string{"usd_btc", "eth_btc", "eth_usd", "btc_usd"},
@@ -512,16 +512,8 @@ func (restClient *RestClient) getServerConfig() gin.HandlerFunc {
 			"version":    restClient.MultyVerison,
 			"ios":        restClient.DeviceVersions.IOS,
 			"donate":     restClient.donationAddresses,
-			"nsversion": map[string]map[string]store.NodeVersion{
-				"btc": map[string]store.NodeVersion{
-					"main": restClient.BTC.VersionMain,
-					"test": restClient.BTC.VersionTest,
-				},
-				"eth": map[string]store.NodeVersion{
-					"main": restClient.ETH.VersionMain,
-					"test": restClient.ETH.VersionTest,
-				},
-			},
+			"nsversion":  nsVersions,
+			"nsendpoint": nsEndpoints,
 		}
 		c.JSON(http.StatusOK, resp)
 	}
@@ -542,7 +534,7 @@ func checkBTCAddressbalance(address string, currencyID, networkid int, restClien
 
 func getBTCAddressSpendableOutputs(address string, currencyID, networkID int, restClient *RestClient) []store.SpendableOutputs {
 	spOuts, err := restClient.userStore.GetAddressSpendableOutputs(address, currencyID, networkID)
-	if err != nil && err != mgo.ErrNotFound {
+	if err != nil && err != store.ErrNotFound {
 		restClient.log.Errorf("getBTCAddressSpendableOutputs: GetAddressSpendableOutputs: %s\t", err.Error())
 	}
 	return spOuts
@@ -598,8 +590,7 @@ func (restClient *RestClient) deleteWallet() gin.HandlerFunc {
 		)
 
 		user := store.User{}
-		query := bson.M{"devices.JWT": token}
-		if err := restClient.userStore.FindUser(query, &user); err != nil {
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
 			restClient.log.Errorf("deleteWallet: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code":    http.StatusBadRequest,
@@ -610,113 +601,74 @@ func (restClient *RestClient) deleteWallet() gin.HandlerFunc {
 		code = http.StatusOK
 		message = http.StatusText(http.StatusOK)
 
-		var totalBalance int64
+		ch, err := restClient.chains.Get(currencyId, networkid)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+			})
+			return
+		}
 
-		switch currencyId {
-		case currencies.Bitcoin:
+		var zeroBalance bool
 
-			if networkid == currencies.Main {
-				for _, wallet := range user.Wallets {
-					if wallet.WalletIndex == walletIndex {
-						for _, address := range wallet.Adresses {
-							totalBalance += checkBTCAddressbalance(address.Address, currencyId, networkid, restClient)
-						}
-					}
-				}
-			}
-			if networkid == currencies.Test {
-				for _, wallet := range user.Wallets {
-					if wallet.WalletIndex == walletIndex {
-						for _, address := range wallet.Adresses {
-							totalBalance += checkBTCAddressbalance(address.Address, currencyId, networkid, restClient)
-						}
+		switch ch := ch.(type) {
+		case chain.UTXO:
+			var totalBalance int64
+			for _, wallet := range user.Wallets {
+				if wallet.WalletIndex == walletIndex && wallet.CurrencyID == currencyId && wallet.NetworkID == networkid {
+					for _, address := range wallet.Adresses {
+						totalBalance += checkBTCAddressbalance(address.Address, currencyId, networkid, restClient)
 					}
 				}
 			}
+			zeroBalance = totalBalance == 0
 
-			if totalBalance == 0 {
-				err := restClient.userStore.DeleteWallet(user.UserID, walletIndex, currencyId, networkid)
-				if err != nil {
-					restClient.log.Errorf("deleteWallet: restClient.userStore.Update: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					c.JSON(http.StatusBadRequest, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": msgErrNoWallet,
-					})
-					return
-				}
-			}
-
-			if totalBalance != 0 {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"code":    http.StatusBadRequest,
-					"message": msgErrWalletNonZeroBalance,
-				})
-				return
-			}
-
-			code = http.StatusOK
-			message = http.StatusText(http.StatusOK)
-
-		case currencies.Ether:
-
+		case chain.Account:
 			var address string
 			for _, wallet := range user.Wallets {
-				if wallet.WalletIndex == walletIndex {
+				if wallet.WalletIndex == walletIndex && wallet.CurrencyID == currencyId && wallet.NetworkID == networkid {
 					if len(wallet.Adresses) > 0 {
 						address = wallet.Adresses[0].Address
 					}
 				}
 			}
 
-			balance := &ethpb.Balance{}
-			if networkid == currencies.ETHMain {
-				balance, err = restClient.ETH.CliMain.EventGetAdressBalance(context.Background(), &ethpb.AddressToResync{
-					Address: address,
-				})
-			}
-			if networkid == currencies.ETHTest {
-				restClient.ETH.CliTest.EventGetAdressBalance(context.Background(), &ethpb.AddressToResync{
-					Address: address,
-				})
-			}
-
-			if balance.Balance == "0" || balance.Balance == "" {
-				err := restClient.userStore.DeleteWallet(user.UserID, walletIndex, currencyId, networkid)
-				if err != nil {
-					restClient.log.Errorf("deleteWallet: restClient.userStore.Update: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					c.JSON(http.StatusBadRequest, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": msgErrNoWallet,
-					})
-					return
-				}
+			if address == "" {
+				zeroBalance = true
+				break
 			}
-
-			if balance.Balance != "0" && balance.Balance != "" {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"code":    http.StatusBadRequest,
-					"message": msgErrWalletNonZeroBalance,
+			balance, _, err := ch.AddressBalance(address)
+			if err != nil {
+				// the wallet is kept while its balance is unknown
+				restClient.log.Errorf("deleteWallet: ch.AddressBalance: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"code":    http.StatusInternalServerError,
+					"message": msgErrServerError,
 				})
 				return
 			}
+			zeroBalance = balance == "0"
+		}
 
-			code = http.StatusOK
-			message = http.StatusText(http.StatusOK)
-		default:
+		if !zeroBalance {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code":    http.StatusBadRequest,
-				"message": msgErrChainIsNotImplemented,
+				"message": msgErrWalletNonZeroBalance,
 			})
 			return
 		}
 
-		if totalBalance != 0 {
+		err = restClient.userStore.DeleteWallet(user.UserID, walletIndex, currencyId, networkid)
+		if err != nil {
+			restClient.log.Errorf("deleteWallet: restClient.userStore.Update: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    http.StatusBadRequest,
-				"message": msgErrWalletNonZeroBalance,
+				"code":    http.StatusInternalServerError,
+				"message": msgErrNoWallet,
 			})
 			return
 		}
+
 		c.JSON(code, gin.H{
 			"code":    code,
 			"message": message,
@@ -781,189 +733,35 @@ func (restClient *RestClient) getFeeRate() gin.HandlerFunc {
 			return
 		}
 
-		switch currencyID {
-		case currencies.Bitcoin:
-			// restClient.BTC.M.Lock()
-			// mempool := *restClient.BTC.BtcMempool
-			// restClient.BTC.M.Unlock()
-
-			type kv struct {
-				Key   string
-				Value int
-			}
-
-			var mp []kv
-			restClient.BTC.BtcMempool.Range(func(k, v interface{}) bool {
-				mp = append(mp, kv{k.(string), v.(int)})
-				return true
-			})
-
-			// var mp []kv
-			// for k, v := range mempool {
-			// 	mp = append(mp, kv{k, v})
-			// }
-
-			sort.Slice(mp, func(i, j int) bool {
-				return mp[i].Value > mp[j].Value
+		ch, err := restClient.chains.Get(currencyID, networkid)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"speeds":  sp,
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
 			})
+			return
+		}
 
-			// for _, kv := range ss {
-			// 	fmt.Printf("%s, %d\n", kv.Key, kv.Value)
-			// }
-
-			// var speeds []string{}
-			var slowestValue, slowValue, mediumValue, fastValue, fastestValue int
-
-			memPoolSize := len(mp)
-
-			if memPoolSize <= 2000 && memPoolSize > 0 {
-				//low rates logic
-
-				fastestPosition := int(memPoolSize / 100 * 5)
-				fastPosition := int(memPoolSize / 100 * 30)
-				mediumPosition := int(memPoolSize / 100 * 50)
-				slowPosition := int(memPoolSize / 100 * 80)
-				//slowestPosition := int(memPoolSize)
-
-				slowestValue = 2
-
-				slowValue = mp[slowPosition].Value
-
-				if slowValue < 2 {
-					slowValue = 2
-				}
-
-				mediumValue = mp[mediumPosition].Value
-				fastValue = mp[fastPosition].Value
-				fastestValue = mp[fastestPosition].Value
-
-			} else if memPoolSize == 0 {
-				slowestValue = 2
-				slowValue = 2
-				mediumValue = 3
-				fastValue = 5
-				fastestValue = 10
-			} else {
-				//high rates logic
-				fastestPosition := 100
-				fastPosition := 500
-				mediumPosition := 2000
-				slowPosition := int(memPoolSize / 100 * 70)
-				slowestPosition := int(memPoolSize / 100 * 90)
-
-				slowestValue = mp[slowestPosition].Value
-
-				if slowestValue < 2 {
-					slowestValue = 2
-				}
-
-				slowValue = mp[slowPosition].Value
-
-				if slowValue < 2 {
-					slowValue = 2
-				}
-
-				mediumValue = mp[mediumPosition].Value
-				fastValue = mp[fastPosition].Value
-				fastestValue = mp[fastestPosition].Value
-
-			}
-
-			if fastValue > fastestValue {
-				fastestValue = fastValue
-			}
-			if mediumValue > fastValue {
-				fastValue = mediumValue
-			}
-			if slowValue > mediumValue {
-				mediumValue = slowValue
-			}
-			if slowestValue > slowValue {
-				slowValue = slowestValue
-			}
-
-			sp = EstimationSpeeds{
-				VerySlow: slowestValue,
-				Slow:     slowValue,
-				Medium:   mediumValue,
-				Fast:     fastValue,
-				VeryFast: fastestValue,
-			}
-
-			restClient.log.Debugf("FeeRates for Bitcoin network id %d is: %v :\n memPoolSize is: %v ", networkid, sp, memPoolSize)
-
-			c.JSON(http.StatusOK, gin.H{
+		rates, err := ch.FeeRates()
+		if err != nil {
+			restClient.log.Errorf("getFeeRate: ch.FeeRates: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
 				"speeds":  sp,
-				"code":    http.StatusOK,
-				"message": http.StatusText(http.StatusOK),
-			})
-		case currencies.Ether:
-			//TODO: make eth feerate
-			var rate *ethpb.GasPrice
-			var err error
-			switch networkid {
-			case currencies.ETHMain:
-				rate, err = restClient.ETH.CliMain.EventGetGasPrice(context.Background(), &ethpb.Empty{})
-			case currencies.ETHTest:
-				rate, err = restClient.ETH.CliTest.EventGetGasPrice(context.Background(), &ethpb.Empty{})
-			default:
-				restClient.log.Errorf("getFeeRate:currencies.Ether: no such networkid")
-			}
-
-			if err != nil {
-				restClient.log.Errorf("getFeeRate:currencies.Ether:restClient.ETH.Cli: %v ", err.Error())
-			}
-			speed, _ := strconv.Atoi(rate.GetGas())
-
-			verySlow := speed * 60 / 100
-			slow := speed * 80 / 100
-			medium := speed
-			fast := speed * 145 / 100
-			veryFast := speed * 195 / 100
-
-			if verySlow < 1000000000 {
-				verySlow = 1000000000
-			}
-
-			if slow < 1000000000 {
-				slow = 1000000000
-			}
-
-			if speed < 1000000000 {
-				medium = 1000000000
-			}
-
-			switch networkid {
-			case currencies.ETHMain:
-				c.JSON(http.StatusOK, gin.H{
-					"speeds": EstimationSpeeds{
-						VerySlow: verySlow,
-						Slow:     slow,
-						Medium:   medium,
-						Fast:     fast,
-						VeryFast: veryFast,
-					},
-					"code":    http.StatusOK,
-					"message": http.StatusText(http.StatusOK),
-				})
-			default:
-				c.JSON(http.StatusOK, gin.H{
-					"speeds": EstimationSpeeds{
-						VerySlow: 1000000000,
-						Slow:     2000000000,
-						Medium:   3000000000,
-						Fast:     4000000000,
-						VeryFast: 5000000000,
-					},
-					"code":    http.StatusOK,
-					"message": http.StatusText(http.StatusOK),
-				})
-			}
-
-		default:
-
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
 		}
+		sp = EstimationSpeeds(rates)
 
+		restClient.log.Debugf("FeeRates for currency id %d network id %d is: %v", currencyID, networkid, sp)
+
+		c.JSON(http.StatusOK, gin.H{
+			"speeds":  sp,
+			"code":    http.StatusOK,
+			"message": http.StatusText(http.StatusOK),
+		})
 	}
 }
 
@@ -1020,8 +818,7 @@ func (restClient *RestClient) getSpendableOutputs() gin.HandlerFunc {
 		)
 
 		user := store.User{}
-		query := bson.M{"devices.JWT": token}
-		if err := restClient.userStore.FindUser(query, &user); err != nil {
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
 			restClient.log.Errorf("getAllWalletsVerbose: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code":    http.StatusBadRequest,
@@ -1086,183 +883,102 @@ func (restClient *RestClient) sendRawHDTransaction() gin.HandlerFunc {
 		}
 
 		user := store.User{}
-		query := bson.M{"devices.JWT": token}
-		if err := restClient.userStore.FindUser(query, &user); err != nil {
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
 			restClient.log.Errorf("sendRawHDTransaction: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 
 			return
 		}
-		code := http.StatusOK
-		switch rawTx.CurrencyID {
-		case currencies.Bitcoin:
-			if rawTx.NetworkID == currencies.Main {
-				err := NewAddressNode(rawTx.Address, user.UserID, rawTx.CurrencyID, rawTx.NetworkID, rawTx.WalletIndex, rawTx.AddressIndex, restClient)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": "err: " + err.Error(),
-					})
-					return
-				}
-
-				resp, err := restClient.BTC.CliMain.EventSendRawTx(context.Background(), &btcpb.RawTx{
-					Transaction: rawTx.Transaction,
-				})
-
-				if err != nil {
-					restClient.log.Errorf("sendRawHDTransaction: restClient.BTC.CliMain.EventSendRawTx: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					code = http.StatusBadRequest
-					c.JSON(code, gin.H{
-						"code":    code,
-						"message": err.Error(),
-					})
-					return
-				}
-
-				if strings.Contains("err:", resp.GetMessage()) {
-					restClient.log.Errorf("sendRawHDTransaction: restClient.BTC.CliMain.EventSendRawTx:resp err %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					code = http.StatusBadRequest
-					c.JSON(code, gin.H{
-						"code":    code,
-						"message": resp.GetMessage(),
-					})
-					return
-				}
+		ch, err := restClient.chains.Get(rawTx.CurrencyID, rawTx.NetworkID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+			})
+			return
+		}
 
-				if rawTx.IsHD {
-					err = addAddressToWallet(rawTx.Address, token, rawTx.CurrencyID, rawTx.NetworkID, rawTx.WalletIndex, rawTx.AddressIndex, restClient, c)
-					if err != nil {
-						c.JSON(http.StatusBadRequest, gin.H{
-							"code":    http.StatusBadRequest,
-							"message": err.Error(),
-						})
-						return
-					}
-				}
+		err = ch.ValidateRawTx(user.UserID, rawTx.Transaction)
+		if txErr, ok := err.(*chain.TxError); ok {
+			restClient.log.Errorf("sendRawHDTransaction: ch.ValidateRawTx: %s\t[addr=%s]", txErr.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": txErr.Error(),
+				"error":   txErr,
+			})
+			return
+		}
+		if err != nil {
+			restClient.log.Errorf("sendRawHDTransaction: ch.ValidateRawTx: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": msgErrServerError,
+			})
+			return
+		}
 
-				c.JSON(code, gin.H{
-					"code":    code,
-					"message": resp.Message,
+		_, isUTXO := ch.(chain.UTXO)
+		if isUTXO {
+			// watch for the change address before the tx hits mempool
+			err := NewAddressNode(rawTx.Address, user.UserID, rawTx.CurrencyID, rawTx.NetworkID, rawTx.WalletIndex, rawTx.AddressIndex, restClient)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"code":    http.StatusInternalServerError,
+					"message": "err: " + err.Error(),
 				})
 				return
-
 			}
-			if rawTx.NetworkID == currencies.Test {
-
-				err := NewAddressNode(rawTx.Address, user.UserID, rawTx.CurrencyID, rawTx.NetworkID, rawTx.WalletIndex, rawTx.AddressIndex, restClient)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": "err: " + err.Error(),
-					})
-					return
-				}
-
-				resp, err := restClient.BTC.CliTest.EventSendRawTx(context.Background(), &btcpb.RawTx{
-					Transaction: rawTx.Transaction,
-				})
-				if err != nil {
-					restClient.log.Errorf("sendRawHDTransaction: restClient.BTC.CliMain.EventSendRawTx: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					code = http.StatusBadRequest
-					c.JSON(code, gin.H{
-						"code":    code,
-						"message": err.Error(),
-					})
-					return
-				}
-
-				if strings.Contains("err:", resp.GetMessage()) {
-					restClient.log.Errorf("sendRawHDTransaction: restClient.BTC.CliMain.EventSendRawTx:resp err %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					code = http.StatusBadRequest
-					c.JSON(code, gin.H{
-						"code":    code,
-						"message": resp.GetMessage(),
-					})
-					return
-				}
+		}
 
-				if rawTx.IsHD {
-					err = addAddressToWallet(rawTx.Address, token, rawTx.CurrencyID, rawTx.NetworkID, rawTx.WalletIndex, rawTx.AddressIndex, restClient, c)
-					if err != nil {
-						c.JSON(http.StatusBadRequest, gin.H{
-							"code":    http.StatusBadRequest,
-							"message": err.Error(),
-						})
-						return
-					}
-				}
-				flag := 4
-				for {
-					ex := restClient.userStore.CheckTx(resp.GetMessage())
-					if ex {
-						break
-					}
-					flag++
-					if flag == 4 {
-						break
-					}
-				}
+		resp, err := ch.SendRawTx(rawTx.Transaction)
+		if err != nil {
+			// node service may have got the tx, the client has to retry with the same key
+			restClient.log.Errorf("sendRawHDTransaction: ch.SendRawTx: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusBadGateway, gin.H{
+				"code":    http.StatusBadGateway,
+				"message": err.Error(),
+			})
+			return
+		}
 
-				c.JSON(code, gin.H{
-					"code":    code,
-					"message": resp.Message,
-				})
-				return
-			}
-		case currencies.Ether:
-			if rawTx.NetworkID == currencies.ETHMain {
-				hash, err := restClient.ETH.CliMain.EventSendRawTx(context.Background(), &ethpb.RawTx{
-					Transaction: rawTx.Transaction,
-				})
-				if err != nil {
-					restClient.log.Errorf("sendRawHDTransaction:eth.SendRawTransaction %s", err.Error())
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": err.Error(),
-					})
-					return
-				}
-				// TODO: Make a wallet
+		if txErr := chain.NodeTxError(resp); txErr != nil {
+			restClient.log.Errorf("sendRawHDTransaction: ch.SendRawTx: resp err %s\t[addr=%s]", resp, c.Request.RemoteAddr)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": resp,
+				"error":   txErr,
+			})
+			return
+		}
 
-				c.JSON(http.StatusOK, gin.H{
-					"code":    http.StatusOK,
-					"message": hash,
-				})
-				return
-			}
-			if rawTx.NetworkID == currencies.ETHTest {
-				hash, err := restClient.ETH.CliTest.EventSendRawTx(context.Background(), &ethpb.RawTx{
-					Transaction: rawTx.Transaction,
-				})
-				if err != nil {
-					restClient.log.Errorf("sendRawHDTransaction:eth.SendRawTransaction %s", err.Error())
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": err.Error(),
-					})
-					return
-				}
+		if err := saveOutboundTx(restClient.userStore, ch, user.UserID, rawTx.Transaction); err != nil {
+			restClient.log.Errorf("sendRawHDTransaction: saveOutboundTx: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+		}
 
-				// TODO: Make a wallet
+		if !isUTXO {
+			// TODO: Make a wallet
+			c.JSON(http.StatusOK, gin.H{
+				"code":    http.StatusOK,
+				"message": gin.H{"message": resp},
+			})
+			return
+		}
 
-				c.JSON(http.StatusOK, gin.H{
-					"code":    http.StatusOK,
-					"message": hash,
+		if rawTx.IsHD {
+			err = addAddressToWallet(rawTx.Address, token, rawTx.CurrencyID, rawTx.NetworkID, rawTx.WalletIndex, rawTx.AddressIndex, restClient, c)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"code":    http.StatusBadRequest,
+					"message": err.Error(),
 				})
 				return
 			}
-
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    http.StatusBadRequest,
-				"message": msgErrChainIsNotImplemented,
-			})
 		}
-	}
-}
 
-func errHandler(resp string) bool {
-	return strings.Contains(resp, "err:")
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"message": resp,
+		})
+	}
 }
 
 type RawTx struct { // remane RawClientTransaction
@@ -1324,15 +1040,22 @@ func (restClient *RestClient) getWalletVerbose() gin.HandlerFunc {
 
 		user := store.User{}
 
-		switch currencyId {
-		case currencies.Bitcoin:
+		ch, err := restClient.chains.Get(currencyId, networkId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+			})
+			return
+		}
+
+		switch ch := ch.(type) {
+		case chain.UTXO:
 			code = http.StatusOK
 			message = http.StatusText(http.StatusOK)
 			var av []AddressVerbose
 
-			query := bson.M{"devices.JWT": token}
-
-			if err := restClient.userStore.FindUser(query, &user); err != nil {
+			if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
 				restClient.log.Errorf("getAllWalletsVerbose: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 			}
 
@@ -1362,8 +1085,7 @@ func (restClient *RestClient) getWalletVerbose() gin.HandlerFunc {
 						pending = true
 					}
 				}
-				// TODO:
-				_, sync := restClient.BTC.Resync.Load(address.Address)
+				sync := ch.IsSyncing(address.Address)
 
 				av = append(av, AddressVerbose{
 					LastActionTime: address.LastActionTime,
@@ -1386,14 +1108,13 @@ func (restClient *RestClient) getWalletVerbose() gin.HandlerFunc {
 			})
 			av = []AddressVerbose{}
 
-		case currencies.Ether:
+		case chain.Account:
 			code = http.StatusOK
 			message = http.StatusText(http.StatusOK)
 
 			var av []ETHAddressVerbose
 
-			query := bson.M{"devices.JWT": token}
-			if err := restClient.userStore.FindUser(query, &user); err != nil {
+			if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
 				restClient.log.Errorf("getAllWalletsVerbose: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 			}
 
@@ -1422,61 +1143,46 @@ func (restClient *RestClient) getWalletVerbose() gin.HandlerFunc {
 			var pendingBalance string
 			var waletNonce int64
 			for _, address := range wallet.Adresses {
-				amount := &ethpb.Balance{}
-				nonce := &ethpb.Nonce{}
-
-				var err error
-				adr := ethpb.AddressToResync{
-					Address: address.Address,
+				balance, addressPendingBalance, err := ch.AddressBalance(address.Address)
+				if err != nil {
+					restClient.log.Errorf("getWalletVerbose: ch.AddressBalance: %v", err.Error())
 				}
-
-				switch networkId {
-				case currencies.ETHTest:
-					nonce, err = restClient.ETH.CliTest.EventGetAdressNonce(context.Background(), &adr)
-					amount, err = restClient.ETH.CliTest.EventGetAdressBalance(context.Background(), &adr)
-				case currencies.ETHMain:
-					nonce, err = restClient.ETH.CliMain.EventGetAdressNonce(context.Background(), &adr)
-					amount, err = restClient.ETH.CliMain.EventGetAdressBalance(context.Background(), &adr)
-				default:
-					c.JSON(code, gin.H{
-						"code":    http.StatusBadRequest,
-						"message": msgErrMethodNotImplennted,
-						"wallet":  wv,
-					})
-					return
+				nonce, err := ch.AddressNonce(address.Address)
+				if err != nil {
+					restClient.log.Errorf("getWalletVerbose: ch.AddressNonce: %v", err.Error())
 				}
-
+				tokens, err := ch.TokenBalances(address.Address)
 				if err != nil {
-					restClient.log.Errorf("EventGetAdressNonce || EventGetAdressBalance: %v", err.Error())
+					restClient.log.Errorf("getWalletVerbose: ch.TokenBalances: %v", err.Error())
 				}
 
-				totalBalance = amount.GetBalance()
-				pendingBalance = amount.GetPendingBalance()
+				totalBalance = balance
+				pendingBalance = addressPendingBalance
 
 				if totalBalance == pendingBalance {
 					pendingBalance = "0"
 					pending = false
 				}
 
-				waletNonce = nonce.GetNonce()
+				waletNonce = nonce
 				userTxs := []store.TransactionETH{}
 				err = restClient.userStore.GetAllWalletEthTransactions(user.UserID, currencyId, networkId, &userTxs)
 
 				// Check for transaction status from transaction history in case of that geth take a lot of time to procces address balances in inner
 				// its a HACK we put a pending flag in case if we have pending txs in out txs history. In this case ballance will be callculated partly from tx hisotry.
 				// NOT FROM NODE
-				pendingBalanceBig, _ := new(big.Int).SetString(amount.GetPendingBalance(), 10)
+				pendingBalanceBig, _ := new(big.Int).SetString(addressPendingBalance, 10)
 				walletHistory := []store.TransactionETH{}
 				err = restClient.userStore.GetAllWalletEthTransactions(user.UserID, wallet.CurrencyID, wallet.NetworkID, &walletHistory)
 				for _, tx := range walletHistory {
 					if tx.WalletIndex == wallet.WalletIndex {
 						if (tx.Status == store.TxStatusAppearedInMempoolIncoming || tx.Status == store.TxStatusAppearedInMempoolOutcoming) && (tx.From == address.Address || tx.To == address.Address) {
-							if tx.Status == store.TxStatusAppearedInMempoolIncoming && amount.GetBalance() == amount.GetPendingBalance() {
+							if tx.Status == store.TxStatusAppearedInMempoolIncoming && balance == addressPendingBalance {
 								inTxAmount, _ := new(big.Int).SetString(tx.Amount, 10)
 								pendingBalanceBig := pendingBalanceBig.Add(pendingBalanceBig, inTxAmount)
 								pendingBalance = pendingBalanceBig.String()
 							}
-							if tx.Status == store.TxStatusAppearedInMempoolOutcoming && amount.GetBalance() == amount.GetPendingBalance() {
+							if tx.Status == store.TxStatusAppearedInMempoolOutcoming && balance == addressPendingBalance {
 								outTxAmount, _ := new(big.Int).SetString(tx.Amount, 10)
 								pendingBalanceBig := pendingBalanceBig.Sub(pendingBalanceBig, outTxAmount)
 								gasLimit := big.NewInt(tx.GasLimit)
@@ -1495,7 +1201,8 @@ func (restClient *RestClient) getWalletVerbose() gin.HandlerFunc {
 					Address:        address.Address,
 					AddressIndex:   address.AddressIndex,
 					Amount:         totalBalance,
-					Nonce:          nonce.GetNonce(),
+					Nonce:          nonce,
+					Tokens:         tokens,
 				})
 
 			}
@@ -1573,6 +1280,7 @@ type ETHAddressVerbose struct {
 	Amount         string                   `json:"amount"`
 	SpendableOuts  []store.SpendableOutputs `json:"spendableoutputs,omitempty"`
 	Nonce          int64                    `json:"nonce,omitempty"`
+	Tokens         []store.TokenBalance     `json:"tokens,omitempty"`
 }
 
 type StockExchangeRate struct {
@@ -1633,9 +1341,8 @@ func (restClient *RestClient) getAllWalletsVerbose() gin.HandlerFunc {
 			message string
 		)
 		user := store.User{}
-		query := bson.M{"devices.JWT": token}
 
-		if err := restClient.userStore.FindUser(query, &user); err != nil {
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
 			restClient.log.Errorf("getAllWalletsVerbose: restClient.userStore.FindUser: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 			c.JSON(code, gin.H{
 				"code":    http.StatusBadRequest,
@@ -1651,186 +1358,172 @@ func (restClient *RestClient) getAllWalletsVerbose() gin.HandlerFunc {
 		message = http.StatusText(http.StatusOK)
 
 		okWallets := fetchUndeletedWallets(user.Wallets)
+		wv = restClient.walletsVerbose(user, okWallets)
 
-		userTxs := []store.MultyTX{}
+		c.JSON(code, gin.H{
+			"code":       code,
+			"message":    message,
+			"wallets":    wv,
+			"topindexes": topIndexes,
+		})
 
-		for _, wallet := range okWallets {
+	}
+}
 
-			switch wallet.CurrencyID {
-			case currencies.Bitcoin:
-				var av []AddressVerbose
-				var pending bool
-				for _, address := range wallet.Adresses {
-					spOuts := getBTCAddressSpendableOutputs(address.Address, wallet.CurrencyID, wallet.NetworkID, restClient)
+// walletsVerbose returns wallets with balances and addresses state
+func (restClient *RestClient) walletsVerbose(user store.User, wallets []store.Wallet) []interface{} {
+	var wv []interface{}
+	userTxs := []store.MultyTX{}
 
-					//all user txs
-					err = restClient.userStore.GetAllWalletTransactions(user.UserID, wallet.CurrencyID, wallet.NetworkID, &userTxs)
-					if err != nil {
-						//empty history
-					}
+	for _, wallet := range wallets {
+		ch, err := restClient.chains.Get(wallet.CurrencyID, wallet.NetworkID)
+		if err != nil {
+			continue
+		}
 
-					for _, tx := range userTxs {
-						if len(tx.TxAddress) > 0 {
-							if tx.TxAddress[0] == address.Address {
-								if tx.TxStatus == store.TxStatusAppearedInMempoolIncoming || tx.TxStatus == store.TxStatusAppearedInMempoolOutcoming {
-									pending = true
-								}
+		switch ch := ch.(type) {
+		case chain.UTXO:
+			var av []AddressVerbose
+			var pending bool
+			for _, address := range wallet.Adresses {
+				spOuts := getBTCAddressSpendableOutputs(address.Address, wallet.CurrencyID, wallet.NetworkID, restClient)
+
+				//all user txs
+				err = restClient.userStore.GetAllWalletTransactions(user.UserID, wallet.CurrencyID, wallet.NetworkID, &userTxs)
+				if err != nil {
+					//empty history
+				}
+
+				for _, tx := range userTxs {
+					if len(tx.TxAddress) > 0 {
+						if tx.TxAddress[0] == address.Address {
+							if tx.TxStatus == store.TxStatusAppearedInMempoolIncoming || tx.TxStatus == store.TxStatusAppearedInMempoolOutcoming {
+								pending = true
 							}
 						}
 					}
+				}
+
+				sync := ch.IsSyncing(address.Address)
+
+				av = append(av, AddressVerbose{
+					LastActionTime: address.LastActionTime,
+					Address:        address.Address,
+					AddressIndex:   address.AddressIndex,
+					Amount:         int64(checkBTCAddressbalance(address.Address, wallet.CurrencyID, wallet.NetworkID, restClient)),
+					SpendableOuts:  spOuts,
+					IsSyncing:      sync,
+				})
 
-					// restClient.BTC.ResyncM.Lock()
-					// re := *restClient.BTC.Resync
-					// restClient.BTC.ResyncM.Unlock()
-					_, sync := restClient.BTC.Resync.Load(address.Address)
+			}
 
-					// sync := re[address.Address]
+			wv = append(wv, WalletVerbose{
+				WalletIndex:    wallet.WalletIndex,
+				CurrencyID:     wallet.CurrencyID,
+				NetworkID:      wallet.NetworkID,
+				WalletName:     wallet.WalletName,
+				LastActionTime: wallet.LastActionTime,
+				DateOfCreation: wallet.DateOfCreation,
+				VerboseAddress: av,
+				Pending:        pending,
+			})
+			av = []AddressVerbose{}
+			userTxs = []store.MultyTX{}
+		case chain.Account:
+			var av []ETHAddressVerbose
+			var pending bool
+			var walletNonce int64
 
-					av = append(av, AddressVerbose{
-						LastActionTime: address.LastActionTime,
-						Address:        address.Address,
-						AddressIndex:   address.AddressIndex,
-						Amount:         int64(checkBTCAddressbalance(address.Address, wallet.CurrencyID, wallet.NetworkID, restClient)),
-						SpendableOuts:  spOuts,
-						IsSyncing:      sync,
-					})
+			var totalBalance string
+			var pendingBalance string
+			// var pendingAmount string
 
+			for _, address := range wallet.Adresses {
+				balance, addressPendingBalance, err := ch.AddressBalance(address.Address)
+				if err != nil {
+					restClient.log.Errorf("getAllWalletsVerbose: ch.AddressBalance: %v", err.Error())
+				}
+				nonce, err := ch.AddressNonce(address.Address)
+				if err != nil {
+					restClient.log.Errorf("getAllWalletsVerbose: ch.AddressNonce: %v", err.Error())
+				}
+				tokens, err := ch.TokenBalances(address.Address)
+				if err != nil {
+					restClient.log.Errorf("getAllWalletsVerbose: ch.TokenBalances: %v", err.Error())
 				}
 
-				wv = append(wv, WalletVerbose{
-					WalletIndex:    wallet.WalletIndex,
-					CurrencyID:     wallet.CurrencyID,
-					NetworkID:      wallet.NetworkID,
-					WalletName:     wallet.WalletName,
-					LastActionTime: wallet.LastActionTime,
-					DateOfCreation: wallet.DateOfCreation,
-					VerboseAddress: av,
-					Pending:        pending,
-				})
-				av = []AddressVerbose{}
-				userTxs = []store.MultyTX{}
-			case currencies.Ether:
-				var av []ETHAddressVerbose
-				var pending bool
-				var walletNonce int64
-
-				var totalBalance string
-				var pendingBalance string
-				// var pendingAmount string
-
-				for _, address := range wallet.Adresses {
-					amount := &ethpb.Balance{}
-					nonce := &ethpb.Nonce{}
-					// blockHeight := &ethpb.BlockHeight{}
-
-					var err error
-					adr := ethpb.AddressToResync{
-						Address: address.Address,
-					}
+				totalBalance = balance
+				pendingBalance = addressPendingBalance
 
-					switch wallet.NetworkID {
-					case currencies.ETHTest:
-						nonce, err = restClient.ETH.CliTest.EventGetAdressNonce(context.Background(), &adr)
-						amount, err = restClient.ETH.CliTest.EventGetAdressBalance(context.Background(), &adr)
-						// blockHeight, err = restClient.ETH.CliMain.EventGetBlockHeight(context.Background(), &adr)
-					case currencies.ETHMain:
-						nonce, err = restClient.ETH.CliMain.EventGetAdressNonce(context.Background(), &adr)
-						amount, err = restClient.ETH.CliMain.EventGetAdressBalance(context.Background(), &adr)
-						// blockHeight, err = restClient.ETH.CliMain.EventGetBlockHeight(context.Background())
-					default:
-						c.JSON(code, gin.H{
-							"code":    http.StatusBadRequest,
-							"message": msgErrMethodNotImplennted,
-							"wallet":  wv,
-						})
-						return
-					}
+				userTxs := []store.TransactionETH{}
+				err = restClient.userStore.GetAllWalletEthTransactions(user.UserID, wallet.CurrencyID, wallet.NetworkID, &userTxs)
 
-					if err != nil {
-						restClient.log.Errorf("EventGetAdressNonce || EventGetAdressBalance: %v", err.Error())
+				history := []store.TransactionETH{}
+				for _, tx := range userTxs {
+					if tx.WalletIndex == wallet.WalletIndex {
+						history = append(history, tx)
 					}
+				}
 
-					totalBalance = amount.GetBalance()
-					pendingBalance = amount.GetPendingBalance()
-
-					userTxs := []store.TransactionETH{}
-					err = restClient.userStore.GetAllWalletEthTransactions(user.UserID, wallet.CurrencyID, wallet.NetworkID, &userTxs)
+				if totalBalance == pendingBalance {
+					pendingBalance = "0"
+				}
 
-					history := []store.TransactionETH{}
-					for _, tx := range userTxs {
-						if tx.WalletIndex == wallet.WalletIndex {
-							history = append(history, tx)
-						}
-					}
+				walletNonce = nonce
 
-					if totalBalance == pendingBalance {
-						pendingBalance = "0"
-					}
+				pendingBalanceBig, _ := new(big.Int).SetString(addressPendingBalance, 10)
+				walletHistory := []store.TransactionETH{}
 
-					walletNonce = nonce.GetNonce()
-
-					pendingBalanceBig, _ := new(big.Int).SetString(amount.GetPendingBalance(), 10)
-					walletHistory := []store.TransactionETH{}
-
-					err = restClient.userStore.GetAllWalletEthTransactions(user.UserID, wallet.CurrencyID, wallet.NetworkID, &walletHistory)
-					for _, tx := range walletHistory {
-						if tx.WalletIndex == wallet.WalletIndex {
-							if (tx.Status == store.TxStatusAppearedInMempoolIncoming || tx.Status == store.TxStatusAppearedInMempoolOutcoming) && (tx.From == address.Address || tx.To == address.Address) {
-								if tx.Status == store.TxStatusAppearedInMempoolIncoming && amount.GetBalance() == amount.GetPendingBalance() {
-									inTxAmount, _ := new(big.Int).SetString(tx.Amount, 10)
-									pendingBalanceBig := pendingBalanceBig.Add(pendingBalanceBig, inTxAmount)
-									pendingBalance = pendingBalanceBig.String()
-								}
-								if tx.Status == store.TxStatusAppearedInMempoolOutcoming && amount.GetBalance() == amount.GetPendingBalance() {
-									outTxAmount, _ := new(big.Int).SetString(tx.Amount, 10)
-									pendingBalanceBig := pendingBalanceBig.Sub(pendingBalanceBig, outTxAmount)
-									gasLimit := big.NewInt(tx.GasLimit)
-									gasPrice := big.NewInt(tx.GasPrice)
-									fee := new(big.Int).Mul(gasLimit, gasPrice)
-									pendingBalanceBig = pendingBalanceBig.Sub(pendingBalanceBig, fee)
-									pendingBalance = pendingBalanceBig.String()
-								}
-								pending = true
+				err = restClient.userStore.GetAllWalletEthTransactions(user.UserID, wallet.CurrencyID, wallet.NetworkID, &walletHistory)
+				for _, tx := range walletHistory {
+					if tx.WalletIndex == wallet.WalletIndex {
+						if (tx.Status == store.TxStatusAppearedInMempoolIncoming || tx.Status == store.TxStatusAppearedInMempoolOutcoming) && (tx.From == address.Address || tx.To == address.Address) {
+							if tx.Status == store.TxStatusAppearedInMempoolIncoming && balance == addressPendingBalance {
+								inTxAmount, _ := new(big.Int).SetString(tx.Amount, 10)
+								pendingBalanceBig := pendingBalanceBig.Add(pendingBalanceBig, inTxAmount)
+								pendingBalance = pendingBalanceBig.String()
 							}
+							if tx.Status == store.TxStatusAppearedInMempoolOutcoming && balance == addressPendingBalance {
+								outTxAmount, _ := new(big.Int).SetString(tx.Amount, 10)
+								pendingBalanceBig := pendingBalanceBig.Sub(pendingBalanceBig, outTxAmount)
+								gasLimit := big.NewInt(tx.GasLimit)
+								gasPrice := big.NewInt(tx.GasPrice)
+								fee := new(big.Int).Mul(gasLimit, gasPrice)
+								pendingBalanceBig = pendingBalanceBig.Sub(pendingBalanceBig, fee)
+								pendingBalance = pendingBalanceBig.String()
+							}
+							pending = true
 						}
 					}
-
-					av = append(av, ETHAddressVerbose{
-						LastActionTime: address.LastActionTime,
-						Address:        address.Address,
-						AddressIndex:   address.AddressIndex,
-						Amount:         totalBalance,
-						Nonce:          nonce.GetNonce(),
-					})
 				}
-				wv = append(wv, WalletVerboseETH{
-					WalletIndex:    wallet.WalletIndex,
-					CurrencyID:     wallet.CurrencyID,
-					NetworkID:      wallet.NetworkID,
-					Balance:        totalBalance,
-					PendingBalance: pendingBalance,
-					Nonce:          walletNonce,
-					WalletName:     wallet.WalletName,
-					LastActionTime: wallet.LastActionTime,
-					DateOfCreation: wallet.DateOfCreation,
-					VerboseAddress: av,
-					Pending:        pending,
-				})
-				av = []ETHAddressVerbose{}
-			default:
 
+				av = append(av, ETHAddressVerbose{
+					LastActionTime: address.LastActionTime,
+					Address:        address.Address,
+					AddressIndex:   address.AddressIndex,
+					Amount:         totalBalance,
+					Nonce:          nonce,
+					Tokens:         tokens,
+				})
 			}
-
+			wv = append(wv, WalletVerboseETH{
+				WalletIndex:    wallet.WalletIndex,
+				CurrencyID:     wallet.CurrencyID,
+				NetworkID:      wallet.NetworkID,
+				Balance:        totalBalance,
+				PendingBalance: pendingBalance,
+				Nonce:          walletNonce,
+				WalletName:     wallet.WalletName,
+				LastActionTime: wallet.LastActionTime,
+				DateOfCreation: wallet.DateOfCreation,
+				VerboseAddress: av,
+				Pending:        pending,
+			})
+			av = []ETHAddressVerbose{}
 		}
 
-		c.JSON(code, gin.H{
-			"code":       code,
-			"message":    message,
-			"wallets":    wv,
-			"topindexes": topIndexes,
-		})
-
 	}
+	return wv
 }
 
 func (restClient *RestClient) getWalletTransactionsHistory() gin.HandlerFunc {
@@ -1880,8 +1573,7 @@ func (restClient *RestClient) getWalletTransactionsHistory() gin.HandlerFunc {
 		}
 
 		user := store.User{}
-		sel := bson.M{"devices.JWT": token}
-		err = restClient.userStore.FindUser(sel, &user)
+		err = restClient.userStore.FindUserByToken(token, &user)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code":    http.StatusBadRequest,
@@ -1891,38 +1583,51 @@ func (restClient *RestClient) getWalletTransactionsHistory() gin.HandlerFunc {
 			return
 		}
 
-		switch currencyId {
-		case currencies.Bitcoin:
+		ch, err := restClient.chains.Get(currencyId, networkid)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+				"history": walletTxs,
+			})
+			return
+		}
 
-			var blockHeight int64
-			switch networkid {
-			case currencies.Test:
-				resp, err := restClient.BTC.CliTest.EventGetBlockHeight(context.Background(), &btcpb.Empty{})
-				if err != nil {
-					restClient.log.Errorf("getWalletTransactionsHistory: restClient.BTC.CliTest.EventGetBlockHeight %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": http.StatusText(http.StatusInternalServerError),
-					})
-					return
-				}
-				blockHeight = resp.Height
-			case currencies.Main:
-				resp, err := restClient.BTC.CliMain.EventGetBlockHeight(context.Background(), &btcpb.Empty{})
-				if err != nil {
-					restClient.log.Errorf("getWalletTransactionsHistory: restClient.BTC.CliTest.EventGetBlockHeight %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": http.StatusText(http.StatusInternalServerError),
-					})
-					return
+		blockHeight, err := ch.BlockHeight()
+		if err != nil {
+			restClient.log.Errorf("getWalletTransactionsHistory: ch.BlockHeight %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": http.StatusText(http.StatusInternalServerError),
+			})
+			return
+		}
+
+		filter, err := txHistoryFilter(c, walletIndex)
+		if err != nil {
+			restClient.log.Errorf("getWalletTransactionsHistory: txHistoryFilter: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrHistoryFilter,
+				"history": walletTxs,
+			})
+			return
+		}
+
+		switch ch.(type) {
+		case chain.UTXO:
+			for _, wallet := range user.Wallets {
+				if wallet.WalletIndex == walletIndex && wallet.CurrencyID == currencyId && wallet.NetworkID == networkid {
+					for _, addresses := range wallet.Adresses {
+						filter.Addresses = append(filter.Addresses, addresses.Address)
+					}
 				}
-				blockHeight = resp.Height
 			}
 
-			userTxs := []store.MultyTX{}
-			err = restClient.userStore.GetAllWalletTransactions(user.UserID, currencyId, networkid, &userTxs)
+			// TODO: fix this logic same wallet to samewallet tx
+			walletTxs, cursor, err := restClient.userStore.FindWalletTransactions(user.UserID, currencyId, networkid, filter)
 			if err != nil {
+				restClient.log.Errorf("getWalletTransactionsHistory: userStore.FindWalletTransactions: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 				c.JSON(http.StatusBadRequest, gin.H{
 					"code":    http.StatusBadRequest,
 					"message": msgErrTxHistory,
@@ -1931,63 +1636,6 @@ func (restClient *RestClient) getWalletTransactionsHistory() gin.HandlerFunc {
 				return
 			}
 
-			walletAddresses := []string{}
-			for _, wallet := range user.Wallets {
-				if wallet.WalletIndex == walletIndex {
-					for _, addresses := range wallet.Adresses {
-						walletAddresses = append(walletAddresses, addresses.Address)
-					}
-				}
-			}
-
-			// TODO: fix this logic same wallet to samewallet tx
-
-			for _, address := range walletAddresses {
-				for _, tx := range userTxs {
-					if len(tx.TxAddress) > 0 {
-						if tx.TxAddress[0] == address {
-							// restClient.log.Infof("---------address= %s, txid= %s", address, tx.TxID)
-							var isTheSameWallet = false
-							for _, input := range tx.WalletsInput {
-								if walletIndex == input.WalletIndex {
-									isTheSameWallet = true
-								}
-							}
-
-							for _, output := range tx.WalletsOutput {
-								if walletIndex == output.WalletIndex {
-									isTheSameWallet = true
-								}
-							}
-
-							if isTheSameWallet {
-								walletTxs = append(walletTxs, tx)
-							}
-						}
-					}
-				}
-			}
-
-			/*
-				for _, tx := range userTxs {
-					//New Logic
-					var isTheSameWallet = false
-					for _, input := range tx.WalletsInput {
-						if walletIndex == input.WalletIndex {
-							isTheSameWallet = true
-						}
-					}
-					for _, output := range tx.WalletsOutput {
-						if walletIndex == output.WalletIndex {
-							isTheSameWallet = true
-						}
-					}
-					if isTheSameWallet {
-						walletTxs = append(walletTxs, tx)
-					}
-				}
-			*/
-
 			for i := 0; i < len(walletTxs); i++ {
 				if walletTxs[i].BlockHeight == -1 {
 					walletTxs[i].Confirmations = 0
@@ -1997,42 +1645,17 @@ func (restClient *RestClient) getWalletTransactionsHistory() gin.HandlerFunc {
 			}
 
 			c.JSON(http.StatusOK, gin.H{
-				"code":    http.StatusOK,
-				"message": http.StatusText(http.StatusOK),
-				"history": walletTxs,
+				"code":       http.StatusOK,
+				"message":    http.StatusText(http.StatusOK),
+				"history":    walletTxs,
+				"nextcursor": cursor,
 			})
 			return
 
-		case currencies.Ether:
-			var blockHeight int64
-
-			switch networkid {
-			case currencies.ETHTest:
-				resp, err := restClient.ETH.CliTest.EventGetBlockHeight(context.Background(), &ethpb.Empty{})
-				if err != nil {
-					restClient.log.Errorf("getWalletTransactionsHistory: restClient.BTC.CliTest.EventGetBlockHeight %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": http.StatusText(http.StatusInternalServerError),
-					})
-					return
-				}
-				blockHeight = resp.Height
-			case currencies.ETHMain:
-				resp, err := restClient.ETH.CliMain.EventGetBlockHeight(context.Background(), &ethpb.Empty{})
-				if err != nil {
-					restClient.log.Errorf("getWalletTransactionsHistory: restClient.BTC.CliTest.EventGetBlockHeight %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"code":    http.StatusInternalServerError,
-						"message": http.StatusText(http.StatusInternalServerError),
-					})
-					return
-				}
-				blockHeight = resp.Height
-			}
-			userTxs := []store.TransactionETH{}
-			err = restClient.userStore.GetAllWalletEthTransactions(user.UserID, currencyId, networkid, &userTxs)
+		case chain.Account:
+			history, cursor, err := restClient.userStore.FindWalletEthTransactions(user.UserID, currencyId, networkid, filter)
 			if err != nil {
+				restClient.log.Errorf("getWalletTransactionsHistory: userStore.FindWalletEthTransactions: %s \t[addr=%s]", err.Error(), c.Request.RemoteAddr)
 				c.JSON(http.StatusBadRequest, gin.H{
 					"code":    http.StatusBadRequest,
 					"message": msgErrTxHistory,
@@ -2041,28 +1664,22 @@ func (restClient *RestClient) getWalletTransactionsHistory() gin.HandlerFunc {
 				return
 			}
 
-			for i := 0; i < len(userTxs); i++ {
-				if userTxs[i].BlockHeight == -1 {
-					userTxs[i].Confirmations = 0
+			for i := 0; i < len(history); i++ {
+				if history[i].BlockHeight == -1 {
+					history[i].Confirmations = 0
 				} else {
-					userTxs[i].Confirmations = int(blockHeight-userTxs[i].BlockHeight) + 1
+					history[i].Confirmations = int(blockHeight-history[i].BlockHeight) + 1
 				}
-				if userTxs[i].Status == store.TxStatusAppearedInMempoolIncoming || userTxs[i].Status == store.TxStatusAppearedInMempoolOutcoming {
-					userTxs[i].Confirmations = 0
-				}
-			}
-
-			history := []store.TransactionETH{}
-			for _, tx := range userTxs {
-				if tx.WalletIndex == walletIndex {
-					history = append(history, tx)
+				if history[i].Status == store.TxStatusAppearedInMempoolIncoming || history[i].Status == store.TxStatusAppearedInMempoolOutcoming {
+					history[i].Confirmations = 0
 				}
 			}
 
 			c.JSON(http.StatusOK, gin.H{
-				"code":    http.StatusOK,
-				"message": http.StatusText(http.StatusOK),
-				"history": history,
+				"code":       http.StatusOK,
+				"message":    http.StatusText(http.StatusOK),
+				"history":    history,
+				"nextcursor": cursor,
 			})
 			return
 
@@ -2078,6 +1695,68 @@ func (restClient *RestClient) getWalletTransactionsHistory() gin.HandlerFunc {
 	}
 }
 
+// txHistoryFilter reads history page and filters from the query:
+// limit, cursor, since and until (unix time), status (comma separated TxStatus* values),
+// direction (incoming or outgoing) and minamount in the smallest units
+func txHistoryFilter(c *gin.Context, walletIndex int) (store.TxHistoryFilter, error) {
+	filter := store.TxHistoryFilter{
+		WalletIndex: walletIndex,
+		Cursor:      c.Query("cursor"),
+	}
+
+	ints := map[string]*int64{
+		"since": &filter.Since,
+		"until": &filter.Until,
+	}
+	for name, v := range ints {
+		if q := c.Query(name); q != "" {
+			i, err := strconv.ParseInt(q, 10, 64)
+			if err != nil || i < 0 {
+				return filter, fmt.Errorf("wrong %s %q", name, q)
+			}
+			*v = i
+		}
+	}
+
+	if q := c.Query("limit"); q != "" {
+		limit, err := strconv.Atoi(q)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("wrong limit %q", q)
+		}
+		filter.Limit = limit
+	}
+
+	if q := c.Query("status"); q != "" {
+		for _, s := range strings.Split(q, ",") {
+			status, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return filter, fmt.Errorf("wrong status %q", s)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	switch q := c.Query("direction"); q {
+	case "":
+	case "incoming":
+		filter.Direction = store.TxDirectionIncoming
+	case "outgoing":
+		filter.Direction = store.TxDirectionOutgoing
+	default:
+		return filter, fmt.Errorf("wrong direction %q", q)
+	}
+
+	if q := c.Query("minamount"); q != "" {
+		min, ok := new(big.Int).SetString(q, 10)
+		if !ok || min.Sign() < 0 {
+			return filter, fmt.Errorf("wrong minamount %q", q)
+		}
+		filter.MinAmount = min
+	}
+
+	return filter, nil
+}
+
 type TxHistory struct {
 	TxID        string               `json:"txid"`
 	TxHash      string               `json:"txhash"`
@@ -2141,8 +1820,7 @@ func (restClient *RestClient) resyncWallet() gin.HandlerFunc {
 		}
 
 		user := store.User{}
-		sel := bson.M{"devices.JWT": token}
-		err = restClient.userStore.FindUser(sel, &user)
+		err = restClient.userStore.FindUserByToken(token, &user)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code":    http.StatusBadRequest,
@@ -2166,48 +1844,33 @@ func (restClient *RestClient) resyncWallet() gin.HandlerFunc {
 			return
 		}
 
-		switch currencyID {
-		case currencies.Bitcoin:
-			if networkID == currencies.Main {
-				for _, address := range walletToResync.Adresses {
-					restClient.BTC.CliMain.EventResyncAddress(context.Background(), &btcpb.AddressToResync{
-						Address:      address.Address,
-						UserID:       user.UserID,
-						WalletIndex:  int32(walletIndex),
-						AddressIndex: int32(address.AddressIndex),
-					})
-					err := restClient.userStore.DeleteHistory(currencyID, networkID, address.Address)
-					if err != nil {
-						restClient.log.Errorf("resyncWallet case currencies.Bitcoin:Main: %v", err.Error())
-					}
-				}
-			}
+		ch, err := restClient.chains.Get(currencyID, networkID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrChainIsNotImplemented,
+			})
+			return
+		}
 
-			if networkID == currencies.Test {
-				for _, address := range walletToResync.Adresses {
-					restClient.BTC.CliTest.EventResyncAddress(context.Background(), &btcpb.AddressToResync{
-						Address:      address.Address,
-						UserID:       user.UserID,
-						WalletIndex:  int32(walletIndex),
-						AddressIndex: int32(address.AddressIndex),
-					})
-					err := restClient.userStore.DeleteHistory(currencyID, networkID, address.Address)
-					if err != nil {
-						restClient.log.Errorf("resyncWallet case currencies.Bitcoin:Test: %v", err.Error())
-					}
+		// account based chains have no history to rebuild
+		if _, ok := ch.(chain.UTXO); ok {
+			for _, address := range walletToResync.Adresses {
+				err := ch.ResyncAddress(address.Address, user.UserID, walletIndex, address.AddressIndex)
+				if err != nil {
+					restClient.log.Errorf("resyncWallet: ch.ResyncAddress: %v", err.Error())
+				}
+				err = restClient.userStore.DeleteHistory(currencyID, networkID, address.Address)
+				if err != nil {
+					restClient.log.Errorf("resyncWallet: restClient.userStore.DeleteHistory: %v", err.Error())
 				}
-
-			}
-		case currencies.Ether:
-			if networkID == currencies.ETHMain {
-
-			}
-
-			if networkID == currencies.ETHTest {
-
 			}
 		}
 
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"message": http.StatusText(http.StatusOK),
+		})
 	}
 }
 
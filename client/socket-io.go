@@ -6,17 +6,11 @@ See LICENSE for details
 package client
 
 import (
-	"context"
 	"fmt"
 	"net/http"
-	"strings"
 	"sync"
 
-	"github.com/Multy-io/Multy-back/btc"
-	"github.com/Multy-io/Multy-back/currencies"
-	"github.com/Multy-io/Multy-back/eth"
-	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
-	ethpb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"github.com/Multy-io/Multy-back/chain"
 	"github.com/Multy-io/Multy-back/store"
 
 	"github.com/gin-gonic/gin"
@@ -79,14 +73,14 @@ func getHeaderDataSocketIO(headers http.Header) (*SocketIOUser, error) {
 	}, nil
 }
 
-func SetSocketIOHandlers(restClient *RestClient, BTC *btc.BTCConn, ETH *eth.ETHConn, r *gin.RouterGroup, address, nsqAddr string, ratesDB store.UserStore) (*SocketIOConnectedPool, error) {
+func SetSocketIOHandlers(restClient *RestClient, chains *chain.Registry, r *gin.RouterGroup, address, nsqAddr string, ratesDB store.UserStore, disableStocks bool) (*SocketIOConnectedPool, error) {
 	server := gosocketio.NewServer(transport.GetDefaultWebsocketTransport())
 	pool, err := InitConnectedPool(server, address, nsqAddr, ratesDB)
 	if err != nil {
 		return nil, fmt.Errorf("connection pool initialization: %s", err.Error())
 	}
 
-	chart, err := newExchangeChart(ratesDB)
+	chart, err := newExchangeChart(ratesDB, disableStocks)
 	if err != nil {
 		return nil, fmt.Errorf("exchange chart initialization: %s", err.Error())
 	}
@@ -190,81 +184,56 @@ func SetSocketIOHandlers(restClient *RestClient, BTC *btc.BTCConn, ETH *eth.ETHC
 	})
 
 	server.On(SendRaw, func(c *gosocketio.Channel, raw store.RawHDTx) string {
-		switch raw.CurrencyID {
-		case currencies.Bitcoin:
-			var resp *btcpb.ReplyInfo
-
-			if raw.NetworkID == currencies.Test {
-				resp, err = BTC.CliTest.EventSendRawTx(context.Background(), &btcpb.RawTx{
-					Transaction: raw.Transaction,
-				})
-			}
-			if raw.NetworkID == currencies.Main {
-				resp, err = BTC.CliMain.EventSendRawTx(context.Background(), &btcpb.RawTx{
-					Transaction: raw.Transaction,
-				})
-			}
+		ch, err := chains.Get(raw.CurrencyID, raw.NetworkID)
+		if err != nil {
+			return "err: no such curid or netid"
+		}
 
-			if err != nil {
-				pool.log.Errorf("sendRawHDTransaction: restClient.BTC.CliMain.EventSendRawTx: %s", err.Error())
-				c.Emit(SendRaw, err.Error())
-				return err.Error()
-			}
+		user := store.User{}
+		if err := restClient.userStore.FindUserByToken(raw.JWT, &user); err != nil {
+			pool.log.Errorf("sendRawHDTransaction: userStore.FindUserByToken: %s", err.Error())
+			return "err: " + msgErrUserNotFound
+		}
 
-			if strings.Contains("err:", resp.GetMessage()) {
-				pool.log.Errorf("sendRawHDTransaction: restClient.BTC.CliMain.EventSendRawTx:resp err %s", err.Error())
-				c.Emit(SendRaw, resp.GetMessage())
-				return err.Error()
+		err = ch.ValidateRawTx(user.UserID, raw.Transaction)
+		if err != nil {
+			pool.log.Errorf("sendRawHDTransaction: ch.ValidateRawTx: %s", err.Error())
+			if txErr, ok := err.(*chain.TxError); ok {
+				c.Emit(SendRaw, txErr)
 			}
+			return "err: " + err.Error()
+		}
 
-			if raw.IsHD && !strings.Contains("err:", resp.GetMessage()) {
-				err = addAddressToWallet(raw.Address, raw.JWT, raw.CurrencyID, raw.NetworkID, raw.WalletIndex, raw.AddressIndex, restClient, nil)
-				if err != nil {
-					pool.log.Errorf("addAddressToWallet: %v", err.Error())
-				}
-				c.Emit(SendRaw, resp.GetMessage())
-				receiversM.Lock()
-				res := receivers[raw.UserCode]
-				receiversM.Unlock()
-				res.Socket.Emit(PaymentReceived, raw)
-			}
+		resp, err := ch.SendRawTx(raw.Transaction)
+		if err != nil {
+			pool.log.Errorf("sendRawHDTransaction: ch.SendRawTx: %s", err.Error())
+			c.Emit(SendRaw, err.Error())
+			return err.Error()
+		}
 
-			return "success:" + resp.GetMessage()
-
-		case currencies.Ether:
-			if raw.NetworkID == currencies.ETHMain {
-				h, err := restClient.ETH.CliMain.EventSendRawTx(context.Background(), &ethpb.RawTx{
-					Transaction: raw.Transaction,
-				})
-				if err != nil {
-					pool.log.Errorf("sendRawHDTransaction:eth.SendRawTransaction %s", err.Error())
-					return err.Error()
-				}
-
-				if strings.Contains("err:", h.GetMessage()) {
-					pool.log.Errorf("sendRawHDTransaction: strings.Contains err: %s", err.Error())
-					return err.Error()
-				}
-				return "success:" + h.GetMessage()
-			}
-			if raw.NetworkID == currencies.ETHTest {
-				h, err := restClient.ETH.CliTest.EventSendRawTx(context.Background(), &ethpb.RawTx{
-					Transaction: raw.Transaction,
-				})
-				if err != nil {
-					pool.log.Errorf("sendRawHDTransaction:eth.SendRawTransaction %s", err.Error())
-					return err.Error()
-				}
-
-				if strings.Contains("err:", h.GetMessage()) {
-					pool.log.Errorf("sendRawHDTransaction: strings.Contains err: %s", err.Error())
-					return err.Error()
-				}
-				return "success:" + h.GetMessage()
-			}
+		if txErr := chain.NodeTxError(resp); txErr != nil {
+			pool.log.Errorf("sendRawHDTransaction: ch.SendRawTx: resp err %s", resp)
+			c.Emit(SendRaw, txErr)
+			return resp
+		}
+
+		if err := saveOutboundTx(restClient.userStore, ch, user.UserID, raw.Transaction); err != nil {
+			pool.log.Errorf("sendRawHDTransaction: saveOutboundTx: %s", err.Error())
+		}
 
+		if _, ok := ch.(chain.UTXO); ok && raw.IsHD {
+			err = addAddressToWallet(raw.Address, raw.JWT, raw.CurrencyID, raw.NetworkID, raw.WalletIndex, raw.AddressIndex, restClient, nil)
+			if err != nil {
+				pool.log.Errorf("addAddressToWallet: %v", err.Error())
+			}
+			c.Emit(SendRaw, resp)
+			receiversM.Lock()
+			res := receivers[raw.UserCode]
+			receiversM.Unlock()
+			res.Socket.Emit(PaymentReceived, raw)
 		}
-		return "err: no such curid or netid"
+
+		return "success:" + resp
 	})
 
 	server.On(gosocketio.OnDisconnection, func(c *gosocketio.Channel) {
@@ -311,6 +280,8 @@ func SetSocketIOHandlers(restClient *RestClient, BTC *btc.BTCConn, ETH *eth.ETHC
 
 	})
 
+	setMempoolHandlers(server, chains, pool.log)
+
 	serveMux := http.NewServeMux()
 	serveMux.Handle("/socket.io/", server)
 
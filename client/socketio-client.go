@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Multy-io/Multy-back/logger"
 	"github.com/Multy-io/Multy-back/store"
 	nsq "github.com/bitly/go-nsq"
 	"github.com/graarh/golang-socketio"
@@ -28,6 +29,8 @@ type SocketIOConnectedPool struct {
 
 	nsqConsumerExchange       *nsq.Consumer
 	nsqConsumerBTCTransaction *nsq.Consumer
+	nsqConsumerTxDropped      *nsq.Consumer
+	nsqConsumerMultisigSign   *nsq.Consumer
 
 	db store.UserStore // TODO: fix store name
 
@@ -41,7 +44,7 @@ func InitConnectedPool(server *gosocketio.Server, address, nsqAddr string, db st
 		m:               &sync.RWMutex{},
 		users:           make(map[string]*SocketIOUser, 0),
 		address:         address,
-		log:             slf.WithContext("connectedPool"),
+		log:             logger.WithContext("connectedPool"),
 		closeChByConnID: make(map[string]chan string, 0),
 		db:              db,
 	}
@@ -54,6 +57,20 @@ func InitConnectedPool(server *gosocketio.Server, address, nsqAddr string, db st
 	}
 	pool.nsqConsumerBTCTransaction = nsqConsumerBTCTransaction
 
+	nsqConsumerTxDropped, err := pool.newConsumerTxDropped(nsqAddr)
+	if err != nil {
+		pool.log.Errorf("Transaction dropped: NSQ initialization: %s", err.Error())
+		return nil, err
+	}
+	pool.nsqConsumerTxDropped = nsqConsumerTxDropped
+
+	nsqConsumerMultisigSign, err := pool.newConsumerMultisigSign(nsqAddr)
+	if err != nil {
+		pool.log.Errorf("Multisig sign needed: NSQ initialization: %s", err.Error())
+		return nil, err
+	}
+	pool.nsqConsumerMultisigSign = nsqConsumerMultisigSign
+
 	return pool, nil
 }
 
@@ -102,6 +119,80 @@ func (sConnPool *SocketIOConnectedPool) sendTransactionNotify(newTransactionWith
 	}
 }
 
+func (sConnPool *SocketIOConnectedPool) newConsumerTxDropped(nsqAddr string) (*nsq.Consumer, error) {
+	consumer, err := nsq.NewConsumer(store.TopicTxDropped, "socketio", nsq.NewConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	consumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
+		var dropped = store.TxDropped{}
+		if err := json.Unmarshal(message.Body, &dropped); err != nil {
+			sConnPool.log.Errorf("topic transaction dropped: %s", err.Error())
+			return err
+		}
+		go sConnPool.sendTxDroppedNotify(dropped)
+		return nil
+	}))
+
+	err = consumer.ConnectToNSQD(nsqAddr)
+	if err != nil {
+		sConnPool.log.Errorf("nsq tx dropped: %s", err.Error())
+	}
+
+	return consumer, nil
+}
+
+func (sConnPool *SocketIOConnectedPool) sendTxDroppedNotify(dropped store.TxDropped) {
+	sConnPool.m.Lock()
+	defer sConnPool.m.Unlock()
+
+	user, ok := sConnPool.users[dropped.UserID]
+	if !ok {
+		return
+	}
+	for _, conn := range user.conns {
+		conn.Emit(store.TopicTxDropped, dropped)
+	}
+}
+
+func (sConnPool *SocketIOConnectedPool) newConsumerMultisigSign(nsqAddr string) (*nsq.Consumer, error) {
+	consumer, err := nsq.NewConsumer(store.TopicMultisigSign, "socketio", nsq.NewConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	consumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
+		var sign = store.MultisigSignNeeded{}
+		if err := json.Unmarshal(message.Body, &sign); err != nil {
+			sConnPool.log.Errorf("topic multisig sign needed: %s", err.Error())
+			return err
+		}
+		go sConnPool.sendMultisigSignNotify(sign)
+		return nil
+	}))
+
+	err = consumer.ConnectToNSQD(nsqAddr)
+	if err != nil {
+		sConnPool.log.Errorf("nsq multisig sign needed: %s", err.Error())
+	}
+
+	return consumer, nil
+}
+
+func (sConnPool *SocketIOConnectedPool) sendMultisigSignNotify(sign store.MultisigSignNeeded) {
+	sConnPool.m.Lock()
+	defer sConnPool.m.Unlock()
+
+	user, ok := sConnPool.users[sign.UserID]
+	if !ok {
+		return
+	}
+	for _, conn := range user.conns {
+		conn.Emit(store.TopicMultisigSign, sign)
+	}
+}
+
 func (sConnPool *SocketIOConnectedPool) removeUserConn(connID string) {
 	sConnPool.log.Debugf("RemoveUserConn by conn ID: %s", connID)
 	sConnPool.m.Lock()
@@ -0,0 +1,203 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/store"
+	"github.com/gin-gonic/gin"
+)
+
+// syncTokenOverlap is how far back the next sync starts, so records written
+// concurrently with the sync are returned next time, clients dedupe them
+const syncTokenOverlap = 5 // seconds
+
+var errBadSyncToken = errors.New("malformed sync token")
+
+// ChainChanges is txs of the chain changed since the last sync,
+// blockheight lets clients recompute confirmations of txs they already have
+type ChainChanges struct {
+	CurrencyID  int         `json:"currencyid"`
+	NetworkID   int         `json:"networkid"`
+	BlockHeight int64       `json:"blockheight"`
+	History     interface{} `json:"history"`
+}
+
+// DeletedWallet is a wallet deleted since the last sync
+type DeletedWallet struct {
+	CurrencyID  int `json:"currencyid"`
+	NetworkID   int `json:"networkid"`
+	WalletIndex int `json:"walletindex"`
+}
+
+func newSyncToken(now time.Time) string {
+	since := now.Unix() - syncTokenOverlap
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(since, 10)))
+}
+
+// parseSyncToken returns time the changes are looked for since,
+// empty token is zero time, that is a full sync
+func parseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errBadSyncToken
+	}
+	since, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || since <= 0 {
+		return 0, errBadSyncToken
+	}
+	return since, nil
+}
+
+// walletChanged tells whether the wallet or any of its addresses was touched since the time
+func walletChanged(wallet store.Wallet, since int64) bool {
+	if wallet.LastActionTime >= since {
+		return true
+	}
+	for _, address := range wallet.Adresses {
+		if address.LastActionTime >= since {
+			return true
+		}
+	}
+	return false
+}
+
+// getChanges returns wallets and txs changed since the sync token
+// and the token for the next sync
+func (restClient *RestClient) getChanges() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// taken before reading anything so nothing written meanwhile is missed
+		nextToken := newSyncToken(time.Now())
+
+		token, err := getToken(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrHeaderError,
+			})
+			return
+		}
+
+		since, err := parseSyncToken(c.Query("token"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrSyncToken,
+			})
+			return
+		}
+
+		user := store.User{}
+		if err := restClient.userStore.FindUserByToken(token, &user); err != nil {
+			restClient.log.Errorf("getChanges: restClient.userStore.FindUserByToken: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": msgErrUserNotFound,
+			})
+			return
+		}
+
+		changed := []store.Wallet{}
+		deleted := []DeletedWallet{}
+		chains := []chain.Chain{}
+		seen := map[[2]int]bool{}
+		for _, wallet := range user.Wallets {
+			if wallet.Status == store.WalletStatusOK {
+				key := [2]int{wallet.CurrencyID, wallet.NetworkID}
+				if ch, err := restClient.chains.Get(wallet.CurrencyID, wallet.NetworkID); err == nil && !seen[key] {
+					seen[key] = true
+					chains = append(chains, ch)
+				}
+			}
+			if !walletChanged(wallet, since) {
+				continue
+			}
+			if wallet.Status == store.WalletStatusDeleted {
+				deleted = append(deleted, DeletedWallet{
+					CurrencyID:  wallet.CurrencyID,
+					NetworkID:   wallet.NetworkID,
+					WalletIndex: wallet.WalletIndex,
+				})
+				continue
+			}
+			changed = append(changed, wallet)
+		}
+
+		changes := []ChainChanges{}
+		for _, ch := range chains {
+			blockHeight, err := ch.BlockHeight()
+			if err != nil {
+				restClient.log.Errorf("getChanges: ch.BlockHeight: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"code":    http.StatusInternalServerError,
+					"message": http.StatusText(http.StatusInternalServerError),
+				})
+				return
+			}
+
+			cc := ChainChanges{
+				CurrencyID:  ch.CurrencyID(),
+				NetworkID:   ch.NetworkID(),
+				BlockHeight: blockHeight,
+			}
+			switch ch.(type) {
+			case chain.UTXO:
+				txs := []store.MultyTX{}
+				err = restClient.userStore.GetChangedTransactions(user.UserID, cc.CurrencyID, cc.NetworkID, since, &txs)
+				for i := range txs {
+					if txs[i].BlockHeight == -1 {
+						txs[i].Confirmations = 0
+					} else {
+						txs[i].Confirmations = int(blockHeight-txs[i].BlockHeight) + 1
+					}
+				}
+				cc.History = txs
+			case chain.Account:
+				txs := []store.TransactionETH{}
+				err = restClient.userStore.GetChangedEthTransactions(user.UserID, cc.CurrencyID, cc.NetworkID, since, &txs)
+				for i := range txs {
+					if txs[i].BlockHeight == -1 || txs[i].Status == store.TxStatusAppearedInMempoolIncoming || txs[i].Status == store.TxStatusAppearedInMempoolOutcoming {
+						txs[i].Confirmations = 0
+					} else {
+						txs[i].Confirmations = int(blockHeight-txs[i].BlockHeight) + 1
+					}
+				}
+				cc.History = txs
+			default:
+				continue
+			}
+			if err != nil {
+				restClient.log.Errorf("getChanges: userStore.GetChanged: %s\t[addr=%s]", err.Error(), c.Request.RemoteAddr)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"code":    http.StatusInternalServerError,
+					"message": msgErrTxHistory,
+				})
+				return
+			}
+			changes = append(changes, cc)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":       http.StatusOK,
+			"message":    http.StatusText(http.StatusOK),
+			"token":      nextToken,
+			"full":       since == 0,
+			"wallets":    restClient.walletsVerbose(user, changed),
+			"deleted":    deleted,
+			"topindexes": findTopIndexes(user.Wallets),
+			"changes":    changes,
+		})
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestSyncToken(t *testing.T) {
+	now := time.Unix(1530000000, 0)
+	since, err := parseSyncToken(newSyncToken(now))
+	if err != nil {
+		t.Fatalf("parseSyncToken: %v", err)
+	}
+	if since != now.Unix()-syncTokenOverlap {
+		t.Errorf("since = %d, want %d", since, now.Unix()-syncTokenOverlap)
+	}
+
+	if since, err := parseSyncToken(""); since != 0 || err != nil {
+		t.Errorf("empty token = %d, %v, want full sync", since, err)
+	}
+	for _, token := range []string{"!!", "YWJj", "LTE"} {
+		if _, err := parseSyncToken(token); err != errBadSyncToken {
+			t.Errorf("token %q err = %v, want errBadSyncToken", token, err)
+		}
+	}
+}
+
+func TestWalletChanged(t *testing.T) {
+	wallet := store.Wallet{
+		LastActionTime: 100,
+		Adresses:       []store.Address{{LastActionTime: 100}, {LastActionTime: 200}},
+	}
+	if !walletChanged(wallet, 150) {
+		t.Errorf("wallet with address used at 200 is not changed since 150")
+	}
+	if walletChanged(wallet, 250) {
+		t.Errorf("wallet is changed since 250")
+	}
+}
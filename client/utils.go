@@ -111,8 +111,16 @@ func reconnectWebSocketConn(addr string, log slf.StructuredLogger) (*websocket.C
 	}
 }
 func convertToHuman(amount string, d int64) string {
-	n, _ := new(big.Float).SetString(amount)
-	divider := new(big.Float).SetInt64(d)
+	return divideToHuman(amount, new(big.Float).SetInt64(d))
+}
+
+// divideToHuman returns the amount divided by the divider without trailing zeros,
+// amounts which are not numbers are returned as is
+func divideToHuman(amount string, divider *big.Float) string {
+	n, ok := new(big.Float).SetString(amount)
+	if !ok {
+		return amount
+	}
 	hu := ""
 	flag := false
 	for _, ch := range Reverse(n.Quo(n, divider).Text('f', 15)) {
@@ -14,13 +14,13 @@ import (
 	_ "github.com/jekabolt/slflog"
 
 	multy "github.com/Multy-io/Multy-back"
+	"github.com/Multy-io/Multy-back/logger"
 	"github.com/Multy-io/Multy-back/store"
-	"github.com/jekabolt/slf"
 	"fmt"
 )
 
 var (
-	log = slf.WithContext("main")
+	log = logger.WithContext("main")
 
 	branch    string
 	commit    string
@@ -53,6 +53,10 @@ var (
 func main() {
 	config.ReadGlobalConfig(&globalOpt, "multy configuration")
 
+	if args := subcommandArgs(); len(args) > 0 && args[0] == "migrate" {
+		os.Exit(migrate(globalOpt.Database, args[1:]))
+	}
+
 	log.Error("--------------------------------new multy back server session")
 
 	log.Infof("CONFIGURATION=%+v", globalOpt)
@@ -62,7 +66,7 @@ func main() {
 	log.Infof("build time: %s", buildtime)
 	log.Infof("tag: %s", lasttag)
 
-	gracefulStop := make(chan os.Signal)
+	gracefulStop := make(chan os.Signal, 1)
 
 	signal.Notify(gracefulStop, os.Interrupt, syscall.SIGTERM)
 
@@ -88,72 +92,19 @@ func main() {
 		log.Fatalf("Server initialization: %s\n", err.Error())
 	}
 
-	// TODO: Last state
-	// go func() {
-	// var ls store.LastState
-
-	// raw, err := ioutil.ReadFile("./state.json")
-	// if err != nil {
-	// 	log.Errorf("ioutil.ReadFile: %v", err.Error())
-	// }
-	// log.Errorf("\n\n\nLAT STATE\n\n\n %v", string(raw))
-	// json.Unmarshal(raw, &ls)
-
-	// rp, err := mu.BTC.CliMain.SyncState(context.Background(), &btcpb.BlockHeight{
-	// 	Height: ls.BTCMainBlock,
-	// })
-
-	// log.Errorf("\n\n\nmu.BTC.CliMain\n\n\n %v", ls.BTCMainBlock)
-
-	// if err != nil {
-	// 	log.Errorf("mu.BTC.CliMain.SyncState %v", err.Error())
-	// }
-	// log.Debugf("mu.BTC.CliMainSyncState: Reply: %v", rp)
-
-	// rp, err = mu.BTC.CliTest.SyncState(context.Background(), &btcpb.BlockHeight{
-	// 	Height: ls.BTCTestBlock,
-	// })
-
-	// log.Errorf("\n\n\nmu.BTC.CliTest\n\n\n %v", ls.BTCTestBlock)
-	// if err != nil {
-	// 	log.Errorf("mu.BTC.CliTest.SyncState %v", err.Error())
-	// }
-	// log.Debugf("mu.BTC.CliMainSyncState: Reply: %v", rp)
-	// // }()
-
-	// go func() {
-	// 	sig := <-gracefulStop
-	// 	fmt.Printf("caught sig: %+v", sig)
-	// 	fmt.Println("Gracefull stop")
-	// 	time.Sleep(time.Second)
-	// 	main, err := mu.BTC.CliMain.EventGetBlockHeight(context.Background(), &btcpb.Empty{})
-	// 	if err != nil {
-	// 		log.Errorf("mu.BTC.CliMain.EventGetBlockHeight %v", err.Error())
-	// 	}
-	// 	test, _ := mu.BTC.CliTest.EventGetBlockHeight(context.Background(), &btcpb.Empty{})
-	// 	if err != nil {
-	// 		log.Errorf("mu.BTC.CliTest.EventGetBlockHeight %v", err.Error())
-	// 	}
-
-	// 	ls := store.LastState{
-	// 		BTCMainBlock: main.GetHeight(),
-	// 		BTCTestBlock: test.GetHeight(),
-	// 		OffTime:      time.Now().String(),
-	// 	}
-
-	// 	fileState, err := json.Marshal(&ls)
-	// 	if err != nil {
-	// 		log.Errorf("json.Marshal %v", err.Error())
-	// 	}
-	// 	err = ioutil.WriteFile("state.json", fileState, 0644)
-	// 	if err != nil {
-	// 		log.Errorf("json.Marshal %v", err.Error())
-	// 	}
-
-	// 	log.Infof("\n\noff state main : %v test: %v\n\n", main, test)
-
-	// 	os.Exit(0)
-	// }()
+	// catch-up from the last synced block is done by multy.Init, progress is on /server/sync
+
+	// rotated node service certificates are picked up without restart
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			log.Infof("Got SIGHUP, reloading certificates")
+			if err := mu.ReloadCertificates(); err != nil {
+				log.Errorf("mu.ReloadCertificates: %s", err.Error())
+			}
+		}
+	}()
 
 	if err = mu.Run(); err != nil {
 		log.Fatalf("Server running: %s\n", err.Error())
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+const migrateUsage = `usage: multy migrate <command> [--config options]
+  list               show known migrations and whether they are applied
+  run                apply pending migrations
+  rollback [version] roll back migrations newer than version, the last one by default`
+
+// subcommandArgs returns positional args, options are handled by config
+func subcommandArgs() []string {
+	args := []string{}
+	for _, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// migrate runs the migrate subcommand and returns process exit code
+func migrate(conf store.Conf, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	version := -1
+	if args[0] == "rollback" && len(args) > 1 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 0 {
+			fmt.Fprintf(os.Stderr, "wrong version %q\n%s\n", args[1], migrateUsage)
+			return 2
+		}
+		version = v
+	}
+
+	migrator, err := store.OpenMigrator(conf)
+	if err != nil {
+		log.Errorf("migrate: store.OpenMigrator: %s", err.Error())
+		return 1
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "list":
+		statuses, err := migrator.List()
+		if err != nil {
+			log.Errorf("migrate: migrator.List: %s", err.Error())
+			return 1
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = "applied " + time.Unix(s.AppliedAt, 0).Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-30s %s\n", s.Version, s.Name, applied)
+		}
+	case "run":
+		done, err := migrator.Run()
+		fmt.Printf("applied: %v\n", done)
+		if err != nil {
+			log.Errorf("migrate: migrator.Run: %s", err.Error())
+			return 1
+		}
+	case "rollback":
+		done, err := migrator.Rollback(version)
+		fmt.Printf("rolled back: %v\n", done)
+		if err != nil {
+			log.Errorf("migrate: migrator.Rollback: %s", err.Error())
+			return 1
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s\n", args[0], migrateUsage)
+		return 2
+	}
+	return 0
+}
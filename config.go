@@ -7,25 +7,32 @@ package multyback
 
 import (
 	// "github.com/Multy-io/Multy-back-exchange-service/core"
+	"github.com/Multy-io/Multy-back/chain"
 	"github.com/Multy-io/Multy-back/client"
 	"github.com/Multy-io/Multy-back/store"
 )
 
 // Configuration is a struct with all service options
 type Configuration struct {
-	Name              string
-	Database          store.Conf
-	SocketioAddr      string
-	RestAddress       string
-	Firebase          client.FirebaseConf
-	NSQAddress        string
-	BTCNodeAddress    string
-	DonationAddresses []store.DonationInfo
-	MultyVerison      store.ServerConfig
+	Name                  string
+	Database              store.Conf
+	SocketioAddr          string
+	RestAddress           string
+	Firebase              client.FirebaseConf
+	DisableFirebase       bool
+	DisableExchangeStocks bool
+	NSQAddress            string
+	BTCNodeAddress        string
+	DonationAddresses     []store.DonationInfo
+	MultyVerison          store.ServerConfig
 	// ExchangerConfiguration core.ManagerConfiguration
 	ServicesInfo   []store.ServiceInfo
 	Secretkey      string
 	SupportedNodes []store.CoinType
 	DeviceVersions store.Versions
 	NSVersions     store.NodeVersion
+	// resending of txs lost by node services
+	Rebroadcast chain.RebroadcastConf
+	// erc-20 tokens which balances and transfers are tracked
+	Tokens []store.Token
 }
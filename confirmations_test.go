@@ -0,0 +1,65 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestNewBlockConfirmsTx(t *testing.T) {
+	h := newHarness(t, walletUser("confirm-user", currencies.Bitcoin, currencies.Test, "confirm-address"))
+	defer h.Close()
+
+	h.btcTest.NewTransaction(&btcpb.BTCTransaction{
+		UserID:      "confirm-user",
+		TxID:        "txid-confirm",
+		TxAddress:   []string{"confirm-address"},
+		TxStatus:    store.TxStatusAppearedInBlockIncoming,
+		TxOutAmount: 1000,
+		BlockHeight: 100,
+		TxInputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "confirm-sender", Amount: 2000},
+		},
+		WalletsOutput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "confirm-user", Address: "confirm-address", Amount: 1000},
+		},
+	})
+
+	status := func() (int, int) {
+		txs := []store.MultyTX{}
+		h.userStore.GetAllWalletTransactions("confirm-user", currencies.Bitcoin, currencies.Test, &txs)
+		if len(txs) == 0 {
+			return 0, 0
+		}
+		return txs[0].TxStatus, txs[0].Confirmations
+	}
+	h.waitFor("tx in history", func() bool {
+		s, _ := status()
+		return s == store.TxStatusAppearedInBlockIncoming
+	})
+
+	h.btcTest.NewBlock(101)
+	h.waitFor("2 confirmations", func() bool {
+		_, c := status()
+		return c == 2
+	})
+	if s, _ := status(); s != store.TxStatusAppearedInBlockIncoming {
+		t.Errorf("status after 2 confirmations: got %d, want %d", s, store.TxStatusAppearedInBlockIncoming)
+	}
+
+	// default depth for btc is 6 blocks
+	h.btcTest.NewBlock(105)
+	h.waitForTxNotification("confirmed notification", "txid-confirm", func(n *store.WsTxNotify) bool {
+		return n.TransactionType == store.TxStatusInBlockConfirmedIncoming
+	})
+	if s, c := status(); s != store.TxStatusInBlockConfirmedIncoming || c != 6 {
+		t.Errorf("status after 6 confirmations: got %d %d, want %d 6", s, c, store.TxStatusInBlockConfirmedIncoming)
+	}
+}
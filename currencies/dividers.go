@@ -6,6 +6,10 @@ const (
 )
 
 var Dividers = map[int]int64{
-	Bitcoin: Satoshi,
-	Ether:   Wei,
+	Bitcoin:      Satoshi,
+	Litecoin:     Satoshi,
+	Dash:         Satoshi,
+	BitcoinCash:  Satoshi,
+	Ether:        Wei,
+	EtherClassic: Wei,
 }
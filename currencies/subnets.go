@@ -3,7 +3,15 @@ package currencies
 const (
 	Main = 0
 	Test = 1
+	// Regtest is a local bitcoin-like network, blocks are mined on demand
+	Regtest = 2
 
 	ETHMain = 1
 	ETHTest = 4
+	// ETHDev is a chain id of a local ethereum dev network
+	ETHDev = 1337
+
+	// ethereum classic networks are identified by their chain ids
+	ETCMain = 61
+	ETCTest = 63
 )
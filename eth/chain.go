@@ -0,0 +1,175 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package eth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/feeestimator"
+	pb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// Chain is a node service of a single network of the EVM chain.
+// It implements chain.Account interface.
+type Chain struct {
+	conn      *ETHConn
+	networkID int
+
+	cli     pb.NodeCommuunicationsClient
+	watch   chan pb.WatchAddress
+	fees    *feeestimator.Estimator
+	streams *chain.Supervisor
+
+	endpoints *chain.Failover
+}
+
+// Chain returns chain of the given network
+func (e *ETHConn) Chain(networkID int) (*Chain, error) {
+	n, ok := e.nodes[networkID]
+	if !ok {
+		return nil, fmt.Errorf("Chain: wrong networkID: %d", networkID)
+	}
+	return &Chain{
+		conn:      e,
+		networkID: networkID,
+		cli:       n.cli,
+		watch:     n.watch,
+		fees:      n.fees,
+		streams:   n.streams,
+		endpoints: n.endpoints,
+	}, nil
+}
+
+func (c *Chain) CurrencyID() int { return c.conn.Params.CurrencyID }
+func (c *Chain) NetworkID() int  { return c.networkID }
+
+func (c *Chain) Streams() *chain.Supervisor { return c.streams }
+
+func (c *Chain) Endpoints() *chain.Failover { return c.endpoints }
+
+func (c *Chain) ServiceInfo() (store.ServiceInfo, error) {
+	sv, err := c.cli.ServiceInfo(context.Background(), &pb.Empty{})
+	if err != nil {
+		return store.ServiceInfo{}, err
+	}
+	return store.ServiceInfo{
+		Branch:    sv.GetBranch(),
+		Commit:    sv.GetCommit(),
+		Buildtime: sv.GetBuildtime(),
+		Lasttag:   sv.GetLasttag(),
+	}, nil
+}
+
+func (c *Chain) InitialAdd(usersData map[string]store.AddressExtended) error {
+	genUd := pb.UsersData{
+		Map: map[string]*pb.AddressExtended{},
+	}
+	for address, ex := range usersData {
+		genUd.Map[address] = &pb.AddressExtended{
+			UserID:       ex.UserID,
+			WalletIndex:  int32(ex.WalletIndex),
+			AddressIndex: int32(ex.AddressIndex),
+		}
+	}
+	resp, err := c.cli.EventInitialAdd(context.Background(), &genUd)
+	if err != nil {
+		return err
+	}
+	log.Debugf("InitialAdd: netID:%d resp: %s", c.networkID, resp.GetMessage())
+	return nil
+}
+
+func (c *Chain) WatchAddress(address, userID string, walletIndex, addressIndex int) {
+	c.watch <- pb.WatchAddress{
+		Address:      address,
+		UserID:       userID,
+		WalletIndex:  int32(walletIndex),
+		AddressIndex: int32(addressIndex),
+	}
+}
+
+func (c *Chain) ResyncAddress(address, userID string, walletIndex, addressIndex int) error {
+	_, err := c.cli.EventResyncAddress(context.Background(), &pb.AddressToResync{
+		Address: address,
+	})
+	return err
+}
+
+func (c *Chain) SendRawTx(rawTx string) (string, error) {
+	resp, err := c.cli.EventSendRawTx(context.Background(), &pb.RawTx{
+		Transaction: rawTx,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetMessage(), nil
+}
+
+func (c *Chain) SyncState(blockHeight int64) error {
+	rp, err := c.cli.SyncState(context.Background(), &pb.BlockHeight{
+		Height: blockHeight,
+	})
+	if err != nil {
+		return err
+	}
+	if strings.Contains(rp.GetMessage(), "err:") {
+		return fmt.Errorf("SyncState: %s", rp.GetMessage())
+	}
+	return nil
+}
+
+func (c *Chain) BlockHeight() (int64, error) {
+	resp, err := c.cli.EventGetBlockHeight(context.Background(), &pb.Empty{})
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetHeight(), nil
+}
+
+func (c *Chain) AddressBalance(address string) (string, string, error) {
+	balance, err := c.cli.EventGetAdressBalance(context.Background(), &pb.AddressToResync{
+		Address: address,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return balance.GetBalance(), balance.GetPendingBalance(), nil
+}
+
+func (c *Chain) AddressNonce(address string) (int64, error) {
+	nonce, err := c.cli.EventGetAdressNonce(context.Background(), &pb.AddressToResync{
+		Address: address,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return nonce.GetNonce(), nil
+}
+
+// gasPrice asks the node for its gas price estimation in wei
+func gasPrice(cli pb.NodeCommuunicationsClient) func() (int, error) {
+	return func() (int, error) {
+		rate, err := cli.EventGetGasPrice(context.Background(), &pb.Empty{})
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(rate.GetGas())
+	}
+}
+
+// FeeRates returns gas prices in wei
+func (c *Chain) FeeRates() (store.FeeRates, error) {
+	return c.fees.Estimate()
+}
+
+func (c *Chain) MempoolStats(hours, feeRate int) (store.MempoolStats, error) {
+	return c.fees.Stats(hours, feeRate)
+}
@@ -6,53 +6,72 @@ See LICENSE for details
 package eth
 
 import (
+	"errors"
 	"fmt"
-	"sync"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
-	mgo "gopkg.in/mgo.v2"
 
+	"github.com/Multy-io/Multy-back/chain"
 	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/feeestimator"
+	"github.com/Multy-io/Multy-back/logger"
 	pb "github.com/Multy-io/Multy-back/node-streamer/eth"
 	"github.com/Multy-io/Multy-back/store"
 	nsq "github.com/bitly/go-nsq"
-	"github.com/jekabolt/slf"
 )
 
-// ETHConn is a main struct of package
+// ETHConn is a main struct of package, it connects node services of all networks of an EVM chain
 type ETHConn struct {
-	NsqProducer      *nsq.Producer // a producer for sending data to clients
-	CliTest          pb.NodeCommuunicationsClient
-	CliMain          pb.NodeCommuunicationsClient
-	WatchAddressTest chan pb.WatchAddress
-	WatchAddressMain chan pb.WatchAddress
-	// Mempool          *map[string]int
-	// MempoolTest      *map[string]int
+	Params      Params
+	NsqProducer *nsq.Producer // a producer for sending data to clients
 
-	Mempool     sync.Map
-	MempoolTest sync.Map
+	nodes map[int]*node
 
-	VersionMain store.NodeVersion
-	VersionTest store.NodeVersion
+	// sent txs are looked up in users history
+	userStore store.UserStore
 
 	// M     *sync.Mutex
 	// MTest *sync.Mutex
 }
 
-var log = slf.WithContext("eth")
+// node is a node service of a single network, calls go to the active one of its endpoints
+type node struct {
+	cli   *failoverClient
+	watch chan pb.WatchAddress
 
-//InitHandlers init nsq mongo and ws connection to node
-// return main client , test client , err
-func InitHandlers(dbConf *store.Conf, coinTypes []store.CoinType, nsqAddr string) (*ETHConn, error) {
+	// fee estimation from mempool of the network
+	fees *feeestimator.Estimator
+
+	// node service streams supervisor
+	streams *chain.Supervisor
+
+	// health of node service endpoints, streams are reopened when the active one changes
+	endpoints *chain.Failover
+
+	// TLS certificates of the connections, nil if they are plaintext
+	certs *chain.Certificates
+}
+
+var log = logger.WithContext("eth")
+
+// defaultConfirmations is a depth after which txs are confirmed if config has none
+const defaultConfirmations = 12
+
+// localConfirmations is the default depth on dev networks, a single block mined on demand confirms txs
+const localConfirmations = 1
+
+// InitHandlers init nsq and grpc connections to node services of the chain,
+// every network of the chain in config gets its own node
+func InitHandlers(params Params, userStore store.UserStore, coinTypes []store.CoinType, nsqAddr string) (*ETHConn, error) {
 	//declare pacakge struct
 	cli := &ETHConn{
-		Mempool:     sync.Map{},
-		MempoolTest: sync.Map{},
+		Params:    params,
+		nodes:     map[int]*node{},
+		userStore: userStore,
 	}
 
-	cli.WatchAddressMain = make(chan pb.WatchAddress)
-	cli.WatchAddressTest = make(chan pb.WatchAddress)
-
 	config := nsq.NewConfig()
 	p, err := nsq.NewProducer(nsqAddr, config)
 	if err != nil {
@@ -60,86 +79,134 @@ func InitHandlers(dbConf *store.Conf, coinTypes []store.CoinType, nsqAddr string
 	}
 
 	cli.NsqProducer = p
-	log.Infof("InitHandlers: nsq.NewProducer: √")
+	log.Infof("InitHandlers: %s: nsq.NewProducer: √", params.Name)
 
-	addr := []string{dbConf.Address}
-
-	mongoDBDial := &mgo.DialInfo{
-		Addrs:    addr,
-		Username: dbConf.Username,
-		Password: dbConf.Password,
+	for _, ct := range coinTypes {
+		if ct.СurrencyID != params.CurrencyID {
+			continue
+		}
+		if ct.Confirmations <= 0 {
+			ct.Confirmations = defaultConfirmations
+			if ct.NetworkID == currencies.ETHDev {
+				ct.Confirmations = localConfirmations
+			}
+		}
+		if ct.MinGasPrice <= 0 {
+			ct.MinGasPrice = params.MinGasPrice
+		}
+		n, err := cli.initNode(ct)
+		if err != nil {
+			return cli, fmt.Errorf("initNode: %s", err.Error())
+		}
+		cli.nodes[ct.NetworkID] = n
+		log.Infof("InitHandlers: %s: initGrpcClient: netID:%d √ %v", params.Name, ct.NetworkID, n.endpoints.ActiveEndpoint())
+	}
+	if len(cli.nodes) == 0 {
+		return cli, fmt.Errorf("no %s node services in config", params.Name)
 	}
 
-	db, err := mgo.DialWithInfo(mongoDBDial)
+	return cli, nil
+}
+
+// initNode connects to node service endpoints of the network, picks a healthy one
+// and starts streams from it
+func (e *ETHConn) initNode(ct store.CoinType) (*node, error) {
+	name := fmt.Sprintf("%s %s", e.Params.Name, e.Params.networkName(ct.NetworkID))
+	maxLag := ct.MaxLag
+	if maxLag <= 0 {
+		maxLag = e.Params.MaxLag
+	}
+	var certs *chain.Certificates
+	if ct.TLS != nil {
+		loaded, err := chain.LoadCertificates(*ct.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("chain.LoadCertificates: %s", err.Error())
+		}
+		certs = loaded
+	}
+	cli, err := newFailoverClient(name, ct.Endpoints(), int64(maxLag), certs)
 	if err != nil {
-		log.Errorf("RunProcess: can't connect to DB: %s", err.Error())
-		return cli, fmt.Errorf("mgo.Dial: %s", err.Error())
+		return nil, fmt.Errorf("initGrpcClient: %s", err.Error())
 	}
-	log.Infof("InitHandlers: mgo.Dial: √")
-
-	usersData = db.DB(dbConf.DBUsers).C(store.TableUsers) // all db tables
-	exRate = db.DB(dbConf.DBStockExchangeRate).C("TableStockExchangeRate")
 
-	// main
-	txsData = db.DB(dbConf.DBTx).C(dbConf.TableTxsDataETHMain)
+	fees := feeestimator.New(e.Params.CurrencyID, ct.NetworkID, feeestimator.NewMempool(feeestimator.DefaultBlocks), e.userStore, e.Params.feeStrategies(ct.MinGasPrice)...).WithParams(e.Params.Blocks)
+	if err := fees.Restore(); err != nil {
+		log.Errorf("initNode: fees.Restore: %s", err.Error())
+	}
+	n := &node{
+		cli:       cli,
+		watch:     make(chan pb.WatchAddress),
+		fees:      fees.WithNodeRate(gasPrice(cli)),
+		streams:   chain.NewSupervisor(name),
+		endpoints: cli.failover,
+		certs:     certs,
+	}
 
-	// test
-	txsDataTest = db.DB(dbConf.DBTx).C(dbConf.TableTxsDataETHTest)
+	n.endpoints.Check()
+	n.endpoints.OnSwitch(n.streams.Reconnect)
+	n.streams.OnDisconnect(n.endpoints.Trigger)
+	n.endpoints.Run(time.Duration(ct.ProbeInterval)*time.Second, n.streams.Done())
 
-	//restore state
-	restoreState = db.DB(dbConf.DBRestoreState).C(dbConf.TableState)
+	setGRPCHandlers(cli, n.streams, e.userStore, e.NsqProducer, e.Params.CurrencyID, ct.NetworkID, ct.Confirmations, n.watch, n.fees)
+	return n, nil
+}
 
-	// setup main net
-	urlMain, err := fethCoinType(coinTypes, currencies.Ether, currencies.ETHMain)
-	if err != nil {
-		return cli, fmt.Errorf("fethCoinType: %s", err.Error())
+// Versions returns versions of active node services by names of their networks
+func (e *ETHConn) Versions() map[string]store.NodeVersion {
+	versions := map[string]store.NodeVersion{}
+	for networkID, n := range e.nodes {
+		versions[e.Params.networkName(networkID)] = n.endpoints.ActiveEndpoint().Version
 	}
+	return versions
+}
 
-	cliMain, err := initGrpcClient(urlMain)
-	if err != nil {
-		return cli, fmt.Errorf("initGrpcClient: %s", err.Error())
+// Endpoints returns urls of active node services by names of their networks
+func (e *ETHConn) Endpoints() map[string]string {
+	endpoints := map[string]string{}
+	for networkID, n := range e.nodes {
+		endpoints[e.Params.networkName(networkID)] = n.endpoints.ActiveEndpoint().URL
 	}
-	setGRPCHandlers(cliMain, cli.NsqProducer, currencies.ETHMain, cli.WatchAddressMain, cli.Mempool)
-
-	cli.CliMain = cliMain
-	log.Infof("InitHandlers: initGrpcClient: Main: √")
+	return endpoints
+}
 
-	// setup testnet
-	urlTest, err := fethCoinType(coinTypes, currencies.Ether, currencies.ETHTest)
-	if err != nil {
-		return cli, fmt.Errorf("fethCoinType: %s", err.Error())
+// ReloadCertificates re-reads TLS certificates of node services of all networks,
+// broken connections are redialed with them right away. Networks failed to reload
+// keep certificates loaded before and don't stop others from reloading.
+func (e *ETHConn) ReloadCertificates() error {
+	failed := []string{}
+	for networkID, n := range e.nodes {
+		if n.certs == nil {
+			continue
+		}
+		if err := n.certs.Reload(); err != nil {
+			log.Errorf("ReloadCertificates: %s %s: %s", e.Params.Name, e.Params.networkName(networkID), err.Error())
+			failed = append(failed, fmt.Sprintf("%s %s: %s", e.Params.Name, e.Params.networkName(networkID), err.Error()))
+			continue
+		}
+		n.cli.redial()
 	}
-	cliTest, err := initGrpcClient(urlTest)
-	if err != nil {
-		return cli, fmt.Errorf("initGrpcClient: %s", err.Error())
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
 	}
-	setGRPCHandlers(cliTest, cli.NsqProducer, currencies.ETHTest, cli.WatchAddressTest, cli.MempoolTest)
-
-	cli.CliTest = cliTest
-	log.Infof("InitHandlers: initGrpcClient: Test: √")
+	return nil
+}
 
-	return cli, nil
+// Fees returns fee estimator of the network, nil if the network is not configured
+func (e *ETHConn) Fees(networkID int) *feeestimator.Estimator {
+	if n, ok := e.nodes[networkID]; ok {
+		return n.fees
+	}
+	return nil
 }
 
-func initGrpcClient(url string) (pb.NodeCommuunicationsClient, error) {
-	conn, err := grpc.Dial(url, grpc.WithInsecure())
+// initGrpcClient dials node service, connections are secured with TLS if certs are given
+func initGrpcClient(url string, certs *chain.Certificates) (*grpc.ClientConn, error) {
+	conn, err := grpc.Dial(url, chain.TransportOption(certs))
 	if err != nil {
 		log.Errorf("initGrpcClient: grpc.Dial: %s", err.Error())
 		return nil, err
 	}
-
-	// Create a new  client
-	client := pb.NewNodeCommuunicationsClient(conn)
-	return client, nil
-}
-
-func fethCoinType(coinTypes []store.CoinType, currencyID, networkID int) (string, error) {
-	for _, ct := range coinTypes {
-		if ct.СurrencyID == currencyID && ct.NetworkID == networkID {
-			return ct.GRPCUrl, nil
-		}
-	}
-	return "", fmt.Errorf("fethCoinType: no such coin in config")
+	return conn, nil
 }
 
 // BtcTransaction stuct for ws notifications
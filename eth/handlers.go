@@ -7,37 +7,38 @@ package eth
 
 import (
 	"context"
+	"fmt"
 	"io"
-	"sync"
 
-	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/feeestimator"
 	pb "github.com/Multy-io/Multy-back/node-streamer/eth"
 	"github.com/Multy-io/Multy-back/store"
 	nsq "github.com/bitly/go-nsq"
-	mgo "gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
 )
 
-func setGRPCHandlers(cli pb.NodeCommuunicationsClient, nsqProducer *nsq.Producer, networtkID int, wa chan pb.WatchAddress, mempool sync.Map) {
+func setGRPCHandlers(cli pb.NodeCommuunicationsClient, streams *chain.Supervisor, userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID, confirmations int, wa chan pb.WatchAddress, fees *feeestimator.Estimator) {
 
 	mempoolCh := make(chan interface{})
 
-	// initial fill mempool respectively network id
-	go func() {
-		stream, err := cli.EventGetAllMempool(context.Background(), &pb.Empty{})
+	// fill mempool respectively network id on start and after node service is reconnected
+	streams.RunSnapshot("EventGetAllMempool", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventGetAllMempool(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
-			// return nil, err
+			return fmt.Errorf("cli.EventGetAllMempool: %s", err.Error())
 		}
+		alive()
 
 		for {
 			mpRec, err := stream.Recv()
 			if err == io.EOF {
-				break
+				// whole mempool is received
+				return nil
 			}
 			if err != nil {
-				log.Errorf("setGRPCHandlers: client.EventGetAllMempool: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
 			mempoolCh <- store.MempoolRecord{
 				Category: int(mpRec.Category),
@@ -47,48 +48,44 @@ func setGRPCHandlers(cli pb.NodeCommuunicationsClient, nsqProducer *nsq.Producer
 				log.Errorf("initGrpcClient: mpRates.Insert: %s", err.Error())
 			}
 		}
-	}()
+	})
 
 	// add transaction on every new tx on node
-	go func() {
-		stream, err := cli.EventAddMempoolRecord(context.Background(), &pb.Empty{})
+	streams.Run("EventAddMempoolRecord", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventAddMempoolRecord(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventAddMempoolRecord: %s", err.Error())
-			// return nil, err
+			return fmt.Errorf("cli.EventAddMempoolRecord: %s", err.Error())
 		}
+		alive()
 
 		for {
 			mpRec, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("setGRPCHandlers: client.EventAddMempoolRecord:stream.Recv: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 			mempoolCh <- store.MempoolRecord{
 				Category: int(mpRec.Category),
 				HashTX:   mpRec.HashTX,
 			}
 		}
-	}()
+	})
 
 	//deleting mempool record on block
-	go func() {
+	streams.Run("EventDeleteMempool", func(ctx context.Context, alive func()) error {
 
-		stream, err := cli.EventDeleteMempool(context.Background(), &pb.Empty{})
+		stream, err := cli.EventDeleteMempool(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
-			// return nil, err
+			return fmt.Errorf("cli.EventDeleteMempool: %s", err.Error())
 		}
+		alive()
 
 		for {
 			mpRec, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("initGrpcClient: cli.EventDeleteMempool:stream.Recv: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
 			mempoolCh <- mpRec.Hash
 
@@ -99,98 +96,146 @@ func setGRPCHandlers(cli pb.NodeCommuunicationsClient, nsqProducer *nsq.Producer
 			}
 		}
 
-	}()
+	})
 
 	// add to transaction history record and send ws notification on tx
-	go func() {
-		stream, err := cli.NewTx(context.Background(), &pb.Empty{})
+	streams.Run("NewTx", func(ctx context.Context, alive func()) error {
+		stream, err := cli.NewTx(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventGetAllMempool: %s", err.Error())
+			return fmt.Errorf("cli.NewTx: %s", err.Error())
 		}
+		alive()
 
 		for {
 			gTx, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
 			if err != nil {
-				log.Errorf("initGrpcClient: cli.NewTx:stream.Recv: %s", err.Error())
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 			tx := generatedTxDataToStore(gTx)
-			setExchangeRates(&tx, gTx.Resync, tx.BlockTime)
+			setExchangeRates(userStore, &tx, gTx.Resync, tx.BlockTime)
 
-			err = saveTransaction(tx, networtkID, gTx.Resync)
-			updateWalletAndAddressDate(tx, networtkID)
+			err = saveTransaction(userStore, tx, currencyID, networtkID)
+			updateWalletAndAddressDate(userStore, tx)
 			if err != nil {
 				log.Errorf("initGrpcClient: saveMultyTransaction: %s", err)
 			}
 
 			if !gTx.GetResync() {
-				sendNotifyToClients(tx, nsqProducer, networtkID)
+				sendNotifyToClients(tx, nsqProducer, currencyID, networtkID)
 			}
 		}
-	}()
+	})
 
-	go func() {
-		stream, err := cli.EventNewBlock(context.Background(), &pb.Empty{})
+	// add to token history and notify on erc-20 transfers of watched addresses
+	streams.Run("NewTokenTransfer", func(ctx context.Context, alive func()) error {
+		stream, err := cli.NewTokenTransfer(ctx, &pb.Empty{})
 		if err != nil {
-			log.Errorf("setGRPCHandlers: cli.EventNewBlock: %s", err.Error())
-			// return nil, err
+			return fmt.Errorf("cli.NewTokenTransfer: %s", err.Error())
 		}
+		alive()
+
 		for {
-			h, err := stream.Recv()
-			if err == io.EOF {
-				break
+			gTr, err := stream.Recv()
+			if err != nil {
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
+
+			err = processTokenTransfer(userStore, nsqProducer, currencyID, networtkID, gTr)
 			if err != nil {
-				log.Errorf("setGRPCHandlers: client.EventNewBlock:stream.Recv: %s", err.Error())
+				log.Errorf("setGRPCHandlers: processTokenTransfer: %s", err.Error())
 			}
+		}
+	})
+
+	// track txs of multisig contracts and ask owners to confirm them
+	streams.Run("NewMultisigEvent", func(ctx context.Context, alive func()) error {
+		stream, err := cli.NewMultisigEvent(ctx, &pb.Empty{})
+		if err != nil {
+			return fmt.Errorf("cli.NewMultisigEvent: %s", err.Error())
+		}
+		alive()
 
-			query := bson.M{"currencyid": currencies.Bitcoin, "networkid": networtkID}
-			update := bson.M{
-				"$set": bson.M{
-					"blockheight": h.GetHeight(),
-				},
+		for {
+			ev, err := stream.Recv()
+			if err != nil {
+				return fmt.Errorf("stream.Recv: %s", err.Error())
+			}
+			alive()
+
+			err = processMultisigEvent(userStore, nsqProducer, currencyID, networtkID, ev)
+			if err != nil {
+				log.Errorf("setGRPCHandlers: processMultisigEvent: %s", err.Error())
+			}
+		}
+	})
+
+	streams.Run("EventNewBlock", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventNewBlock(ctx, &pb.Empty{})
+		if err != nil {
+			return fmt.Errorf("cli.EventNewBlock: %s", err.Error())
+		}
+		alive()
+		for {
+			h, err := stream.Recv()
+			if err != nil {
+				return fmt.Errorf("stream.Recv: %s", err.Error())
 			}
+			alive()
 
-			err = restoreState.Update(query, update)
-			if err == mgo.ErrNotFound {
-				restoreState.Insert(store.LastState{
-					BlockHeight: h.GetHeight(),
-					CurrencyID:  currencies.Bitcoin,
-					NetworkID:   networtkID,
-				})
+			block := store.Block{Height: h.GetHeight(), Hash: h.GetHash(), PrevHash: h.GetPrevHash()}
+			if !rollbackOrphaned(userStore, nsqProducer, currencyID, networtkID, block) {
+				continue
 			}
 
+			err = userStore.SetLastSyncBlockState(networtkID, currencyID, block)
 			if err != nil {
-				log.Errorf("initGrpcClient: restoreState.Update: %s", err.Error())
+				log.Errorf("initGrpcClient: userStore.SetLastSyncBlockState: %s", err.Error())
 			}
+
+			updateConfirmations(userStore, nsqProducer, currencyID, networtkID, h.GetHeight(), confirmations)
+			fees.NewBlock()
 		}
-	}()
+	})
+
+	// watch for channel and push to node,
+	// the address failed to be added is pushed again once the node service is back
+	var watched *pb.WatchAddress
+	streams.Run("EventAddNewAddress", func(ctx context.Context, alive func()) error {
+		if _, err := cli.ServiceInfo(ctx, &pb.Empty{}); err != nil {
+			return fmt.Errorf("cli.ServiceInfo: %s", err.Error())
+		}
+		alive()
 
-	// watch for channel and push to node
-	go func() {
 		for {
-			select {
-			case addr := <-wa:
-				a := addr
-				rp, err := cli.EventAddNewAddress(context.Background(), &a)
-				if err != nil {
-					log.Errorf("NewAddressNode: cli.EventAddNewAddress %s\n", err.Error())
+			if watched == nil {
+				select {
+				case addr := <-wa:
+					watched = &addr
+				case <-ctx.Done():
+					return ctx.Err()
 				}
-				log.Debugf("EventAddNewAddress Reply %s", rp)
+			}
 
-				rp, err = cli.EventResyncAddress(context.Background(), &pb.AddressToResync{
-					Address: addr.Address,
-				})
-				if err != nil {
-					log.Errorf("EventResyncAddress: cli.EventResyncAddress %s\n", err.Error())
-				}
-				log.Debugf("EventResyncAddress Reply %s", rp)
+			rp, err := cli.EventAddNewAddress(ctx, watched)
+			if err != nil {
+				return fmt.Errorf("cli.EventAddNewAddress: %s", err.Error())
+			}
+			log.Debugf("EventAddNewAddress Reply %s", rp)
 
+			rp, err = cli.EventResyncAddress(ctx, &pb.AddressToResync{
+				Address: watched.Address,
+			})
+			if err != nil {
+				return fmt.Errorf("cli.EventResyncAddress: %s", err.Error())
 			}
+			log.Debugf("EventResyncAddress Reply %s", rp)
+
+			watched = nil
+			alive()
 		}
-	}()
+	})
 
 	go func() {
 
@@ -200,10 +245,10 @@ func setGRPCHandlers(cli pb.NodeCommuunicationsClient, nsqProducer *nsq.Producer
 			// 	log.Errorf("Not found type: %v", v)
 			case string:
 				// delete tx from pool
-				mempool.Delete(v)
+				fees.Mempool().Remove(v)
 			case store.MempoolRecord:
 				// add tx to pool
-				mempool.Store(v.HashTX, v.Category)
+				fees.Mempool().Add(v.HashTX, v.Category)
 			}
 		}
 	}()
@@ -0,0 +1,254 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Multy-io/Multy-back/chain"
+	pb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"github.com/Multy-io/Multy-back/store"
+	nsq "github.com/bitly/go-nsq"
+)
+
+// Multisig reads owners and required confirmations of the contract from the node service
+// and associates the owners with wallets of the users
+func (c *Chain) Multisig(contract string) (store.Multisig, error) {
+	contract = strings.ToLower(contract)
+	ms, err := c.cli.EventGetMultisig(context.Background(), &pb.MultisigRequest{Contract: contract})
+	if err != nil {
+		return store.Multisig{}, err
+	}
+	if len(ms.GetOwners()) == 0 {
+		return store.Multisig{}, chain.ErrNotMultisig
+	}
+
+	multisig := store.Multisig{
+		CurrencyID:      c.CurrencyID(),
+		NetworkID:       c.networkID,
+		ContractAddress: contract,
+		Confirmations:   int(ms.GetConfirmations()),
+		Owners:          []store.AddressExtended{},
+	}
+	for _, owner := range ms.GetOwners() {
+		multisig.Owners = append(multisig.Owners, associateOwner(c.conn.userStore, c.CurrencyID(), c.networkID, owner))
+	}
+	return multisig, nil
+}
+
+// AddMultisig saves the contract read by Multisig and makes node service watch for its events.
+// Contracts registered already keep their name and get owners and confirmations of the chain.
+func (c *Chain) AddMultisig(multisig store.Multisig) (store.Multisig, error) {
+	found, err := c.conn.userStore.FindMultisig(c.CurrencyID(), c.networkID, multisig.ContractAddress)
+	if err == nil {
+		found.Owners = multisig.Owners
+		found.Confirmations = multisig.Confirmations
+		found.LastActionTime = time.Now().Unix()
+		if err := c.conn.userStore.SaveMultisig(found); err != nil {
+			return found, err
+		}
+		return found, c.watchMultisig(found)
+	}
+	if err != store.ErrNotFound {
+		return multisig, err
+	}
+
+	multisig.DateOfCreation = time.Now().Unix()
+	multisig.LastActionTime = multisig.DateOfCreation
+	if err := c.conn.userStore.SaveMultisig(multisig); err != nil {
+		return multisig, err
+	}
+	return multisig, c.watchMultisig(multisig)
+}
+
+// WatchMultisigs sends all multisig contracts of the chain to the node service
+func (c *Chain) WatchMultisigs() error {
+	multisigs, err := c.conn.userStore.GetMultisigs(c.CurrencyID(), c.networkID)
+	if err != nil {
+		return err
+	}
+	for _, multisig := range multisigs {
+		if err := c.watchMultisig(multisig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Chain) watchMultisig(multisig store.Multisig) error {
+	owners := []string{}
+	for _, owner := range multisig.Owners {
+		owners = append(owners, owner.Address)
+	}
+	rp, err := c.cli.EventAddMultisig(context.Background(), &pb.Multisig{
+		Contract:      multisig.ContractAddress,
+		Owners:        owners,
+		Confirmations: int32(multisig.Confirmations),
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("watchMultisig: netID:%d contract:%s reply: %s", c.networkID, multisig.ContractAddress, rp.GetMessage())
+	return nil
+}
+
+// associateOwner looks for the wallet of the owner address among all users
+func associateOwner(userStore store.UserStore, currencyID, networtkID int, address string) store.AddressExtended {
+	owner := store.AddressExtended{
+		Address: address,
+	}
+	ua := store.UserAddress{}
+	if err := userStore.FindAddress(currencyID, networtkID, address, &ua); err == nil {
+		owner.Associated = true
+		owner.UserID = ua.UserID
+		owner.WalletIndex = ua.WalletIndex
+		owner.AddressIndex = ua.AddressIndex
+	}
+	return owner
+}
+
+// processMultisigEvent updates owners of the registered contract or the tx submitted to it,
+// owners are notified when their confirmation of a pending tx is needed
+func processMultisigEvent(userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID int, ev *pb.MultisigEvent) error {
+	contract := strings.ToLower(ev.GetContract())
+	multisig, err := userStore.FindMultisig(currencyID, networtkID, contract)
+	if err == store.ErrNotFound {
+		log.Debugf("processMultisigEvent: unknown contract %s", contract)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	multisig.LastActionTime = time.Now().Unix()
+
+	switch ev.GetEvent() {
+	case store.MultisigEventOwnerAddition:
+		// events are replayed on resync
+		for _, owner := range multisig.Owners {
+			if strings.EqualFold(owner.Address, ev.GetOwner()) {
+				return nil
+			}
+		}
+		multisig.Owners = append(multisig.Owners, associateOwner(userStore, currencyID, networtkID, ev.GetOwner()))
+		return userStore.SaveMultisig(multisig)
+	case store.MultisigEventOwnerRemoval:
+		owners := []store.AddressExtended{}
+		for _, owner := range multisig.Owners {
+			if !strings.EqualFold(owner.Address, ev.GetOwner()) {
+				owners = append(owners, owner)
+			}
+		}
+		multisig.Owners = owners
+		return userStore.SaveMultisig(multisig)
+	case store.MultisigEventRequirement:
+		multisig.Confirmations = int(ev.GetRequired())
+		return userStore.SaveMultisig(multisig)
+	}
+
+	tx, err := userStore.FindMultisigTx(currencyID, networtkID, contract, ev.GetTxIndex())
+	if err == store.ErrNotFound {
+		tx = store.MultisigTx{
+			CurrencyID:    currencyID,
+			NetworkID:     networtkID,
+			Contract:      contract,
+			TxIndex:       ev.GetTxIndex(),
+			Confirmations: []store.MultisigConfirmation{},
+			Status:        store.MultisigTxPending,
+		}
+	} else if err != nil {
+		return err
+	}
+
+	switch ev.GetEvent() {
+	case store.MultisigEventSubmission:
+		tx.Hash = ev.GetHash()
+		tx.Submitter = ev.GetOwner()
+		tx.To = ev.GetTo()
+		tx.Amount = ev.GetAmount()
+		tx.Submitted = ev.GetBlockTime()
+	case store.MultisigEventConfirmation:
+		if !confirmedBy(tx, ev.GetOwner()) {
+			tx.Confirmations = append(tx.Confirmations, store.MultisigConfirmation{
+				Owner: ev.GetOwner(),
+				Hash:  ev.GetHash(),
+				Time:  ev.GetBlockTime(),
+			})
+		}
+	case store.MultisigEventRevocation:
+		confirmations := []store.MultisigConfirmation{}
+		for _, conf := range tx.Confirmations {
+			if !strings.EqualFold(conf.Owner, ev.GetOwner()) {
+				confirmations = append(confirmations, conf)
+			}
+		}
+		tx.Confirmations = confirmations
+	case store.MultisigEventExecution:
+		tx.Status = store.MultisigTxExecuted
+	case store.MultisigEventExecutionFailure:
+		tx.Status = store.MultisigTxFailed
+	default:
+		log.Errorf("processMultisigEvent: unknown event %q of contract %s", ev.GetEvent(), contract)
+		return nil
+	}
+	if err := userStore.SaveMultisigTx(tx); err != nil {
+		return err
+	}
+	if err := userStore.SaveMultisig(multisig); err != nil {
+		log.Errorf("processMultisigEvent: userStore.SaveMultisig: %s", err.Error())
+	}
+
+	// the submitter confirms the tx right after the submission, owners are asked after that
+	if ev.GetEvent() == store.MultisigEventConfirmation && !ev.GetResync() && tx.Status == store.MultisigTxPending {
+		notifySigners(multisig, tx, nsqProducer)
+	}
+	return nil
+}
+
+// confirmedBy reports whether the owner confirmed the tx
+func confirmedBy(tx store.MultisigTx, owner string) bool {
+	for _, conf := range tx.Confirmations {
+		if strings.EqualFold(conf.Owner, owner) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifySigners asks owners with multy wallets to confirm the tx until it has enough confirmations
+func notifySigners(multisig store.Multisig, tx store.MultisigTx, nsqProducer *nsq.Producer) {
+	if len(tx.Confirmations) >= multisig.Confirmations {
+		return
+	}
+	for _, owner := range multisig.Owners {
+		if !owner.Associated || confirmedBy(tx, owner.Address) {
+			continue
+		}
+		msg, err := json.Marshal(store.MultisigSignNeeded{
+			UserID:      owner.UserID,
+			CurrencyID:  multisig.CurrencyID,
+			NetworkID:   multisig.NetworkID,
+			Contract:    multisig.ContractAddress,
+			WalletName:  multisig.WalletName,
+			Owner:       owner.Address,
+			TxIndex:     tx.TxIndex,
+			To:          tx.To,
+			Amount:      tx.Amount,
+			Confirmed:   len(tx.Confirmations),
+			Required:    multisig.Confirmations,
+			WalletIndex: owner.WalletIndex,
+		})
+		if err != nil {
+			log.Errorf("notifySigners: json.Marshal: %s", err.Error())
+			continue
+		}
+		if err := nsqProducer.Publish(store.TopicMultisigSign, msg); err != nil {
+			log.Errorf("notifySigners: nsqProducer.Publish: %s", err.Error())
+		}
+	}
+}
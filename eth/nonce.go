@@ -0,0 +1,121 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package eth
+
+import (
+	"time"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+const (
+	// stuckAfter is how long a tx may wait in mempool before it is considered stuck
+	stuckAfter = 10 * time.Minute
+	// replacementBump is how many percent nodes want over the gas price of the replaced tx
+	replacementBump = 10
+	// cancelGasLimit is the gas of a plain transfer, a cancel sends nothing to the sender itself
+	cancelGasLimit = 21000
+)
+
+// reasons the pending tx is stuck
+const (
+	stuckLowGas   = "low_gas_price"
+	stuckTooLong  = "pending_too_long"
+	stuckNonceGap = "nonce_gap"
+)
+
+// Nonces combines the nonce of the address confirmed by the node with its pending txs.
+// Pending txs are stuck if a lower nonce is missing, if they pay less than the slow rate
+// or if they wait for too long. Stuck txs get speed up and cancel suggestions.
+func (c *Chain) Nonces(address string) (store.AddressNonces, error) {
+	nonces := store.AddressNonces{
+		Address: address,
+		Pending: []store.PendingETH{},
+		Gaps:    []int64{},
+	}
+	confirmed, err := c.AddressNonce(address)
+	if err != nil {
+		return nonces, err
+	}
+	nonces.Confirmed = confirmed
+
+	txs, err := c.conn.userStore.GetAddressPendingTxs(c.CurrencyID(), c.networkID, address)
+	if err != nil {
+		return nonces, err
+	}
+	rates, err := c.fees.Estimate()
+	if err != nil {
+		return nonces, err
+	}
+
+	seen := map[string]bool{}
+	expected := confirmed
+	for _, tx := range txs {
+		nonce := int64(tx.Nonce)
+		// mined or replaced while history was not updated yet
+		if nonce < confirmed || seen[tx.Hash] {
+			continue
+		}
+		seen[tx.Hash] = true
+
+		for ; expected < nonce; expected++ {
+			nonces.Gaps = append(nonces.Gaps, expected)
+		}
+		if expected == nonce {
+			expected++
+		}
+		gap := len(nonces.Gaps) > 0
+
+		pending := store.PendingETH{
+			Hash:     tx.Hash,
+			Nonce:    nonce,
+			GasPrice: tx.GasPrice,
+			PoolTime: tx.PoolTime,
+		}
+		switch {
+		case gap:
+			pending.Stuck = stuckNonceGap
+		case tx.GasPrice < int64(rates.Slow):
+			pending.Stuck = stuckLowGas
+		case tx.PoolTime > 0 && time.Since(time.Unix(tx.PoolTime, 0)) > stuckAfter:
+			pending.Stuck = stuckTooLong
+		}
+		if pending.Stuck != "" {
+			gasPrice := replacementGasPrice(tx.GasPrice, int64(rates.Fast))
+			pending.SpeedUp = &store.SuggestedTxETH{
+				Nonce:    nonce,
+				To:       tx.To,
+				Amount:   tx.Amount,
+				GasPrice: gasPrice,
+				GasLimit: tx.GasLimit,
+			}
+			pending.Cancel = &store.SuggestedTxETH{
+				Nonce:    nonce,
+				To:       address,
+				Amount:   "0",
+				GasPrice: gasPrice,
+				GasLimit: cancelGasLimit,
+			}
+		}
+		nonces.Pending = append(nonces.Pending, pending)
+	}
+
+	// the lowest missing nonce unblocks the txs after it
+	nonces.Next = expected
+	if len(nonces.Gaps) > 0 {
+		nonces.Next = nonces.Gaps[0]
+	}
+	return nonces, nil
+}
+
+// replacementGasPrice is the fast rate if nodes accept it as a replacement of the tx with the gas price
+func replacementGasPrice(gasPrice, fast int64) int64 {
+	min := gasPrice + gasPrice*replacementBump/100 + 1
+	if fast < min {
+		return min
+	}
+	return fast
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package eth
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// TxHash returns 0x prefixed keccak hash of the signed tx
+func (c *Chain) TxHash(rawTx string) (string, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(rawTx, "0x"))
+	if err != nil {
+		return "", err
+	}
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(raw)
+	return "0x" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (c *Chain) TxSeen(txID string) (bool, error) {
+	if c.fees.Mempool().Has(txID) {
+		return true, nil
+	}
+	return c.conn.userStore.IsTxInBlock(c.CurrencyID(), c.networkID, txID)
+}
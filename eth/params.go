@@ -0,0 +1,105 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package eth
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/feeestimator"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// Params describe an EVM chain served by node services of the eth protocol,
+// gas prices are in wei of the native coin
+type Params struct {
+	CurrencyID int
+	// Name is a short name of the coin used in logs and node versions
+	Name string
+	// Networks name well known networks of the chain, others are named by their ids
+	Networks map[int]string
+	// MinGasPrice is the lowest gas price we suggest to the clients
+	MinGasPrice int
+	// MaxLag is how many blocks node service may be behind the others before traffic fails over
+	MaxLag int
+	// FallbackRates are suggested while neither mempool nor node can estimate
+	FallbackRates store.FeeRates
+	// Blocks describe blocks of the chain for mempool analytics
+	Blocks feeestimator.Params
+}
+
+var chainParams = map[int]Params{
+	currencies.Ether: {
+		CurrencyID: currencies.Ether,
+		Name:       "eth",
+		Networks: map[int]string{
+			currencies.ETHMain: "main",
+			currencies.ETHTest: "test",
+			currencies.ETHDev:  "dev",
+		},
+		MinGasPrice: 1000000000,
+		MaxLag:      20,
+		FallbackRates: store.FeeRates{
+			VerySlow: 1000000000,
+			Slow:     2000000000,
+			Medium:   3000000000,
+			Fast:     4000000000,
+			VeryFast: 5000000000,
+		},
+		Blocks: feeestimator.Params{
+			BlockInterval: 15 * time.Second,
+			BlockTxs:      200,
+			Buckets:       []int{1000000000, 2000000000, 5000000000, 10000000000, 20000000000, 50000000000, 100000000000},
+		},
+	},
+	currencies.EtherClassic: {
+		CurrencyID: currencies.EtherClassic,
+		Name:       "etc",
+		Networks: map[int]string{
+			currencies.ETCMain: "main",
+			currencies.ETCTest: "test",
+		},
+		MinGasPrice: 1000000000,
+		MaxLag:      20,
+		FallbackRates: store.FeeRates{
+			VerySlow: 1000000000,
+			Slow:     1000000000,
+			Medium:   2000000000,
+			Fast:     3000000000,
+			VeryFast: 5000000000,
+		},
+		Blocks: feeestimator.Params{
+			BlockInterval: 15 * time.Second,
+			BlockTxs:      50,
+			Buckets:       []int{1000000000, 2000000000, 5000000000, 10000000000, 20000000000, 50000000000},
+		},
+	},
+}
+
+// ParamsOf returns params of the EVM chain, false if the currency is not one of them
+func ParamsOf(currencyID int) (Params, bool) {
+	params, ok := chainParams[currencyID]
+	return params, ok
+}
+
+// networkName is a name of the network in logs and node versions
+func (p Params) networkName(networkID int) string {
+	if name, ok := p.Networks[networkID]; ok {
+		return name
+	}
+	return strconv.Itoa(networkID)
+}
+
+// feeStrategies estimate gas prices in wei, mempool fee categories are gas prices
+func (p Params) feeStrategies(minGasPrice int) []feeestimator.Strategy {
+	return []feeestimator.Strategy{
+		feeestimator.MempoolPercentile{Min: minGasPrice},
+		feeestimator.RecentBlocks{Min: minGasPrice},
+		feeestimator.NodeRate{Min: minGasPrice},
+		feeestimator.Fallback{Rates: p.FallbackRates},
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package eth
+
+import (
+	"errors"
+)
+
+var errRLP = errors.New("malformed rlp")
+
+// rlpItem splits the first rlp item off the data, it returns the item payload,
+// whether it is a list and the rest of the data
+func rlpItem(data []byte) (payload []byte, isList bool, rest []byte, err error) {
+	if len(data) == 0 {
+		return nil, false, nil, errRLP
+	}
+	b := data[0]
+	var offset, size int
+	switch {
+	case b < 0x80:
+		return data[:1], false, data[1:], nil
+	case b < 0xb8:
+		offset, size = 1, int(b-0x80)
+	case b < 0xc0:
+		offset, size, err = rlpLongSize(data, int(b-0xb7))
+	case b < 0xf8:
+		offset, size, isList = 1, int(b-0xc0), true
+	default:
+		offset, size, err = rlpLongSize(data, int(b-0xf7))
+		isList = true
+	}
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if size < 0 || offset+size > len(data) {
+		return nil, false, nil, errRLP
+	}
+	return data[offset : offset+size], isList, data[offset+size:], nil
+}
+
+// rlpLongSize reads the payload size of long strings and lists
+func rlpLongSize(data []byte, sizeLen int) (offset, size int, err error) {
+	if sizeLen > 4 || 1+sizeLen > len(data) {
+		return 0, 0, errRLP
+	}
+	for _, b := range data[1 : 1+sizeLen] {
+		size = size<<8 | int(b)
+	}
+	return 1 + sizeLen, size, nil
+}
+
+// rlpStrings decodes the rlp list of strings the whole data is
+func rlpStrings(data []byte) ([][]byte, error) {
+	payload, isList, rest, err := rlpItem(data)
+	if err != nil {
+		return nil, err
+	}
+	if !isList || len(rest) != 0 {
+		return nil, errRLP
+	}
+	items := [][]byte{}
+	for len(payload) > 0 {
+		var item []byte
+		item, isList, payload, err = rlpItem(payload)
+		if err != nil {
+			return nil, err
+		}
+		if isList {
+			return nil, errRLP
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
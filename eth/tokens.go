@@ -0,0 +1,121 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package eth
+
+import (
+	"context"
+	"strings"
+
+	pb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"github.com/Multy-io/Multy-back/store"
+	nsq "github.com/bitly/go-nsq"
+)
+
+// TokenBalances returns balances of the address in all tokens of the registry,
+// tokens node service failed to reply balances of are marked unknown
+func (c *Chain) TokenBalances(address string) ([]store.TokenBalance, error) {
+	tokens, err := c.conn.userStore.GetTokens(c.CurrencyID(), c.networkID)
+	if err != nil {
+		return nil, err
+	}
+	balances := []store.TokenBalance{}
+	for _, token := range tokens {
+		balance, err := c.cli.EventGetTokenBalance(context.Background(), &pb.TokenBalanceRequest{
+			Address:  address,
+			Contract: token.Contract,
+		})
+		if err != nil {
+			log.Errorf("TokenBalances: cli.EventGetTokenBalance: %s %s", token.Contract, err.Error())
+			balances = append(balances, store.TokenBalance{
+				Contract: token.Contract,
+				Symbol:   token.Symbol,
+				Decimals: token.Decimals,
+				Unknown:  true,
+			})
+			continue
+		}
+		balances = append(balances, store.TokenBalance{
+			Contract:       token.Contract,
+			Symbol:         token.Symbol,
+			Decimals:       token.Decimals,
+			Balance:        balance.GetBalance(),
+			PendingBalance: balance.GetPendingBalance(),
+		})
+	}
+	return balances, nil
+}
+
+// processTokenTransfer saves the transfer of a registered token and notifies the user
+func processTokenTransfer(userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID int, gTr *pb.TokenTransfer) error {
+	contract := strings.ToLower(gTr.GetContract())
+	token, err := userStore.FindToken(currencyID, networtkID, contract)
+	if err == store.ErrNotFound {
+		log.Debugf("processTokenTransfer: unknown token %s", contract)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	transfer := store.TokenTransfer{
+		CurrencyID:   currencyID,
+		NetworkID:    networtkID,
+		UserID:       gTr.GetUserID(),
+		WalletIndex:  int(gTr.GetWalletIndex()),
+		AddressIndex: int(gTr.GetAddressIndex()),
+		Hash:         gTr.GetHash(),
+		LogIndex:     int(gTr.GetLogIndex()),
+		Contract:     token.Contract,
+		Symbol:       token.Symbol,
+		Decimals:     token.Decimals,
+		From:         gTr.GetFrom(),
+		To:           gTr.GetTo(),
+		Amount:       gTr.GetAmount(),
+		Status:       int(gTr.GetStatus()),
+		BlockTime:    gTr.GetBlockTime(),
+		PoolTime:     gTr.GetTxpoolTime(),
+		BlockHeight:  gTr.GetBlockHeight(),
+	}
+	if err := userStore.SaveTokenTransfer(transfer); err != nil {
+		return err
+	}
+	for _, address := range []string{transfer.From, transfer.To} {
+		err := userStore.UpdateAddressLastAction(transfer.UserID, address)
+		if err != nil && err != store.ErrNotFound {
+			log.Errorf("processTokenTransfer: userStore.UpdateAddressLastAction: %s", err.Error())
+		}
+	}
+
+	if !gTr.GetResync() {
+		sendTokenNotify(transfer, nsqProducer)
+	}
+	return nil
+}
+
+func sendTokenNotify(transfer store.TokenTransfer, nsqProducer *nsq.Producer) {
+	address := transfer.From
+	switch transfer.Status {
+	case store.TxStatusAppearedInBlockIncoming, store.TxStatusAppearedInMempoolIncoming, store.TxStatusInBlockConfirmedIncoming:
+		address = transfer.To
+	}
+	sendNotify(&store.TransactionWithUserID{
+		UserID: transfer.UserID,
+		NotificationMsg: &store.WsTxNotify{
+			CurrencyID:      transfer.CurrencyID,
+			NetworkID:       transfer.NetworkID,
+			Address:         address,
+			Amount:          transfer.Amount,
+			TxID:            transfer.Hash,
+			TransactionType: transfer.Status,
+			WalletIndex:     transfer.WalletIndex,
+			From:            transfer.From,
+			To:              transfer.To,
+			Contract:        transfer.Contract,
+			Symbol:          transfer.Symbol,
+			Decimals:        transfer.Decimals,
+		},
+	}, nsqProducer)
+}
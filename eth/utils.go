@@ -8,133 +8,26 @@ package eth
 import (
 	"encoding/json"
 	"errors"
-	"strconv"
-	"time"
 
-	"github.com/Multy-io/Multy-back/currencies"
 	ethpb "github.com/Multy-io/Multy-back/node-streamer/eth"
 	"github.com/Multy-io/Multy-back/store"
 	nsq "github.com/bitly/go-nsq"
-	_ "github.com/jekabolt/slflog"
-	mgo "gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
 )
 
-var (
-	exRate    *mgo.Collection
-	usersData *mgo.Collection
-
-	txsData          *mgo.Collection
-	spendableOutputs *mgo.Collection
-	spentOutputs     *mgo.Collection
-
-	txsDataTest          *mgo.Collection
-	spendableOutputsTest *mgo.Collection
-	spentOutputsTest     *mgo.Collection
-
-	restoreState *mgo.Collection
-)
-
-func updateWalletAndAddressDate(tx store.TransactionETH, networkID int) error {
-
-	sel := bson.M{"userID": tx.UserID, "wallets.addresses.address": tx.From}
-	user := store.User{}
-	err := usersData.Find(sel).One(&user)
-	update := bson.M{}
-
-	var ok bool
-
-	for i := range user.Wallets {
-		for j, addr := range user.Wallets[i].Adresses {
-			if addr.Address == tx.From {
-				ok = true
-				update = bson.M{
-					"$set": bson.M{
-						"wallets." + strconv.Itoa(i) + ".lastActionTime":                                   time.Now().Unix(),
-						"wallets." + strconv.Itoa(i) + ".addresses." + strconv.Itoa(j) + ".lastActionTime": time.Now().Unix(),
-						"wallets." + strconv.Itoa(i) + ".status":                                           store.WalletStatusOK,
-					},
-				}
-				break
-			}
-		}
-	}
-
-	if ok {
-		err = usersData.Update(sel, update)
-		if err != nil {
-			return errors.New("updateWalletAndAddressDate:usersData.Update: " + err.Error())
-		}
-	}
-
-	sel = bson.M{"userID": tx.UserID, "wallets.addresses.address": tx.To}
-	user = store.User{}
-	err = usersData.Find(sel).One(&user)
-	update = bson.M{}
-
-	ok = false
-
-	for i := range user.Wallets {
-		for j, addr := range user.Wallets[i].Adresses {
-			if addr.Address == tx.To {
-				ok = true
-				update = bson.M{
-					"$set": bson.M{
-						"wallets." + strconv.Itoa(i) + ".lastActionTime":                                   time.Now().Unix(),
-						"wallets." + strconv.Itoa(i) + ".addresses." + strconv.Itoa(j) + ".lastActionTime": time.Now().Unix(),
-						"wallets." + strconv.Itoa(i) + ".status":                                           store.WalletStatusOK,
-					},
-				}
-				break
-			}
-		}
-	}
-	if ok {
-		err = usersData.Update(sel, update)
-		if err != nil {
-			return errors.New("updateWalletAndAddressDate:usersData.Update: " + err.Error())
+func updateWalletAndAddressDate(userStore store.UserStore, tx store.TransactionETH) error {
+	for _, address := range []string{tx.From, tx.To} {
+		err := userStore.UpdateAddressLastAction(tx.UserID, address)
+		if err != nil && err != store.ErrNotFound {
+			return errors.New("updateWalletAndAddressDate:userStore.UpdateAddressLastAction: " + err.Error())
 		}
-
 	}
-
 	return nil
 }
 
-func GetReSyncExchangeRate(time int64) ([]store.ExchangeRatesRecord, error) {
-	selCCCAGG := bson.M{
-		"stockexchange": "CCCAGG",
-		"timestamp":     bson.M{"$lt": time},
-	}
-	stocksCCCAGG := store.ExchangeRatesRecord{}
-	err := exRate.Find(selCCCAGG).Sort("-timestamp").One(&stocksCCCAGG)
-	return []store.ExchangeRatesRecord{stocksCCCAGG}, err
-}
-
-func GetLatestExchangeRate() ([]store.ExchangeRatesRecord, error) {
-	selGdax := bson.M{
-		"stockexchange": "Gdax",
-	}
-	selPoloniex := bson.M{
-		"stockexchange": "Poloniex",
-	}
-	stocksGdax := store.ExchangeRatesRecord{}
-	err := exRate.Find(selGdax).Sort("-timestamp").One(&stocksGdax)
-	if err != nil {
-		return nil, err
-	}
-
-	stocksPoloniex := store.ExchangeRatesRecord{}
-	err = exRate.Find(selPoloniex).Sort("-timestamp").One(&stocksPoloniex)
-	if err != nil {
-		return nil, err
-	}
-	return []store.ExchangeRatesRecord{stocksPoloniex, stocksGdax}, nil
-}
-
-func setExchangeRates(tx *store.TransactionETH, isReSync bool, TxTime int64) {
+func setExchangeRates(userStore store.UserStore, tx *store.TransactionETH, isReSync bool, TxTime int64) {
 	var err error
 	if isReSync {
-		rates, err := GetReSyncExchangeRate(tx.BlockTime)
+		rates, err := userStore.GetReSyncExchangeRate(tx.BlockTime)
 		if err != nil {
 			log.Errorf("processTransaction:ExchangeRates: %s", err.Error())
 		}
@@ -142,7 +35,7 @@ func setExchangeRates(tx *store.TransactionETH, isReSync bool, TxTime int64) {
 		return
 	}
 	if !isReSync || err != nil {
-		rates, err := GetLatestExchangeRate()
+		rates, err := userStore.GetLatestExchangeRate()
 		if err != nil {
 			log.Errorf("processTransaction:ExchangeRates: %s", err.Error())
 		}
@@ -150,14 +43,14 @@ func setExchangeRates(tx *store.TransactionETH, isReSync bool, TxTime int64) {
 	}
 }
 
-func sendNotifyToClients(tx store.TransactionETH, nsqProducer *nsq.Producer, netid int) {
+func sendNotifyToClients(tx store.TransactionETH, nsqProducer *nsq.Producer, currencyID, netid int) {
 	//TODO: make correct notify
 
 	if tx.Status == store.TxStatusAppearedInBlockIncoming || tx.Status == store.TxStatusAppearedInMempoolIncoming || tx.Status == store.TxStatusInBlockConfirmedIncoming {
 		txMsq := store.TransactionWithUserID{
 			UserID: tx.UserID,
 			NotificationMsg: &store.WsTxNotify{
-				CurrencyID:      currencies.Ether,
+				CurrencyID:      currencyID,
 				NetworkID:       netid,
 				Address:         tx.To,
 				Amount:          tx.Amount,
@@ -175,7 +68,7 @@ func sendNotifyToClients(tx store.TransactionETH, nsqProducer *nsq.Producer, net
 		txMsq := store.TransactionWithUserID{
 			UserID: tx.UserID,
 			NotificationMsg: &store.WsTxNotify{
-				CurrencyID:      currencies.Ether,
+				CurrencyID:      currencyID,
 				NetworkID:       netid,
 				Address:         tx.From,
 				Amount:          tx.Amount,
@@ -225,74 +118,84 @@ func generatedTxDataToStore(tx *ethpb.ETHTransaction) store.TransactionETH {
 	}
 }
 
-func saveTransaction(tx store.TransactionETH, networtkID int, resync bool) error {
-
-	txStore := &mgo.Collection{}
-	switch networtkID {
-	case currencies.ETHMain:
-		txStore = txsData
-	case currencies.ETHTest:
-		txStore = txsDataTest
+func saveTransaction(userStore store.UserStore, tx store.TransactionETH, currencyID, networtkID int) error {
+	switch tx.Status {
+	case store.TxStatusAppearedInBlockIncoming, store.TxStatusAppearedInMempoolIncoming, store.TxStatusInBlockConfirmedIncoming:
+		log.Debugf("saveTransaction new incoming tx to %v", tx.To)
+	case store.TxStatusAppearedInBlockOutcoming, store.TxStatusAppearedInMempoolOutcoming, store.TxStatusInBlockConfirmedOutcoming:
+		log.Debugf("saveTransaction new outcoming tx  %v", tx.From)
 	default:
-		return errors.New("saveMultyTransaction: wrong networkID")
+		return nil
+	}
+
+	err := userStore.SaveEthTransaction(currencyID, networtkID, tx)
+	if err != nil {
+		log.Errorf("saveTransaction:userStore.SaveEthTransaction %s", err.Error())
 	}
+	return err
+}
 
-	// fetchedTxs := []store.MultyTX{}
-	// query := bson.M{"txid": tx.TxID}
-	// txStore.Find(query).All(&fetchedTxs)
+// updateConfirmations recomputes confirmations of txs in blocks from the new chain tip,
+// txs deep enough become confirmed and clients are notified
+func updateConfirmations(userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID int, height int64, depth int) {
+	txs := []store.TransactionETH{}
+	err := userStore.GetInBlockEthTransactions(currencyID, networtkID, &txs)
+	if err != nil {
+		log.Errorf("updateConfirmations: userStore.GetInBlockEthTransactions: %s", err.Error())
+		return
+	}
 
-	// This is splited transaction! That means that transaction's WalletsInputs and WalletsOutput have the same WalletIndex!
-	//Here we have outgoing transaction for exact wallet!
-	multyTX := store.TransactionETH{}
-	if tx.Status == store.TxStatusAppearedInBlockIncoming || tx.Status == store.TxStatusAppearedInMempoolIncoming || tx.Status == store.TxStatusInBlockConfirmedIncoming {
-		log.Debugf("saveTransaction new incoming tx to %v", tx.To)
-		sel := bson.M{"userid": tx.UserID, "hash": tx.Hash, "walletindex": tx.WalletIndex}
-		err := txStore.Find(sel).One(&multyTX)
-		if err == mgo.ErrNotFound {
-			// initial insertion
-			err := txStore.Insert(tx)
-			return err
+	for _, tx := range txs {
+		confirmations := store.Confirmations(height, tx.BlockHeight)
+		status := tx.Status
+		if confirmations >= depth {
+			status = store.ConfirmedStatus(status)
 		}
-		if err != nil && err != mgo.ErrNotFound {
-			// database error
-			return err
+		if status == tx.Status && confirmations == tx.Confirmations {
+			continue
 		}
 
-		update := bson.M{
-			"$set": bson.M{
-				"txstatus":    tx.Status,
-				"blockheight": tx.BlockHeight,
-				"blocktime":   tx.BlockTime,
-			},
-		}
-		err = txStore.Update(sel, update)
-		return err
-	} else if tx.Status == store.TxStatusAppearedInBlockOutcoming || tx.Status == store.TxStatusAppearedInMempoolOutcoming || tx.Status == store.TxStatusInBlockConfirmedOutcoming {
-		log.Debugf("saveTransaction new outcoming tx  %v", tx.From)
-		sel := bson.M{"userid": tx.UserID, "hash": tx.Hash, "walletindex": tx.WalletIndex}
-		err := txStore.Find(sel).One(&multyTX)
-		if err == mgo.ErrNotFound {
-			// initial insertion
-			err := txStore.Insert(tx)
-			return err
+		err = userStore.UpdateTransactionConfirmations(currencyID, networtkID, store.TxKey{TxID: tx.Hash, UserID: tx.UserID, WalletIndex: tx.WalletIndex}, status, confirmations)
+		if err != nil {
+			log.Errorf("updateConfirmations: userStore.UpdateTransactionConfirmations: %s", err.Error())
+			continue
 		}
-		if err != nil && err != mgo.ErrNotFound {
-			// database error
-			return err
+		if status != tx.Status {
+			tx.Status = status
+			tx.Confirmations = confirmations
+			sendNotifyToClients(tx, nsqProducer, currencyID, networtkID)
 		}
+	}
+}
 
-		update := bson.M{
-			"$set": bson.M{
-				"txstatus":    tx.Status,
-				"blockheight": tx.BlockHeight,
-				"blocktime":   tx.BlockTime,
-			},
-		}
-		err = txStore.Update(sel, update)
-		if err != nil {
-			log.Errorf("saveMultyTransaction:txsData.Update %s", err.Error())
+// rollbackOrphaned returns txs of blocks orphaned by the new block back to mempool
+// and notifies clients of the reversed status. False is returned if the block doesn't
+// connect to recent blocks and has to be ignored.
+func rollbackOrphaned(userStore store.UserStore, nsqProducer *nsq.Producer, currencyID, networtkID int, block store.Block) bool {
+	ls, err := userStore.FethLastSyncBlockState(networtkID, currencyID)
+	if err != nil {
+		if err != store.ErrNotFound {
+			log.Errorf("rollbackOrphaned: userStore.FethLastSyncBlockState: %s", err.Error())
 		}
-		return err
+		return true
 	}
-	return nil
+	if !store.Connects(ls.Blocks, block) {
+		log.Warnf("rollbackOrphaned: block %d %s is seen already or doesn't connect to recent blocks, skipped", block.Height, block.Hash)
+		return false
+	}
+	fork := store.ForkHeight(ls.Blocks, block)
+	if fork == 0 {
+		return true
+	}
+	log.Warnf("rollbackOrphaned: reorg, block %d %s orphans blocks from %d", block.Height, block.Hash, fork)
+
+	txs, err := userStore.RollbackEthTransactions(currencyID, networtkID, fork)
+	if err != nil {
+		log.Errorf("rollbackOrphaned: userStore.RollbackEthTransactions: %s", err.Error())
+		return true
+	}
+	for _, tx := range txs {
+		sendNotifyToClients(tx, nsqProducer, currencyID, networtkID)
+	}
+	return true
 }
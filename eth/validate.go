@@ -0,0 +1,59 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package eth
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	"github.com/Multy-io/Multy-back/chain"
+)
+
+// legacy signed tx is nonce, gasPrice, gas, to, value, data, v, r, s
+const (
+	txFieldGasPrice = 1
+	txFieldV        = 6
+	txFields        = 9
+)
+
+// ValidateRawTx decodes the tx and checks it is signed for the network
+// and its gas price is not far from the current estimation
+func (c *Chain) ValidateRawTx(userID, rawTx string) error {
+	raw, err := hex.DecodeString(strings.TrimPrefix(rawTx, "0x"))
+	if err != nil {
+		return chain.NewTxError(chain.TxErrDecode, "wrong hex: %s", err.Error())
+	}
+	fields, err := rlpStrings(raw)
+	if err != nil {
+		return chain.NewTxError(chain.TxErrDecode, "wrong tx: %s", err.Error())
+	}
+	if len(fields) != txFields {
+		return chain.NewTxError(chain.TxErrDecode, "wrong tx: %d fields", len(fields))
+	}
+
+	// v is 27 or 28 for txs signed without chain id, chainID*2+35 or +36 otherwise
+	v := new(big.Int).SetBytes(fields[txFieldV])
+	if v.Cmp(big.NewInt(35)) >= 0 {
+		chainID := new(big.Int).Sub(v, big.NewInt(35))
+		chainID.Rsh(chainID, 1)
+		if chainID.Cmp(big.NewInt(int64(c.networkID))) != 0 {
+			return chain.NewTxError(chain.TxErrWrongNetwork, "tx is signed for chain %s", chainID.String())
+		}
+	}
+
+	gasPrice := new(big.Int).SetBytes(fields[txFieldGasPrice])
+	if !gasPrice.IsInt64() {
+		return chain.NewTxError(chain.TxErrFeeTooHigh, "gas price %s is absurd", gasPrice.String())
+	}
+
+	rates, err := c.fees.Estimate()
+	if err != nil {
+		log.Errorf("ValidateRawTx: fees.Estimate: netID:%d %s", c.networkID, err.Error())
+		return nil
+	}
+	return chain.CheckFeeRate(gasPrice.Int64(), rates)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Multy-io/Multy-back/client"
+	"github.com/Multy-io/Multy-back/currencies"
+	ethpb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestEtherClassicChain(t *testing.T) {
+	h := newHarness(t, walletUser("etc-user", currencies.EtherClassic, currencies.ETCMain, "0xetc"))
+	defer h.Close()
+	token := h.login("etc-user")
+
+	if _, ok := h.etcMain.UsersData()["0xetc"]; !ok {
+		t.Errorf("etc main UsersData: 0xetc is not sent")
+	}
+	if _, ok := h.ethMain.UsersData()["0xetc"]; ok {
+		t.Errorf("eth main UsersData: ethereum classic address is sent to ethereum")
+	}
+
+	h.etcMain.NewTransaction(&ethpb.ETHTransaction{
+		UserID:     "etc-user",
+		Hash:       "0xetc-tx",
+		From:       "0xsender",
+		To:         "0xetc",
+		Amount:     "1000000000000000000",
+		Status:     store.TxStatusAppearedInMempoolIncoming,
+		TxpoolTime: time.Now().Unix(),
+	})
+	h.waitForTxNotification("etc transaction notification", "0xetc-tx", func(n *store.WsTxNotify) bool {
+		return n.CurrencyID == currencies.EtherClassic && n.NetworkID == currencies.ETCMain
+	})
+	txs := []store.TransactionETH{}
+	if err := h.userStore.GetAllWalletEthTransactions("etc-user", currencies.EtherClassic, currencies.ETCMain, &txs); err != nil || len(txs) != 1 {
+		t.Errorf("etc history: %+v %v", txs, err)
+	}
+
+	// the node suggests less than the configured min gas price
+	h.etcMain.SetGasPrice("1000")
+	w := h.do(http.MethodGet, "/api/v1/transaction/feerate/61/61", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET feerate: %d %s", w.Code, w.Body.String())
+	}
+	resp := struct {
+		Speeds client.EstimationSpeeds `json:"speeds"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal: %s", err.Error())
+	}
+	if resp.Speeds.VerySlow < 5000000000 {
+		t.Errorf("etc fee rates: got %+v, want at least the min gas price", resp.Speeds)
+	}
+
+	if versions := h.nodeVersions(); versions["etc"]["main"].Branch != "mock" || versions["eth"]["test"].Branch != "mock" {
+		t.Errorf("node versions: got %+v", versions)
+	}
+}
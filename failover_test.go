@@ -0,0 +1,96 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestNodeServiceFailover(t *testing.T) {
+	h := newHarness(t, walletUser("failover-user", currencies.Bitcoin, currencies.Test, "failover-address"))
+	defer h.Close()
+
+	c, err := h.multy.chains.Get(currencies.Bitcoin, currencies.Test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activeEndpoint := func() string {
+		w := h.do(http.MethodGet, "/server/config", "", nil)
+		config := struct {
+			NSEndpoint map[string]map[string]string `json:"nsendpoint"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &config); err != nil {
+			t.Fatalf("json.Unmarshal: %s", err.Error())
+		}
+		return config.NSEndpoint["btc"]["test"]
+	}
+	if got := activeEndpoint(); got != h.btcTest.Addr() {
+		t.Errorf("active endpoint: got %s, want %s", got, h.btcTest.Addr())
+	}
+
+	// the backup is far ahead, the primary lags behind the chain tip
+	h.btcTest.SetHeight(100)
+	h.backup.SetHeight(110)
+	c.Endpoints().Check()
+	if got := activeEndpoint(); got != h.backup.Addr() {
+		t.Errorf("active endpoint after lag: got %s, want %s", got, h.backup.Addr())
+	}
+	h.waitFor("users data sent to the backup", func() bool {
+		_, ok := h.backup.UsersData()["failover-address"]
+		return ok
+	})
+
+	h.backup.NewTransaction(&btcpb.BTCTransaction{
+		UserID:      "failover-user",
+		TxID:        "txid-failover",
+		TxAddress:   []string{"failover-address"},
+		TxStatus:    store.TxStatusAppearedInMempoolIncoming,
+		TxOutAmount: 1000,
+		TxInputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "failover-sender", Amount: 2000},
+		},
+		WalletsOutput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "failover-user", Address: "failover-address", Amount: 1000},
+		},
+	})
+	h.waitFor("tx from the backup in history", func() bool {
+		_, err := h.userStore.FindUserTransaction("failover-user", currencies.Bitcoin, currencies.Test, "txid-failover")
+		return err == nil
+	})
+
+	// the backup goes down, traffic goes back to the primary
+	initialAdds := h.btcTest.InitialAdds()
+	h.backup.Stop()
+	c.Endpoints().Check()
+	if got := activeEndpoint(); got != h.btcTest.Addr() {
+		t.Errorf("active endpoint after backup is down: got %s, want %s", got, h.btcTest.Addr())
+	}
+	h.waitFor("users data sent to the primary again", func() bool {
+		return h.btcTest.InitialAdds() > initialAdds
+	})
+}
+
+func TestNodeServiceFailoverOnDisconnect(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	c, err := h.multy.chains.Get(currencies.Bitcoin, currencies.Test)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// streams break right away, the periodic probe is 30 seconds away
+	h.btcTest.Stop()
+	h.waitFor("traffic moved to the backup", func() bool {
+		return c.Endpoints().ActiveEndpoint().URL == h.backup.Addr()
+	})
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestFeeBumpAndReplacement(t *testing.T) {
+	h := newHarness(t, walletUser("rbf-user", currencies.Bitcoin, currencies.Test, "rbf-address", "rbf-change"))
+	defer h.Close()
+	token := h.login("rbf-user")
+	fundingTxID := strings.Repeat("ef", 32)
+
+	h.btcTest.AddSpendableOut(&btcpb.AddSpOut{
+		UserID:      "rbf-user",
+		TxID:        fundingTxID,
+		TxOutAmount: 100000,
+		Address:     "rbf-address",
+		TxStatus:    store.TxStatusInBlockConfirmedIncoming,
+	})
+	h.waitFor("funding output", func() bool {
+		outs, _ := h.userStore.GetTxSpendableOutputs(currencies.Bitcoin, currencies.Test, "rbf-user", fundingTxID)
+		return len(outs) == 1
+	})
+
+	h.btcTest.NewTransaction(&btcpb.BTCTransaction{
+		UserID:      "rbf-user",
+		TxID:        "txid-rbf",
+		TxAddress:   []string{"rbf-address"},
+		TxStatus:    store.TxStatusAppearedInMempoolOutcoming,
+		TxOutAmount: 50000,
+		TxFee:       1000,
+		Rbf:         true,
+		TxInputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "rbf-address", Amount: 100000},
+		},
+		TxOutputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "rbf-receiver", Amount: 50000},
+			{Address: "rbf-change", Amount: 49000},
+		},
+		WalletsInput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "rbf-user", Address: "rbf-address", Amount: 100000},
+		},
+		WalletsOutput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "rbf-user", Address: "rbf-change", Amount: 49000, TxOutIndex: 1},
+		},
+	})
+	h.btcTest.AddSpendableOut(&btcpb.AddSpOut{
+		UserID:      "rbf-user",
+		TxID:        "txid-rbf",
+		TxOutID:     1,
+		TxOutAmount: 49000,
+		Address:     "rbf-change",
+		TxStatus:    store.TxStatusAppearedInMempoolIncoming,
+	})
+	h.btcTest.DeleteSpendableOut(&btcpb.ReqDeleteSpOut{
+		UserID:    "rbf-user",
+		TxID:      fundingTxID,
+		Address:   "rbf-address",
+		SpendTxID: "txid-rbf",
+	})
+	tx := func() store.MultyTX {
+		tx, _ := h.userStore.FindUserTransaction("rbf-user", currencies.Bitcoin, currencies.Test, "txid-rbf")
+		return tx
+	}
+	h.waitFor("pending rbf tx", func() bool {
+		spent, _ := h.userStore.GetTxSpentOutputs(currencies.Bitcoin, currencies.Test, "rbf-user", "txid-rbf")
+		own, _ := h.userStore.GetTxSpendableOutputs(currencies.Bitcoin, currencies.Test, "rbf-user", "txid-rbf")
+		return tx().RBF && len(spent) == 1 && len(own) == 1
+	})
+
+	w := h.do(http.MethodGet, "/api/v1/transaction/feebump/0/1/txid-rbf", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET feebump: %d %s", w.Code, w.Body.String())
+	}
+	resp := struct {
+		FeeBump store.FeeBump `json:"feebump"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal: %s", err.Error())
+	}
+	bump := resp.FeeBump
+	if !bump.RBF || bump.Fee != 1000 {
+		t.Errorf("fee bump: got %+v", bump)
+	}
+	if r := bump.Replacement; r == nil || len(r.Inputs) != 1 || r.Inputs[0].TxID != fundingTxID ||
+		r.Fee < 1000+int64(r.Size) || r.Outputs[1].Amount != 49000-(r.Fee-1000) || r.Outputs[0].Amount != 50000 {
+		t.Fatalf("replacement: got %+v", r)
+	}
+	if c := bump.CPFP; c == nil || len(c.Inputs) != 1 || c.Inputs[0].TxID != "txid-rbf" || c.Outputs[0].Amount != 49000-c.Fee {
+		t.Errorf("cpfp: got %+v", c)
+	}
+
+	// the client signs the suggested replacement and sends it
+	r := bump.Replacement
+	w = h.sendTx(token, nil, currencies.Bitcoin, currencies.Test, "rbf-change", rawBTCTx(t, fundingTxID, r.Outputs[0].Amount, r.Outputs[1].Amount))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST replacement: %d %s", w.Code, w.Body.String())
+	}
+	if raw := h.btcTest.RawTxs(); len(raw) != 1 {
+		t.Errorf("broadcast txs: %v", raw)
+	}
+
+	// the replacement spends the same output
+	h.btcTest.DeleteSpendableOut(&btcpb.ReqDeleteSpOut{
+		UserID:    "rbf-user",
+		TxID:      fundingTxID,
+		Address:   "rbf-address",
+		SpendTxID: "txid-rbf-2",
+	})
+	h.waitFor("replaced tx", func() bool {
+		return tx().TxStatus == store.TxStatusReplaced
+	})
+	if got := tx().ReplacedBy; got != "txid-rbf-2" {
+		t.Errorf("replaced by: got %q, want txid-rbf-2", got)
+	}
+	if own, _ := h.userStore.GetTxSpendableOutputs(currencies.Bitcoin, currencies.Test, "rbf-user", "txid-rbf"); len(own) != 0 {
+		t.Errorf("outputs of the replaced tx are kept: %+v", own)
+	}
+	if spent, _ := h.userStore.GetTxSpentOutputs(currencies.Bitcoin, currencies.Test, "rbf-user", "txid-rbf-2"); len(spent) != 1 {
+		t.Errorf("outputs spent by the replacement: got %+v", spent)
+	}
+
+	w = h.do(http.MethodGet, "/api/v1/transaction/feebump/0/1/txid-rbf", token, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GET feebump of replaced tx: got %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
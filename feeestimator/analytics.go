@@ -0,0 +1,111 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package feeestimator
+
+import (
+	"time"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// maxTrendPoints is how many points the trend is thinned to, snapshots are saved on every block
+const maxTrendPoints = 96
+
+// Params describes the chain for mempool analytics
+type Params struct {
+	BlockInterval time.Duration // average time between blocks
+	BlockTxs      int           // txs a block takes while recent blocks are unknown
+	Buckets       []int         // lower bounds of histogram buckets except the first one, ascending
+}
+
+// WithParams sets chain parameters of the analytics
+func (e *Estimator) WithParams(params Params) *Estimator {
+	e.params = params
+	return e
+}
+
+// Histogram counts rates into buckets bounded by the bounds
+func Histogram(rates []int, bounds []int) []store.FeeBucket {
+	buckets := make([]store.FeeBucket, len(bounds)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].From = bounds[i-1]
+		}
+		if i < len(bounds) {
+			buckets[i].To = bounds[i]
+		}
+	}
+	for _, rate := range rates {
+		i := 0
+		for i < len(bounds) && rate >= bounds[i] {
+			i++
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// ConfirmationBlocks returns in how many blocks a tx paying the rate is mined,
+// rates are of txs in mempool highest first, every block takes blockTxs best paying ones
+func ConfirmationBlocks(rates []int, rate, blockTxs int) int {
+	if blockTxs <= 0 {
+		blockTxs = 1
+	}
+	ahead := 0
+	for ahead < len(rates) && rates[ahead] > rate {
+		ahead++
+	}
+	return ahead/blockTxs + 1
+}
+
+// Trend thins the snapshots down to at most max points evenly spread in time order
+func Trend(snapshots []store.FeeSnapshot, max int) []store.FeeTrendPoint {
+	step := 1
+	if max > 0 && len(snapshots) > max {
+		step = (len(snapshots) + max - 1) / max
+	}
+	trend := []store.FeeTrendPoint{}
+	for i := len(snapshots) - 1; i >= 0; i -= step {
+		// walk from the newest so the last point is always the current one
+		trend = append([]store.FeeTrendPoint{{
+			Time:        snapshots[i].Time.Unix(),
+			MempoolSize: snapshots[i].MempoolSize,
+			Rates:       snapshots[i].Rates,
+		}}, trend...)
+	}
+	return trend
+}
+
+// Stats returns the mempool state of the chain with the trend of the last hours,
+// confirmation time is estimated if the fee rate is positive
+func (e *Estimator) Stats(hours, feeRate int) (store.MempoolStats, error) {
+	rates := e.mempool.Rates()
+	snapshots, err := e.persister.GetFeeSnapshots(e.currencyID, e.networkID, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		return store.MempoolStats{}, err
+	}
+
+	stats := store.MempoolStats{
+		CurrencyID: e.currencyID,
+		NetworkID:  e.networkID,
+		Size:       len(rates),
+		Histogram:  Histogram(rates, e.params.Buckets),
+		Trend:      Trend(snapshots, maxTrendPoints),
+	}
+	if feeRate > 0 {
+		blockTxs := e.mempool.BlockTxs()
+		if blockTxs == 0 {
+			blockTxs = e.params.BlockTxs
+		}
+		blocks := ConfirmationBlocks(rates, feeRate, blockTxs)
+		stats.Confirmation = &store.FeeConfirmation{
+			FeeRate: feeRate,
+			Blocks:  blocks,
+			Seconds: int64(time.Duration(blocks) * e.params.BlockInterval / time.Second),
+		}
+	}
+	return stats, nil
+}
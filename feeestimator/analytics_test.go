@@ -0,0 +1,91 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package feeestimator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestHistogram(t *testing.T) {
+	got := Histogram([]int{1, 5, 7, 10, 100}, []int{5, 10})
+	want := []store.FeeBucket{
+		{From: 0, To: 5, Count: 1},
+		{From: 5, To: 10, Count: 2},
+		{From: 10, To: 0, Count: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestConfirmationBlocks(t *testing.T) {
+	rates := []int{50, 40, 30, 20, 10}
+	for _, tc := range []struct {
+		rate, blockTxs, want int
+	}{
+		{rate: 60, blockTxs: 2, want: 1},
+		{rate: 40, blockTxs: 2, want: 1},
+		{rate: 30, blockTxs: 2, want: 2},
+		{rate: 5, blockTxs: 2, want: 3},
+		{rate: 5, blockTxs: 0, want: 6},
+	} {
+		if got := ConfirmationBlocks(rates, tc.rate, tc.blockTxs); got != tc.want {
+			t.Errorf("rate %d blockTxs %d: got %d, want %d", tc.rate, tc.blockTxs, got, tc.want)
+		}
+	}
+}
+
+func TestTrend(t *testing.T) {
+	now := time.Now()
+	snapshots := []store.FeeSnapshot{}
+	for i := 0; i < 5; i++ {
+		snapshots = append(snapshots, store.FeeSnapshot{Time: now.Add(time.Duration(i) * time.Minute), MempoolSize: i})
+	}
+
+	trend := Trend(snapshots, 2)
+	if len(trend) != 2 || trend[0].MempoolSize != 1 || trend[1].MempoolSize != 4 {
+		t.Errorf("thinned trend = %+v", trend)
+	}
+	if trend := Trend(snapshots, 10); len(trend) != 5 || trend[0].MempoolSize != 0 {
+		t.Errorf("full trend = %+v", trend)
+	}
+}
+
+func TestEstimatorStats(t *testing.T) {
+	db := store.NewMemoryUserStore()
+	mp := NewMempool(DefaultBlocks)
+	e := New(currencies.Bitcoin, currencies.Main, mp, db, fallback).
+		WithParams(Params{BlockInterval: 10 * time.Minute, BlockTxs: 2, Buckets: []int{10}})
+	for i, hash := range []string{"a", "b", "c", "d"} {
+		mp.Add(hash, (i+1)*5)
+	}
+	e.NewBlock()
+
+	stats, err := e.Stats(1, 12)
+	if err != nil {
+		t.Fatalf("Stats: %s", err.Error())
+	}
+	if stats.Size != 4 || stats.Histogram[0].Count != 1 || stats.Histogram[1].Count != 3 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if len(stats.Trend) != 1 || stats.Trend[0].MempoolSize != 4 {
+		t.Errorf("trend = %+v", stats.Trend)
+	}
+	// two txs pay more and a block takes two of them
+	if c := stats.Confirmation; c == nil || c.Blocks != 2 || c.Seconds != 1200 {
+		t.Errorf("confirmation = %+v", c)
+	}
+
+	stats, err = e.Stats(1, 0)
+	if err != nil || stats.Confirmation != nil {
+		t.Errorf("no fee rate: confirmation = %+v %v", stats.Confirmation, err)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+
+// Package feeestimator estimates fee rates of a chain from its mempool and recent blocks.
+// Strategies are tried in order until one has enough data, snapshots of estimations
+// are saved on every block so block statistics survive restarts.
+package feeestimator
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Multy-io/Multy-back/logger"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+var log = logger.WithContext("feeestimator")
+
+// ErrNoEstimation is returned if none of the strategies could estimate rates
+var ErrNoEstimation = errors.New("no fee estimation strategy succeeded")
+
+// Persister saves estimation snapshots, store.UserStore implements it
+type Persister interface {
+	SaveFeeSnapshot(snapshot store.FeeSnapshot) error
+	GetLastFeeSnapshot(currencyID, networkID int) (store.FeeSnapshot, error)
+	GetFeeSnapshots(currencyID, networkID int, since time.Time) ([]store.FeeSnapshot, error)
+}
+
+// Estimator estimates fee rates of a single chain
+type Estimator struct {
+	currencyID int
+	networkID  int
+
+	mempool    *Mempool
+	strategies []Strategy
+	persister  Persister
+	nodeRate   func() (int, error)
+	params     Params
+}
+
+// New makes estimator of the chain trying strategies in the given order
+func New(currencyID, networkID int, mempool *Mempool, persister Persister, strategies ...Strategy) *Estimator {
+	return &Estimator{
+		currencyID: currencyID,
+		networkID:  networkID,
+		mempool:    mempool,
+		strategies: strategies,
+		persister:  persister,
+	}
+}
+
+// WithNodeRate sets the way to ask the node for its own estimation
+func (e *Estimator) WithNodeRate(nodeRate func() (int, error)) *Estimator {
+	e.nodeRate = nodeRate
+	return e
+}
+
+// Mempool returns mempool statistics the estimator uses
+func (e *Estimator) Mempool() *Mempool {
+	return e.mempool
+}
+
+func (e *Estimator) stats() Stats {
+	stats := Stats{
+		Mempool: e.mempool.Rates(),
+		Blocks:  e.mempool.BlockRates(),
+	}
+	if e.nodeRate != nil {
+		rate, err := e.nodeRate()
+		if err != nil {
+			log.Errorf("stats: nodeRate: curID:%d netID:%d %s", e.currencyID, e.networkID, err.Error())
+		}
+		stats.NodeRate = rate
+	}
+	return stats
+}
+
+// estimate returns rates of the first strategy succeeded and its name
+func (e *Estimator) estimate(stats Stats) (store.FeeRates, string, error) {
+	for _, s := range e.strategies {
+		rates, ok := s.Estimate(stats)
+		if ok {
+			return ordered(rates), s.Name(), nil
+		}
+	}
+	return store.FeeRates{}, "", ErrNoEstimation
+}
+
+// Estimate returns fee rates for the current chain state
+func (e *Estimator) Estimate() (store.FeeRates, error) {
+	rates, strategy, err := e.estimate(e.stats())
+	if err != nil {
+		return rates, err
+	}
+	log.Debugf("Estimate: curID:%d netID:%d strategy:%s rates:%v", e.currencyID, e.networkID, strategy, rates)
+	return rates, nil
+}
+
+// NewBlock closes statistics of the block and saves the snapshot
+func (e *Estimator) NewBlock() {
+	e.mempool.NewBlock()
+
+	stats := e.stats()
+	rates, strategy, err := e.estimate(stats)
+	if err != nil {
+		log.Errorf("NewBlock: estimate: curID:%d netID:%d %s", e.currencyID, e.networkID, err.Error())
+		return
+	}
+	err = e.persister.SaveFeeSnapshot(store.FeeSnapshot{
+		CurrencyID:  e.currencyID,
+		NetworkID:   e.networkID,
+		Time:        time.Now(),
+		MempoolSize: len(stats.Mempool),
+		Blocks:      e.mempool.Blocks(),
+		BlockSizes:  e.mempool.Sizes(),
+		Strategy:    strategy,
+		Rates:       rates,
+	})
+	if err != nil {
+		log.Errorf("NewBlock: persister.SaveFeeSnapshot: curID:%d netID:%d %s", e.currencyID, e.networkID, err.Error())
+	}
+}
+
+// Restore loads block statistics of the last snapshot saved
+func (e *Estimator) Restore() error {
+	snapshot, err := e.persister.GetLastFeeSnapshot(e.currencyID, e.networkID)
+	if err == store.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	e.mempool.RestoreBlocks(snapshot.Blocks, snapshot.BlockSizes)
+	return nil
+}
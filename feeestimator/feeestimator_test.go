@@ -0,0 +1,100 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package feeestimator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+var fallback = Fallback{Rates: store.FeeRates{VerySlow: 1, Slow: 1, Medium: 1, Fast: 1, VeryFast: 1}}
+
+func TestMempoolBlocks(t *testing.T) {
+	mp := NewMempool(2)
+	for i, hash := range []string{"a", "b", "c"} {
+		mp.Add(hash, (i+1)*10)
+	}
+	if got := mp.Rates(); !reflect.DeepEqual(got, []int{30, 20, 10}) {
+		t.Errorf("rates = %v", got)
+	}
+
+	// a block without mempool txs leaves no statistics
+	mp.NewBlock()
+	mp.Remove("b")
+	mp.Remove("unknown")
+	mp.NewBlock()
+	mp.Remove("a")
+	mp.NewBlock()
+	mp.Remove("c")
+	mp.NewBlock()
+
+	blocks := mp.Blocks()
+	if len(blocks) != 2 || blocks[0][0] != 10 || blocks[1][100] != 30 {
+		t.Fatalf("blocks = %v", blocks)
+	}
+	if mp.Len() != 0 || len(mp.BlockRates()) != 202 {
+		t.Errorf("len %d, block rates %d", mp.Len(), len(mp.BlockRates()))
+	}
+}
+
+func TestEstimatorStrategiesOrder(t *testing.T) {
+	mp := NewMempool(DefaultBlocks)
+	e := New(currencies.Bitcoin, currencies.Main, mp, store.NewMemoryUserStore(), MempoolPercentile{Min: 2}, NodeRate{Min: 2}, fallback)
+
+	rates, err := e.Estimate()
+	if err != nil || rates != fallback.Rates {
+		t.Errorf("empty chain: got %+v %v, want fallback", rates, err)
+	}
+
+	e.WithNodeRate(func() (int, error) { return 100, nil })
+	if rates, _ := e.Estimate(); rates.Medium != 100 {
+		t.Errorf("node rate: got %+v", rates)
+	}
+
+	mp.Add("a", 50)
+	if rates, _ := e.Estimate(); rates.VeryFast != 50 {
+		t.Errorf("mempool: got %+v", rates)
+	}
+
+	e.WithNodeRate(func() (int, error) { return 0, errors.New("node is down") })
+	if _, err := New(currencies.Bitcoin, currencies.Main, NewMempool(1), nil, NodeRate{}).WithNodeRate(e.nodeRate).Estimate(); err != ErrNoEstimation {
+		t.Errorf("no strategy: got %v, want ErrNoEstimation", err)
+	}
+}
+
+func TestEstimatorSnapshots(t *testing.T) {
+	s := store.NewMemoryUserStore()
+	e := New(currencies.Ether, currencies.ETHMain, NewMempool(DefaultBlocks), s, RecentBlocks{}, fallback)
+	e.Mempool().Add("a", 7)
+	e.Mempool().Remove("a")
+	e.NewBlock()
+
+	snapshot, err := s.GetLastFeeSnapshot(currencies.Ether, currencies.ETHMain)
+	if err != nil {
+		t.Fatalf("GetLastFeeSnapshot: %v", err)
+	}
+	if snapshot.Strategy != "blocks" || snapshot.Rates.Medium != 7 || len(snapshot.Blocks) != 1 {
+		t.Errorf("snapshot = %+v", snapshot)
+	}
+
+	// block statistics survive the restart
+	restarted := New(currencies.Ether, currencies.ETHMain, NewMempool(DefaultBlocks), s, RecentBlocks{}, fallback)
+	if err := restarted.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if rates, _ := restarted.Estimate(); rates != snapshot.Rates {
+		t.Errorf("restored: got %+v, want %+v", rates, snapshot.Rates)
+	}
+
+	fresh := New(currencies.Bitcoin, currencies.Test, NewMempool(DefaultBlocks), s, fallback)
+	if err := fresh.Restore(); err != nil {
+		t.Errorf("Restore without snapshots: %v", err)
+	}
+}
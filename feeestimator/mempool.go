@@ -0,0 +1,178 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package feeestimator
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultBlocks is how many recent blocks fee statistics are kept for
+const DefaultBlocks = 6
+
+// Mempool keeps fee rates of txs in the chain mempool and fee rates of txs
+// recent blocks took from it. It is safe for concurrent use.
+type Mempool struct {
+	m sync.Mutex
+
+	txs       map[string]int // tx hash to fee rate
+	mined     []int          // rates of txs which left mempool since the last block
+	blocks    [][]int        // rate percentiles of recent blocks, oldest first
+	sizes     []int          // number of mempool txs taken by recent blocks
+	maxBlocks int
+}
+
+// NewMempool makes empty mempool keeping statistics of maxBlocks recent blocks
+func NewMempool(maxBlocks int) *Mempool {
+	if maxBlocks <= 0 {
+		maxBlocks = DefaultBlocks
+	}
+	return &Mempool{
+		txs:       map[string]int{},
+		maxBlocks: maxBlocks,
+	}
+}
+
+// Add adds the tx to mempool
+func (mp *Mempool) Add(hash string, rate int) {
+	mp.m.Lock()
+	mp.txs[hash] = rate
+	mp.m.Unlock()
+}
+
+// Has reports whether the tx is in mempool
+func (mp *Mempool) Has(hash string) bool {
+	mp.m.Lock()
+	_, ok := mp.txs[hash]
+	mp.m.Unlock()
+	return ok
+}
+
+// Remove removes the tx from mempool, its rate is counted to the next block.
+// Node removes txs when they are mined, dropped txs are rare enough to be ignored.
+func (mp *Mempool) Remove(hash string) {
+	mp.m.Lock()
+	defer mp.m.Unlock()
+	rate, ok := mp.txs[hash]
+	if !ok {
+		return
+	}
+	delete(mp.txs, hash)
+	mp.mined = append(mp.mined, rate)
+}
+
+// NewBlock closes statistics of the block with txs removed since the previous one
+func (mp *Mempool) NewBlock() {
+	mp.m.Lock()
+	defer mp.m.Unlock()
+	if len(mp.mined) == 0 {
+		return
+	}
+	mp.blocks = append(mp.blocks, percentiles(mp.mined))
+	mp.sizes = append(mp.sizes, len(mp.mined))
+	mp.trim()
+	mp.mined = nil
+}
+
+func (mp *Mempool) trim() {
+	if len(mp.blocks) > mp.maxBlocks {
+		mp.blocks = mp.blocks[len(mp.blocks)-mp.maxBlocks:]
+	}
+	if len(mp.sizes) > mp.maxBlocks {
+		mp.sizes = mp.sizes[len(mp.sizes)-mp.maxBlocks:]
+	}
+}
+
+// Len returns number of txs in mempool
+func (mp *Mempool) Len() int {
+	mp.m.Lock()
+	defer mp.m.Unlock()
+	return len(mp.txs)
+}
+
+// Rates returns fee rates of txs in mempool, highest first
+func (mp *Mempool) Rates() []int {
+	mp.m.Lock()
+	rates := make([]int, 0, len(mp.txs))
+	for _, rate := range mp.txs {
+		rates = append(rates, rate)
+	}
+	mp.m.Unlock()
+	sortDesc(rates)
+	return rates
+}
+
+// BlockRates returns fee rate percentiles of all recent blocks, highest first
+func (mp *Mempool) BlockRates() []int {
+	mp.m.Lock()
+	rates := []int{}
+	for _, block := range mp.blocks {
+		rates = append(rates, block...)
+	}
+	mp.m.Unlock()
+	sortDesc(rates)
+	return rates
+}
+
+// Blocks returns fee rate percentiles of recent blocks, oldest first
+func (mp *Mempool) Blocks() [][]int {
+	mp.m.Lock()
+	defer mp.m.Unlock()
+	blocks := make([][]int, 0, len(mp.blocks))
+	for _, block := range mp.blocks {
+		blocks = append(blocks, append([]int{}, block...))
+	}
+	return blocks
+}
+
+// Sizes returns number of mempool txs taken by recent blocks, oldest first
+func (mp *Mempool) Sizes() []int {
+	mp.m.Lock()
+	defer mp.m.Unlock()
+	return append([]int{}, mp.sizes...)
+}
+
+// BlockTxs returns average number of mempool txs a recent block took, zero if unknown
+func (mp *Mempool) BlockTxs() int {
+	mp.m.Lock()
+	defer mp.m.Unlock()
+	if len(mp.sizes) == 0 {
+		return 0
+	}
+	total := 0
+	for _, size := range mp.sizes {
+		total += size
+	}
+	return total / len(mp.sizes)
+}
+
+// RestoreBlocks sets statistics of recent blocks saved before the restart
+func (mp *Mempool) RestoreBlocks(blocks [][]int, sizes []int) {
+	mp.m.Lock()
+	defer mp.m.Unlock()
+	mp.blocks = nil
+	for _, block := range blocks {
+		mp.blocks = append(mp.blocks, append([]int{}, block...))
+	}
+	mp.sizes = append([]int{}, sizes...)
+	mp.trim()
+}
+
+// percentiles returns 0th to 100th percentiles of the rates, lowest first,
+// so statistics of a block take the same space whatever its size is
+func percentiles(rates []int) []int {
+	sorted := append([]int{}, rates...)
+	sort.Ints(sorted)
+	p := make([]int, 101)
+	for i := range p {
+		p[i] = sorted[(len(sorted)-1)*i/100]
+	}
+	return p
+}
+
+func sortDesc(rates []int) {
+	sort.Sort(sort.Reverse(sort.IntSlice(rates)))
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package feeestimator
+
+import (
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// Stats is the chain state fee rates are estimated from
+type Stats struct {
+	Mempool  []int // fee rates of txs in mempool, highest first
+	Blocks   []int // fee rate percentiles of recent blocks, highest first
+	NodeRate int   // estimation of the node, zero if unknown
+}
+
+// Strategy estimates fee rates from the chain state.
+// It returns false if stats are not enough for it, the next strategy is tried then.
+type Strategy interface {
+	Name() string
+	Estimate(stats Stats) (store.FeeRates, bool)
+}
+
+// MempoolPercentile takes rates of txs at fixed positions of mempool sorted by fee rate.
+// Big mempool is cut at fixed depths, a block takes about 2000 txs.
+type MempoolPercentile struct {
+	Min int // rate every tx pays at least
+}
+
+func (s MempoolPercentile) Name() string { return "mempool" }
+
+func (s MempoolPercentile) Estimate(stats Stats) (store.FeeRates, bool) {
+	mp := stats.Mempool
+	size := len(mp)
+	if size == 0 {
+		return store.FeeRates{}, false
+	}
+
+	rates := store.FeeRates{}
+	if size <= 2000 {
+		//low rates logic
+		rates.VerySlow = s.Min
+		rates.Slow = mp[size/100*80]
+		rates.Medium = mp[size/100*50]
+		rates.Fast = mp[size/100*30]
+		rates.VeryFast = mp[size/100*5]
+	} else {
+		//high rates logic
+		rates.VerySlow = mp[size/100*90]
+		rates.Slow = mp[size/100*70]
+		rates.Medium = mp[2000]
+		rates.Fast = mp[500]
+		rates.VeryFast = mp[100]
+	}
+	return atLeast(rates, s.Min), true
+}
+
+// RecentBlocks takes rates recently mined txs paid, the lowest rates
+// which still got into blocks are the slow ones
+type RecentBlocks struct {
+	Min        int
+	MinSamples int // fewer percentiles are not trusted, zero means any
+}
+
+func (s RecentBlocks) Name() string { return "blocks" }
+
+func (s RecentBlocks) Estimate(stats Stats) (store.FeeRates, bool) {
+	rates := stats.Blocks
+	n := len(rates)
+	if n == 0 || n < s.MinSamples {
+		return store.FeeRates{}, false
+	}
+	at := func(percent int) int {
+		return rates[(n-1)*percent/100]
+	}
+	return atLeast(store.FeeRates{
+		VerySlow: at(90),
+		Slow:     at(75),
+		Medium:   at(50),
+		Fast:     at(25),
+		VeryFast: at(10),
+	}, s.Min), true
+}
+
+// NodeRate spreads estimation of the node to the speeds
+type NodeRate struct {
+	Min int
+}
+
+func (s NodeRate) Name() string { return "node" }
+
+func (s NodeRate) Estimate(stats Stats) (store.FeeRates, bool) {
+	speed := stats.NodeRate
+	if speed <= 0 {
+		return store.FeeRates{}, false
+	}
+	return atLeast(store.FeeRates{
+		VerySlow: speed * 60 / 100,
+		Slow:     speed * 80 / 100,
+		Medium:   speed,
+		Fast:     speed * 145 / 100,
+		VeryFast: speed * 195 / 100,
+	}, s.Min), true
+}
+
+// Fallback returns fixed rates, it is the last strategy of the chain
+type Fallback struct {
+	Rates store.FeeRates
+}
+
+func (s Fallback) Name() string { return "fallback" }
+
+func (s Fallback) Estimate(stats Stats) (store.FeeRates, bool) {
+	return s.Rates, true
+}
+
+func atLeast(rates store.FeeRates, min int) store.FeeRates {
+	for _, r := range []*int{&rates.VerySlow, &rates.Slow, &rates.Medium, &rates.Fast, &rates.VeryFast} {
+		if *r < min {
+			*r = min
+		}
+	}
+	return rates
+}
+
+// ordered makes faster speeds never cheaper than slower ones,
+// every speed is raised up to the previous one starting from the slowest
+func ordered(rates store.FeeRates) store.FeeRates {
+	if rates.VerySlow > rates.Slow {
+		rates.Slow = rates.VerySlow
+	}
+	if rates.Slow > rates.Medium {
+		rates.Medium = rates.Slow
+	}
+	if rates.Medium > rates.Fast {
+		rates.Fast = rates.Medium
+	}
+	if rates.Fast > rates.VeryFast {
+		rates.VeryFast = rates.Fast
+	}
+	return rates
+}
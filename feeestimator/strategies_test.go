@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package feeestimator
+
+import (
+	"testing"
+
+	"github.com/Multy-io/Multy-back/store"
+)
+
+// descending returns rates n, n-1, ..., 1
+func descending(n int) []int {
+	rates := make([]int, n)
+	for i := range rates {
+		rates[i] = n - i
+	}
+	return rates
+}
+
+func TestMempoolPercentile(t *testing.T) {
+	s := MempoolPercentile{Min: 2}
+	if _, ok := s.Estimate(Stats{}); ok {
+		t.Errorf("empty mempool is estimated")
+	}
+
+	cases := []struct {
+		name    string
+		mempool []int
+		want    store.FeeRates
+	}{
+		{"small", descending(1000), store.FeeRates{VerySlow: 2, Slow: 200, Medium: 500, Fast: 700, VeryFast: 950}},
+		{"big", descending(10000), store.FeeRates{VerySlow: 1000, Slow: 3000, Medium: 8000, Fast: 9500, VeryFast: 9900}},
+		{"cheap", []int{1, 1, 1}, store.FeeRates{VerySlow: 2, Slow: 2, Medium: 2, Fast: 2, VeryFast: 2}},
+	}
+	for _, c := range cases {
+		got, ok := s.Estimate(Stats{Mempool: c.mempool})
+		if !ok || got != c.want {
+			t.Errorf("%s: got %+v %v, want %+v", c.name, got, ok, c.want)
+		}
+	}
+}
+
+func TestRecentBlocks(t *testing.T) {
+	s := RecentBlocks{Min: 2, MinSamples: 50}
+	if _, ok := s.Estimate(Stats{Blocks: descending(49)}); ok {
+		t.Errorf("too few samples are estimated")
+	}
+	got, ok := s.Estimate(Stats{Blocks: descending(101)})
+	want := store.FeeRates{VerySlow: 11, Slow: 26, Medium: 51, Fast: 76, VeryFast: 91}
+	if !ok || got != want {
+		t.Errorf("got %+v %v, want %+v", got, ok, want)
+	}
+}
+
+func TestNodeRate(t *testing.T) {
+	s := NodeRate{Min: 1000}
+	if _, ok := s.Estimate(Stats{}); ok {
+		t.Errorf("unknown node rate is estimated")
+	}
+	got, ok := s.Estimate(Stats{NodeRate: 1000})
+	want := store.FeeRates{VerySlow: 1000, Slow: 1000, Medium: 1000, Fast: 1450, VeryFast: 1950}
+	if !ok || got != want {
+		t.Errorf("got %+v %v, want %+v", got, ok, want)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	rates := store.FeeRates{VerySlow: 1, Slow: 2, Medium: 3, Fast: 4, VeryFast: 5}
+	got, ok := Fallback{Rates: rates}.Estimate(Stats{})
+	if !ok || got != rates {
+		t.Errorf("got %+v %v, want %+v", got, ok, rates)
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	got := ordered(store.FeeRates{VerySlow: 5, Slow: 4, Medium: 3, Fast: 2, VeryFast: 1})
+	want := store.FeeRates{VerySlow: 5, Slow: 5, Medium: 5, Fast: 5, VeryFast: 5}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
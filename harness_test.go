@@ -0,0 +1,369 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/nodemock"
+	"github.com/Multy-io/Multy-back/store"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// harness is a backend booted against mock node services and a fake nsqd
+type harness struct {
+	t *testing.T
+
+	multy *Multy
+	nsqd  *fakeNSQD
+
+	btcMain *nodemock.BTC
+	btcTest *nodemock.BTC
+	bchMain *nodemock.BTC
+	ltcMain *nodemock.BTC
+	regtest *nodemock.BTC
+	ethMain *nodemock.ETH
+	ethTest *nodemock.ETH
+	etcMain *nodemock.ETH
+
+	// backup is a backup endpoint of the btc testnet node service
+	backup *nodemock.BTC
+
+	// litecoin node service accepts backend clients with certificates of clientCA only,
+	// the backend reads them from certDir
+	nodeCA   *testCA
+	clientCA *testCA
+	certDir  string
+
+	userStore *store.MemoryUserStore
+}
+
+// newHarness starts mock node services, users from seed are stored before the backend boots
+func newHarness(t *testing.T, seed ...store.User) *harness {
+	h := &harness{
+		t:       t,
+		btcMain: nodemock.NewBTC(),
+		btcTest: nodemock.NewBTC(),
+		backup:  nodemock.NewBTC(),
+		bchMain: nodemock.NewBTC(),
+		ltcMain: nodemock.NewBTC(),
+		regtest: nodemock.NewBTC(),
+		ethMain: nodemock.NewETH(),
+		ethTest: nodemock.NewETH(),
+		etcMain: nodemock.NewETH(),
+	}
+
+	h.userStore = store.NewMemoryUserStore()
+	for _, user := range seed {
+		if err := h.userStore.Insert(user); err != nil {
+			t.Fatalf("userStore.Insert: %s", err.Error())
+		}
+	}
+
+	nodeTLS, err := h.setupTLS()
+	if err != nil {
+		h.Close()
+		t.Fatalf("setupTLS: %s", err.Error())
+	}
+
+	for _, node := range []interface {
+		Start(string) error
+	}{h.btcMain, h.btcTest, h.backup, h.bchMain, h.ltcMain, h.regtest, h.ethMain, h.ethTest, h.etcMain} {
+		if err := node.Start("127.0.0.1:0"); err != nil {
+			h.Close()
+			t.Fatalf("node.Start: %s", err.Error())
+		}
+	}
+
+	nsqd, err := startFakeNSQD()
+	if err != nil {
+		h.Close()
+		t.Fatal(err)
+	}
+	h.nsqd = nsqd
+
+	conf := &Configuration{
+		Name:            "multy-back test",
+		SocketioAddr:    freeAddr(t),
+		RestAddress:     freeAddr(t),
+		NSQAddress:      nsqd.Addr(),
+		DisableFirebase: true,
+		Secretkey:       "secret",
+
+		DisableExchangeStocks: true,
+		SupportedNodes: []store.CoinType{
+			{СurrencyID: currencies.Bitcoin, NetworkID: currencies.Main, GRPCUrl: h.btcMain.Addr()},
+			{СurrencyID: currencies.Bitcoin, NetworkID: currencies.Test, GRPCUrl: h.btcTest.Addr(), GRPCUrls: []string{h.backup.Addr()}},
+			{СurrencyID: currencies.BitcoinCash, NetworkID: currencies.Main, GRPCUrl: h.bchMain.Addr()},
+			{СurrencyID: currencies.Litecoin, NetworkID: currencies.Main, GRPCUrl: h.ltcMain.Addr(), TLS: nodeTLS},
+			{СurrencyID: currencies.Bitcoin, NetworkID: currencies.Regtest, GRPCUrl: h.regtest.Addr()},
+			{СurrencyID: currencies.Ether, NetworkID: currencies.ETHMain, GRPCUrl: h.ethMain.Addr()},
+			{СurrencyID: currencies.Ether, NetworkID: currencies.ETHTest, GRPCUrl: h.ethTest.Addr()},
+			{СurrencyID: currencies.EtherClassic, NetworkID: currencies.ETCMain, GRPCUrl: h.etcMain.Addr(), MinGasPrice: 5000000000},
+		},
+		Rebroadcast: chain.RebroadcastConf{Window: 60, Attempts: 2},
+	}
+
+	multy, err := initWithStore(conf, h.userStore)
+	if err != nil {
+		h.Close()
+		t.Fatalf("initWithStore: %s", err.Error())
+	}
+	h.multy = multy
+
+	// don't wait for a second between reconnects in tests
+	for _, c := range multy.chains.All() {
+		c.Streams().SetBackoff(chain.Backoff{Min: 10 * time.Millisecond, Max: 100 * time.Millisecond})
+	}
+	return h
+}
+
+// Close stops the backend streams and mock node services
+func (h *harness) Close() {
+	if h.multy != nil {
+		for _, c := range h.multy.chains.All() {
+			c.Streams().Stop()
+		}
+	}
+	h.btcMain.Stop()
+	h.btcTest.Stop()
+	h.backup.Stop()
+	h.bchMain.Stop()
+	h.ltcMain.Stop()
+	h.regtest.Stop()
+	h.ethMain.Stop()
+	h.ethTest.Stop()
+	h.etcMain.Stop()
+	if h.nsqd != nil {
+		h.nsqd.Close()
+	}
+	if h.certDir != "" {
+		os.RemoveAll(h.certDir)
+	}
+}
+
+// setupTLS issues certificates of the litecoin node service and the backend client,
+// it returns TLS config of the backend connections to the node service
+func (h *harness) setupTLS() (*store.NodeTLS, error) {
+	var err error
+	if h.nodeCA, err = newTestCA("node ca"); err != nil {
+		return nil, err
+	}
+	if h.clientCA, err = newTestCA("client ca"); err != nil {
+		return nil, err
+	}
+	if h.certDir, err = ioutil.TempDir("", "multy-back-certs"); err != nil {
+		return nil, err
+	}
+
+	serverTLS, err := h.nodeCA.serverTLS(h.clientCA)
+	if err != nil {
+		return nil, err
+	}
+	h.ltcMain.SetTLS(serverTLS)
+
+	if err := ioutil.WriteFile(filepath.Join(h.certDir, "ca.pem"), h.nodeCA.pem, 0600); err != nil {
+		return nil, err
+	}
+	if err := h.clientCA.writeClientCert(h.certDir); err != nil {
+		return nil, err
+	}
+	return &store.NodeTLS{
+		CAFile:     filepath.Join(h.certDir, "ca.pem"),
+		CertFile:   filepath.Join(h.certDir, "cert.pem"),
+		KeyFile:    filepath.Join(h.certDir, "key.pem"),
+		ServerName: testNodeName,
+	}, nil
+}
+
+// do makes a request to REST api, body is sent as json
+func (h *harness) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	h.t.Helper()
+	return h.doWithHeader(method, path, token, nil, body)
+}
+
+// doWithHeader makes a request to REST api with additional headers
+func (h *harness) doWithHeader(method, path, token string, header http.Header, body interface{}) *httptest.ResponseRecorder {
+	h.t.Helper()
+	buf := &bytes.Buffer{}
+	if body != nil {
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			h.t.Fatalf("json.Encode: %s", err.Error())
+		}
+	}
+	req := httptest.NewRequest(method, path, buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.multy.route.ServeHTTP(w, req)
+	return w
+}
+
+// login registers the user with a new device and returns jwt token
+func (h *harness) login(userID string) string {
+	h.t.Helper()
+	w := h.do(http.MethodPost, "/auth", "", map[string]interface{}{
+		"userID":     userID,
+		"deviceID":   "device-" + userID,
+		"pushToken":  "push-" + userID,
+		"appVersion": "1.0",
+		"deviceType": 1,
+	})
+	if w.Code != http.StatusOK {
+		h.t.Fatalf("login: %d %s", w.Code, w.Body.String())
+	}
+	resp := struct {
+		Token string `json:"token"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Token == "" {
+		h.t.Fatalf("login: bad reply %s", w.Body.String())
+	}
+	return resp.Token
+}
+
+// addWallet creates a wallet of the user with a single address through REST api
+func (h *harness) addWallet(token string, currencyID, networkID, walletIndex int, address string) {
+	h.t.Helper()
+	w := h.do(http.MethodPost, "/api/v1/wallet", token, map[string]interface{}{
+		"currencyID":   currencyID,
+		"networkID":    networkID,
+		"address":      address,
+		"addressIndex": 0,
+		"walletIndex":  walletIndex,
+		"walletName":   address,
+	})
+	if w.Code != http.StatusCreated {
+		h.t.Fatalf("POST /api/v1/wallet: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// addOutput stores an unspent output of the user as if the node service reported it before
+func (h *harness) addOutput(currencyID, networkID int, userID, address, txID string, amount int64) {
+	h.t.Helper()
+	err := h.userStore.AddSpendableOutput(currencyID, networkID, store.SpendableOutputs{
+		TxID:        txID,
+		TxOutAmount: amount,
+		Address:     address,
+		UserID:      userID,
+	})
+	if err != nil {
+		h.t.Fatalf("userStore.AddSpendableOutput: %s", err.Error())
+	}
+}
+
+// sendTx sends the raw tx through REST api, change is the address of the user to watch
+func (h *harness) sendTx(token string, header http.Header, currencyID, networkID int, change, rawTx string) *httptest.ResponseRecorder {
+	h.t.Helper()
+	return h.doWithHeader(http.MethodPost, "/api/v1/transaction/send", token, header, map[string]interface{}{
+		"currencyid": currencyID,
+		"networkID":  networkID,
+		"payload": map[string]interface{}{
+			"address":     change,
+			"transaction": rawTx,
+		},
+	})
+}
+
+// waitForTxNotification waits for a notification of the tx published to clients which matches,
+// nil match takes the first notification of the tx
+func (h *harness) waitForTxNotification(what, txID string, match func(*store.WsTxNotify) bool) store.TransactionWithUserID {
+	h.t.Helper()
+	var notify store.TransactionWithUserID
+	h.waitFor(what, func() bool {
+		for _, msg := range h.nsqd.Published(store.TopicTransaction) {
+			n := store.TransactionWithUserID{}
+			if err := json.Unmarshal(msg, &n); err != nil {
+				h.t.Fatalf("json.Unmarshal: %s", err.Error())
+			}
+			if n.NotificationMsg != nil && n.NotificationMsg.TxID == txID && (match == nil || match(n.NotificationMsg)) {
+				notify = n
+				return true
+			}
+		}
+		return false
+	})
+	return notify
+}
+
+// nodeVersions returns versions of node services the backend reports to clients
+func (h *harness) nodeVersions() map[string]map[string]store.NodeVersion {
+	h.t.Helper()
+	w := h.do(http.MethodGet, "/server/config", "", nil)
+	config := struct {
+		NSVersion map[string]map[string]store.NodeVersion `json:"nsversion"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &config); err != nil {
+		h.t.Fatalf("json.Unmarshal: %s", err.Error())
+	}
+	return config.NSVersion
+}
+
+// waitFor polls the condition until it's true or the test times out
+func (h *harness) waitFor(what string, cond func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("freeAddr: net.Listen: %s", err.Error())
+	}
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+// walletUser is a user with a single wallet of the chain, addresses are indexed in order
+func walletUser(userID string, currencyID, networkID int, addresses ...string) store.User {
+	wallet := store.Wallet{CurrencyID: currencyID, NetworkID: networkID}
+	for i, address := range addresses {
+		wallet.Adresses = append(wallet.Adresses, store.Address{Address: address, AddressIndex: i})
+	}
+	return store.User{UserID: userID, Wallets: []store.Wallet{wallet}}
+}
+
+// rawBTCTx is an unsigned tx spending the first output of prevTxID to outputs of the values
+func rawBTCTx(t *testing.T, prevTxID string, outs ...int64) string {
+	t.Helper()
+	hash, err := chainhash.NewHashFromStr(prevTxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, 0), nil, nil))
+	for _, value := range outs {
+		tx.AddTxOut(wire.NewTxOut(value, make([]byte, 25)))
+	}
+	buf := &bytes.Buffer{}
+	if err := tx.Serialize(buf); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(buf.Bytes())
+}
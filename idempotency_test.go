@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+)
+
+func TestIdempotentSend(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	token := h.login("retry-user")
+	prevTxID := strings.Repeat("a1", 32)
+	h.addOutput(currencies.Bitcoin, currencies.Test, "retry-user", "retry-address", prevTxID, 100000)
+
+	send := func(key, rawTx string) *httptest.ResponseRecorder {
+		return h.sendTx(token, http.Header{"Idempotency-Key": {key}}, currencies.Bitcoin, currencies.Test, "retry-change", rawTx)
+	}
+
+	rawTx := rawBTCTx(t, prevTxID, 99000)
+	first := send("key-1", rawTx)
+	if first.Code != http.StatusOK {
+		t.Fatalf("send: %d %s", first.Code, first.Body.String())
+	}
+	watched := len(h.btcTest.Watched())
+
+	retry := send("key-1", rawTx)
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry: got %d %s, want %d %s", retry.Code, retry.Body.String(), first.Code, first.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry is not marked replayed")
+	}
+	if raw := h.btcTest.RawTxs(); len(raw) != 1 {
+		t.Errorf("retry is broadcast again: %v", raw)
+	}
+	if n := len(h.btcTest.Watched()); n != watched {
+		t.Errorf("retry watches the change address again: %d, want %d", n, watched)
+	}
+
+	if w := send("key-1", rawBTCTx(t, prevTxID, 98000)); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reused for another tx: got %d %s", w.Code, w.Body.String())
+	}
+
+	// rejected txs are replayed too, the client has to fix the tx and use a new key
+	if w := send("key-2", "zz"); w.Code != http.StatusBadRequest {
+		t.Errorf("rejected tx: got %d", w.Code)
+	}
+	if w := send("key-2", "zz"); w.Code != http.StatusBadRequest || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("rejected tx retry: got %d %v", w.Code, w.Header())
+	}
+
+	// node service is unreachable, the tx may be received so the key is kept free for a retry
+	failedTx := rawBTCTx(t, prevTxID, 99500)
+	h.btcTest.FailSendRawTx(errors.New("connection reset"))
+	if w := send("key-3", failedTx); w.Code != http.StatusBadGateway {
+		t.Errorf("send while node service fails: got %d %s", w.Code, w.Body.String())
+	}
+	h.btcTest.FailSendRawTx(nil)
+	if w := send("key-3", failedTx); w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after node service failure: got %d %s", w.Code, w.Body.String())
+	}
+
+	// keys belong to the user
+	other := h.login("other-user")
+	w := h.doWithHeader(http.MethodPost, "/api/v1/transaction/send", other, http.Header{"Idempotency-Key": {"key-1"}}, map[string]interface{}{
+		"currencyid": currencies.Bitcoin,
+		"networkID":  currencies.Test,
+		"payload":    map[string]interface{}{"transaction": "zz"},
+	})
+	if w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("key of another user is replayed: %d %s", w.Code, w.Body.String())
+	}
+}
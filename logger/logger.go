@@ -0,0 +1,160 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+
+// Package logger makes loggers of the configured slf factory safe for concurrent use.
+// slog loggers keep the time and the level of the last entry unguarded, while package
+// loggers are shared by every goroutine of node service streams and REST handlers.
+package logger
+
+import (
+	"sync"
+
+	"github.com/jekabolt/slf"
+	// sets slog as the log factory before loggers are made
+	_ "github.com/jekabolt/slflog"
+)
+
+// WithContext returns logger of the context which serializes its entries
+func WithContext(context string) slf.StructuredLogger {
+	return newStructuredLogger(slf.WithContext(context))
+}
+
+// syncLogger serializes entries of the logger and traces of them
+type syncLogger struct {
+	m   *sync.Mutex
+	log slf.Logger
+}
+
+func (l syncLogger) tracer(t slf.Tracer) slf.Tracer {
+	return syncTracer{m: l.m, tracer: t}
+}
+
+func (l syncLogger) Log(level slf.Level, message string) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Log(level, message))
+}
+
+func (l syncLogger) Debug(message string) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Debug(message))
+}
+
+func (l syncLogger) Debugf(format string, args ...interface{}) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Debugf(format, args...))
+}
+
+func (l syncLogger) Info(message string) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Info(message))
+}
+
+func (l syncLogger) Infof(format string, args ...interface{}) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Infof(format, args...))
+}
+
+func (l syncLogger) Warn(message string) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Warn(message))
+}
+
+func (l syncLogger) Warnf(format string, args ...interface{}) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Warnf(format, args...))
+}
+
+func (l syncLogger) Error(message string) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Error(message))
+}
+
+func (l syncLogger) Errorf(format string, args ...interface{}) slf.Tracer {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.tracer(l.log.Errorf(format, args...))
+}
+
+func (l syncLogger) Panic(message string) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.log.Panic(message)
+}
+
+func (l syncLogger) Panicf(format string, args ...interface{}) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.log.Panicf(format, args...)
+}
+
+func (l syncLogger) Fatal(message string) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.log.Fatal(message)
+}
+
+func (l syncLogger) Fatalf(format string, args ...interface{}) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.log.Fatalf(format, args...)
+}
+
+// syncStructuredLogger makes loggers with fields which serialize their entries too
+type syncStructuredLogger struct {
+	syncLogger
+	structured slf.StructuredLogger
+}
+
+func newStructuredLogger(log slf.StructuredLogger) syncStructuredLogger {
+	return syncStructuredLogger{
+		syncLogger: syncLogger{m: &sync.Mutex{}, log: log},
+		structured: log,
+	}
+}
+
+func (l syncStructuredLogger) WithField(key string, value interface{}) slf.StructuredLogger {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return newStructuredLogger(l.structured.WithField(key, value))
+}
+
+func (l syncStructuredLogger) WithFields(fields slf.Fields) slf.StructuredLogger {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return newStructuredLogger(l.structured.WithFields(fields))
+}
+
+func (l syncStructuredLogger) WithCaller(caller slf.CallerInfo) slf.StructuredLogger {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return newStructuredLogger(l.structured.WithCaller(caller))
+}
+
+func (l syncStructuredLogger) WithError(err error) slf.Logger {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return syncLogger{m: &sync.Mutex{}, log: l.structured.WithError(err)}
+}
+
+// syncTracer traces under the lock of the logger it was returned by
+type syncTracer struct {
+	m      *sync.Mutex
+	tracer slf.Tracer
+}
+
+func (t syncTracer) Trace(err *error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.tracer.Trace(err)
+}
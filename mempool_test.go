@@ -0,0 +1,52 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestMempoolStats(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	token := h.login("mempool-user")
+	for i, hash := range []string{"mp-1", "mp-2", "mp-3"} {
+		h.btcTest.AddMempoolRecord(&btcpb.MempoolRecord{Category: int32((i + 1) * 10), HashTX: hash})
+	}
+
+	resp := struct {
+		Mempool store.MempoolStats `json:"mempool"`
+	}{}
+	h.waitFor("mempool records", func() bool {
+		w := h.do(http.MethodGet, "/api/v1/mempool/0/1?hours=1&feerate=15", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /api/v1/mempool: %d %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("json.Unmarshal: %s", err.Error())
+		}
+		return resp.Mempool.Size == 3
+	})
+
+	if resp.Mempool.CurrencyID != currencies.Bitcoin || resp.Mempool.NetworkID != currencies.Test {
+		t.Errorf("chain: got %d/%d", resp.Mempool.CurrencyID, resp.Mempool.NetworkID)
+	}
+	if c := resp.Mempool.Confirmation; c == nil || c.FeeRate != 15 || c.Blocks != 1 {
+		t.Errorf("confirmation: got %+v", c)
+	}
+
+	w := h.do(http.MethodGet, "/api/v1/mempool/0/1?hours=x", token, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad hours: got %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/client"
+	"github.com/Multy-io/Multy-back/currencies"
+	ethpb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestMultisigSignatures(t *testing.T) {
+	ethWallet := func(userID, address string) store.User {
+		user := walletUser(userID, currencies.Ether, currencies.ETHTest, address)
+		user.Wallets[0].WalletIndex = 1
+		return user
+	}
+	// owners are checksummed on the chain, wallets of alice keep the address so too
+	h := newHarness(t, ethWallet("alice", "0xAlice"), ethWallet("bob", "0xbob"), ethWallet("carol", "0xcarol"))
+	defer h.Close()
+	h.ethTest.SetMultisigContract("0xmsig", []string{"0xAlice", "0xBob", "0xoutsider"}, 2)
+
+	// owners and confirmations of the request are ignored
+	params := map[string]interface{}{
+		"currencyID":      currencies.Ether,
+		"networkID":       currencies.ETHTest,
+		"contractAddress": "0xMSIG",
+		"walletName":      "shared",
+		"owners":          []string{"0xAlice", "0xcarol"},
+		"confirmations":   1,
+	}
+	carol := h.login("carol")
+	if w := h.do(http.MethodPost, "/api/v1/multisig", carol, params); w.Code != http.StatusBadRequest {
+		t.Errorf("multisig of not an owner: %d %s", w.Code, w.Body.String())
+	}
+	unknown := map[string]interface{}{
+		"currencyID":      currencies.Ether,
+		"networkID":       currencies.ETHTest,
+		"contractAddress": "0xtoken",
+	}
+	if w := h.do(http.MethodPost, "/api/v1/multisig", carol, unknown); w.Code != http.StatusBadRequest {
+		t.Errorf("not a multisig contract: %d %s", w.Code, w.Body.String())
+	}
+	alice := h.login("alice")
+	if w := h.do(http.MethodPost, "/api/v1/multisig", alice, params); w.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v1/multisig: %d %s", w.Code, w.Body.String())
+	}
+	if ms, ok := h.ethTest.Multisigs()["0xmsig"]; !ok || len(ms.Owners) != 3 || ms.Confirmations != 2 {
+		t.Fatalf("watched multisig: %+v", h.ethTest.Multisigs())
+	}
+
+	h.ethTest.AddMultisigEvent(&ethpb.MultisigEvent{Contract: "0xmsig", Event: store.MultisigEventSubmission, TxIndex: 0, Owner: "0xalice", To: "0xshop", Amount: "1000", Hash: "0xsubmit"})
+	h.ethTest.AddMultisigEvent(&ethpb.MultisigEvent{Contract: "0xmsig", Event: store.MultisigEventConfirmation, TxIndex: 0, Owner: "0xalice", Hash: "0xsubmit"})
+
+	var signs []store.MultisigSignNeeded
+	h.waitFor("sign notification", func() bool {
+		signs = nil
+		for _, msg := range h.nsqd.Published(store.TopicMultisigSign) {
+			sign := store.MultisigSignNeeded{}
+			if err := json.Unmarshal(msg, &sign); err != nil {
+				t.Fatalf("json.Unmarshal: %s", err.Error())
+			}
+			signs = append(signs, sign)
+		}
+		return len(signs) > 0 && signs[len(signs)-1].Confirmed == 1
+	})
+	for _, sign := range signs {
+		if sign.UserID != "bob" || sign.Owner != "0xBob" || sign.Required != 2 || sign.WalletIndex != 1 || sign.To != "0xshop" {
+			t.Errorf("sign notification: %+v", sign)
+		}
+	}
+
+	resp := struct {
+		Multisigs []client.MultisigVerbose `json:"multisigs"`
+	}{}
+	w := h.do(http.MethodGet, "/api/v1/multisig/60/4", h.login("bob"), nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Multisigs) != 1 {
+		t.Fatalf("GET /api/v1/multisig: %d %s", w.Code, w.Body.String())
+	}
+	ms := resp.Multisigs[0]
+	if ms.ContractAddress != "0xmsig" || len(ms.Owners) != 3 || ms.Owners[0].Associated || !ms.Owners[1].Associated {
+		t.Errorf("multisig of bob: %+v", ms)
+	}
+	if len(ms.Txs) != 1 || ms.Txs[0].Status != store.MultisigTxPending || len(ms.Txs[0].Confirmations) != 1 {
+		t.Errorf("multisig txs: %+v", ms.Txs)
+	}
+
+	n := len(h.nsqd.Published(store.TopicMultisigSign))
+	h.ethTest.AddMultisigEvent(&ethpb.MultisigEvent{Contract: "0xmsig", Event: store.MultisigEventConfirmation, TxIndex: 0, Owner: "0xbob", Hash: "0xconfirm"})
+	h.ethTest.AddMultisigEvent(&ethpb.MultisigEvent{Contract: "0xmsig", Event: store.MultisigEventExecution, TxIndex: 0, Hash: "0xconfirm"})
+	h.waitFor("executed multisig tx", func() bool {
+		tx, err := h.userStore.FindMultisigTx(currencies.Ether, currencies.ETHTest, "0xmsig", 0)
+		return err == nil && tx.Status == store.MultisigTxExecuted && len(tx.Confirmations) == 2
+	})
+	if len(h.nsqd.Published(store.TopicMultisigSign)) != n {
+		t.Errorf("owners notified about the confirmed tx")
+	}
+	if ms, _ := h.userStore.GetUserMultisigs(currencies.Ether, currencies.ETHTest, "carol"); len(ms) != 0 {
+		t.Errorf("multisigs of carol: %+v", ms)
+	}
+
+	// owner additions replayed on resync keep a single entry of the owner
+	h.ethTest.AddMultisigEvent(&ethpb.MultisigEvent{Contract: "0xmsig", Event: store.MultisigEventOwnerAddition, Owner: "0xbob", Resync: true})
+	h.ethTest.AddMultisigEvent(&ethpb.MultisigEvent{Contract: "0xmsig", Event: store.MultisigEventRequirement, Required: 3, Resync: true})
+	h.waitFor("requirement change", func() bool {
+		ms, err := h.userStore.FindMultisig(currencies.Ether, currencies.ETHTest, "0xmsig")
+		return err == nil && ms.Confirmations == 3
+	})
+	if ms, _ := h.userStore.FindMultisig(currencies.Ether, currencies.ETHTest, "0xmsig"); len(ms.Owners) != 3 {
+		t.Errorf("owners after replayed addition: %+v", ms.Owners)
+	}
+
+	if err := h.ethTest.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	h.waitFor("multisig after node restart", func() bool {
+		_, ok := h.ethTest.Multisigs()["0xmsig"]
+		return ok
+	})
+}
@@ -6,23 +6,24 @@ See LICENSE for details
 package multyback
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	// exchanger "github.com/Multy-io/Multy-back-exchange-service"
 	"github.com/Multy-io/Multy-back/btc"
+	"github.com/Multy-io/Multy-back/chain"
 	"github.com/Multy-io/Multy-back/client"
 	"github.com/Multy-io/Multy-back/currencies"
 	"github.com/Multy-io/Multy-back/eth"
-	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
-	ethpb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"github.com/Multy-io/Multy-back/logger"
 	"github.com/Multy-io/Multy-back/store"
+	nsq "github.com/bitly/go-nsq"
 	"github.com/gin-gonic/gin"
-	"github.com/jekabolt/slf"
 )
 
 var (
-	log = slf.WithContext("multy-back")
+	log = logger.WithContext("multy-back")
 )
 
 const (
@@ -52,86 +53,112 @@ type Multy struct {
 
 	BTC *btc.BTCConn
 	ETH *eth.ETHConn
+
+	// connections of bitcoin-like chains by currency, bitcoin one included
+	UTXO map[int]*btc.BTCConn
+	// connections of EVM chains by currency, ethereum one included
+	EVM map[int]*eth.ETHConn
+
+	chains        *chain.Registry
+	syncTracker   *chain.SyncTracker
+	rebroadcaster *chain.Rebroadcaster
 }
 
 // Init initializes Multy instance
 func Init(conf *Configuration) (*Multy, error) {
-	multy := &Multy{
-		config: conf,
-	}
 	// DB initialization
 	userStore, err := store.InitUserStore(conf.Database)
 	if err != nil {
 		return nil, fmt.Errorf("DB initialization: %s on port %s", err.Error(), conf.Database.Address)
 	}
-	multy.userStore = userStore
 	log.Infof("UserStore initialization done on %s √", conf.Database)
 
+	return initWithStore(conf, userStore)
+}
+
+// initWithStore initializes Multy instance on top of already opened user store
+func initWithStore(conf *Configuration, userStore store.UserStore) (*Multy, error) {
+	multy := &Multy{
+		config:    conf,
+		userStore: userStore,
+	}
+
 	// exchange rates
 	// exchange := &exchanger.Exchanger{}
 	// exchange.InitExchanger(conf.ExchangerConfiguration)
 
-	//BTC
-	btcCli, err := btc.InitHandlers(&conf.Database, conf.SupportedNodes, conf.NSQAddress)
-	if err != nil {
-		return nil, fmt.Errorf("Init: btc.InitHandlers: %s", err.Error())
+	// token registry
+	for _, token := range conf.Tokens {
+		token.Contract = strings.ToLower(token.Contract)
+		if err := userStore.SaveToken(token); err != nil {
+			return nil, fmt.Errorf("Init: userStore.SaveToken: %s", err.Error())
+		}
 	}
 
-	//BTC Main ver
-	btcMainVer, err := btcCli.CliMain.ServiceInfo(context.Background(), &btcpb.Empty{})
-	multy.BTC = btcCli
-	log.Infof(" BTC mainnet initialization done on %v √", btcMainVer)
-
-	multy.BTC.VersionMain = store.NodeVersion{
-		Branch: btcMainVer.Branch,
-		Commit: btcMainVer.Commit,
+	// UTXO chains, every bitcoin-like coin in config gets its own connection
+	multy.UTXO = map[int]*btc.BTCConn{}
+	for _, ct := range conf.SupportedNodes {
+		params, ok := btc.ParamsOf(ct.СurrencyID)
+		if !ok || multy.UTXO[ct.СurrencyID] != nil {
+			continue
+		}
+		conn, err := btc.InitHandlers(params, userStore, conf.SupportedNodes, conf.NSQAddress)
+		if err != nil {
+			return nil, fmt.Errorf("Init: btc.InitHandlers: %s: %s", params.Name, err.Error())
+		}
+		multy.UTXO[ct.СurrencyID] = conn
+		log.Infof(" %s initialization done √", params.Name)
 	}
-
-	//BTC Test ver
-	btcTestVer, err := btcCli.CliTest.ServiceInfo(context.Background(), &btcpb.Empty{})
-	log.Infof(" BTC testnet initialization done on %v √", btcTestVer)
-
-	multy.BTC.VersionTest = store.NodeVersion{
-		Branch: btcTestVer.Branch,
-		Commit: btcTestVer.Commit,
+	multy.BTC = multy.UTXO[currencies.Bitcoin]
+	if multy.BTC == nil {
+		return nil, fmt.Errorf("Init: no bitcoin node services in config")
 	}
 
-	// ETH
-	ethCli, err := eth.InitHandlers(&conf.Database, conf.SupportedNodes, conf.NSQAddress)
-	if err != nil {
-		return nil, fmt.Errorf("Init: btc.InitHandlers: %s", err.Error())
+	// EVM chains, every EVM coin in config gets its own connection
+	multy.EVM = map[int]*eth.ETHConn{}
+	for _, ct := range conf.SupportedNodes {
+		params, ok := eth.ParamsOf(ct.СurrencyID)
+		if !ok || multy.EVM[ct.СurrencyID] != nil {
+			continue
+		}
+		conn, err := eth.InitHandlers(params, userStore, conf.SupportedNodes, conf.NSQAddress)
+		if err != nil {
+			return nil, fmt.Errorf("Init: eth.InitHandlers: %s: %s", params.Name, err.Error())
+		}
+		multy.EVM[ct.СurrencyID] = conn
+		log.Infof(" %s initialization done √", params.Name)
 	}
-
-	//ETH Main ver
-	ethMainVer, err := ethCli.CliMain.ServiceInfo(context.Background(), &ethpb.Empty{})
-	multy.ETH = ethCli
-
-	log.Infof(" ETH mainnet initialization done on %v √", ethMainVer)
-
-	multy.ETH.VersionMain = store.NodeVersion{
-		Branch: ethMainVer.Branch,
-		Commit: ethMainVer.Commit,
+	multy.ETH = multy.EVM[currencies.Ether]
+	if multy.ETH == nil {
+		return nil, fmt.Errorf("Init: no ethereum node services in config")
 	}
 
-	//ETH Test ver
-	ethTestVer, err := ethCli.CliTest.ServiceInfo(context.Background(), &ethpb.Empty{})
-	multy.ETH = ethCli
-	log.Infof(" ETH testnet initialization done on %v √", ethTestVer)
-
-	multy.ETH.VersionTest = store.NodeVersion{
-		Branch: ethTestVer.Branch,
-		Commit: ethTestVer.Commit,
+	// chains registry
+	chains, err := multy.initChains(conf.SupportedNodes)
+	if err != nil {
+		return nil, fmt.Errorf("Init: multy.initChains: %s", err.Error())
 	}
+	multy.chains = chains
+	multy.syncTracker = chain.NewSyncTracker(multy.userStore)
 
 	//users data set
-	sv, err := multy.SetUserData(multy.userStore, conf.SupportedNodes)
+	sv, err := multy.SetUserData(multy.userStore, multy.chains.All())
 	if err != nil {
 		return nil, fmt.Errorf("Init: multy.SetUserData: %s", err.Error())
 	}
 	log.Infof("Users data  initialization done √")
 
+	// node services lose watched addresses on restart, send them again on reconnect
+	for _, c := range multy.chains.All() {
+		multy.setReconnectHook(c)
+	}
+
 	log.Debugf("Server versions %v", sv)
 
+	// txs sent by users are sent again until they are seen in mempool or a block
+	multy.rebroadcaster = chain.NewRebroadcaster(multy.chains, multy.userStore, conf.Rebroadcast, multy.notifyDropped)
+	go multy.rebroadcaster.Run()
+
 	// REST handlers
 	if err = multy.initHttpRoutes(conf); err != nil {
 		return nil, fmt.Errorf("Router initialization: %s", err.Error())
@@ -140,145 +167,152 @@ func Init(conf *Configuration) (*Multy, error) {
 }
 
 // SetUserData make initial userdata to node service
-func (m *Multy) SetUserData(userStore store.UserStore, ct []store.CoinType) ([]store.ServiceInfo, error) {
+func (m *Multy) SetUserData(userStore store.UserStore, chains []chain.Chain) ([]store.ServiceInfo, error) {
 	servicesInfo := []store.ServiceInfo{}
-	for _, conCred := range ct {
-		usersData, err := userStore.FindUserDataChain(conCred.СurrencyID, conCred.NetworkID)
+	for _, c := range chains {
+		usersData, err := userStore.FindUserDataChain(c.CurrencyID(), c.NetworkID())
 		if err != nil {
-			return servicesInfo, fmt.Errorf("SetUserData: userStore.FindUserDataChain: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
+			return servicesInfo, fmt.Errorf("SetUserData: userStore.FindUserDataChain: curID :%d netID :%d err =%s", c.CurrencyID(), c.NetworkID(), err.Error())
 		}
 		if len(usersData) == 0 {
 			log.Infof("Empty userdata")
 		}
 
-		switch conCred.СurrencyID {
-		case currencies.Bitcoin:
-			var cli btcpb.NodeCommuunicationsClient
-			switch conCred.NetworkID {
-			case currencies.Main:
-				cli = m.BTC.CliMain
-			case currencies.Test:
-				cli = m.BTC.CliTest
-			default:
-				log.Errorf("setGRPCHandlers: wrong networkID:")
-			}
-
-			//TODO: Re State
-			// h, err := m.userStore.FethLastSyncBlockState(conCred.СurrencyID, conCred.NetworkID)
-			// if err != nil {
-			// 	log.Errorf("SetUserData:  btcCli.CliMain.cli.FethLastSyncBlockState: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			// 	// return servicesInfo, fmt.Errorf("SetUserData:  btcCli.CliMain.FethLastSyncBlockState: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			// }
-			// rp, err := cli.SyncState(context.Background(), &btcpb.BlockHeight{
-			// 	Height: h,
-			// })
-			// if err != nil {
-			// 	log.Errorf("SetUserData:  btcCli.CliMain.cli.SyncState: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			// 	// return servicesInfo, fmt.Errorf("SetUserData:  btcCli.CliMain.cli.SyncState: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			// }
-
-			// if strings.Contains("err:", rp.GetMessage()) {
-			// 	log.Errorf("SetUserData:  Contains err : curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			// 	// return servicesInfo, fmt.Errorf("SetUserData:  Contains err : curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			// }
-			// log.Errorf("BTC ++++++++++ %v", h)
-
-			genUd := btcpb.UsersData{
-				Map: map[string]*btcpb.AddressExtended{},
-			}
-			for address, ex := range usersData {
-				genUd.Map[address] = &btcpb.AddressExtended{
-					UserID:       ex.UserID,
-					WalletIndex:  int32(ex.WalletIndex),
-					AddressIndex: int32(ex.AddressIndex),
-				}
-			}
-			resp, err := cli.EventInitialAdd(context.Background(), &genUd)
-			if err != nil {
-				return servicesInfo, fmt.Errorf("SetUserData:  btcCli.CliMain.EventInitialAdd: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
+		err = c.InitialAdd(usersData)
+		if err != nil {
+			return servicesInfo, fmt.Errorf("SetUserData: c.InitialAdd: curID :%d netID :%d err =%s", c.CurrencyID(), c.NetworkID(), err.Error())
+		}
+		if ac, ok := c.(chain.Account); ok {
+			if err := ac.WatchMultisigs(); err != nil {
+				return servicesInfo, fmt.Errorf("SetUserData: c.WatchMultisigs: curID :%d netID :%d err =%s", c.CurrencyID(), c.NetworkID(), err.Error())
 			}
-			log.Debugf("Btc EventInitialAdd: resp: %s", resp.Message)
+		}
 
-			sv, err := cli.ServiceInfo(context.Background(), &btcpb.Empty{})
-			if err != nil {
-				return servicesInfo, fmt.Errorf("SetUserData:  cli.ServiceInfo: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			}
-			servicesInfo = append(servicesInfo, store.ServiceInfo{
-				Branch:    sv.Branch,
-				Commit:    sv.Commit,
-				Buildtime: sv.Buildtime,
-				Lasttag:   sv.Lasttag,
-			})
-
-		case currencies.Ether:
-			var cli ethpb.NodeCommuunicationsClient
-			switch conCred.NetworkID {
-			case currencies.ETHMain:
-				cli = m.ETH.CliMain
-			case currencies.ETHTest:
-				cli = m.ETH.CliTest
-			default:
-				log.Errorf("setGRPCHandlers: wrong networkID:")
-			}
+		// node service have to know all addresses before resending missed blocks
+		err = m.restoreState(userStore, c)
+		if err != nil {
+			log.Errorf("SetUserData: m.restoreState: curID :%d netID :%d err =%s", c.CurrencyID(), c.NetworkID(), err.Error())
+		}
 
-			//TODO: Re State
-			// var height int64
-			// states, err := m.userStore.FethLastSyncBlockState(conCred.СurrencyID, conCred.NetworkID)
-			// for _, state := range states {
-			// 	if state.CurrencyID == conCred.СurrencyID && state.NetworkID == conCred.NetworkID {
-			// 		height = state.BlockHeight
-			// 	}
-			// }
-			// bh, _ := cli.EventGetBlockHeight(context.Background(), &ethpb.Empty{})
-			// if err != nil {
-			// 	log.Errorf("SetUserData:  btcCli.CliMain.cli.FethLastSyncBlockState: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			// 	height = bh.GetHeight()
-			// }
-
-			// log.Warnf("ETH Re state last stored block = %v current block height = %v", height, bh.GetHeight())
-			// if height == 0 {
-			// 	height = bh.GetHeight()
-			// 	log.Errorf("SetUserData:  btcCli.CliMain.cli.FethLastSyncBlockState:no such record in db curID :%d netID :%d", conCred.СurrencyID, conCred.NetworkID)
-			// }
-			// _, err = cli.SyncState(context.Background(), &ethpb.BlockHeight{
-			// 	Height: height,
-			// })
-
-			// if err != nil {
-			// 	log.Errorf("SetUserData:  btcCli.CliMain.cli.SyncState: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			// }
-
-			genUd := ethpb.UsersData{
-				Map: map[string]*ethpb.AddressExtended{},
-			}
+		sv, err := c.ServiceInfo()
+		if err != nil {
+			return servicesInfo, fmt.Errorf("SetUserData: c.ServiceInfo: curID :%d netID :%d err =%s", c.CurrencyID(), c.NetworkID(), err.Error())
+		}
+		servicesInfo = append(servicesInfo, sv)
+	}
 
-			for address, ex := range usersData {
-				genUd.Map[address] = &ethpb.AddressExtended{
-					UserID:       ex.UserID,
-					WalletIndex:  int32(ex.WalletIndex),
-					AddressIndex: int32(ex.AddressIndex),
-				}
-			}
-			resp, err := cli.EventInitialAdd(context.Background(), &genUd)
-			if err != nil {
-				return servicesInfo, fmt.Errorf("SetUserData: Ether.EventInitialAdd: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
-			}
-			log.Debugf("Ether cli.EventInitialAdd: resp: %s", resp.Message)
+	return servicesInfo, nil
+}
 
-			sv, err := cli.ServiceInfo(context.Background(), &ethpb.Empty{})
-			if err != nil {
-				return servicesInfo, fmt.Errorf("SetUserData:  cli.ServiceInfo: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
+// setReconnectHook makes chain streams supervisor re-send users data and catch-up
+// from the last synced block once node service is back
+func (m *Multy) setReconnectHook(c chain.Chain) {
+	c.Streams().OnReconnect(func() error {
+		usersData, err := m.userStore.FindUserDataChain(c.CurrencyID(), c.NetworkID())
+		if err != nil {
+			return fmt.Errorf("userStore.FindUserDataChain: %s", err.Error())
+		}
+		if err := c.InitialAdd(usersData); err != nil {
+			return fmt.Errorf("c.InitialAdd: %s", err.Error())
+		}
+		if ac, ok := c.(chain.Account); ok {
+			if err := ac.WatchMultisigs(); err != nil {
+				return fmt.Errorf("c.WatchMultisigs: %s", err.Error())
 			}
-			servicesInfo = append(servicesInfo, store.ServiceInfo{
-				Branch:    sv.Branch,
-				Commit:    sv.Commit,
-				Buildtime: sv.Buildtime,
-				Lasttag:   sv.Lasttag,
-			})
 		}
+		log.Infof("setReconnectHook: users data re-sent curID :%d netID :%d", c.CurrencyID(), c.NetworkID())
+		return m.restoreState(m.userStore, c)
+	})
+}
+
+// restoreState asks node service to resend everything since the last block seen
+// before shutdown, so txs which came while the backend was down are not missed
+func (m *Multy) restoreState(userStore store.UserStore, c chain.Chain) error {
+	tip, err := c.BlockHeight()
+	if err != nil {
+		return fmt.Errorf("restoreState: c.BlockHeight: %s", err.Error())
+	}
+
+	ls, err := userStore.FethLastSyncBlockState(c.NetworkID(), c.CurrencyID())
+	if err != nil && err != store.ErrNotFound {
+		return fmt.Errorf("restoreState: userStore.FethLastSyncBlockState: %s", err.Error())
+	}
+
+	if ls.BlockHeight == 0 {
+		log.Infof("restoreState: no last state for curID :%d netID :%d nothing to catch up", c.CurrencyID(), c.NetworkID())
+		m.syncTracker.Start(c, tip, tip)
+		return nil
 	}
 
-	return nil, nil
+	log.Infof("restoreState: curID :%d netID :%d last synced block %d chain tip %d", c.CurrencyID(), c.NetworkID(), ls.BlockHeight, tip)
+	m.syncTracker.Start(c, ls.BlockHeight, tip)
+	if ls.BlockHeight >= tip {
+		return nil
+	}
+
+	err = c.SyncState(ls.BlockHeight)
+	if err != nil {
+		return fmt.Errorf("restoreState: c.SyncState: %s", err.Error())
+	}
+	return nil
+}
+
+// notifyDropped tells the user the tx was not mined and is not sent anymore
+func (m *Multy) notifyDropped(tx store.OutboundTx) {
+	dropped, err := json.Marshal(store.TxDropped{
+		UserID:     tx.UserID,
+		CurrencyID: tx.CurrencyID,
+		NetworkID:  tx.NetworkID,
+		TxID:       tx.TxID,
+	})
+	if err != nil {
+		log.Errorf("notifyDropped: json.Marshal: %s", err.Error())
+		return
+	}
+	producer := m.nsqProducer(tx.CurrencyID)
+	if producer == nil {
+		log.Errorf("notifyDropped: no connection for currency %d", tx.CurrencyID)
+		return
+	}
+	if err := producer.Publish(store.TopicTxDropped, dropped); err != nil {
+		log.Errorf("notifyDropped: nsqProducer.Publish: %s", err.Error())
+	}
+}
+
+// nsqProducer returns producer of the chain connection of the currency
+func (m *Multy) nsqProducer(currencyID int) *nsq.Producer {
+	if conn, ok := m.UTXO[currencyID]; ok {
+		return conn.NsqProducer
+	}
+	if conn, ok := m.EVM[currencyID]; ok {
+		return conn.NsqProducer
+	}
+	return nil
+}
+
+// initChains registers node services of all supported chains
+func (m *Multy) initChains(ct []store.CoinType) (*chain.Registry, error) {
+	chains := chain.NewRegistry()
+	for _, conCred := range ct {
+		var (
+			c   chain.Chain
+			err error
+		)
+		if conn, ok := m.UTXO[conCred.СurrencyID]; ok {
+			c, err = conn.Chain(conCred.NetworkID)
+		} else if conn, ok := m.EVM[conCred.СurrencyID]; ok {
+			c, err = conn.Chain(conCred.NetworkID)
+		} else {
+			err = fmt.Errorf("unsupported currency")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("initChains: curID :%d netID :%d err =%s", conCred.СurrencyID, conCred.NetworkID, err.Error())
+		}
+		if err = chains.Register(c); err != nil {
+			return nil, fmt.Errorf("initChains: chains.Register: %s", err.Error())
+		}
+	}
+	return chains, nil
 }
 
 // initRoutes initialize client communication services
@@ -295,8 +329,10 @@ func (multy *Multy) initHttpRoutes(conf *Configuration) error {
 		multy.userStore,
 		router,
 		conf.DonationAddresses,
-		multy.BTC,
-		multy.ETH,
+		multy.UTXO,
+		multy.EVM,
+		multy.chains,
+		multy.syncTracker,
 		conf.MultyVerison,
 		conf.Secretkey,
 		conf.DeviceVersions,
@@ -308,12 +344,16 @@ func (multy *Multy) initHttpRoutes(conf *Configuration) error {
 
 	// socketIO server initialization. server -> mobile client
 	socketIORoute := router.Group("/socketio")
-	socketIOPool, err := client.SetSocketIOHandlers(multy.restClient, multy.BTC, multy.ETH, socketIORoute, conf.SocketioAddr, conf.NSQAddress, multy.userStore)
+	socketIOPool, err := client.SetSocketIOHandlers(multy.restClient, multy.chains, socketIORoute, conf.SocketioAddr, conf.NSQAddress, multy.userStore, conf.DisableExchangeStocks)
 	if err != nil {
 		return err
 	}
 	multy.clientPool = socketIOPool
 
+	if conf.DisableFirebase {
+		log.Infof("Firebase push notifications are disabled")
+		return nil
+	}
 	firebaseClient, err := client.InitFirebaseConn(&conf.Firebase, multy.route, conf.NSQAddress)
 	if err != nil {
 		return err
@@ -323,6 +363,27 @@ func (multy *Multy) initHttpRoutes(conf *Configuration) error {
 	return nil
 }
 
+// ReloadCertificates re-reads TLS certificates of node service connections of all chains,
+// chains failed to reload are reported together once others are reloaded
+func (multy *Multy) ReloadCertificates() error {
+	failed := []string{}
+	for _, conn := range multy.UTXO {
+		if err := conn.ReloadCertificates(); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	for _, conn := range multy.EVM {
+		if err := conn.ReloadCertificates(); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("ReloadCertificates: %s", strings.Join(failed, "; "))
+	}
+	log.Infof("ReloadCertificates: node service certificates reloaded √")
+	return nil
+}
+
 // Run runs service
 func (multy *Multy) Run() error {
 	log.Info("Running server")
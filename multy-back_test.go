@@ -0,0 +1,139 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestInitialAddOnBoot(t *testing.T) {
+	h := newHarness(t, store.User{
+		UserID: "boot-user",
+		Wallets: []store.Wallet{{
+			CurrencyID:  currencies.Bitcoin,
+			NetworkID:   currencies.Test,
+			WalletIndex: 1,
+			Adresses: []store.Address{{
+				Address:      "boot-address",
+				AddressIndex: 2,
+			}},
+		}},
+	})
+	defer h.Close()
+
+	if h.btcTest.InitialAdds() != 1 {
+		t.Errorf("btc test InitialAdds: got %d, want 1", h.btcTest.InitialAdds())
+	}
+	ud, ok := h.btcTest.UsersData()["boot-address"]
+	if !ok {
+		t.Fatalf("btc test UsersData: boot-address is not sent")
+	}
+	if ud.UserID != "boot-user" || ud.WalletIndex != 1 || ud.AddressIndex != 2 {
+		t.Errorf("btc test UsersData: got %v", ud)
+	}
+	if _, ok := h.btcMain.UsersData()["boot-address"]; ok {
+		t.Errorf("btc main UsersData: testnet address is sent to mainnet")
+	}
+}
+
+func TestNodeRestartResendsUsers(t *testing.T) {
+	h := newHarness(t, walletUser("restart-user", currencies.Ether, currencies.ETHTest, "0xrestart"))
+	defer h.Close()
+
+	if err := h.ethTest.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	h.waitFor("users data after node restart", func() bool {
+		_, ok := h.ethTest.UsersData()["0xrestart"]
+		return ok
+	})
+}
+
+func TestAddWalletWatchesAddress(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	token := h.login("wallet-user")
+	h.addWallet(token, currencies.Bitcoin, currencies.Test, 0, "wallet-address")
+
+	h.waitFor("watched address", func() bool {
+		for _, wa := range h.btcTest.Watched() {
+			if wa.Address == "wallet-address" && wa.UserID == "wallet-user" {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestAddWalletWatchesAddressAfterNodeRestart(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	addr := h.btcTest.Addr()
+	h.btcTest.Stop()
+
+	token := h.login("watch-user")
+	h.addWallet(token, currencies.Bitcoin, currencies.Test, 0, "watch-address")
+
+	if err := h.btcTest.Start(addr); err != nil {
+		t.Fatal(err)
+	}
+	h.waitFor("watched address after node restart", func() bool {
+		for _, wa := range h.btcTest.Watched() {
+			if wa.Address == "watch-address" && wa.UserID == "watch-user" {
+				return true
+			}
+		}
+		return false
+	})
+	h.waitFor("resynced address after node restart", func() bool {
+		for _, r := range h.btcTest.Resynced() {
+			if r.Address == "watch-address" {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestNewTxNotifiesClients(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	token := h.login("tx-user")
+	h.addWallet(token, currencies.Bitcoin, currencies.Test, 0, "tx-address")
+
+	h.btcTest.NewTransaction(&btcpb.BTCTransaction{
+		UserID:      "tx-user",
+		TxID:        "txid-1",
+		TxAddress:   []string{"tx-address"},
+		TxStatus:    store.TxStatusAppearedInMempoolIncoming,
+		TxOutAmount: 1000,
+		TxInputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "sender-address", Amount: 2000},
+		},
+		TxOutputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "tx-address", Amount: 1000},
+		},
+		WalletsOutput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "tx-user", Address: "tx-address", Amount: 1000},
+		},
+	})
+
+	notify := h.waitForTxNotification("transaction notification", "txid-1", nil)
+
+	if notify.UserID != "tx-user" {
+		t.Errorf("notification userID: got %q, want tx-user", notify.UserID)
+	}
+	if notify.NotificationMsg.NetworkID != currencies.Test {
+		t.Errorf("notification networkID: got %d, want %d", notify.NotificationMsg.NetworkID, currencies.Test)
+	}
+}
@@ -41,6 +41,7 @@ type BTCTransaction struct {
 	WalletsInput         []*BTCTransaction_WalletForTx  `protobuf:"bytes,15,rep,name=WalletsInput,proto3" json:"WalletsInput,omitempty"`
 	WalletsOutput        []*BTCTransaction_WalletForTx  `protobuf:"bytes,16,rep,name=WalletsOutput,proto3" json:"WalletsOutput,omitempty"`
 	Resync               bool                           `protobuf:"varint,17,opt,name=resync,proto3" json:"resync,omitempty"`
+	Rbf                  bool                           `protobuf:"varint,18,opt,name=rbf,proto3" json:"rbf,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}                       `json:"-"`
 	XXX_unrecognized     []byte                         `json:"-"`
 	XXX_sizecache        int32                          `json:"-"`
@@ -189,6 +190,13 @@ func (m *BTCTransaction) GetResync() bool {
 	return false
 }
 
+func (m *BTCTransaction) GetRbf() bool {
+	if m != nil {
+		return m.Rbf
+	}
+	return false
+}
+
 type BTCTransaction_AddresAmount struct {
 	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
 	Amount               int64    `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
@@ -455,6 +463,8 @@ func (m *Resync) GetSpOutDelete() []*ReqDeleteSpOut {
 
 type BlockHeight struct {
 	Height               int64    `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Hash                 string   `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	PrevHash             string   `protobuf:"bytes,3,opt,name=prevHash,proto3" json:"prevHash,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -491,10 +501,25 @@ func (m *BlockHeight) GetHeight() int64 {
 	return 0
 }
 
+func (m *BlockHeight) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *BlockHeight) GetPrevHash() string {
+	if m != nil {
+		return m.PrevHash
+	}
+	return ""
+}
+
 type ReqDeleteSpOut struct {
 	UserID               string   `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
 	TxID                 string   `protobuf:"bytes,2,opt,name=txID,proto3" json:"txID,omitempty"`
 	Address              string   `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	SpendTxID            string   `protobuf:"bytes,4,opt,name=spendTxID,proto3" json:"spendTxID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -545,6 +570,13 @@ func (m *ReqDeleteSpOut) GetAddress() string {
 	return ""
 }
 
+func (m *ReqDeleteSpOut) GetSpendTxID() string {
+	if m != nil {
+		return m.SpendTxID
+	}
+	return ""
+}
+
 type MempoolToDelete struct {
 	Hash                 string   `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -1788,78 +1820,80 @@ var _NodeCommuunications_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("streamer.proto", fileDescriptor_streamer_251e065a582d5ab8) }
 
 var fileDescriptor_streamer_251e065a582d5ab8 = []byte{
-	// 1159 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xdd, 0x6f, 0x1b, 0x45,
-	0x10, 0xcf, 0xf9, 0xe2, 0xaf, 0x71, 0xec, 0xa4, 0x9b, 0xd0, 0x9e, 0x2c, 0x04, 0xd6, 0x89, 0x48,
-	0x2e, 0x42, 0x26, 0xa4, 0x8a, 0x54, 0x4a, 0x55, 0xe1, 0x26, 0x69, 0x6b, 0x89, 0xa6, 0xd2, 0xd9,
-	0x2d, 0xbc, 0xae, 0xef, 0xb6, 0xf1, 0xa9, 0xf7, 0x61, 0xee, 0xd6, 0xc9, 0xf9, 0x1d, 0x21, 0xf1,
-	0x80, 0xc4, 0x5f, 0xc5, 0x1b, 0xff, 0x13, 0xda, 0xd9, 0x3d, 0x7b, 0x97, 0x98, 0x26, 0x48, 0xbc,
-	0xdd, 0xcc, 0xce, 0xd7, 0xfe, 0xe6, 0x37, 0xb3, 0x36, 0x74, 0x72, 0x9e, 0x31, 0x1a, 0xb3, 0x6c,
-	0x30, 0xcf, 0x52, 0x9e, 0x12, 0x7b, 0xca, 0x7d, 0xf7, 0xaf, 0x1a, 0x74, 0x9e, 0x4f, 0x4e, 0x27,
-	0x19, 0x4d, 0x72, 0xea, 0xf3, 0x30, 0x4d, 0xc8, 0x7d, 0xa8, 0x2d, 0x72, 0x96, 0x8d, 0xce, 0x1c,
-	0xab, 0x67, 0xf5, 0x9b, 0x9e, 0x92, 0x08, 0x81, 0x6d, 0x5e, 0x8c, 0xce, 0x9c, 0x0a, 0x6a, 0xf1,
-	0x5b, 0xd8, 0xf2, 0xe2, 0x15, 0xcd, 0x67, 0x8e, 0x2d, 0x6d, 0xa5, 0x44, 0x7a, 0xd0, 0xe2, 0xc5,
-	0x9b, 0x05, 0x1f, 0xfb, 0x59, 0x38, 0xe7, 0xce, 0x36, 0x1e, 0xea, 0x2a, 0xf2, 0x29, 0x34, 0x79,
-	0x31, 0x0c, 0x82, 0x8c, 0xe5, 0xb9, 0x53, 0xed, 0xd9, 0xfd, 0xa6, 0xb7, 0x56, 0x90, 0x2e, 0x34,
-	0x78, 0x31, 0xe6, 0x94, 0x2f, 0x72, 0xa7, 0xd6, 0xb3, 0xfa, 0x55, 0x6f, 0x25, 0xaf, 0x62, 0x0f,
-	0xe3, 0x74, 0x91, 0x70, 0xa7, 0xde, 0xb3, 0xfa, 0xb6, 0xa7, 0xab, 0x44, 0xec, 0x69, 0x94, 0xfa,
-	0x1f, 0x26, 0x61, 0xcc, 0x9c, 0x06, 0x9e, 0xaf, 0x15, 0xc2, 0x1f, 0x85, 0x57, 0x2c, 0xbc, 0x9c,
-	0x71, 0xa7, 0x29, 0xfd, 0x35, 0x15, 0xf9, 0x02, 0xda, 0x7e, 0x9a, 0xbc, 0x0f, 0xb3, 0x98, 0x0a,
-	0x44, 0x72, 0x07, 0xb0, 0x04, 0x53, 0x49, 0x0e, 0xa0, 0xca, 0x8b, 0x17, 0x8c, 0x39, 0x2d, 0x8c,
-	0x20, 0x05, 0x11, 0x3d, 0x66, 0xf1, 0x3c, 0x4d, 0x23, 0xcc, 0xbe, 0x23, 0xa3, 0x6b, 0x2a, 0xf2,
-	0x54, 0xdc, 0x6d, 0x94, 0xcc, 0x17, 0x3c, 0x77, 0xda, 0x3d, 0xbb, 0xdf, 0x3a, 0xee, 0x0d, 0xa6,
-	0xdc, 0x1f, 0x98, 0x6d, 0x18, 0x48, 0x28, 0xe4, 0x8d, 0xbc, 0x95, 0x07, 0x79, 0x06, 0xcd, 0x89,
-	0xb8, 0x2a, 0xba, 0x77, 0xee, 0xe8, 0xbe, 0x76, 0x21, 0xa7, 0xb0, 0xf3, 0x23, 0x8d, 0x22, 0xc6,
-	0x73, 0x0c, 0xe8, 0xec, 0x62, 0x88, 0xcf, 0x37, 0x85, 0x90, 0x76, 0x2f, 0xd2, 0x6c, 0x52, 0x78,
-	0x86, 0x13, 0x39, 0x87, 0xb6, 0x92, 0x65, 0x58, 0x67, 0xef, 0x6e, 0x51, 0x4c, 0x2f, 0xc1, 0x9e,
-	0x8c, 0xe5, 0xcb, 0xc4, 0x77, 0xee, 0xf5, 0xac, 0x7e, 0xc3, 0x53, 0x52, 0xf7, 0x7b, 0xd8, 0xd1,
-	0xcb, 0x27, 0x0e, 0xd4, 0xa9, 0x62, 0x8a, 0xa4, 0x64, 0x29, 0x8a, 0x08, 0x54, 0xd2, 0xa0, 0x82,
-	0x40, 0x2b, 0xa9, 0x7b, 0x0d, 0x2d, 0x2d, 0x6f, 0x49, 0xe9, 0x30, 0xd0, 0x29, 0x1d, 0x06, 0x7a,
-	0xe0, 0x8a, 0x19, 0xf8, 0x33, 0x00, 0x64, 0xd4, 0x28, 0x09, 0x58, 0x81, 0xe4, 0xae, 0x7a, 0x9a,
-	0x46, 0x4b, 0xbc, 0xad, 0x27, 0x76, 0xff, 0xa8, 0x40, 0x63, 0x18, 0x04, 0xe3, 0xf9, 0x9b, 0x05,
-	0x5f, 0x4d, 0x8c, 0xa5, 0x4d, 0x8c, 0x03, 0x75, 0x19, 0x46, 0x0e, 0x52, 0xd5, 0x2b, 0xc5, 0x7f,
-	0xf2, 0xda, 0xbe, 0xc9, 0xeb, 0xdb, 0xa7, 0x4a, 0xbb, 0x50, 0xf5, 0x06, 0x52, 0x6a, 0xaa, 0x6b,
-	0xc6, 0x54, 0xeb, 0x93, 0x56, 0xbf, 0x39, 0x69, 0xd7, 0x88, 0xa2, 0x44, 0xa1, 0x81, 0xc7, 0xba,
-	0x8a, 0xb8, 0xb0, 0xa3, 0x12, 0x48, 0x93, 0x26, 0x9a, 0x18, 0x3a, 0xf7, 0x77, 0x0b, 0x6a, 0x1e,
-	0x36, 0x96, 0x1c, 0x82, 0x3d, 0x29, 0x44, 0x13, 0x05, 0x5b, 0xf6, 0x37, 0xb0, 0xc5, 0x13, 0xe7,
-	0xe4, 0x10, 0x6a, 0x08, 0xa0, 0xe8, 0x8a, 0xb0, 0x6c, 0xa3, 0x65, 0x09, 0xab, 0xa7, 0x0e, 0xc9,
-	0x09, 0xb4, 0xf0, 0xeb, 0x8c, 0x45, 0x8c, 0x33, 0xc7, 0xd6, 0xa2, 0x7a, 0xec, 0x67, 0xa9, 0x95,
-	0x1e, 0xba, 0x9d, 0x7b, 0x08, 0xad, 0xe7, 0xda, 0xb0, 0xdf, 0x87, 0xda, 0x4c, 0x6e, 0x02, 0x4b,
-	0x76, 0x52, 0x4a, 0xee, 0x3b, 0xe8, 0x98, 0x51, 0xfe, 0xd3, 0x62, 0xd4, 0x1a, 0x61, 0x1b, 0x8d,
-	0x70, 0x0f, 0x61, 0xf7, 0xb5, 0xda, 0x06, 0xa9, 0x8c, 0x2e, 0x02, 0xcc, 0xc4, 0x0e, 0x55, 0x3c,
-	0x11, 0xdf, 0xee, 0xaf, 0x96, 0x18, 0x54, 0xee, 0xcf, 0xca, 0x95, 0xf8, 0xd1, 0x21, 0x50, 0x75,
-	0x55, 0x8c, 0xba, 0x7a, 0xe5, 0x10, 0xe8, 0x24, 0xd6, 0x55, 0xa2, 0x7d, 0x43, 0xbd, 0x7d, 0xdb,
-	0xb2, 0x7d, 0xba, 0xce, 0x3d, 0x85, 0xb6, 0xaa, 0xd7, 0x63, 0x7e, 0x9a, 0x05, 0x82, 0x31, 0x3e,
-	0xe5, 0xec, 0x32, 0xcd, 0x96, 0x58, 0x49, 0xd5, 0x5b, 0xc9, 0x08, 0x26, 0xcd, 0x67, 0x93, 0x9f,
-	0xca, 0x52, 0xa4, 0xe4, 0xd6, 0xa1, 0x7a, 0x1e, 0xcf, 0xf9, 0xd2, 0x7d, 0x08, 0x55, 0x8f, 0x5e,
-	0x4f, 0x0a, 0xe4, 0xf2, 0xba, 0xef, 0xea, 0x4a, 0xba, 0xca, 0xfd, 0xcd, 0x82, 0x5d, 0x55, 0xc9,
-	0x24, 0x55, 0x04, 0x72, 0xa0, 0x3e, 0x34, 0x41, 0x18, 0xae, 0x41, 0x78, 0x6b, 0x80, 0xf0, 0xf6,
-	0xff, 0x04, 0xe1, 0x17, 0x0b, 0x9a, 0x22, 0x60, 0x7e, 0x46, 0x39, 0x25, 0x0f, 0xc1, 0x8e, 0xe9,
-	0x5c, 0xd1, 0xf8, 0x01, 0x12, 0x6e, 0x75, 0x38, 0x78, 0x4d, 0xe7, 0xe7, 0x09, 0xcf, 0x96, 0x9e,
-	0xb0, 0xe9, 0xfe, 0x00, 0x8d, 0x52, 0x41, 0xf6, 0xc0, 0xfe, 0xc0, 0x96, 0xaa, 0x70, 0xf1, 0x49,
-	0xbe, 0x84, 0xea, 0x15, 0x8d, 0x16, 0x0c, 0x6b, 0x6e, 0x1d, 0x1f, 0x94, 0x3c, 0x17, 0x89, 0xcf,
-	0x0b, 0xce, 0x92, 0x80, 0x05, 0x9e, 0x34, 0x79, 0x52, 0x79, 0x6c, 0xb9, 0xe9, 0x0a, 0x91, 0xf2,
-	0x54, 0xbb, 0xb7, 0xf5, 0xb1, 0x7b, 0x57, 0x6e, 0xbf, 0xb7, 0xbd, 0xe1, 0xde, 0x87, 0xd0, 0xf4,
-	0xd8, 0x3c, 0x5a, 0x8e, 0x92, 0xf7, 0xa9, 0x00, 0x3f, 0x66, 0x79, 0x4e, 0x2f, 0x59, 0x09, 0xbe,
-	0x12, 0xdd, 0x02, 0x3a, 0x63, 0x96, 0x5d, 0x85, 0x3e, 0x7b, 0xc7, 0xb2, 0x5c, 0xfd, 0x88, 0x98,
-	0x66, 0x34, 0xf1, 0x4b, 0x52, 0x2b, 0x49, 0xe8, 0xfd, 0x34, 0x8e, 0x43, 0x5e, 0xb6, 0x49, 0x4a,
-	0xf8, 0x64, 0x2f, 0xc2, 0x28, 0xe0, 0xe2, 0xd1, 0x94, 0x13, 0xb3, 0x56, 0x88, 0xcc, 0x11, 0xcd,
-	0x39, 0xa7, 0x97, 0x6a, 0xe9, 0x95, 0xe2, 0xf1, 0x9f, 0x35, 0xd8, 0xbf, 0x48, 0x03, 0x76, 0x9a,
-	0xc6, 0xf1, 0x62, 0x91, 0x84, 0xbe, 0x7a, 0x9c, 0x8f, 0xa0, 0xa5, 0x2a, 0xc2, 0xd2, 0x01, 0x91,
-	0x45, 0x0a, 0x76, 0xe5, 0x86, 0x30, 0xeb, 0x75, 0xb7, 0xc8, 0x23, 0xd8, 0x3d, 0xbf, 0x62, 0x09,
-	0x1f, 0x25, 0x21, 0x0f, 0x69, 0x34, 0x0c, 0x02, 0xd2, 0x31, 0x5b, 0xdb, 0xed, 0xa8, 0xdd, 0xa2,
-	0x00, 0x71, 0xb7, 0xc8, 0xd7, 0xd0, 0x1c, 0x2f, 0x13, 0x5f, 0xec, 0x4b, 0x46, 0xf6, 0xe4, 0x42,
-	0x5b, 0xef, 0x96, 0x0d, 0x0e, 0xdf, 0x02, 0xc1, 0x2c, 0xc3, 0x20, 0xb8, 0x60, 0xd7, 0x25, 0x79,
-	0xef, 0xa1, 0x9d, 0x3e, 0xee, 0x1b, 0x5c, 0x4f, 0x60, 0x1f, 0x5d, 0x5f, 0x32, 0xae, 0xef, 0x2f,
-	0xfd, 0x6a, 0x37, 0x2a, 0x70, 0xb7, 0xc8, 0x63, 0x95, 0xf1, 0x25, 0xe3, 0xc3, 0x28, 0x52, 0xa3,
-	0x6c, 0x78, 0x11, 0xfc, 0x36, 0x86, 0xdc, 0xdd, 0x3a, 0xb2, 0xc8, 0x77, 0xf0, 0x49, 0x59, 0xab,
-	0xb9, 0x01, 0xee, 0xe2, 0xfc, 0x44, 0xa5, 0x95, 0x2b, 0x6e, 0x53, 0xda, 0x03, 0xdd, 0xb3, 0xdc,
-	0x85, 0xe8, 0xfb, 0x54, 0xf9, 0xca, 0xa1, 0x2f, 0x41, 0x32, 0xa6, 0xa3, 0xdc, 0x08, 0x1b, 0x70,
-	0x1a, 0x40, 0x07, 0xbd, 0xc7, 0x2c, 0x09, 0xe4, 0xae, 0x91, 0x59, 0xf1, 0x7b, 0x83, 0xfd, 0x33,
-	0x78, 0xa0, 0x55, 0x3a, 0x9e, 0xb3, 0x24, 0xa0, 0xd3, 0x88, 0x89, 0x8d, 0x7f, 0x93, 0x36, 0xe6,
-	0x93, 0x80, 0xd5, 0x7e, 0x03, 0x6d, 0xf4, 0xbf, 0x60, 0xd7, 0x88, 0xfc, 0x6d, 0x1d, 0x39, 0xb2,
-	0xc8, 0x09, 0x1c, 0x94, 0xc8, 0xfe, 0x6b, 0x3e, 0xf3, 0xd1, 0x43, 0xb7, 0xaf, 0xa0, 0x7a, 0xc1,
-	0xd6, 0x17, 0xd2, 0xeb, 0x32, 0x9f, 0x51, 0x65, 0xdd, 0x36, 0x01, 0xd4, 0xbd, 0x5a, 0xea, 0x36,
-	0xe2, 0x5c, 0x58, 0x4f, 0x6b, 0xf8, 0xa7, 0xe0, 0xd1, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x0e,
-	0x17, 0x29, 0xeb, 0x26, 0x0c, 0x00, 0x00,
+	// 1192 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0x6d, 0x6b, 0x1b, 0x47,
+	0x10, 0xf6, 0xe9, 0xac, 0xb7, 0x91, 0x25, 0x27, 0x1b, 0x37, 0x39, 0x44, 0x68, 0xc5, 0x51, 0x83,
+	0x52, 0x8a, 0x9a, 0x3a, 0x18, 0xd2, 0x34, 0x84, 0x2a, 0xb6, 0x93, 0x08, 0x1a, 0x07, 0x4e, 0x72,
+	0xd3, 0xaf, 0xab, 0xbb, 0xb5, 0x75, 0xe4, 0x5e, 0xd4, 0xbb, 0x95, 0x7d, 0xfa, 0x5e, 0x0a, 0xfd,
+	0x50, 0xe8, 0xaf, 0xea, 0xdf, 0xe8, 0x5f, 0x29, 0x3b, 0xbb, 0x27, 0xed, 0xc6, 0x6a, 0xec, 0x42,
+	0xbf, 0xed, 0xcc, 0xce, 0xdb, 0x3e, 0x33, 0xcf, 0x9c, 0x04, 0x9d, 0x9c, 0x67, 0x8c, 0xc6, 0x2c,
+	0x1b, 0xcc, 0xb3, 0x94, 0xa7, 0xc4, 0x9e, 0x72, 0xdf, 0xfd, 0xbb, 0x06, 0x9d, 0x97, 0x93, 0xa3,
+	0x49, 0x46, 0x93, 0x9c, 0xfa, 0x3c, 0x4c, 0x13, 0x72, 0x1f, 0x6a, 0x8b, 0x9c, 0x65, 0xa3, 0x63,
+	0xc7, 0xea, 0x59, 0xfd, 0xa6, 0xa7, 0x24, 0x42, 0x60, 0x9b, 0x17, 0xa3, 0x63, 0xa7, 0x82, 0x5a,
+	0x3c, 0x0b, 0x5b, 0x5e, 0xbc, 0xa1, 0xf9, 0xcc, 0xb1, 0xa5, 0xad, 0x94, 0x48, 0x0f, 0x5a, 0xbc,
+	0x78, 0xb7, 0xe0, 0x63, 0x3f, 0x0b, 0xe7, 0xdc, 0xd9, 0xc6, 0x4b, 0x5d, 0x45, 0x1e, 0x42, 0x93,
+	0x17, 0xc3, 0x20, 0xc8, 0x58, 0x9e, 0x3b, 0xd5, 0x9e, 0xdd, 0x6f, 0x7a, 0x6b, 0x05, 0xe9, 0x42,
+	0x83, 0x17, 0x63, 0x4e, 0xf9, 0x22, 0x77, 0x6a, 0x3d, 0xab, 0x5f, 0xf5, 0x56, 0xf2, 0x2a, 0xf6,
+	0x30, 0x4e, 0x17, 0x09, 0x77, 0xea, 0x3d, 0xab, 0x6f, 0x7b, 0xba, 0x4a, 0xc4, 0x9e, 0x46, 0xa9,
+	0xff, 0x61, 0x12, 0xc6, 0xcc, 0x69, 0xe0, 0xfd, 0x5a, 0x21, 0xfc, 0x51, 0x78, 0xc3, 0xc2, 0x8b,
+	0x19, 0x77, 0x9a, 0xd2, 0x5f, 0x53, 0x91, 0x2f, 0xa1, 0xed, 0xa7, 0xc9, 0x79, 0x98, 0xc5, 0x54,
+	0x20, 0x92, 0x3b, 0x80, 0x25, 0x98, 0x4a, 0xb2, 0x07, 0x55, 0x5e, 0xbc, 0x62, 0xcc, 0x69, 0x61,
+	0x04, 0x29, 0x88, 0xe8, 0x31, 0x8b, 0xe7, 0x69, 0x1a, 0x61, 0xf6, 0x1d, 0x19, 0x5d, 0x53, 0x91,
+	0xe7, 0xe2, 0x6d, 0xa3, 0x64, 0xbe, 0xe0, 0xb9, 0xd3, 0xee, 0xd9, 0xfd, 0xd6, 0x41, 0x6f, 0x30,
+	0xe5, 0xfe, 0xc0, 0x6c, 0xc3, 0x40, 0x42, 0x21, 0x5f, 0xe4, 0xad, 0x3c, 0xc8, 0x0b, 0x68, 0x4e,
+	0xc4, 0x53, 0xd1, 0xbd, 0x73, 0x4b, 0xf7, 0xb5, 0x0b, 0x39, 0x82, 0x9d, 0xf7, 0x34, 0x8a, 0x18,
+	0xcf, 0x31, 0xa0, 0xb3, 0x8b, 0x21, 0xbe, 0xd8, 0x14, 0x42, 0xda, 0xbd, 0x4a, 0xb3, 0x49, 0xe1,
+	0x19, 0x4e, 0xe4, 0x04, 0xda, 0x4a, 0x96, 0x61, 0x9d, 0x3b, 0xb7, 0x8b, 0x62, 0x7a, 0x89, 0xe9,
+	0xc9, 0x58, 0xbe, 0x4c, 0x7c, 0xe7, 0x6e, 0xcf, 0xea, 0x37, 0x3c, 0x25, 0x91, 0x3b, 0x60, 0x67,
+	0xd3, 0x73, 0x87, 0xa0, 0x52, 0x1c, 0xbb, 0x3f, 0xc0, 0x8e, 0xfe, 0x20, 0xe2, 0x40, 0x9d, 0xaa,
+	0xd9, 0x91, 0x43, 0x5a, 0x8a, 0x22, 0x26, 0x95, 0x83, 0x51, 0x41, 0xe8, 0x95, 0xd4, 0xbd, 0x82,
+	0x96, 0x56, 0x49, 0x39, 0xe4, 0x61, 0xa0, 0x0f, 0x79, 0x18, 0xe8, 0x81, 0x2b, 0x66, 0xe0, 0xcf,
+	0x01, 0x70, 0xc6, 0x46, 0x49, 0xc0, 0x0a, 0x1c, 0xf7, 0xaa, 0xa7, 0x69, 0xb4, 0xc4, 0xdb, 0x7a,
+	0x62, 0xf7, 0xcf, 0x0a, 0x34, 0x86, 0x41, 0x30, 0x9e, 0xbf, 0x5b, 0xf0, 0x15, 0x87, 0x2c, 0x8d,
+	0x43, 0x0e, 0xd4, 0x65, 0x18, 0x49, 0xad, 0xaa, 0x57, 0x8a, 0x1f, 0x4f, 0xba, 0x7d, 0x7d, 0xd2,
+	0x6f, 0xe6, 0x99, 0xf6, 0xa0, 0xea, 0x35, 0xa4, 0x14, 0xcf, 0x6b, 0x06, 0xcf, 0x75, 0xee, 0xd5,
+	0xaf, 0x73, 0xef, 0x0a, 0x51, 0x94, 0x28, 0x34, 0xf0, 0x5a, 0x57, 0x11, 0x17, 0x76, 0x54, 0x02,
+	0x69, 0xd2, 0x44, 0x13, 0x43, 0xe7, 0xfe, 0x61, 0x41, 0xcd, 0x93, 0xad, 0xde, 0x07, 0x7b, 0x52,
+	0x88, 0x26, 0x8a, 0xf9, 0xb9, 0xb7, 0x61, 0x7e, 0x3c, 0x71, 0x4f, 0xf6, 0xa1, 0x86, 0x00, 0x8a,
+	0xae, 0x08, 0xcb, 0x36, 0x5a, 0x96, 0xb0, 0x7a, 0xea, 0x92, 0x1c, 0x42, 0x0b, 0x4f, 0xc7, 0x2c,
+	0x62, 0x9c, 0x39, 0xb6, 0x16, 0xd5, 0x63, 0xbf, 0x48, 0xad, 0xf4, 0xd0, 0xed, 0xdc, 0x33, 0x68,
+	0xbd, 0xd4, 0xe8, 0x7f, 0x1f, 0x6a, 0x33, 0x3c, 0x61, 0x9b, 0x6c, 0x4f, 0x49, 0xa2, 0x79, 0x33,
+	0xb1, 0xea, 0xd4, 0x02, 0x14, 0x67, 0x01, 0xd6, 0x3c, 0x63, 0x97, 0xda, 0x0a, 0x5c, 0xc9, 0x2e,
+	0x87, 0x8e, 0x99, 0xf5, 0x3f, 0xad, 0x56, 0xad, 0x71, 0xb6, 0xd9, 0xb8, 0x87, 0xd0, 0xcc, 0xe7,
+	0x2c, 0x09, 0x26, 0xc2, 0x45, 0xb6, 0x7c, 0xad, 0x70, 0xf7, 0x61, 0xf7, 0xad, 0xda, 0x36, 0xa9,
+	0xcc, 0xbd, 0x2a, 0xdc, 0x5a, 0x17, 0xee, 0xfe, 0x66, 0x89, 0x45, 0xc0, 0xfd, 0x59, 0xb9, 0x72,
+	0x3f, 0x49, 0x29, 0x55, 0x75, 0xc5, 0xa8, 0xba, 0x57, 0x52, 0x4a, 0xa7, 0x44, 0xeb, 0xbd, 0x39,
+	0x0c, 0x43, 0x7d, 0x18, 0xb6, 0xe5, 0x30, 0xe8, 0x3a, 0xf7, 0x08, 0xda, 0xaa, 0x5e, 0x8f, 0xf9,
+	0x69, 0x16, 0x08, 0x48, 0x7d, 0xca, 0xd9, 0x45, 0x9a, 0x2d, 0xb1, 0x92, 0xaa, 0xb7, 0x92, 0xb1,
+	0x35, 0x34, 0x9f, 0x4d, 0x7e, 0x2e, 0x4b, 0x91, 0x92, 0x5b, 0x87, 0xea, 0x49, 0x3c, 0xe7, 0x4b,
+	0xf7, 0x11, 0x54, 0x3d, 0x7a, 0x35, 0x29, 0x90, 0x19, 0xeb, 0x29, 0x52, 0x4f, 0xd2, 0x55, 0xee,
+	0xef, 0x16, 0xec, 0xaa, 0x4a, 0x26, 0xa9, 0x1a, 0x47, 0x07, 0xea, 0x43, 0x13, 0x84, 0xe1, 0x1a,
+	0x84, 0x33, 0x03, 0x84, 0xb3, 0xff, 0x13, 0x84, 0x5f, 0x2d, 0x68, 0x8a, 0x80, 0xf9, 0x31, 0xe5,
+	0x94, 0x3c, 0x02, 0x3b, 0xa6, 0x73, 0x45, 0x8a, 0x07, 0x38, 0xbe, 0xab, 0xcb, 0xc1, 0x5b, 0x3a,
+	0x3f, 0x49, 0x78, 0xb6, 0xf4, 0x84, 0x4d, 0xf7, 0x47, 0x68, 0x94, 0x0a, 0xb1, 0x36, 0x3f, 0xb0,
+	0xa5, 0x2a, 0x5c, 0x1c, 0xc9, 0x57, 0x50, 0xbd, 0xa4, 0xd1, 0x82, 0x61, 0xcd, 0xad, 0x83, 0xbd,
+	0x92, 0x35, 0x22, 0xf1, 0x49, 0xc1, 0x59, 0x12, 0xb0, 0xc0, 0x93, 0x26, 0xcf, 0x2a, 0x4f, 0x2d,
+	0x37, 0x85, 0xdd, 0x8f, 0x6e, 0xb5, 0x77, 0x5b, 0x9f, 0x7a, 0x77, 0xe5, 0xe6, 0x77, 0xdb, 0x1b,
+	0xde, 0xbd, 0x0f, 0x4d, 0x8f, 0xcd, 0xa3, 0xe5, 0x28, 0x39, 0x4f, 0x05, 0xf8, 0x31, 0xcb, 0x73,
+	0x7a, 0xc1, 0x4a, 0xf0, 0x95, 0xe8, 0x16, 0xd0, 0x19, 0xb3, 0xec, 0x32, 0xf4, 0xd9, 0x4f, 0x2c,
+	0xcb, 0xd5, 0x8f, 0x94, 0x69, 0x46, 0x13, 0xbf, 0x1c, 0x6a, 0x25, 0x09, 0xbd, 0x9f, 0xc6, 0x71,
+	0xc8, 0xcb, 0x36, 0x49, 0x09, 0x7f, 0x12, 0x2c, 0xc2, 0x28, 0xe0, 0xe2, 0xa3, 0x2c, 0xf9, 0xb4,
+	0x56, 0x88, 0xcc, 0x11, 0xcd, 0x39, 0xa7, 0x17, 0x8a, 0x4f, 0xa5, 0x78, 0xf0, 0x57, 0x0d, 0xee,
+	0x9d, 0xa6, 0x01, 0x3b, 0x4a, 0xe3, 0x78, 0xb1, 0x48, 0x42, 0x5f, 0x7d, 0xfc, 0x1f, 0x43, 0x4b,
+	0x55, 0x84, 0xa5, 0x03, 0x22, 0x8b, 0x23, 0xd8, 0x95, 0xfb, 0xc6, 0xac, 0xd7, 0xdd, 0x22, 0x4f,
+	0x60, 0xf7, 0xe4, 0x92, 0x25, 0x7c, 0x94, 0x84, 0x3c, 0xa4, 0xd1, 0x30, 0x08, 0x48, 0xc7, 0x6c,
+	0x6d, 0xb7, 0xa3, 0x36, 0x95, 0x02, 0xc4, 0xdd, 0x22, 0xdf, 0x40, 0x73, 0xbc, 0x4c, 0x7c, 0xb1,
+	0x7d, 0x19, 0xb9, 0x23, 0xd7, 0xe3, 0x7a, 0x53, 0x6d, 0x70, 0xf8, 0x0e, 0x08, 0x66, 0x19, 0x06,
+	0xc1, 0x29, 0xbb, 0x2a, 0x87, 0xf7, 0x2e, 0xda, 0xe9, 0x74, 0xdf, 0xe0, 0x7a, 0x08, 0xf7, 0xd0,
+	0xf5, 0x35, 0xe3, 0xfa, 0x36, 0xd4, 0x9f, 0x76, 0xad, 0x02, 0x77, 0x8b, 0x3c, 0x55, 0x19, 0x5f,
+	0x33, 0x3e, 0x8c, 0x22, 0x45, 0x65, 0xc3, 0x8b, 0xe0, 0xd9, 0x20, 0xb9, 0xbb, 0xf5, 0xd8, 0x22,
+	0xdf, 0xc3, 0x67, 0x65, 0xad, 0xc6, 0xe5, 0xad, 0x9c, 0x9f, 0xa9, 0xb4, 0x72, 0xc5, 0x6d, 0x4a,
+	0xbb, 0xa7, 0x7b, 0x96, 0xbb, 0x10, 0x7d, 0x9f, 0x2b, 0x5f, 0x49, 0xfa, 0x12, 0x24, 0x83, 0x1d,
+	0xe5, 0x46, 0xd8, 0x80, 0xd3, 0x00, 0x3a, 0xe8, 0x3d, 0x66, 0x49, 0x20, 0x77, 0x8d, 0xcc, 0x8a,
+	0xe7, 0x0d, 0xf6, 0x2f, 0xe0, 0x81, 0x56, 0xe9, 0x58, 0x2c, 0x6a, 0x3a, 0x8d, 0x98, 0xf8, 0x1e,
+	0x5c, 0x1f, 0x1b, 0xf3, 0x83, 0x81, 0xd5, 0x7e, 0x0b, 0x6d, 0xf4, 0x3f, 0x65, 0x57, 0x88, 0xfc,
+	0x4d, 0x1d, 0x79, 0x6c, 0x91, 0x43, 0xd8, 0x2b, 0x91, 0xfd, 0xd7, 0x7c, 0xe6, 0x27, 0x14, 0xdd,
+	0xbe, 0x86, 0xea, 0x29, 0x5b, 0x3f, 0x48, 0xaf, 0xcb, 0xfc, 0x28, 0x2b, 0xeb, 0xb6, 0x09, 0xa0,
+	0xee, 0xd5, 0x52, 0xaf, 0x11, 0xf7, 0xc2, 0x7a, 0x5a, 0xc3, 0x3f, 0x1d, 0x4f, 0xfe, 0x19, 0x00,
+	0x12, 0x31, 0xfa, 0x38, 0x86, 0x0c, 0x00, 0x00,
 }
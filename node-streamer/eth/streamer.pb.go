@@ -23,6 +23,10 @@ It has these top-level messages:
 	AddressExtended
 	ReplyInfo
 	ServiceVersion
+	TokenTransfer
+	TokenBalanceRequest
+	Multisig
+	MultisigEvent
 */
 package eth
 
@@ -231,7 +235,9 @@ func (m *ETHTransaction) GetResync() bool {
 }
 
 type BlockHeight struct {
-	Height int64 `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	Height   int64  `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	Hash     string `protobuf:"bytes,2,opt,name=hash" json:"hash,omitempty"`
+	PrevHash string `protobuf:"bytes,3,opt,name=prevHash" json:"prevHash,omitempty"`
 }
 
 func (m *BlockHeight) Reset()                    { *m = BlockHeight{} }
@@ -246,6 +252,20 @@ func (m *BlockHeight) GetHeight() int64 {
 	return 0
 }
 
+func (m *BlockHeight) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *BlockHeight) GetPrevHash() string {
+	if m != nil {
+		return m.PrevHash
+	}
+	return ""
+}
+
 type MempoolToDelete struct {
 	Hash string `protobuf:"bytes,1,opt,name=hash" json:"hash,omitempty"`
 }
@@ -470,6 +490,294 @@ func (m *ServiceVersion) GetLasttag() string {
 	return ""
 }
 
+type TokenTransfer struct {
+	UserID       string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	WalletIndex  int32  `protobuf:"varint,2,opt,name=WalletIndex" json:"WalletIndex,omitempty"`
+	AddressIndex int32  `protobuf:"varint,3,opt,name=AddressIndex" json:"AddressIndex,omitempty"`
+	Hash         string `protobuf:"bytes,4,opt,name=Hash" json:"Hash,omitempty"`
+	Contract     string `protobuf:"bytes,5,opt,name=Contract" json:"Contract,omitempty"`
+	From         string `protobuf:"bytes,6,opt,name=From" json:"From,omitempty"`
+	To           string `protobuf:"bytes,7,opt,name=To" json:"To,omitempty"`
+	Amount       string `protobuf:"bytes,8,opt,name=Amount" json:"Amount,omitempty"`
+	LogIndex     int32  `protobuf:"varint,9,opt,name=LogIndex" json:"LogIndex,omitempty"`
+	Status       int32  `protobuf:"varint,10,opt,name=Status" json:"Status,omitempty"`
+	BlockTime    int64  `protobuf:"varint,11,opt,name=BlockTime" json:"BlockTime,omitempty"`
+	TxpoolTime   int64  `protobuf:"varint,12,opt,name=TxpoolTime" json:"TxpoolTime,omitempty"`
+	BlockHeight  int64  `protobuf:"varint,13,opt,name=BlockHeight" json:"BlockHeight,omitempty"`
+	Resync       bool   `protobuf:"varint,14,opt,name=Resync" json:"Resync,omitempty"`
+}
+
+func (m *TokenTransfer) Reset()                    { *m = TokenTransfer{} }
+func (m *TokenTransfer) String() string            { return proto.CompactTextString(m) }
+func (*TokenTransfer) ProtoMessage()               {}
+func (*TokenTransfer) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *TokenTransfer) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *TokenTransfer) GetWalletIndex() int32 {
+	if m != nil {
+		return m.WalletIndex
+	}
+	return 0
+}
+
+func (m *TokenTransfer) GetAddressIndex() int32 {
+	if m != nil {
+		return m.AddressIndex
+	}
+	return 0
+}
+
+func (m *TokenTransfer) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *TokenTransfer) GetContract() string {
+	if m != nil {
+		return m.Contract
+	}
+	return ""
+}
+
+func (m *TokenTransfer) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *TokenTransfer) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *TokenTransfer) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *TokenTransfer) GetLogIndex() int32 {
+	if m != nil {
+		return m.LogIndex
+	}
+	return 0
+}
+
+func (m *TokenTransfer) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *TokenTransfer) GetBlockTime() int64 {
+	if m != nil {
+		return m.BlockTime
+	}
+	return 0
+}
+
+func (m *TokenTransfer) GetTxpoolTime() int64 {
+	if m != nil {
+		return m.TxpoolTime
+	}
+	return 0
+}
+
+func (m *TokenTransfer) GetBlockHeight() int64 {
+	if m != nil {
+		return m.BlockHeight
+	}
+	return 0
+}
+
+func (m *TokenTransfer) GetResync() bool {
+	if m != nil {
+		return m.Resync
+	}
+	return false
+}
+
+type TokenBalanceRequest struct {
+	Address  string `protobuf:"bytes,1,opt,name=Address" json:"Address,omitempty"`
+	Contract string `protobuf:"bytes,2,opt,name=Contract" json:"Contract,omitempty"`
+}
+
+func (m *TokenBalanceRequest) Reset()                    { *m = TokenBalanceRequest{} }
+func (m *TokenBalanceRequest) String() string            { return proto.CompactTextString(m) }
+func (*TokenBalanceRequest) ProtoMessage()               {}
+func (*TokenBalanceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *TokenBalanceRequest) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *TokenBalanceRequest) GetContract() string {
+	if m != nil {
+		return m.Contract
+	}
+	return ""
+}
+
+type Multisig struct {
+	Contract      string   `protobuf:"bytes,1,opt,name=Contract" json:"Contract,omitempty"`
+	Owners        []string `protobuf:"bytes,2,rep,name=Owners" json:"Owners,omitempty"`
+	Confirmations int32    `protobuf:"varint,3,opt,name=Confirmations" json:"Confirmations,omitempty"`
+}
+
+func (m *Multisig) Reset()                    { *m = Multisig{} }
+func (m *Multisig) String() string            { return proto.CompactTextString(m) }
+func (*Multisig) ProtoMessage()               {}
+func (*Multisig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *Multisig) GetContract() string {
+	if m != nil {
+		return m.Contract
+	}
+	return ""
+}
+
+func (m *Multisig) GetOwners() []string {
+	if m != nil {
+		return m.Owners
+	}
+	return nil
+}
+
+func (m *Multisig) GetConfirmations() int32 {
+	if m != nil {
+		return m.Confirmations
+	}
+	return 0
+}
+
+type MultisigEvent struct {
+	Contract    string `protobuf:"bytes,1,opt,name=Contract" json:"Contract,omitempty"`
+	Event       string `protobuf:"bytes,2,opt,name=Event" json:"Event,omitempty"`
+	TxIndex     int64  `protobuf:"varint,3,opt,name=TxIndex" json:"TxIndex,omitempty"`
+	Owner       string `protobuf:"bytes,4,opt,name=Owner" json:"Owner,omitempty"`
+	To          string `protobuf:"bytes,5,opt,name=To" json:"To,omitempty"`
+	Amount      string `protobuf:"bytes,6,opt,name=Amount" json:"Amount,omitempty"`
+	Required    int32  `protobuf:"varint,7,opt,name=Required" json:"Required,omitempty"`
+	Hash        string `protobuf:"bytes,8,opt,name=Hash" json:"Hash,omitempty"`
+	BlockTime   int64  `protobuf:"varint,9,opt,name=BlockTime" json:"BlockTime,omitempty"`
+	BlockHeight int64  `protobuf:"varint,10,opt,name=BlockHeight" json:"BlockHeight,omitempty"`
+	Resync      bool   `protobuf:"varint,11,opt,name=Resync" json:"Resync,omitempty"`
+}
+
+func (m *MultisigEvent) Reset()                    { *m = MultisigEvent{} }
+func (m *MultisigEvent) String() string            { return proto.CompactTextString(m) }
+func (*MultisigEvent) ProtoMessage()               {}
+func (*MultisigEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *MultisigEvent) GetContract() string {
+	if m != nil {
+		return m.Contract
+	}
+	return ""
+}
+
+func (m *MultisigEvent) GetEvent() string {
+	if m != nil {
+		return m.Event
+	}
+	return ""
+}
+
+func (m *MultisigEvent) GetTxIndex() int64 {
+	if m != nil {
+		return m.TxIndex
+	}
+	return 0
+}
+
+func (m *MultisigEvent) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *MultisigEvent) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *MultisigEvent) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *MultisigEvent) GetRequired() int32 {
+	if m != nil {
+		return m.Required
+	}
+	return 0
+}
+
+func (m *MultisigEvent) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *MultisigEvent) GetBlockTime() int64 {
+	if m != nil {
+		return m.BlockTime
+	}
+	return 0
+}
+
+func (m *MultisigEvent) GetBlockHeight() int64 {
+	if m != nil {
+		return m.BlockHeight
+	}
+	return 0
+}
+
+func (m *MultisigEvent) GetResync() bool {
+	if m != nil {
+		return m.Resync
+	}
+	return false
+}
+
+type MultisigRequest struct {
+	Contract string `protobuf:"bytes,1,opt,name=Contract" json:"Contract,omitempty"`
+}
+
+func (m *MultisigRequest) Reset()                    { *m = MultisigRequest{} }
+func (m *MultisigRequest) String() string            { return proto.CompactTextString(m) }
+func (*MultisigRequest) ProtoMessage()               {}
+func (*MultisigRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *MultisigRequest) GetContract() string {
+	if m != nil {
+		return m.Contract
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Balance)(nil), "eth.Balance")
 	proto.RegisterType((*Nonce)(nil), "eth.Nonce")
@@ -486,6 +794,11 @@ func init() {
 	proto.RegisterType((*AddressExtended)(nil), "eth.AddressExtended")
 	proto.RegisterType((*ReplyInfo)(nil), "eth.ReplyInfo")
 	proto.RegisterType((*ServiceVersion)(nil), "eth.ServiceVersion")
+	proto.RegisterType((*TokenTransfer)(nil), "eth.TokenTransfer")
+	proto.RegisterType((*TokenBalanceRequest)(nil), "eth.TokenBalanceRequest")
+	proto.RegisterType((*Multisig)(nil), "eth.Multisig")
+	proto.RegisterType((*MultisigEvent)(nil), "eth.MultisigEvent")
+	proto.RegisterType((*MultisigRequest)(nil), "eth.MultisigRequest")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -514,6 +827,11 @@ type NodeCommuunicationsClient interface {
 	EventSendRawTx(ctx context.Context, in *RawTx, opts ...grpc.CallOption) (*ReplyInfo, error)
 	NewTx(ctx context.Context, in *Empty, opts ...grpc.CallOption) (NodeCommuunications_NewTxClient, error)
 	SyncState(ctx context.Context, in *BlockHeight, opts ...grpc.CallOption) (*ReplyInfo, error)
+	NewTokenTransfer(ctx context.Context, in *Empty, opts ...grpc.CallOption) (NodeCommuunications_NewTokenTransferClient, error)
+	EventGetTokenBalance(ctx context.Context, in *TokenBalanceRequest, opts ...grpc.CallOption) (*Balance, error)
+	EventAddMultisig(ctx context.Context, in *Multisig, opts ...grpc.CallOption) (*ReplyInfo, error)
+	NewMultisigEvent(ctx context.Context, in *Empty, opts ...grpc.CallOption) (NodeCommuunications_NewMultisigEventClient, error)
+	EventGetMultisig(ctx context.Context, in *MultisigRequest, opts ...grpc.CallOption) (*Multisig, error)
 }
 
 type nodeCommuunicationsClient struct {
@@ -774,6 +1092,97 @@ func (c *nodeCommuunicationsClient) SyncState(ctx context.Context, in *BlockHeig
 	return out, nil
 }
 
+func (c *nodeCommuunicationsClient) NewTokenTransfer(ctx context.Context, in *Empty, opts ...grpc.CallOption) (NodeCommuunications_NewTokenTransferClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_NodeCommuunications_serviceDesc.Streams[5], c.cc, "/eth.NodeCommuunications/NewTokenTransfer", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodeCommuunicationsNewTokenTransferClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NodeCommuunications_NewTokenTransferClient interface {
+	Recv() (*TokenTransfer, error)
+	grpc.ClientStream
+}
+
+type nodeCommuunicationsNewTokenTransferClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodeCommuunicationsNewTokenTransferClient) Recv() (*TokenTransfer, error) {
+	m := new(TokenTransfer)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nodeCommuunicationsClient) EventGetTokenBalance(ctx context.Context, in *TokenBalanceRequest, opts ...grpc.CallOption) (*Balance, error) {
+	out := new(Balance)
+	err := grpc.Invoke(ctx, "/eth.NodeCommuunications/EventGetTokenBalance", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeCommuunicationsClient) EventAddMultisig(ctx context.Context, in *Multisig, opts ...grpc.CallOption) (*ReplyInfo, error) {
+	out := new(ReplyInfo)
+	err := grpc.Invoke(ctx, "/eth.NodeCommuunications/EventAddMultisig", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeCommuunicationsClient) NewMultisigEvent(ctx context.Context, in *Empty, opts ...grpc.CallOption) (NodeCommuunications_NewMultisigEventClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_NodeCommuunications_serviceDesc.Streams[6], c.cc, "/eth.NodeCommuunications/NewMultisigEvent", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodeCommuunicationsNewMultisigEventClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NodeCommuunications_NewMultisigEventClient interface {
+	Recv() (*MultisigEvent, error)
+	grpc.ClientStream
+}
+
+type nodeCommuunicationsNewMultisigEventClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodeCommuunicationsNewMultisigEventClient) Recv() (*MultisigEvent, error) {
+	m := new(MultisigEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nodeCommuunicationsClient) EventGetMultisig(ctx context.Context, in *MultisigRequest, opts ...grpc.CallOption) (*Multisig, error) {
+	out := new(Multisig)
+	err := grpc.Invoke(ctx, "/eth.NodeCommuunications/EventGetMultisig", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for NodeCommuunications service
 
 type NodeCommuunicationsServer interface {
@@ -792,6 +1201,11 @@ type NodeCommuunicationsServer interface {
 	EventSendRawTx(context.Context, *RawTx) (*ReplyInfo, error)
 	NewTx(*Empty, NodeCommuunications_NewTxServer) error
 	SyncState(context.Context, *BlockHeight) (*ReplyInfo, error)
+	NewTokenTransfer(*Empty, NodeCommuunications_NewTokenTransferServer) error
+	EventGetTokenBalance(context.Context, *TokenBalanceRequest) (*Balance, error)
+	EventAddMultisig(context.Context, *Multisig) (*ReplyInfo, error)
+	NewMultisigEvent(*Empty, NodeCommuunications_NewMultisigEventServer) error
+	EventGetMultisig(context.Context, *MultisigRequest) (*Multisig, error)
 }
 
 func RegisterNodeCommuunicationsServer(s *grpc.Server, srv NodeCommuunicationsServer) {
@@ -1083,6 +1497,102 @@ func _NodeCommuunications_SyncState_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NodeCommuunications_NewTokenTransfer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeCommuunicationsServer).NewTokenTransfer(m, &nodeCommuunicationsNewTokenTransferServer{stream})
+}
+
+type NodeCommuunications_NewTokenTransferServer interface {
+	Send(*TokenTransfer) error
+	grpc.ServerStream
+}
+
+type nodeCommuunicationsNewTokenTransferServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodeCommuunicationsNewTokenTransferServer) Send(m *TokenTransfer) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NodeCommuunications_EventGetTokenBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TokenBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeCommuunicationsServer).EventGetTokenBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eth.NodeCommuunications/EventGetTokenBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeCommuunicationsServer).EventGetTokenBalance(ctx, req.(*TokenBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeCommuunications_EventAddMultisig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Multisig)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeCommuunicationsServer).EventAddMultisig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eth.NodeCommuunications/EventAddMultisig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeCommuunicationsServer).EventAddMultisig(ctx, req.(*Multisig))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeCommuunications_NewMultisigEvent_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeCommuunicationsServer).NewMultisigEvent(m, &nodeCommuunicationsNewMultisigEventServer{stream})
+}
+
+type NodeCommuunications_NewMultisigEventServer interface {
+	Send(*MultisigEvent) error
+	grpc.ServerStream
+}
+
+type nodeCommuunicationsNewMultisigEventServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodeCommuunicationsNewMultisigEventServer) Send(m *MultisigEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NodeCommuunications_EventGetMultisig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultisigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeCommuunicationsServer).EventGetMultisig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eth.NodeCommuunications/EventGetMultisig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeCommuunicationsServer).EventGetMultisig(ctx, req.(*MultisigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _NodeCommuunications_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "eth.NodeCommuunications",
 	HandlerType: (*NodeCommuunicationsServer)(nil),
@@ -1127,6 +1637,18 @@ var _NodeCommuunications_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SyncState",
 			Handler:    _NodeCommuunications_SyncState_Handler,
 		},
+		{
+			MethodName: "EventGetTokenBalance",
+			Handler:    _NodeCommuunications_EventGetTokenBalance_Handler,
+		},
+		{
+			MethodName: "EventAddMultisig",
+			Handler:    _NodeCommuunications_EventAddMultisig_Handler,
+		},
+		{
+			MethodName: "EventGetMultisig",
+			Handler:    _NodeCommuunications_EventGetMultisig_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1154,6 +1676,16 @@ var _NodeCommuunications_serviceDesc = grpc.ServiceDesc{
 			Handler:       _NodeCommuunications_NewTx_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "NewTokenTransfer",
+			Handler:       _NodeCommuunications_NewTokenTransfer_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "NewMultisigEvent",
+			Handler:       _NodeCommuunications_NewMultisigEvent_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "streamer.proto",
 }
@@ -1161,63 +1693,82 @@ var _NodeCommuunications_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("streamer.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 918 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0xdd, 0x6e, 0xe3, 0xc4,
-	0x17, 0x8f, 0xeb, 0x26, 0x6d, 0x4e, 0xda, 0xb4, 0xff, 0x69, 0xff, 0x8b, 0x15, 0x2d, 0xa8, 0x1a,
-	0xa9, 0xa8, 0x0b, 0x28, 0x5b, 0xba, 0x42, 0x5a, 0x16, 0xb8, 0xc8, 0x6e, 0x43, 0x5b, 0xb1, 0x1b,
-	0xad, 0x5c, 0xc3, 0x72, 0x3b, 0xb5, 0x0f, 0x89, 0x55, 0xdb, 0x13, 0xd9, 0x93, 0x36, 0xb9, 0x47,
-	0x3c, 0x05, 0x8f, 0xc4, 0x43, 0xa1, 0x39, 0x33, 0x76, 0x9c, 0x36, 0x68, 0xb9, 0xe1, 0xee, 0x7c,
-	0x9f, 0xdf, 0x9c, 0x2f, 0x1b, 0xba, 0x85, 0xca, 0x51, 0xa4, 0x98, 0xf7, 0xa7, 0xb9, 0x54, 0x92,
-	0xb9, 0xa8, 0x26, 0xfc, 0x27, 0xd8, 0x7a, 0x2d, 0x12, 0x91, 0x85, 0xc8, 0xbc, 0x8a, 0xf4, 0x9c,
-	0x23, 0xe7, 0xa4, 0xed, 0x57, 0x9a, 0xcf, 0xa1, 0xfb, 0x1e, 0xb3, 0x28, 0xce, 0xc6, 0xa5, 0xc1,
-	0x06, 0x19, 0x3c, 0x90, 0xf2, 0x4f, 0xa1, 0x39, 0x92, 0xda, 0xe1, 0xd0, 0x12, 0x14, 0xc8, 0xf5,
-	0x0d, 0xc3, 0x9f, 0xc2, 0xf6, 0x85, 0x28, 0xde, 0xe7, 0x71, 0x88, 0x6c, 0x1f, 0xdc, 0x0b, 0x51,
-	0xd8, 0x44, 0x9a, 0xe4, 0x7f, 0xba, 0xd0, 0x1d, 0x06, 0x97, 0x41, 0x2e, 0xb2, 0x42, 0x84, 0x2a,
-	0x96, 0x19, 0x7b, 0x02, 0xad, 0x9f, 0x0b, 0xcc, 0xaf, 0xce, 0xad, 0x9d, 0xe5, 0xd8, 0x11, 0x74,
-	0x3e, 0x88, 0x24, 0x41, 0x75, 0x95, 0x45, 0x38, 0x27, 0x30, 0x4d, 0xbf, 0x2e, 0x62, 0x1c, 0x76,
-	0x06, 0x51, 0x94, 0x63, 0x51, 0x18, 0x13, 0x97, 0x4c, 0x56, 0x64, 0x8c, 0xc1, 0xe6, 0xa5, 0x28,
-	0x26, 0xde, 0x26, 0xc5, 0x26, 0x5a, 0xcb, 0x7e, 0xcc, 0x65, 0xea, 0x35, 0x8d, 0x4c, 0xd3, 0xac,
-	0x0b, 0x1b, 0x81, 0xf4, 0x5a, 0x24, 0xd9, 0x08, 0xa4, 0x46, 0x35, 0x48, 0xe5, 0x2c, 0x53, 0xde,
-	0x96, 0x41, 0x65, 0x38, 0xd6, 0x5b, 0x3e, 0xcf, 0xdb, 0xa6, 0x77, 0x2f, 0x9f, 0x6b, 0x74, 0x6f,
-	0xe3, 0x34, 0x56, 0x5e, 0xbb, 0xd2, 0x11, 0xbf, 0x2c, 0x16, 0x10, 0x48, 0x5b, 0xc2, 0x27, 0xd0,
-	0xba, 0x56, 0x42, 0xcd, 0x0a, 0xaf, 0x43, 0x62, 0xcb, 0xb1, 0xa7, 0xd0, 0x7e, 0x9d, 0xc8, 0xf0,
-	0x36, 0x88, 0x53, 0xf4, 0x76, 0x28, 0xd4, 0x52, 0xc0, 0x3e, 0x03, 0x08, 0xe6, 0x53, 0x29, 0x13,
-	0x52, 0xef, 0x92, 0xba, 0x26, 0xd1, 0x95, 0x23, 0xe3, 0x4b, 0x8c, 0xc7, 0x13, 0xe5, 0x75, 0xc9,
-	0xa0, 0x2e, 0xd2, 0x79, 0x7d, 0x2c, 0x16, 0x59, 0xe8, 0xed, 0x1d, 0x39, 0x27, 0xdb, 0xbe, 0xe5,
-	0xf8, 0x31, 0x3c, 0x34, 0x9b, 0x98, 0x18, 0xa6, 0xc5, 0x96, 0xe3, 0xc7, 0xb0, 0xf7, 0x0e, 0x53,
-	0xca, 0x27, 0xcf, 0x31, 0x41, 0x85, 0xba, 0xa6, 0x13, 0x5d, 0x67, 0xd3, 0x43, 0xa2, 0xf9, 0x1f,
-	0x0e, 0xec, 0x7c, 0x10, 0x2a, 0x9c, 0xd8, 0x8e, 0xe8, 0xe1, 0x13, 0x86, 0x2c, 0x87, 0xcf, 0xb2,
-	0x3a, 0xd3, 0xcc, 0x0c, 0x81, 0x19, 0x3a, 0xcb, 0x3d, 0x1c, 0x02, 0xf7, 0xe3, 0x43, 0xb0, 0xf9,
-	0x78, 0x08, 0xf8, 0x1b, 0xd8, 0xb5, 0x78, 0x7d, 0x0c, 0x65, 0x1e, 0xe9, 0x4e, 0x85, 0x42, 0xe1,
-	0x58, 0xe6, 0x0b, 0x42, 0xd2, 0xf4, 0x2b, 0x9e, 0x1e, 0x2d, 0x8a, 0x49, 0xf0, 0x6b, 0x09, 0xc5,
-	0x70, 0x7c, 0x0b, 0x9a, 0xc3, 0x74, 0xaa, 0x16, 0xfc, 0x19, 0x34, 0x7d, 0x71, 0x1f, 0xcc, 0x35,
-	0x38, 0xb5, 0x1c, 0x64, 0xfb, 0xa4, 0xba, 0x88, 0x7f, 0x09, 0x7b, 0x16, 0x48, 0x20, 0x4d, 0x89,
-	0xff, 0xb9, 0x06, 0xfc, 0x77, 0x07, 0xda, 0x7a, 0xf6, 0x8b, 0x73, 0xa1, 0x04, 0x7b, 0x06, 0x6e,
-	0x2a, 0xa6, 0x9e, 0x73, 0xe4, 0x9e, 0x74, 0xce, 0x3e, 0xe9, 0xa3, 0x9a, 0xf4, 0x2b, 0x65, 0xff,
-	0x9d, 0x98, 0x0e, 0x33, 0x95, 0x2f, 0x7c, 0x6d, 0xd3, 0x7b, 0x0b, 0xdb, 0xa5, 0x40, 0xaf, 0xdc,
-	0x2d, 0x2e, 0xca, 0x95, 0xbb, 0xc5, 0x05, 0xfb, 0x02, 0x9a, 0x77, 0x22, 0x99, 0x99, 0x75, 0xee,
-	0x9c, 0x1d, 0x52, 0x28, 0x8b, 0x6a, 0x38, 0x57, 0x98, 0x45, 0x18, 0xf9, 0xc6, 0xe4, 0xd5, 0xc6,
-	0x4b, 0x87, 0xcb, 0x0a, 0x73, 0xa9, 0xfd, 0x6f, 0x57, 0x94, 0x1f, 0x43, 0xdb, 0xc7, 0x69, 0xb2,
-	0xb8, 0xca, 0x7e, 0x93, 0xba, 0x3c, 0x29, 0x16, 0x85, 0x18, 0x57, 0xf7, 0xc9, 0xb2, 0x7c, 0x0e,
-	0xdd, 0x6b, 0xcc, 0xef, 0xe2, 0x10, 0x7f, 0xc1, 0xbc, 0xb0, 0x97, 0xe3, 0x26, 0x17, 0x59, 0x58,
-	0x4e, 0x9d, 0xe5, 0xb4, 0x3c, 0x94, 0xa9, 0xde, 0x42, 0xdb, 0x41, 0xc3, 0xe9, 0xad, 0xba, 0x99,
-	0xc5, 0x49, 0xa4, 0xf4, 0xda, 0xb8, 0xa4, 0x5a, 0x0a, 0x74, 0xe6, 0x44, 0x14, 0x4a, 0x89, 0xb1,
-	0x3d, 0x16, 0x25, 0x7b, 0xf6, 0x57, 0x0b, 0x0e, 0x46, 0x32, 0xc2, 0x37, 0x32, 0x4d, 0x67, 0xb3,
-	0x2c, 0x0e, 0x85, 0x6e, 0x6e, 0xc1, 0x4e, 0xa1, 0x63, 0x11, 0x11, 0x74, 0xa0, 0xca, 0xd2, 0x8c,
-	0xf4, 0x0e, 0x88, 0x5e, 0xc5, 0xcb, 0x1b, 0xec, 0x39, 0xec, 0x0f, 0xef, 0x30, 0x53, 0x17, 0xa8,
-	0xaa, 0xab, 0x51, 0x77, 0xdb, 0x25, 0xba, 0x54, 0xf1, 0x06, 0x7b, 0x01, 0x7b, 0xe4, 0x70, 0x95,
-	0xc5, 0x2a, 0x16, 0xc9, 0x20, 0x8a, 0x58, 0x77, 0x75, 0x16, 0x7a, 0x86, 0xaf, 0x2a, 0xc8, 0x1b,
-	0xec, 0x5b, 0x60, 0xe4, 0x34, 0x88, 0xa2, 0x11, 0xde, 0x97, 0xcb, 0xf7, 0x3f, 0xb2, 0xab, 0xef,
-	0xe3, 0x1a, 0xd7, 0x6f, 0xe0, 0xa0, 0x04, 0x58, 0x3f, 0x04, 0x75, 0x8c, 0xfb, 0x44, 0xd7, 0xb4,
-	0x94, 0xb1, 0x72, 0x1b, 0x50, 0x68, 0xfb, 0x85, 0xa8, 0xcf, 0x5a, 0xb9, 0x01, 0x3d, 0x13, 0xcc,
-	0x7c, 0x2d, 0x1a, 0xec, 0x07, 0xf8, 0xff, 0xaa, 0x6b, 0xf9, 0x3d, 0x5a, 0xef, 0xbc, 0x63, 0xb2,
-	0xdb, 0x6f, 0x51, 0x83, 0xbd, 0xb4, 0x6f, 0xd5, 0xee, 0x49, 0x62, 0xb7, 0x7c, 0x05, 0x2f, 0x23,
-	0x7a, 0x65, 0xff, 0x79, 0xe3, 0xd4, 0x61, 0xdf, 0xd9, 0xc4, 0x83, 0x28, 0x5a, 0x3d, 0x0e, 0xff,
-	0xc6, 0xf9, 0x95, 0x4d, 0x6b, 0xae, 0xdf, 0xba, 0xb4, 0x87, 0x75, 0xcf, 0xf2, 0x4c, 0x92, 0xef,
-	0xf7, 0xd6, 0xd7, 0xbc, 0xa8, 0x6c, 0xcf, 0xfa, 0xe7, 0x3e, 0xee, 0xd0, 0xd7, 0xb0, 0x4b, 0xde,
-	0x23, 0xbc, 0xa7, 0x1e, 0x7c, 0xac, 0x37, 0xa7, 0x0e, 0xeb, 0x43, 0x97, 0x5c, 0xae, 0x31, 0x8b,
-	0xcc, 0xe5, 0x32, 0x3e, 0x44, 0xaf, 0x49, 0xf1, 0x15, 0x34, 0x47, 0xb8, 0x34, 0xab, 0x4f, 0xf4,
-	0xea, 0xb7, 0x9b, 0xa2, 0x3f, 0x87, 0xf6, 0xf5, 0x22, 0x0b, 0xf5, 0x97, 0x0b, 0xd9, 0x23, 0x00,
-	0x8f, 0xc3, 0xdf, 0xb4, 0xe8, 0xcf, 0xe4, 0xc5, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x48, 0xa4,
-	0xc1, 0x7d, 0xab, 0x08, 0x00, 0x00,
+	// 1223 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x57, 0x5d, 0x6f, 0xdb, 0x36,
+	0x17, 0xb6, 0xad, 0xda, 0xb1, 0x8f, 0x63, 0x3b, 0x2f, 0xe3, 0xb7, 0x13, 0x8c, 0x6e, 0x08, 0x88,
+	0x75, 0x68, 0xf7, 0x91, 0x76, 0x09, 0x36, 0xb4, 0xdd, 0x06, 0xcc, 0x4d, 0xb2, 0x24, 0x68, 0x92,
+	0x15, 0x8a, 0xbb, 0xee, 0x96, 0x91, 0x18, 0x5b, 0x88, 0x24, 0x7a, 0x12, 0x9d, 0xd8, 0xf7, 0xdb,
+	0x7e, 0xc5, 0x6e, 0xf6, 0x07, 0xf7, 0x1b, 0x06, 0x1e, 0x52, 0xb2, 0x64, 0x3b, 0xc9, 0x6e, 0x86,
+	0xdd, 0xf1, 0x39, 0x3c, 0x87, 0xe7, 0xf0, 0x7c, 0x3c, 0x94, 0xa0, 0x9d, 0xc8, 0x98, 0xb3, 0x90,
+	0xc7, 0xdb, 0xe3, 0x58, 0x48, 0x41, 0x2c, 0x2e, 0x47, 0xf4, 0x0d, 0xac, 0xbd, 0x66, 0x01, 0x8b,
+	0x5c, 0x4e, 0xec, 0x6c, 0x69, 0x97, 0xb7, 0xca, 0x4f, 0x1a, 0x4e, 0xb6, 0xf3, 0x09, 0xb4, 0xdf,
+	0xf2, 0xc8, 0xf3, 0xa3, 0x61, 0xaa, 0x50, 0x41, 0x85, 0x05, 0x29, 0xfd, 0x10, 0xaa, 0x67, 0x42,
+	0x19, 0x74, 0xcd, 0x02, 0x0f, 0xb2, 0x1c, 0x0d, 0xe8, 0x23, 0xa8, 0x1f, 0xb2, 0xe4, 0x6d, 0xec,
+	0xbb, 0x9c, 0x6c, 0x80, 0x75, 0xc8, 0x12, 0xe3, 0x48, 0x2d, 0xe9, 0x1f, 0x16, 0xb4, 0x0f, 0x06,
+	0x47, 0x83, 0x98, 0x45, 0x09, 0x73, 0xa5, 0x2f, 0x22, 0xf2, 0x10, 0x6a, 0xef, 0x12, 0x1e, 0x1f,
+	0xef, 0x1b, 0x3d, 0x83, 0xc8, 0x16, 0x34, 0xdf, 0xb3, 0x20, 0xe0, 0xf2, 0x38, 0xf2, 0xf8, 0x14,
+	0x83, 0xa9, 0x3a, 0x79, 0x11, 0xa1, 0xb0, 0xde, 0xf7, 0xbc, 0x98, 0x27, 0x89, 0x56, 0xb1, 0x50,
+	0xa5, 0x20, 0x23, 0x04, 0x1e, 0x1c, 0xb1, 0x64, 0x64, 0x3f, 0xc0, 0xb3, 0x71, 0xad, 0x64, 0x3f,
+	0xc4, 0x22, 0xb4, 0xab, 0x5a, 0xa6, 0xd6, 0xa4, 0x0d, 0x95, 0x81, 0xb0, 0x6b, 0x28, 0xa9, 0x0c,
+	0x84, 0x8a, 0xaa, 0x1f, 0x8a, 0x49, 0x24, 0xed, 0x35, 0x1d, 0x95, 0x46, 0xa4, 0x37, 0xbf, 0x9e,
+	0x5d, 0xc7, 0x7b, 0xcf, 0xaf, 0xab, 0xf7, 0x4e, 0xfc, 0xd0, 0x97, 0x76, 0x23, 0xdb, 0x43, 0x3c,
+	0x4f, 0x16, 0x60, 0x90, 0x1a, 0x28, 0x2f, 0xe7, 0x92, 0xc9, 0x49, 0x62, 0x37, 0x51, 0x6c, 0x10,
+	0x79, 0x04, 0x8d, 0xd7, 0x81, 0x70, 0xaf, 0x06, 0x7e, 0xc8, 0xed, 0x75, 0x3c, 0x6a, 0x2e, 0x20,
+	0x1f, 0x01, 0x0c, 0xa6, 0x63, 0x21, 0x02, 0xdc, 0x6e, 0xe1, 0x76, 0x4e, 0xa2, 0x32, 0x87, 0xca,
+	0x47, 0xdc, 0x1f, 0x8e, 0xa4, 0xdd, 0x46, 0x85, 0xbc, 0x48, 0xf9, 0x75, 0x78, 0x32, 0x8b, 0x5c,
+	0xbb, 0xb3, 0x55, 0x7e, 0x52, 0x77, 0x0c, 0xa2, 0xef, 0x60, 0x51, 0x6d, 0xa4, 0xcf, 0xd0, 0x25,
+	0x36, 0x48, 0x25, 0x70, 0xa4, 0x92, 0xaa, 0x1b, 0x04, 0xd7, 0xea, 0xf2, 0xe3, 0x98, 0x5f, 0x63,
+	0xb2, 0x2d, 0x94, 0x67, 0x98, 0x3e, 0x86, 0xce, 0x29, 0x0f, 0x31, 0x3e, 0xb1, 0xcf, 0x03, 0x2e,
+	0x79, 0x76, 0x44, 0x79, 0x7e, 0x04, 0xfd, 0xbd, 0x0c, 0xeb, 0xef, 0x99, 0x74, 0x47, 0xa6, 0x82,
+	0xaa, 0x59, 0x99, 0x5e, 0xa6, 0xcd, 0x6a, 0xa0, 0x8a, 0x6c, 0xa2, 0x9b, 0x46, 0xc7, 0x60, 0xd0,
+	0x62, 0xd3, 0x58, 0xf7, 0x37, 0xcd, 0x83, 0xe5, 0xa6, 0xa1, 0x7b, 0xd0, 0x32, 0xf1, 0x3a, 0xdc,
+	0x15, 0xb1, 0xa7, 0x2e, 0xe7, 0x32, 0xc9, 0x87, 0x22, 0x9e, 0x61, 0x24, 0x55, 0x27, 0xc3, 0x98,
+	0x24, 0x96, 0x8c, 0x06, 0x3f, 0xa7, 0xa1, 0x68, 0x44, 0xd7, 0xa0, 0x7a, 0x10, 0x8e, 0xe5, 0x8c,
+	0x3e, 0x85, 0xaa, 0xc3, 0x6e, 0x06, 0x53, 0x15, 0x9c, 0x9c, 0x37, 0xbe, 0xb9, 0x52, 0x5e, 0x44,
+	0x3f, 0x83, 0x8e, 0x09, 0x64, 0x20, 0x74, 0x49, 0x6e, 0xcf, 0x01, 0xfd, 0xb5, 0x0c, 0x0d, 0x35,
+	0x2b, 0xc9, 0x3e, 0x93, 0x8c, 0x3c, 0x05, 0x2b, 0x64, 0x63, 0xbb, 0xbc, 0x65, 0x3d, 0x69, 0xee,
+	0x7c, 0xb0, 0xcd, 0xe5, 0x68, 0x3b, 0xdb, 0xdc, 0x3e, 0x65, 0xe3, 0x83, 0x48, 0xc6, 0x33, 0x47,
+	0xe9, 0xf4, 0x4e, 0xa0, 0x9e, 0x0a, 0xd4, 0x88, 0x5e, 0xf1, 0x59, 0x3a, 0xa2, 0x57, 0x7c, 0x46,
+	0x3e, 0x85, 0xea, 0x35, 0x0b, 0x26, 0x7a, 0xfc, 0x9b, 0x3b, 0x5d, 0x3c, 0xca, 0x44, 0x75, 0x30,
+	0x95, 0x3c, 0xf2, 0xb8, 0xe7, 0x68, 0x95, 0x57, 0x95, 0x17, 0x65, 0x2a, 0xa0, 0xb3, 0xb0, 0xfb,
+	0xef, 0x8e, 0x34, 0x7d, 0x0c, 0x0d, 0x87, 0x8f, 0x83, 0xd9, 0x71, 0x74, 0x29, 0x54, 0x7a, 0x42,
+	0x9e, 0x24, 0x6c, 0x98, 0xf1, 0x99, 0x81, 0x74, 0x0a, 0xed, 0x73, 0x1e, 0x5f, 0xfb, 0x2e, 0xff,
+	0x89, 0xc7, 0x89, 0x61, 0x9a, 0x8b, 0x98, 0x45, 0x6e, 0xda, 0x75, 0x06, 0x29, 0xb9, 0x2b, 0x42,
+	0x35, 0xb5, 0xa6, 0x82, 0x1a, 0xa9, 0x29, 0xbc, 0x98, 0xf8, 0x81, 0x27, 0xd5, 0x98, 0xe9, 0x9e,
+	0x9e, 0x0b, 0x94, 0xe7, 0x80, 0x25, 0x52, 0xb2, 0xa1, 0x21, 0x97, 0x14, 0xd2, 0xdf, 0x2c, 0x68,
+	0x0d, 0xc4, 0x15, 0x8f, 0x90, 0xe6, 0x2e, 0x79, 0xfc, 0x1f, 0x70, 0x5c, 0x0f, 0xea, 0x7b, 0x22,
+	0x92, 0x31, 0x73, 0xa5, 0xe1, 0xb9, 0x0c, 0x67, 0xfc, 0x57, 0x5b, 0xe2, 0xbf, 0xb5, 0x15, 0xfc,
+	0x57, 0x5f, 0xe4, 0xbf, 0x13, 0x31, 0xd4, 0xb1, 0x34, 0xf4, 0x24, 0xa4, 0x38, 0xc7, 0x66, 0x70,
+	0x3b, 0x9b, 0x35, 0xef, 0x66, 0xb3, 0xf5, 0xfb, 0xd8, 0xac, 0x75, 0x17, 0x9b, 0xb5, 0x0b, 0x6c,
+	0xf6, 0x06, 0x36, 0xb1, 0x0c, 0xe6, 0xe5, 0x72, 0xf8, 0x2f, 0x13, 0x9e, 0x48, 0x55, 0xb8, 0x7e,
+	0x71, 0xa2, 0x0c, 0x2c, 0x24, 0xad, 0x52, 0x4c, 0x1a, 0xf5, 0xa0, 0x7e, 0x3a, 0x09, 0xa4, 0x9f,
+	0xf8, 0xc3, 0x82, 0x5e, 0x79, 0x21, 0xb9, 0x0f, 0xa1, 0xf6, 0xe3, 0x4d, 0xc4, 0xe3, 0xc4, 0xae,
+	0x6c, 0x59, 0x2a, 0x71, 0x1a, 0x91, 0x8f, 0xa1, 0xb5, 0x27, 0xa2, 0x4b, 0x3f, 0x0e, 0x99, 0x1a,
+	0xf5, 0xc4, 0x54, 0xb2, 0x28, 0xa4, 0x7f, 0x56, 0xa0, 0x95, 0xba, 0x39, 0xb8, 0xe6, 0x3a, 0xe1,
+	0xb7, 0xfa, 0xea, 0x42, 0x15, 0x95, 0x4c, 0xb0, 0x1a, 0xa8, 0xfb, 0x0d, 0xa6, 0xf3, 0x6e, 0xb1,
+	0x9c, 0x14, 0x2a, 0x7d, 0x8c, 0xc6, 0x74, 0x8a, 0x06, 0xa6, 0xf4, 0xd5, 0x15, 0xa5, 0xaf, 0x2d,
+	0x96, 0x5e, 0xa5, 0xd0, 0x8f, 0xb9, 0x87, 0x8d, 0x52, 0x75, 0x32, 0x9c, 0xb5, 0x60, 0x3d, 0xd7,
+	0x82, 0x85, 0xb2, 0x37, 0x16, 0xcb, 0xbe, 0x50, 0x56, 0xb8, 0xab, 0xac, 0xcd, 0x42, 0x59, 0xbf,
+	0x80, 0x4e, 0x9a, 0xa2, 0xb4, 0xa4, 0x77, 0x24, 0x69, 0xe7, 0xaf, 0x3a, 0x6c, 0x9e, 0x09, 0x8f,
+	0xef, 0x89, 0x30, 0x9c, 0x4c, 0x22, 0xdf, 0xd5, 0xa9, 0x26, 0xcf, 0xa1, 0x69, 0xf8, 0x01, 0x89,
+	0x04, 0x90, 0xe7, 0x90, 0xb1, 0x7b, 0x9b, 0xb8, 0x2e, 0xb2, 0x07, 0x2d, 0x91, 0x67, 0xb0, 0x81,
+	0x19, 0x3e, 0xe4, 0x32, 0x7b, 0xf3, 0xf3, 0x66, 0x2d, 0x5c, 0xa7, 0x5b, 0xb4, 0x44, 0x76, 0xa1,
+	0x83, 0x06, 0xc7, 0x91, 0x2f, 0x7d, 0x16, 0xf4, 0x3d, 0x8f, 0xb4, 0x8b, 0xcc, 0xdc, 0xd3, 0x38,
+	0xe3, 0x33, 0x5a, 0x22, 0x2f, 0x81, 0xa0, 0x51, 0xdf, 0xf3, 0xce, 0xf8, 0x4d, 0xda, 0x9a, 0xff,
+	0x43, 0xbd, 0xfc, 0xeb, 0xb8, 0xc2, 0xf4, 0x2b, 0xd8, 0x4c, 0x03, 0xcc, 0x27, 0x32, 0x1f, 0xe3,
+	0x06, 0xae, 0x73, 0xbb, 0xe8, 0x31, 0x33, 0xeb, 0xe3, 0xd1, 0xe6, 0xfb, 0x2e, 0xcf, 0xfc, 0xe9,
+	0x7b, 0xd4, 0xd3, 0x87, 0xa1, 0x06, 0x2d, 0x91, 0xef, 0xe0, 0xff, 0x45, 0xd3, 0xf4, 0x6b, 0x72,
+	0xb5, 0xf1, 0xba, 0xf6, 0xae, 0x75, 0x68, 0x89, 0xbc, 0x00, 0x92, 0x99, 0x07, 0x81, 0x79, 0x73,
+	0x0b, 0xf1, 0x12, 0x5c, 0x17, 0x5e, 0x63, 0x5a, 0x7a, 0x5e, 0x26, 0xdf, 0x18, 0xc7, 0x7d, 0xcf,
+	0x2b, 0x6c, 0xfe, 0x23, 0xe3, 0x57, 0xc6, 0xad, 0xfe, 0x16, 0x59, 0xe5, 0xb6, 0x9b, 0xb7, 0x4c,
+	0x3f, 0x5a, 0xd0, 0xf6, 0x5b, 0x63, 0xab, 0x6f, 0x94, 0x96, 0x67, 0xf5, 0x75, 0x97, 0x2b, 0xf4,
+	0x25, 0xb4, 0xd0, 0xfa, 0x8c, 0xdf, 0x60, 0x0d, 0xee, 0xab, 0xcd, 0xf3, 0x32, 0xd9, 0x86, 0x36,
+	0x9a, 0x9c, 0xf3, 0xc8, 0xd3, 0xdf, 0x11, 0xda, 0x06, 0xd7, 0x2b, 0x5c, 0x7c, 0x0e, 0xd5, 0x33,
+	0x3e, 0x57, 0xcb, 0x77, 0x74, 0xf1, 0xcb, 0x1b, 0x4f, 0x7f, 0x06, 0x8d, 0xf3, 0x59, 0xe4, 0x2a,
+	0xa6, 0xe6, 0x64, 0x29, 0x80, 0x15, 0xc7, 0x7f, 0x0d, 0x1b, 0xea, 0xf8, 0xc2, 0xf3, 0xb6, 0x9c,
+	0xf3, 0xc2, 0x3e, 0x3a, 0xfa, 0x1e, 0xba, 0x69, 0xa9, 0xf3, 0xa4, 0x4c, 0xec, 0xb9, 0x7e, 0x91,
+	0xa7, 0x97, 0x9a, 0x65, 0x17, 0x36, 0xb2, 0x92, 0xa7, 0x4c, 0xac, 0x47, 0x2e, 0x85, 0xb7, 0x86,
+	0x5b, 0xa4, 0xd4, 0x15, 0x2d, 0x92, 0xdf, 0xc7, 0x70, 0x5f, 0xce, 0x67, 0x3d, 0x73, 0xd6, 0x2d,
+	0xe8, 0xa6, 0x61, 0x16, 0x43, 0xa0, 0xa5, 0x8b, 0x1a, 0xfe, 0x79, 0xed, 0xfe, 0x3d, 0x00, 0xd7,
+	0x3a, 0x85, 0x94, 0x8b, 0x0d, 0x00, 0x00,
 }
@@ -0,0 +1,323 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package nodemock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	pb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"google.golang.org/grpc"
+)
+
+const (
+	btcNewTx              = "NewTx"
+	btcNewBlock           = "EventNewBlock"
+	btcAddSpendableOut    = "EventAddSpendableOut"
+	btcDeleteSpendableOut = "EventDeleteSpendableOut"
+	btcAddMempoolRecord   = "EventAddMempoolRecord"
+	btcDeleteMempool      = "EventDeleteMempool"
+	btcResyncAddress      = "ResyncAddress"
+)
+
+// BTC is a fake bitcoin node service
+type BTC struct {
+	server
+	events *hub
+
+	m sync.Mutex
+
+	version     pb.ServiceVersion
+	height      int64
+	sendRawTx   func(rawTx string) string
+	sendRawErr  error
+	mempool     map[string]int32
+	usersData   map[string]*pb.AddressExtended
+	initialAdds int
+	watched     []pb.WatchAddress
+	resynced    []pb.AddressToResync
+	rawTxs      []string
+	syncStates  []int64
+}
+
+// NewBTC returns not started bitcoin node service
+func NewBTC() *BTC {
+	b := &BTC{
+		events: newHub(),
+		version: pb.ServiceVersion{
+			Branch: "mock",
+			Commit: "mock",
+		},
+		sendRawTx: func(rawTx string) string {
+			return "txid:" + rawTx
+		},
+		mempool:   map[string]int32{},
+		usersData: map[string]*pb.AddressExtended{},
+	}
+	b.register = func(g *grpc.Server) {
+		pb.RegisterNodeCommuunicationsServer(g, b)
+	}
+	return b
+}
+
+// Start starts the server on the address, use "127.0.0.1:0" for a random port
+func (b *BTC) Start(addr string) error {
+	return b.start(addr)
+}
+
+// Stop stops the server
+func (b *BTC) Stop() {
+	b.stop()
+}
+
+// Restart stops the server and starts it on the same address with empty users data
+// like a real node service does after restart
+func (b *BTC) Restart() error {
+	addr := b.Addr()
+	b.stop()
+
+	b.m.Lock()
+	b.usersData = map[string]*pb.AddressExtended{}
+	b.m.Unlock()
+
+	return b.start(addr)
+}
+
+// SetHeight sets the chain tip height
+func (b *BTC) SetHeight(height int64) {
+	b.m.Lock()
+	b.height = height
+	b.m.Unlock()
+}
+
+// SetSendRawTx sets reply of EventSendRawTx
+func (b *BTC) SetSendRawTx(f func(rawTx string) string) {
+	b.m.Lock()
+	b.sendRawTx = f
+	b.m.Unlock()
+}
+
+// FailSendRawTx makes EventSendRawTx fail with the error after the tx is received,
+// nil makes it reply again
+func (b *BTC) FailSendRawTx(err error) {
+	b.m.Lock()
+	b.sendRawErr = err
+	b.m.Unlock()
+}
+
+// NewTransaction sends the tx to NewTx stream
+func (b *BTC) NewTransaction(tx *pb.BTCTransaction) {
+	b.events.push(btcNewTx, tx)
+}
+
+// NewBlock moves the chain tip and sends it to EventNewBlock stream
+func (b *BTC) NewBlock(height int64) {
+	b.SetHeight(height)
+	b.events.push(btcNewBlock, &pb.BlockHeight{Height: height})
+}
+
+// ConnectBlock sends the block with its hashes to EventNewBlock stream,
+// connecting a block at the height seen already makes a reorg
+func (b *BTC) ConnectBlock(block *pb.BlockHeight) {
+	b.SetHeight(block.Height)
+	b.events.push(btcNewBlock, block)
+}
+
+// AddSpendableOut sends the output to EventAddSpendableOut stream
+func (b *BTC) AddSpendableOut(out *pb.AddSpOut) {
+	b.events.push(btcAddSpendableOut, out)
+}
+
+// DeleteSpendableOut sends the output to EventDeleteSpendableOut stream
+func (b *BTC) DeleteSpendableOut(out *pb.ReqDeleteSpOut) {
+	b.events.push(btcDeleteSpendableOut, out)
+}
+
+// AddMempoolRecord adds the tx to mempool and sends it to EventAddMempoolRecord stream
+func (b *BTC) AddMempoolRecord(rec *pb.MempoolRecord) {
+	b.m.Lock()
+	b.mempool[rec.HashTX] = rec.Category
+	b.m.Unlock()
+	b.events.push(btcAddMempoolRecord, rec)
+}
+
+// DeleteMempool removes the tx from mempool and sends it to EventDeleteMempool stream
+func (b *BTC) DeleteMempool(hash string) {
+	b.m.Lock()
+	delete(b.mempool, hash)
+	b.m.Unlock()
+	b.events.push(btcDeleteMempool, &pb.MempoolToDelete{Hash: hash})
+}
+
+// Resync sends address history to ResyncAddress stream
+func (b *BTC) Resync(r *pb.Resync) {
+	b.events.push(btcResyncAddress, r)
+}
+
+// UsersData returns addresses received with EventInitialAdd and EventAddNewAddress
+func (b *BTC) UsersData() map[string]*pb.AddressExtended {
+	b.m.Lock()
+	defer b.m.Unlock()
+	ud := map[string]*pb.AddressExtended{}
+	for k, v := range b.usersData {
+		ud[k] = v
+	}
+	return ud
+}
+
+// InitialAdds returns how many times EventInitialAdd was called
+func (b *BTC) InitialAdds() int {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.initialAdds
+}
+
+// Watched returns addresses received with EventAddNewAddress
+func (b *BTC) Watched() []pb.WatchAddress {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return append([]pb.WatchAddress{}, b.watched...)
+}
+
+// Resynced returns addresses received with EventResyncAddress
+func (b *BTC) Resynced() []pb.AddressToResync {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return append([]pb.AddressToResync{}, b.resynced...)
+}
+
+// RawTxs returns transactions received with EventSendRawTx
+func (b *BTC) RawTxs() []string {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return append([]string{}, b.rawTxs...)
+}
+
+// SyncStates returns heights received with SyncState
+func (b *BTC) SyncStates() []int64 {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return append([]int64{}, b.syncStates...)
+}
+
+func (b *BTC) ServiceInfo(ctx context.Context, in *pb.Empty) (*pb.ServiceVersion, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	v := b.version
+	return &v, nil
+}
+
+func (b *BTC) EventInitialAdd(ctx context.Context, in *pb.UsersData) (*pb.ReplyInfo, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.initialAdds++
+	for address, ex := range in.GetMap() {
+		b.usersData[address] = ex
+	}
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (b *BTC) SyncState(ctx context.Context, in *pb.BlockHeight) (*pb.ReplyInfo, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.syncStates = append(b.syncStates, in.GetHeight())
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (b *BTC) EventAddNewAddress(ctx context.Context, in *pb.WatchAddress) (*pb.ReplyInfo, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.watched = append(b.watched, *in)
+	b.usersData[in.GetAddress()] = &pb.AddressExtended{
+		UserID:       in.GetUserID(),
+		WalletIndex:  in.GetWalletIndex(),
+		AddressIndex: in.GetAddressIndex(),
+	}
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (b *BTC) EventGetBlockHeight(ctx context.Context, in *pb.Empty) (*pb.BlockHeight, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return &pb.BlockHeight{Height: b.height}, nil
+}
+
+func (b *BTC) EventResyncAddress(ctx context.Context, in *pb.AddressToResync) (*pb.ReplyInfo, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.resynced = append(b.resynced, *in)
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (b *BTC) EventSendRawTx(ctx context.Context, in *pb.RawTx) (*pb.ReplyInfo, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.rawTxs = append(b.rawTxs, in.GetTransaction())
+	if b.sendRawErr != nil {
+		return nil, b.sendRawErr
+	}
+	return &pb.ReplyInfo{Message: b.sendRawTx(in.GetTransaction())}, nil
+}
+
+// EventGetAllMempool sends the whole mempool and closes the stream
+func (b *BTC) EventGetAllMempool(in *pb.Empty, stream pb.NodeCommuunications_EventGetAllMempoolServer) error {
+	b.m.Lock()
+	recs := make([]*pb.MempoolRecord, 0, len(b.mempool))
+	for hash, category := range b.mempool {
+		recs = append(recs, &pb.MempoolRecord{HashTX: hash, Category: category})
+	}
+	b.m.Unlock()
+
+	for _, rec := range recs {
+		if err := stream.Send(rec); err != nil {
+			return fmt.Errorf("EventGetAllMempool: stream.Send: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+func (b *BTC) EventAddMempoolRecord(in *pb.Empty, stream pb.NodeCommuunications_EventAddMempoolRecordServer) error {
+	return b.events.serve(stream.Context(), btcAddMempoolRecord, func(e interface{}) error {
+		return stream.Send(e.(*pb.MempoolRecord))
+	})
+}
+
+func (b *BTC) EventDeleteMempool(in *pb.Empty, stream pb.NodeCommuunications_EventDeleteMempoolServer) error {
+	return b.events.serve(stream.Context(), btcDeleteMempool, func(e interface{}) error {
+		return stream.Send(e.(*pb.MempoolToDelete))
+	})
+}
+
+func (b *BTC) EventDeleteSpendableOut(in *pb.Empty, stream pb.NodeCommuunications_EventDeleteSpendableOutServer) error {
+	return b.events.serve(stream.Context(), btcDeleteSpendableOut, func(e interface{}) error {
+		return stream.Send(e.(*pb.ReqDeleteSpOut))
+	})
+}
+
+func (b *BTC) EventNewBlock(in *pb.Empty, stream pb.NodeCommuunications_EventNewBlockServer) error {
+	return b.events.serve(stream.Context(), btcNewBlock, func(e interface{}) error {
+		return stream.Send(e.(*pb.BlockHeight))
+	})
+}
+
+func (b *BTC) EventAddSpendableOut(in *pb.Empty, stream pb.NodeCommuunications_EventAddSpendableOutServer) error {
+	return b.events.serve(stream.Context(), btcAddSpendableOut, func(e interface{}) error {
+		return stream.Send(e.(*pb.AddSpOut))
+	})
+}
+
+func (b *BTC) NewTx(in *pb.Empty, stream pb.NodeCommuunications_NewTxServer) error {
+	return b.events.serve(stream.Context(), btcNewTx, func(e interface{}) error {
+		return stream.Send(e.(*pb.BTCTransaction))
+	})
+}
+
+func (b *BTC) ResyncAddress(in *pb.Empty, stream pb.NodeCommuunications_ResyncAddressServer) error {
+	return b.events.serve(stream.Context(), btcResyncAddress, func(e interface{}) error {
+		return stream.Send(e.(*pb.Resync))
+	})
+}
@@ -0,0 +1,442 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package nodemock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	pb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"google.golang.org/grpc"
+)
+
+const (
+	ethNewTx            = "NewTx"
+	ethNewBlock         = "EventNewBlock"
+	ethAddMempoolRecord = "EventAddMempoolRecord"
+	ethDeleteMempool    = "EventDeleteMempool"
+	ethTokenTransfer    = "NewTokenTransfer"
+	ethMultisigEvent    = "NewMultisigEvent"
+)
+
+// ETH is a fake ethereum node service
+type ETH struct {
+	server
+	events *hub
+
+	m sync.Mutex
+
+	version     pb.ServiceVersion
+	height      int64
+	gasPrice    string
+	balances    map[string]pb.Balance
+	balanceErr  error
+	tokens      map[tokenKey]pb.Balance
+	tokenErrs   map[string]error
+	nonces      map[string]int64
+	sendRawTx   func(rawTx string) string
+	mempool     map[string]int32
+	usersData   map[string]*pb.AddressExtended
+	multisigs   map[string]pb.Multisig
+	contracts   map[string]pb.Multisig
+	initialAdds int
+	watched     []pb.WatchAddress
+	resynced    []pb.AddressToResync
+	rawTxs      []string
+	syncStates  []int64
+}
+
+// NewETH returns not started ethereum node service
+func NewETH() *ETH {
+	e := &ETH{
+		events: newHub(),
+		version: pb.ServiceVersion{
+			Branch: "mock",
+			Commit: "mock",
+		},
+		gasPrice:  "1000000000",
+		balances:  map[string]pb.Balance{},
+		tokens:    map[tokenKey]pb.Balance{},
+		tokenErrs: map[string]error{},
+		nonces:    map[string]int64{},
+		sendRawTx: func(rawTx string) string {
+			return "txid:" + rawTx
+		},
+		mempool:   map[string]int32{},
+		usersData: map[string]*pb.AddressExtended{},
+		multisigs: map[string]pb.Multisig{},
+		contracts: map[string]pb.Multisig{},
+	}
+	e.register = func(g *grpc.Server) {
+		pb.RegisterNodeCommuunicationsServer(g, e)
+	}
+	return e
+}
+
+// Start starts the server on the address, use "127.0.0.1:0" for a random port
+func (e *ETH) Start(addr string) error {
+	return e.start(addr)
+}
+
+// Stop stops the server
+func (e *ETH) Stop() {
+	e.stop()
+}
+
+// Restart stops the server and starts it on the same address with empty users data
+// and multisigs like a real node service does after restart
+func (e *ETH) Restart() error {
+	addr := e.Addr()
+	e.stop()
+
+	e.m.Lock()
+	e.usersData = map[string]*pb.AddressExtended{}
+	e.multisigs = map[string]pb.Multisig{}
+	e.m.Unlock()
+
+	return e.start(addr)
+}
+
+// SetHeight sets the chain tip height
+func (e *ETH) SetHeight(height int64) {
+	e.m.Lock()
+	e.height = height
+	e.m.Unlock()
+}
+
+// SetGasPrice sets reply of EventGetGasPrice
+func (e *ETH) SetGasPrice(gas string) {
+	e.m.Lock()
+	e.gasPrice = gas
+	e.m.Unlock()
+}
+
+// SetBalance sets balance and pending balance of the address
+func (e *ETH) SetBalance(address, balance, pending string) {
+	e.m.Lock()
+	e.balances[address] = pb.Balance{
+		Balance:        balance,
+		PendingBalance: pending,
+	}
+	e.m.Unlock()
+}
+
+// FailBalances makes EventGetAdressBalance fail with the error, nil makes it reply again
+func (e *ETH) FailBalances(err error) {
+	e.m.Lock()
+	e.balanceErr = err
+	e.m.Unlock()
+}
+
+type tokenKey struct {
+	address  string
+	contract string
+}
+
+// SetTokenBalance sets balance and pending balance of the address in the token
+func (e *ETH) SetTokenBalance(address, contract, balance, pending string) {
+	e.m.Lock()
+	e.tokens[tokenKey{address, contract}] = pb.Balance{
+		Balance:        balance,
+		PendingBalance: pending,
+	}
+	e.m.Unlock()
+}
+
+// FailTokenBalance makes EventGetTokenBalance of the token fail with the error, nil makes it reply again
+func (e *ETH) FailTokenBalance(contract string, err error) {
+	e.m.Lock()
+	e.tokenErrs[contract] = err
+	e.m.Unlock()
+}
+
+// SetNonce sets nonce of the address
+func (e *ETH) SetNonce(address string, nonce int64) {
+	e.m.Lock()
+	e.nonces[address] = nonce
+	e.m.Unlock()
+}
+
+// SetSendRawTx sets reply of EventSendRawTx
+func (e *ETH) SetSendRawTx(f func(rawTx string) string) {
+	e.m.Lock()
+	e.sendRawTx = f
+	e.m.Unlock()
+}
+
+// NewTransaction sends the tx to NewTx stream
+func (e *ETH) NewTransaction(tx *pb.ETHTransaction) {
+	e.events.push(ethNewTx, tx)
+}
+
+// AddTokenTransfer sends the transfer to NewTokenTransfer stream
+func (e *ETH) AddTokenTransfer(tr *pb.TokenTransfer) {
+	e.events.push(ethTokenTransfer, tr)
+}
+
+// AddMultisigEvent sends the event to NewMultisigEvent stream
+func (e *ETH) AddMultisigEvent(ev *pb.MultisigEvent) {
+	e.events.push(ethMultisigEvent, ev)
+}
+
+// NewBlock moves the chain tip and sends it to EventNewBlock stream
+func (e *ETH) NewBlock(height int64) {
+	e.SetHeight(height)
+	e.events.push(ethNewBlock, &pb.BlockHeight{Height: height})
+}
+
+// ConnectBlock sends the block with its hashes to EventNewBlock stream,
+// connecting a block at the height seen already makes a reorg
+func (e *ETH) ConnectBlock(block *pb.BlockHeight) {
+	e.SetHeight(block.Height)
+	e.events.push(ethNewBlock, block)
+}
+
+// AddMempoolRecord adds the tx to mempool and sends it to EventAddMempoolRecord stream
+func (e *ETH) AddMempoolRecord(rec *pb.MempoolRecord) {
+	e.m.Lock()
+	e.mempool[rec.HashTX] = rec.Category
+	e.m.Unlock()
+	e.events.push(ethAddMempoolRecord, rec)
+}
+
+// DeleteMempool removes the tx from mempool and sends it to EventDeleteMempool stream
+func (e *ETH) DeleteMempool(hash string) {
+	e.m.Lock()
+	delete(e.mempool, hash)
+	e.m.Unlock()
+	e.events.push(ethDeleteMempool, &pb.MempoolToDelete{Hash: hash})
+}
+
+// UsersData returns addresses received with EventInitialAdd and EventAddNewAddress
+func (e *ETH) UsersData() map[string]*pb.AddressExtended {
+	e.m.Lock()
+	defer e.m.Unlock()
+	ud := map[string]*pb.AddressExtended{}
+	for k, v := range e.usersData {
+		ud[k] = v
+	}
+	return ud
+}
+
+// SetMultisigContract sets owners and required confirmations of the multisig contract on the chain
+func (e *ETH) SetMultisigContract(contract string, owners []string, confirmations int32) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.contracts[contract] = pb.Multisig{Contract: contract, Owners: owners, Confirmations: confirmations}
+}
+
+// Multisigs returns contracts received with EventAddMultisig
+func (e *ETH) Multisigs() map[string]pb.Multisig {
+	e.m.Lock()
+	defer e.m.Unlock()
+	ms := map[string]pb.Multisig{}
+	for k, v := range e.multisigs {
+		ms[k] = v
+	}
+	return ms
+}
+
+// InitialAdds returns how many times EventInitialAdd was called
+func (e *ETH) InitialAdds() int {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return e.initialAdds
+}
+
+// Watched returns addresses received with EventAddNewAddress
+func (e *ETH) Watched() []pb.WatchAddress {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return append([]pb.WatchAddress{}, e.watched...)
+}
+
+// Resynced returns addresses received with EventResyncAddress
+func (e *ETH) Resynced() []pb.AddressToResync {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return append([]pb.AddressToResync{}, e.resynced...)
+}
+
+// RawTxs returns transactions received with EventSendRawTx
+func (e *ETH) RawTxs() []string {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return append([]string{}, e.rawTxs...)
+}
+
+// SyncStates returns heights received with SyncState
+func (e *ETH) SyncStates() []int64 {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return append([]int64{}, e.syncStates...)
+}
+
+func (e *ETH) ServiceInfo(ctx context.Context, in *pb.Empty) (*pb.ServiceVersion, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	v := e.version
+	return &v, nil
+}
+
+func (e *ETH) EventGetGasPrice(ctx context.Context, in *pb.Empty) (*pb.GasPrice, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return &pb.GasPrice{Gas: e.gasPrice}, nil
+}
+
+func (e *ETH) EventInitialAdd(ctx context.Context, in *pb.UsersData) (*pb.ReplyInfo, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.initialAdds++
+	for address, ex := range in.GetMap() {
+		e.usersData[address] = ex
+	}
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (e *ETH) SyncState(ctx context.Context, in *pb.BlockHeight) (*pb.ReplyInfo, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.syncStates = append(e.syncStates, in.GetHeight())
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (e *ETH) EventAddNewAddress(ctx context.Context, in *pb.WatchAddress) (*pb.ReplyInfo, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.watched = append(e.watched, *in)
+	e.usersData[in.GetAddress()] = &pb.AddressExtended{
+		UserID:       in.GetUserID(),
+		WalletIndex:  in.GetWalletIndex(),
+		AddressIndex: in.GetAddressIndex(),
+	}
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (e *ETH) EventGetBlockHeight(ctx context.Context, in *pb.Empty) (*pb.BlockHeight, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return &pb.BlockHeight{Height: e.height}, nil
+}
+
+func (e *ETH) EventGetAdressNonce(ctx context.Context, in *pb.AddressToResync) (*pb.Nonce, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return &pb.Nonce{Nonce: e.nonces[in.GetAddress()]}, nil
+}
+
+func (e *ETH) EventGetAdressBalance(ctx context.Context, in *pb.AddressToResync) (*pb.Balance, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	if e.balanceErr != nil {
+		return nil, e.balanceErr
+	}
+	b, ok := e.balances[in.GetAddress()]
+	if !ok {
+		b = pb.Balance{Balance: "0", PendingBalance: "0"}
+	}
+	return &b, nil
+}
+
+func (e *ETH) EventGetTokenBalance(ctx context.Context, in *pb.TokenBalanceRequest) (*pb.Balance, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	if err := e.tokenErrs[in.GetContract()]; err != nil {
+		return nil, err
+	}
+	b, ok := e.tokens[tokenKey{in.GetAddress(), in.GetContract()}]
+	if !ok {
+		b = pb.Balance{Balance: "0", PendingBalance: "0"}
+	}
+	return &b, nil
+}
+
+func (e *ETH) EventAddMultisig(ctx context.Context, in *pb.Multisig) (*pb.ReplyInfo, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.multisigs[in.GetContract()] = *in
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (e *ETH) EventGetMultisig(ctx context.Context, in *pb.MultisigRequest) (*pb.Multisig, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	ms, ok := e.contracts[in.GetContract()]
+	if !ok {
+		// not a multisig contract has no owners
+		ms = pb.Multisig{Contract: in.GetContract()}
+	}
+	return &ms, nil
+}
+
+func (e *ETH) EventResyncAddress(ctx context.Context, in *pb.AddressToResync) (*pb.ReplyInfo, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.resynced = append(e.resynced, *in)
+	return &pb.ReplyInfo{Message: "ok"}, nil
+}
+
+func (e *ETH) EventSendRawTx(ctx context.Context, in *pb.RawTx) (*pb.ReplyInfo, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.rawTxs = append(e.rawTxs, in.GetTransaction())
+	return &pb.ReplyInfo{Message: e.sendRawTx(in.GetTransaction())}, nil
+}
+
+// EventGetAllMempool sends the whole mempool and closes the stream
+func (e *ETH) EventGetAllMempool(in *pb.Empty, stream pb.NodeCommuunications_EventGetAllMempoolServer) error {
+	e.m.Lock()
+	recs := make([]*pb.MempoolRecord, 0, len(e.mempool))
+	for hash, category := range e.mempool {
+		recs = append(recs, &pb.MempoolRecord{HashTX: hash, Category: category})
+	}
+	e.m.Unlock()
+
+	for _, rec := range recs {
+		if err := stream.Send(rec); err != nil {
+			return fmt.Errorf("EventGetAllMempool: stream.Send: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+func (e *ETH) EventAddMempoolRecord(in *pb.Empty, stream pb.NodeCommuunications_EventAddMempoolRecordServer) error {
+	return e.events.serve(stream.Context(), ethAddMempoolRecord, func(ev interface{}) error {
+		return stream.Send(ev.(*pb.MempoolRecord))
+	})
+}
+
+func (e *ETH) EventDeleteMempool(in *pb.Empty, stream pb.NodeCommuunications_EventDeleteMempoolServer) error {
+	return e.events.serve(stream.Context(), ethDeleteMempool, func(ev interface{}) error {
+		return stream.Send(ev.(*pb.MempoolToDelete))
+	})
+}
+
+func (e *ETH) EventNewBlock(in *pb.Empty, stream pb.NodeCommuunications_EventNewBlockServer) error {
+	return e.events.serve(stream.Context(), ethNewBlock, func(ev interface{}) error {
+		return stream.Send(ev.(*pb.BlockHeight))
+	})
+}
+
+func (e *ETH) NewTx(in *pb.Empty, stream pb.NodeCommuunications_NewTxServer) error {
+	return e.events.serve(stream.Context(), ethNewTx, func(ev interface{}) error {
+		return stream.Send(ev.(*pb.ETHTransaction))
+	})
+}
+
+func (e *ETH) NewTokenTransfer(in *pb.Empty, stream pb.NodeCommuunications_NewTokenTransferServer) error {
+	return e.events.serve(stream.Context(), ethTokenTransfer, func(ev interface{}) error {
+		return stream.Send(ev.(*pb.TokenTransfer))
+	})
+}
+
+func (e *ETH) NewMultisigEvent(in *pb.Empty, stream pb.NodeCommuunications_NewMultisigEventServer) error {
+	return e.events.serve(stream.Context(), ethMultisigEvent, func(ev interface{}) error {
+		return stream.Send(ev.(*pb.MultisigEvent))
+	})
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+
+// Package nodemock is an in-process fake of BTC and ETH node services.
+// Tests script node events (new txs, blocks, spendable outputs, mempool)
+// and check what the backend has sent to the node.
+package nodemock
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// events queue size of a single stream
+const queueSize = 1024
+
+// hub keeps scripted events until the backend opens the stream
+type hub struct {
+	m      sync.Mutex
+	queues map[string]chan interface{}
+}
+
+func newHub() *hub {
+	return &hub{
+		queues: map[string]chan interface{}{},
+	}
+}
+
+func (h *hub) queue(name string) chan interface{} {
+	h.m.Lock()
+	defer h.m.Unlock()
+	q, ok := h.queues[name]
+	if !ok {
+		q = make(chan interface{}, queueSize)
+		h.queues[name] = q
+	}
+	return q
+}
+
+func (h *hub) push(name string, event interface{}) {
+	h.queue(name) <- event
+}
+
+// serve sends queued events to the stream until the client goes away
+func (h *hub) serve(ctx context.Context, name string, send func(interface{}) error) error {
+	q := h.queue(name)
+	for {
+		select {
+		case event := <-q:
+			if err := send(event); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// server is a grpc server listening on a local port which can be restarted on the same address
+type server struct {
+	m        sync.Mutex
+	addr     string
+	grpc     *grpc.Server
+	register func(*grpc.Server)
+	tls      *tls.Config
+	// clientCerts are certificates clients presented in TLS handshakes
+	clientCerts []*x509.Certificate
+}
+
+// SetTLS makes the server accept TLS connections only once it's started or restarted
+func (s *server) SetTLS(config *tls.Config) {
+	s.m.Lock()
+	s.tls = config
+	s.m.Unlock()
+}
+
+func (s *server) start(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("start: net.Listen: %s", err.Error())
+	}
+
+	opts := []grpc.ServerOption{}
+	s.m.Lock()
+	if s.tls != nil {
+		config := s.tls.Clone()
+		config.VerifyPeerCertificate = s.recordClientCert
+		opts = append(opts, grpc.Creds(credentials.NewTLS(config)))
+	}
+	s.m.Unlock()
+
+	g := grpc.NewServer(opts...)
+	s.register(g)
+
+	s.m.Lock()
+	s.addr = lis.Addr().String()
+	s.grpc = g
+	s.m.Unlock()
+
+	go g.Serve(lis)
+	return nil
+}
+
+func (s *server) recordClientCert(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return err
+	}
+	s.m.Lock()
+	s.clientCerts = append(s.clientCerts, cert)
+	s.m.Unlock()
+	return nil
+}
+
+// ClientCertificates returns certificates clients presented in TLS handshakes, the latest is the last
+func (s *server) ClientCertificates() []*x509.Certificate {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]*x509.Certificate{}, s.clientCerts...)
+}
+
+func (s *server) stop() {
+	s.m.Lock()
+	g := s.grpc
+	s.grpc = nil
+	s.m.Unlock()
+	if g != nil {
+		g.Stop()
+	}
+}
+
+// Addr returns address the server listens on
+func (s *server) Addr() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.addr
+}
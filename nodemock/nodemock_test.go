@@ -0,0 +1,264 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package nodemock
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Multy-io/Multy-back/chain"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	ethpb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"google.golang.org/grpc"
+)
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func dial(t *testing.T, addr string) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("grpc.Dial: %s", err.Error())
+	}
+	return conn
+}
+
+func TestBTCStreams(t *testing.T) {
+	node := NewBTC()
+	if err := node.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer node.Stop()
+
+	conn := dial(t, node.Addr())
+	defer conn.Close()
+	cli := btcpb.NewNodeCommuunicationsClient(conn)
+	ctx := context.Background()
+
+	// events scripted before the stream is opened are kept
+	node.NewTransaction(&btcpb.BTCTransaction{TxID: "tx1"})
+	node.NewBlock(101)
+
+	txs, err := cli.NewTx(ctx, &btcpb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := txs.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.TxID != "tx1" {
+		t.Errorf("NewTx: got %q, want tx1", tx.TxID)
+	}
+
+	blocks, err := cli.EventNewBlock(ctx, &btcpb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := blocks.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Height != 101 {
+		t.Errorf("EventNewBlock: got %d, want 101", block.Height)
+	}
+
+	height, err := cli.EventGetBlockHeight(ctx, &btcpb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height.Height != 101 {
+		t.Errorf("EventGetBlockHeight: got %d, want 101", height.Height)
+	}
+
+	node.AddMempoolRecord(&btcpb.MempoolRecord{HashTX: "m1", Category: 5})
+	all, err := cli.EventGetAllMempool(ctx, &btcpb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := all.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.HashTX != "m1" || rec.Category != 5 {
+		t.Errorf("EventGetAllMempool: got %v", rec)
+	}
+
+	_, err = cli.EventAddNewAddress(ctx, &btcpb.WatchAddress{Address: "addr1", UserID: "user1", WalletIndex: 2, AddressIndex: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := node.Watched(); len(w) != 1 || w[0].Address != "addr1" {
+		t.Errorf("Watched: got %v", w)
+	}
+	if ud := node.UsersData()["addr1"]; ud == nil || ud.UserID != "user1" || ud.WalletIndex != 2 {
+		t.Errorf("UsersData: got %v", ud)
+	}
+
+	reply, err := cli.EventSendRawTx(ctx, &btcpb.RawTx{Transaction: "raw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Message != "txid:raw" {
+		t.Errorf("EventSendRawTx: got %q", reply.Message)
+	}
+}
+
+func TestETHBalanceAndNonce(t *testing.T) {
+	node := NewETH()
+	if err := node.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer node.Stop()
+
+	conn := dial(t, node.Addr())
+	defer conn.Close()
+	cli := ethpb.NewNodeCommuunicationsClient(conn)
+	ctx := context.Background()
+
+	node.SetBalance("0xabc", "100", "90")
+	node.SetNonce("0xabc", 7)
+
+	balance, err := cli.EventGetAdressBalance(ctx, &ethpb.AddressToResync{Address: "0xabc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Balance != "100" || balance.PendingBalance != "90" {
+		t.Errorf("EventGetAdressBalance: got %v", balance)
+	}
+
+	nonce, err := cli.EventGetAdressNonce(ctx, &ethpb.AddressToResync{Address: "0xabc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce.Nonce != 7 {
+		t.Errorf("EventGetAdressNonce: got %d, want 7", nonce.Nonce)
+	}
+
+	node.NewTransaction(&ethpb.ETHTransaction{Hash: "0x1", To: "0xabc"})
+	txs, err := cli.NewTx(ctx, &ethpb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := txs.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Hash != "0x1" {
+		t.Errorf("NewTx: got %q, want 0x1", tx.Hash)
+	}
+}
+
+// restarted node service gets users data again through supervisor reconnect hook
+// and its mempool is taken again
+func TestSupervisorReconnect(t *testing.T) {
+	node := NewBTC()
+	if err := node.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer node.Stop()
+
+	conn := dial(t, node.Addr())
+	defer conn.Close()
+	cli := btcpb.NewNodeCommuunicationsClient(conn)
+
+	usersData := &btcpb.UsersData{
+		Map: map[string]*btcpb.AddressExtended{
+			"addr1": {UserID: "user1"},
+		},
+	}
+	if _, err := cli.EventInitialAdd(context.Background(), usersData); err != nil {
+		t.Fatal(err)
+	}
+
+	streams := chain.NewSupervisor("btc mock")
+	defer streams.Stop()
+	streams.SetBackoff(chain.Backoff{Min: 10 * time.Millisecond, Max: 100 * time.Millisecond})
+	streams.OnReconnect(func() error {
+		_, err := cli.EventInitialAdd(context.Background(), usersData)
+		return err
+	})
+
+	var received int32
+	streams.Run("NewTx", func(ctx context.Context, alive func()) error {
+		stream, err := cli.NewTx(ctx, &btcpb.Empty{})
+		if err != nil {
+			return err
+		}
+		alive()
+		for {
+			_, err := stream.Recv()
+			if err != nil {
+				return err
+			}
+			alive()
+			atomic.AddInt32(&received, 1)
+		}
+	})
+
+	var snapshots, records int32
+	streams.RunSnapshot("EventGetAllMempool", func(ctx context.Context, alive func()) error {
+		stream, err := cli.EventGetAllMempool(ctx, &btcpb.Empty{})
+		if err != nil {
+			return err
+		}
+		alive()
+		for {
+			_, err := stream.Recv()
+			if err == io.EOF {
+				atomic.AddInt32(&snapshots, 1)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			alive()
+			atomic.AddInt32(&records, 1)
+		}
+	})
+
+	node.AddMempoolRecord(&btcpb.MempoolRecord{HashTX: "mp1"})
+	waitFor(t, "mempool snapshot", func() bool { return atomic.LoadInt32(&snapshots) == 1 })
+
+	node.NewTransaction(&btcpb.BTCTransaction{TxID: "tx1"})
+	waitFor(t, "first tx", func() bool { return atomic.LoadInt32(&received) == 1 })
+
+	if err := node.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "users data after restart", func() bool { return node.InitialAdds() == 2 })
+	if _, ok := node.UsersData()["addr1"]; !ok {
+		t.Errorf("UsersData: addr1 is not restored")
+	}
+
+	node.NewTransaction(&btcpb.BTCTransaction{TxID: "tx2"})
+	waitFor(t, "tx after restart", func() bool { return atomic.LoadInt32(&received) == 2 })
+	// mempool of the restarted node service is taken again
+	waitFor(t, "mempool snapshot after restart", func() bool { return atomic.LoadInt32(&snapshots) >= 2 })
+	if n := atomic.LoadInt32(&records); n < 2 {
+		t.Errorf("mempool records: got %d, want the snapshot taken twice", n)
+	}
+
+	h := streams.Health()
+	if len(h) != 2 || h[1].Name != "NewTx" || h[1].Reconnects == 0 || !h[1].Connected {
+		t.Errorf("Health: got %+v", h)
+	}
+	if !h[0].Finished {
+		t.Errorf("Health: mempool snapshot is not finished: %+v", h[0])
+	}
+}
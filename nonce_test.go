@@ -0,0 +1,100 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestAddressNonces(t *testing.T) {
+	h := newHarness(t,
+		walletUser("nonce-user", currencies.Ether, currencies.ETHTest, "0xnonce"),
+		walletUser("stranger", currencies.Ether, currencies.ETHTest, "0xstranger"),
+	)
+	defer h.Close()
+
+	token := h.login("nonce-user")
+	address := "0xnonce"
+	h.ethTest.SetNonce(address, 5)
+	now := time.Now().Unix()
+	for _, tx := range []store.TransactionETH{
+		{UserID: "nonce-user", Hash: "0xmined", Nonce: 4, GasPrice: 100e9},
+		{UserID: "nonce-user", Hash: "0xfive", Nonce: 5, GasPrice: 100e9},
+		// the receiver has the same tx in its history
+		{UserID: "receiver", Hash: "0xfive", Nonce: 5, GasPrice: 100e9},
+		{UserID: "nonce-user", Hash: "0xcheap", Nonce: 6, GasPrice: 1, To: "0xto", Amount: "1000", GasLimit: 50000},
+		{UserID: "nonce-user", Hash: "0xafter-gap", Nonce: 8, GasPrice: 100e9},
+	} {
+		tx.From = address
+		tx.Status = store.TxStatusAppearedInMempoolOutcoming
+		tx.PoolTime = now
+		if err := h.userStore.SaveEthTransaction(currencies.Ether, currencies.ETHTest, tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := h.do(http.MethodGet, "/api/v1/nonce/60/4/"+address, token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("nonce: %d %s", w.Code, w.Body.String())
+	}
+	resp := struct {
+		Nonces store.AddressNonces `json:"nonces"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	nonces := resp.Nonces
+	if nonces.Confirmed != 5 || nonces.Next != 7 || len(nonces.Gaps) != 1 || nonces.Gaps[0] != 7 {
+		t.Errorf("nonces: confirmed %d next %d gaps %v", nonces.Confirmed, nonces.Next, nonces.Gaps)
+	}
+	if len(nonces.Pending) != 3 {
+		t.Fatalf("pending: %+v", nonces.Pending)
+	}
+
+	five, cheap, afterGap := nonces.Pending[0], nonces.Pending[1], nonces.Pending[2]
+	if five.Stuck != "" || five.SpeedUp != nil {
+		t.Errorf("tx paying well is stuck: %+v", five)
+	}
+	if cheap.Stuck != "low_gas_price" || cheap.SpeedUp == nil || cheap.Cancel == nil {
+		t.Fatalf("cheap tx: %+v", cheap)
+	}
+	if s := cheap.SpeedUp; s.Nonce != 6 || s.To != "0xto" || s.Amount != "1000" || s.GasLimit != 50000 || s.GasPrice <= 1 {
+		t.Errorf("speed up: %+v", s)
+	}
+	if s := cheap.Cancel; s.Nonce != 6 || s.To != address || s.Amount != "0" || s.GasPrice != cheap.SpeedUp.GasPrice {
+		t.Errorf("cancel: %+v", s)
+	}
+	if afterGap.Stuck != "nonce_gap" || afterGap.SpeedUp == nil || afterGap.SpeedUp.GasPrice <= 110e9 {
+		t.Errorf("tx after gap: %+v %+v", afterGap, afterGap.SpeedUp)
+	}
+
+	// checksum cased address is the same address
+	w = h.do(http.MethodGet, "/api/v1/nonce/60/4/0xNoNCe", token, nil)
+	resp.Nonces = store.AddressNonces{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || resp.Nonces.Next != 7 || len(resp.Nonces.Pending) != 3 {
+		t.Errorf("mixed case nonce: %d %s", w.Code, w.Body.String())
+	}
+
+	// pending txs of addresses of other users and unknown ones are not shown
+	for _, other := range []string{"0xstranger", "0xunknown"} {
+		if w := h.do(http.MethodGet, "/api/v1/nonce/60/4/"+other, token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("nonce of %s: got %d, want %d", other, w.Code, http.StatusNotFound)
+		}
+	}
+
+	if w := h.do(http.MethodGet, "/api/v1/nonce/0/1/"+address, token, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("btc nonce: got %d", w.Code)
+	}
+}
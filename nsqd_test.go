@@ -0,0 +1,205 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	nsqFrameResponse = 0
+	nsqFrameError    = 1
+	nsqFrameMessage  = 2
+)
+
+// fakeNSQD speaks just enough of nsqd TCP protocol for go-nsq producers and consumers.
+// Published messages are recorded and delivered to every subscribed channel.
+type fakeNSQD struct {
+	lis net.Listener
+
+	m         sync.Mutex
+	published map[string][][]byte
+	subs      map[string]map[string]*nsqConn // topic -> channel -> conn
+	msgID     int64
+}
+
+type nsqConn struct {
+	wm   sync.Mutex
+	conn net.Conn
+}
+
+func startFakeNSQD() (*fakeNSQD, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("startFakeNSQD: net.Listen: %s", err.Error())
+	}
+	d := &fakeNSQD{
+		lis:       lis,
+		published: map[string][][]byte{},
+		subs:      map[string]map[string]*nsqConn{},
+	}
+	go d.accept()
+	return d, nil
+}
+
+func (d *fakeNSQD) Addr() string {
+	return d.lis.Addr().String()
+}
+
+func (d *fakeNSQD) Close() {
+	d.lis.Close()
+}
+
+// Published returns bodies of all messages published to the topic
+func (d *fakeNSQD) Published(topic string) [][]byte {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return append([][]byte{}, d.published[topic]...)
+}
+
+func (d *fakeNSQD) accept() {
+	for {
+		conn, err := d.lis.Accept()
+		if err != nil {
+			return
+		}
+		go d.serve(&nsqConn{conn: conn})
+	}
+}
+
+func (d *fakeNSQD) serve(c *nsqConn) {
+	defer c.conn.Close()
+	r := bufio.NewReader(c.conn)
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != "  V2" {
+		return
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		params := strings.Split(strings.TrimSpace(line), " ")
+
+		switch params[0] {
+		case "IDENTIFY":
+			if _, err := readBody(r); err != nil {
+				return
+			}
+			c.write(nsqFrameResponse, []byte("OK"))
+		case "SUB":
+			if len(params) < 3 {
+				c.write(nsqFrameError, []byte("E_INVALID"))
+				return
+			}
+			d.subscribe(params[1], params[2], c)
+			c.write(nsqFrameResponse, []byte("OK"))
+		case "PUB", "DPUB":
+			body, err := readBody(r)
+			if err != nil {
+				return
+			}
+			d.publish(params[1], body)
+			c.write(nsqFrameResponse, []byte("OK"))
+		case "MPUB":
+			body, err := readBody(r)
+			if err != nil {
+				return
+			}
+			for _, msg := range splitMPUB(body) {
+				d.publish(params[1], msg)
+			}
+			c.write(nsqFrameResponse, []byte("OK"))
+		case "CLS":
+			c.write(nsqFrameResponse, []byte("CLOSE_WAIT"))
+			return
+		case "RDY", "FIN", "REQ", "TOUCH", "NOP":
+		default:
+			c.write(nsqFrameError, []byte("E_INVALID"))
+			return
+		}
+	}
+}
+
+func (d *fakeNSQD) subscribe(topic, channel string, c *nsqConn) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.subs[topic] == nil {
+		d.subs[topic] = map[string]*nsqConn{}
+	}
+	d.subs[topic][channel] = c
+}
+
+func (d *fakeNSQD) publish(topic string, body []byte) {
+	d.m.Lock()
+	d.published[topic] = append(d.published[topic], body)
+	conns := []*nsqConn{}
+	for _, c := range d.subs[topic] {
+		conns = append(conns, c)
+	}
+	d.msgID++
+	id := d.msgID
+	d.m.Unlock()
+
+	// [timestamp int64][attempts uint16][id 16 bytes][body]
+	msg := &bytes.Buffer{}
+	binary.Write(msg, binary.BigEndian, time.Now().UnixNano())
+	binary.Write(msg, binary.BigEndian, uint16(1))
+	msg.WriteString(fmt.Sprintf("%016x", id))
+	msg.Write(body)
+
+	for _, c := range conns {
+		c.write(nsqFrameMessage, msg.Bytes())
+	}
+}
+
+// write sends [size int32][frame type int32][data]
+func (c *nsqConn) write(frameType int32, data []byte) {
+	c.wm.Lock()
+	defer c.wm.Unlock()
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, int32(len(data)+4))
+	binary.Write(buf, binary.BigEndian, frameType)
+	buf.Write(data)
+	c.conn.Write(buf.Bytes())
+}
+
+func readBody(r io.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	body := make([]byte, size)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
+
+func splitMPUB(body []byte) [][]byte {
+	r := bytes.NewReader(body)
+	var num int32
+	if err := binary.Read(r, binary.BigEndian, &num); err != nil {
+		return nil
+	}
+	msgs := [][]byte{}
+	for i := int32(0); i < num; i++ {
+		msg, err := readBody(r)
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestRebroadcastLostTx(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	token := h.login("lost-user")
+	prevTxID := strings.Repeat("ef", 32)
+	h.addOutput(currencies.Bitcoin, currencies.Test, "lost-user", "lost-address", prevTxID, 100000)
+
+	rawTx := rawBTCTx(t, prevTxID, 99000)
+	w := h.sendTx(token, nil, currencies.Bitcoin, currencies.Test, "lost-change", rawTx)
+	if w.Code != http.StatusOK {
+		t.Fatalf("send: %d %s", w.Code, w.Body.String())
+	}
+	pending, _ := h.userStore.GetOutboundTxs(currencies.Bitcoin, currencies.Test, store.OutboundTxPending)
+	if len(pending) != 1 || pending[0].RawTx != rawTx || pending[0].UserID != "lost-user" {
+		t.Fatalf("outbound txs: %+v", pending)
+	}
+	txID := pending[0].TxID
+
+	rebroadcaster := h.multy.rebroadcaster
+	now := time.Now()
+	rebroadcaster.Check(now)
+	if n := len(h.btcTest.RawTxs()); n != 1 {
+		t.Errorf("tx sent again within the window: %d sends", n)
+	}
+
+	// the node keeps the tx in mempool, nothing to do
+	h.btcTest.AddMempoolRecord(&btcpb.MempoolRecord{Category: 10, HashTX: txID})
+	h.waitFor("tx in mempool", func() bool { return h.multy.BTC.Fees(currencies.Test).Mempool().Has(txID) })
+	now = now.Add(2 * rebroadcaster.Window())
+	rebroadcaster.Check(now)
+	if n := len(h.btcTest.RawTxs()); n != 1 {
+		t.Errorf("tx seen in mempool is sent again: %d sends", n)
+	}
+
+	// node restart loses the mempool
+	h.btcTest.DeleteMempool(txID)
+	h.waitFor("tx left mempool", func() bool { return !h.multy.BTC.Fees(currencies.Test).Mempool().Has(txID) })
+	for i := 0; i < 2; i++ {
+		now = now.Add(rebroadcaster.Window())
+		rebroadcaster.Check(now)
+	}
+	if raw := h.btcTest.RawTxs(); len(raw) != 3 || raw[2] != rawTx {
+		t.Errorf("tx is not sent again: %v", raw)
+	}
+	if len(h.nsqd.Published(store.TopicTxDropped)) != 0 {
+		t.Errorf("tx dropped before all attempts")
+	}
+
+	now = now.Add(rebroadcaster.Window())
+	rebroadcaster.Check(now)
+	h.waitFor("dropped notification", func() bool { return len(h.nsqd.Published(store.TopicTxDropped)) == 1 })
+	dropped := store.TxDropped{}
+	if err := json.Unmarshal(h.nsqd.Published(store.TopicTxDropped)[0], &dropped); err != nil {
+		t.Fatal(err)
+	}
+	if dropped.UserID != "lost-user" || dropped.TxID != txID || dropped.NetworkID != currencies.Test {
+		t.Errorf("dropped notification: %+v", dropped)
+	}
+	if pending, _ := h.userStore.GetOutboundTxs(currencies.Bitcoin, currencies.Test, store.OutboundTxPending); len(pending) != 0 {
+		t.Errorf("dropped tx is still pending: %+v", pending)
+	}
+
+	rebroadcaster.Check(now.Add(rebroadcaster.Window()))
+	if n := len(h.btcTest.RawTxs()); n != 3 {
+		t.Errorf("dropped tx is sent again: %d sends", n)
+	}
+}
+
+func TestRebroadcastConflictedTx(t *testing.T) {
+	h := newHarness(t, walletUser("conflict-user", currencies.Bitcoin, currencies.Test, "conflict-address"))
+	defer h.Close()
+
+	token := h.login("conflict-user")
+	txIDs := []string{}
+	for _, prevTxID := range []string{strings.Repeat("c1", 32), strings.Repeat("c2", 32)} {
+		h.addOutput(currencies.Bitcoin, currencies.Test, "conflict-user", "conflict-address", prevTxID, 100000)
+		rawTx := rawBTCTx(t, prevTxID, 99000)
+		w := h.sendTx(token, nil, currencies.Bitcoin, currencies.Test, "conflict-change", rawTx)
+		if w.Code != http.StatusOK {
+			t.Fatalf("send: %d %s", w.Code, w.Body.String())
+		}
+		pending, _ := h.userStore.GetOutboundTxs(currencies.Bitcoin, currencies.Test, store.OutboundTxPending)
+		for _, tx := range pending {
+			if tx.RawTx == rawTx {
+				txIDs = append(txIDs, tx.TxID)
+			}
+		}
+	}
+	if len(txIDs) != 2 {
+		t.Fatalf("outbound txs: %v", txIDs)
+	}
+	conflicted, replaced := txIDs[0], txIDs[1]
+
+	// the node spends the output by the tx which then conflicts with another one
+	h.btcTest.DeleteSpendableOut(&btcpb.ReqDeleteSpOut{
+		UserID:    "conflict-user",
+		TxID:      strings.Repeat("c1", 32),
+		Address:   "conflict-address",
+		SpendTxID: conflicted,
+	})
+	h.waitFor("output spent", func() bool {
+		outs, _ := h.userStore.GetAddressSpendableOutputs("conflict-address", currencies.Bitcoin, currencies.Test)
+		return len(outs) == 1
+	})
+
+	// the other tx is replaced by fee
+	h.btcTest.NewTransaction(&btcpb.BTCTransaction{
+		UserID:    "conflict-user",
+		TxID:      replaced,
+		TxAddress: []string{"conflict-address"},
+		TxStatus:  store.TxStatusAppearedInMempoolOutcoming,
+		WalletsInput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "conflict-user", Address: "conflict-address", Amount: 100000},
+		},
+	})
+	h.btcTest.DeleteSpendableOut(&btcpb.ReqDeleteSpOut{
+		UserID:    "conflict-user",
+		TxID:      strings.Repeat("c2", 32),
+		Address:   "conflict-address",
+		SpendTxID: replaced,
+	})
+	tx := func() store.MultyTX {
+		tx, _ := h.userStore.FindUserTransaction("conflict-user", currencies.Bitcoin, currencies.Test, replaced)
+		return tx
+	}
+	h.waitFor("pending tx", func() bool {
+		spent, _ := h.userStore.GetTxSpentOutputs(currencies.Bitcoin, currencies.Test, "conflict-user", replaced)
+		return tx().TxID == replaced && len(spent) == 1
+	})
+	h.btcTest.DeleteSpendableOut(&btcpb.ReqDeleteSpOut{
+		UserID:    "conflict-user",
+		TxID:      strings.Repeat("c2", 32),
+		Address:   "conflict-address",
+		SpendTxID: "txid-replacement",
+	})
+	h.waitFor("replaced tx", func() bool { return tx().TxStatus == store.TxStatusReplaced })
+	h.btcTest.SetSendRawTx(func(rawTx string) string { return "err: 258: txn-mempool-conflict" })
+
+	rebroadcaster := h.multy.rebroadcaster
+	now := time.Now().Add(rebroadcaster.Window())
+	for i := 0; i < 10; i++ {
+		rebroadcaster.Check(now)
+		now = now.Add(rebroadcaster.Window())
+	}
+
+	if n := len(h.btcTest.RawTxs()); n != 3 {
+		t.Errorf("conflicted or replaced tx is sent again: %d sends", n)
+	}
+	for status, txID := range map[int]string{store.OutboundTxConflicted: conflicted, store.OutboundTxReplaced: replaced} {
+		txs, _ := h.userStore.GetOutboundTxs(currencies.Bitcoin, currencies.Test, status)
+		if len(txs) != 1 || txs[0].TxID != txID {
+			t.Errorf("outbound txs with status %d: %+v", status, txs)
+		}
+	}
+	outs, _ := h.userStore.GetAddressSpendableOutputs("conflict-address", currencies.Bitcoin, currencies.Test)
+	// the replacement still spends input of the replaced tx
+	if len(outs) != 1 || outs[0].TxID != strings.Repeat("c1", 32) {
+		t.Errorf("input of the conflicted tx is not spendable again: %+v", outs)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := len(h.nsqd.Published(store.TopicTxDropped)); n != 0 {
+		t.Errorf("%d dropped notifications of conflicted or replaced txs", n)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestRegtestConfirmsOnNextBlock(t *testing.T) {
+	h := newHarness(t, walletUser("regtest-user", currencies.Bitcoin, currencies.Regtest, "regtest-address"))
+	defer h.Close()
+
+	if _, ok := h.regtest.UsersData()["regtest-address"]; !ok {
+		t.Errorf("regtest UsersData: regtest-address is not sent")
+	}
+	if _, ok := h.btcTest.UsersData()["regtest-address"]; ok {
+		t.Errorf("btc test UsersData: regtest address is sent to testnet")
+	}
+
+	h.regtest.NewTransaction(&btcpb.BTCTransaction{
+		UserID:      "regtest-user",
+		TxID:        "txid-regtest",
+		TxAddress:   []string{"regtest-address"},
+		TxStatus:    store.TxStatusAppearedInBlockIncoming,
+		TxOutAmount: 1000,
+		BlockHeight: 100,
+		TxInputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "regtest-sender", Amount: 2000},
+		},
+		WalletsOutput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "regtest-user", Address: "regtest-address", Amount: 1000},
+		},
+	})
+	h.waitFor("tx in regtest history", func() bool {
+		txs := []store.MultyTX{}
+		h.userStore.GetAllWalletTransactions("regtest-user", currencies.Bitcoin, currencies.Regtest, &txs)
+		return len(txs) == 1
+	})
+
+	// blocks are mined on demand on regtest, the next one confirms the tx
+	h.regtest.NewBlock(101)
+	h.waitForTxNotification("confirmed regtest notification", "txid-regtest", func(n *store.WsTxNotify) bool {
+		return n.TransactionType == store.TxStatusInBlockConfirmedIncoming && n.NetworkID == currencies.Regtest
+	})
+
+	if versions := h.nodeVersions(); versions["btc"]["regtest"].Branch != "mock" {
+		t.Errorf("regtest node version: got %+v", versions)
+	}
+}
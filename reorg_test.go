@@ -0,0 +1,136 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	btcpb "github.com/Multy-io/Multy-back/node-streamer/btc"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestReorgRollsBackTx(t *testing.T) {
+	h := newHarness(t, walletUser("reorg-user", currencies.Bitcoin, currencies.Test, "reorg-address", "reorg-change"))
+	defer h.Close()
+
+	lastBlock := func() string {
+		ls, err := h.userStore.FethLastSyncBlockState(currencies.Test, currencies.Bitcoin)
+		if err != nil || len(ls.Blocks) == 0 {
+			return ""
+		}
+		return ls.Blocks[len(ls.Blocks)-1].Hash
+	}
+	h.btcTest.ConnectBlock(&btcpb.BlockHeight{Height: 99, Hash: "a99", PrevHash: "a98"})
+	h.waitFor("block 99", func() bool { return lastBlock() == "a99" })
+	h.btcTest.ConnectBlock(&btcpb.BlockHeight{Height: 100, Hash: "a100", PrevHash: "a99"})
+	h.waitFor("block 100", func() bool { return lastBlock() == "a100" })
+
+	h.btcTest.AddSpendableOut(&btcpb.AddSpOut{
+		UserID:      "reorg-user",
+		TxID:        "txid-funding",
+		TxOutAmount: 5000,
+		Address:     "reorg-address",
+		TxStatus:    store.TxStatusInBlockConfirmedIncoming,
+	})
+	spendable := func() []store.SpendableOutputs {
+		outs, _ := h.userStore.GetAddressSpendableOutputs("reorg-address", currencies.Bitcoin, currencies.Test)
+		return outs
+	}
+	h.waitFor("funding output", func() bool { return len(spendable()) == 1 })
+
+	h.btcTest.NewTransaction(&btcpb.BTCTransaction{
+		UserID:      "reorg-user",
+		TxID:        "txid-spend",
+		TxAddress:   []string{"reorg-address"},
+		TxStatus:    store.TxStatusAppearedInBlockOutcoming,
+		TxOutAmount: 4000,
+		BlockHeight: 100,
+		TxInputs: []*btcpb.BTCTransaction_AddresAmount{
+			{Address: "reorg-address", Amount: 5000},
+		},
+		WalletsInput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "reorg-user", Address: "reorg-address", Amount: 5000},
+		},
+		WalletsOutput: []*btcpb.BTCTransaction_WalletForTx{
+			{Userid: "reorg-user", Address: "reorg-change", Amount: 1000},
+		},
+	})
+	h.btcTest.DeleteSpendableOut(&btcpb.ReqDeleteSpOut{
+		UserID:    "reorg-user",
+		TxID:      "txid-funding",
+		Address:   "reorg-address",
+		SpendTxID: "txid-spend",
+	})
+	h.btcTest.AddSpendableOut(&btcpb.AddSpOut{
+		UserID:      "reorg-user",
+		TxID:        "txid-spend",
+		TxOutAmount: 1000,
+		Address:     "reorg-change",
+		TxStatus:    store.TxStatusAppearedInBlockIncoming,
+	})
+	balance := func() int64 {
+		var sum int64
+		for _, address := range []string{"reorg-address", "reorg-change"} {
+			outs, _ := h.userStore.GetAddressSpendableOutputs(address, currencies.Bitcoin, currencies.Test)
+			for _, out := range outs {
+				sum += out.TxOutAmount
+			}
+		}
+		return sum
+	}
+	tx := func() store.MultyTX {
+		txs := []store.MultyTX{}
+		h.userStore.GetAllWalletTransactions("reorg-user", currencies.Bitcoin, currencies.Test, &txs)
+		if len(txs) == 0 {
+			return store.MultyTX{}
+		}
+		return txs[0]
+	}
+	h.waitFor("spend in block", func() bool {
+		return tx().TxStatus == store.TxStatusAppearedInBlockOutcoming && len(spendable()) == 0 && balance() == 1000
+	})
+
+	// block 100 is replaced by another one, the spend is back in mempool
+	h.btcTest.ConnectBlock(&btcpb.BlockHeight{Height: 100, Hash: "b100", PrevHash: "a99"})
+	h.waitForTxNotification("reversed notification", "txid-spend", func(n *store.WsTxNotify) bool {
+		return n.TransactionType == store.TxStatusAppearedInMempoolOutcoming
+	})
+	if got := tx(); got.TxStatus != store.TxStatusAppearedInMempoolOutcoming || got.BlockHeight != -1 {
+		t.Errorf("rolled back tx: got status %d height %d, want %d -1", got.TxStatus, got.BlockHeight, store.TxStatusAppearedInMempoolOutcoming)
+	}
+	// the spend is pending, its input must not be spent twice
+	if outs := spendable(); len(outs) != 0 {
+		t.Errorf("input of the pending spend is spendable: got %+v", outs)
+	}
+	if spent, _ := h.userStore.IsSpentOutput(currencies.Bitcoin, currencies.Test, "reorg-user", "txid-funding", "reorg-address"); !spent {
+		t.Errorf("input of the pending spend is not marked spent")
+	}
+	if got := balance(); got != 1000 {
+		t.Errorf("balance with the spend in mempool: got %d, want 1000", got)
+	}
+	if got := lastBlock(); got != "b100" {
+		t.Errorf("last block: got %q, want b100", got)
+	}
+
+	// the spend is mined again on the new chain
+	h.btcTest.ConnectBlock(&btcpb.BlockHeight{Height: 101, Hash: "b101", PrevHash: "b100"})
+	h.waitFor("block 101", func() bool { return lastBlock() == "b101" })
+	h.btcTest.AddSpendableOut(&btcpb.AddSpOut{
+		UserID:      "reorg-user",
+		TxID:        "txid-spend",
+		TxOutAmount: 1000,
+		Address:     "reorg-change",
+		TxStatus:    store.TxStatusAppearedInBlockIncoming,
+	})
+	h.waitFor("change mined again", func() bool {
+		outs, _ := h.userStore.GetAddressSpendableOutputs("reorg-change", currencies.Bitcoin, currencies.Test)
+		return len(outs) == 1 && outs[0].TxStatus == store.TxStatusAppearedInBlockIncoming
+	})
+	if got := balance(); got != 1000 {
+		t.Errorf("balance with the spend mined again: got %d, want 1000", got)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ConfirmedStatus returns status of the tx in block once it is deep enough,
+// other statuses are returned as is
+func ConfirmedStatus(status int) int {
+	switch status {
+	case TxStatusAppearedInBlockIncoming:
+		return TxStatusInBlockConfirmedIncoming
+	case TxStatusAppearedInBlockOutcoming:
+		return TxStatusInBlockConfirmedOutcoming
+	}
+	return status
+}
+
+// Confirmations returns number of blocks on top of the tx block including it,
+// txs in mempool have no confirmations
+func Confirmations(tip, blockHeight int64) int {
+	if blockHeight <= 0 || tip < blockHeight {
+		return 0
+	}
+	return int(tip-blockHeight) + 1
+}
+
+func isInBlockStatus(status int) bool {
+	return status == TxStatusAppearedInBlockIncoming || status == TxStatusAppearedInBlockOutcoming
+}
+
+// keepConfirmedStatus keeps confirmed status of the tx resent by node service
+// which knows nothing about confirmation depth, replaced txs resent from mempool stay replaced
+func keepConfirmedStatus(stored, status int) int {
+	if isInBlockStatus(status) && ConfirmedStatus(status) == stored {
+		return stored
+	}
+	if stored == TxStatusReplaced && IsMempoolStatus(status) {
+		return stored
+	}
+	return status
+}
+
+var inBlockQuery = bson.M{"txstatus": bson.M{"$in": []int{TxStatusAppearedInBlockIncoming, TxStatusAppearedInBlockOutcoming}}}
+
+// GetInBlockTransactions returns utxo txs which are in block but not confirmed yet
+func (mStore *MongoUserStore) GetInBlockTransactions(currencyID, networkID int, txs *[]MultyTX) error {
+	if _, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]; !ok {
+		return nil
+	}
+	return mStore.txsData[chainKey{currencyID, networkID}].Find(inBlockQuery).All(txs)
+}
+
+// GetInBlockEthTransactions returns eth txs which are in block but not confirmed yet
+func (mStore *MongoUserStore) GetInBlockEthTransactions(currencyID, networkID int, txs *[]TransactionETH) error {
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok || !isETH(currencyID, networkID) {
+		return nil
+	}
+	return txsData.Find(inBlockQuery).All(txs)
+}
+
+// UpdateTransactionConfirmations sets status and confirmations of the tx records of any chain
+func (mStore *MongoUserStore) UpdateTransactionConfirmations(currencyID, networkID int, key TxKey, status, confirmations int) error {
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok {
+		return ErrNotFound
+	}
+	sel := bson.M{"txid": key.TxID, "userid": key.UserID}
+	if isETH(currencyID, networkID) {
+		sel = bson.M{"hash": key.TxID, "userid": key.UserID, "walletindex": key.WalletIndex}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"txstatus":      status,
+			"confirmations": confirmations,
+			"lastupdate":    time.Now().Unix(),
+		},
+	}
+	info, err := txsData.UpdateAll(sel, update)
+	if err != nil {
+		return err
+	}
+	if info.Matched == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
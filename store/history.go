@@ -0,0 +1,324 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Directions of txs in TxHistoryFilter
+const (
+	TxDirectionAny = iota
+	TxDirectionIncoming
+	TxDirectionOutgoing
+)
+
+// MaxTxHistoryLimit is the biggest page of the tx history
+const MaxTxHistoryLimit = 500
+
+// ErrBadCursor is returned for a cursor which was not made by the store
+var ErrBadCursor = errors.New("malformed history cursor")
+
+// TxHistoryFilter selects a page of the wallet tx history, newest txs first
+type TxHistoryFilter struct {
+	WalletIndex int
+	Addresses   []string // addresses of utxo wallet
+
+	Since int64 // first seen time bounds, zero means no bound
+	Until int64
+
+	Statuses  []int // TxStatus* values, empty means any
+	Direction int
+	MinAmount *big.Int // in the smallest units of the currency
+
+	Limit  int // zero means whole history
+	Cursor string
+}
+
+var (
+	incomingStatuses = []int{TxStatusAppearedInMempoolIncoming, TxStatusAppearedInBlockIncoming, TxStatusInBlockConfirmedIncoming}
+	outgoingStatuses = []int{TxStatusAppearedInMempoolOutcoming, TxStatusAppearedInBlockOutcoming, TxStatusInBlockConfirmedOutcoming}
+)
+
+// statuses returns statuses matching both status and direction filters,
+// nil is any status
+func (f TxHistoryFilter) statuses() []int {
+	var direction []int
+	switch f.Direction {
+	case TxDirectionIncoming:
+		direction = incomingStatuses
+	case TxDirectionOutgoing:
+		direction = outgoingStatuses
+	default:
+		return f.Statuses
+	}
+	if len(f.Statuses) == 0 {
+		return direction
+	}
+	statuses := []int{}
+	for _, s := range f.Statuses {
+		for _, d := range direction {
+			if s == d {
+				statuses = append(statuses, s)
+			}
+		}
+	}
+	return statuses
+}
+
+// limit returns the page size, zero is no limit
+func (f TxHistoryFilter) limit() int {
+	if f.Limit > MaxTxHistoryLimit {
+		return MaxTxHistoryLimit
+	}
+	if f.Limit < 0 {
+		return 0
+	}
+	return f.Limit
+}
+
+// txCursor points to the last tx of a page
+type txCursor struct {
+	time int64
+	id   bson.ObjectId
+}
+
+func (c txCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.time, 10) + ":" + c.id.Hex()))
+}
+
+func parseTxCursor(cursor string) (*txCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || !bson.IsObjectIdHex(parts[1]) {
+		return nil, ErrBadCursor
+	}
+	t, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	return &txCursor{time: t, id: bson.ObjectIdHex(parts[1])}, nil
+}
+
+// after tells whether the tx is on the pages following the cursor
+func (c *txCursor) after(t int64, id bson.ObjectId) bool {
+	if c == nil {
+		return true
+	}
+	return olderTx(t, id, c.time, c.id)
+}
+
+// olderTx tells whether the first tx goes after the second one in the history
+func olderTx(t int64, id bson.ObjectId, thanTime int64, thanID bson.ObjectId) bool {
+	return t < thanTime || t == thanTime && id < thanID
+}
+
+// historyQuery makes conditions common for history of all chains,
+// returns false if nothing can match the filter
+func (f TxHistoryFilter) historyQuery(userid, timeField string) ([]bson.M, bool, error) {
+	cursor, err := parseTxCursor(f.Cursor)
+	if err != nil {
+		return nil, false, err
+	}
+
+	query := []bson.M{{"userid": userid}}
+	if f.Since != 0 {
+		query = append(query, bson.M{timeField: bson.M{"$gte": f.Since}})
+	}
+	if f.Until != 0 {
+		query = append(query, bson.M{timeField: bson.M{"$lte": f.Until}})
+	}
+	if statuses := f.statuses(); statuses != nil {
+		if len(statuses) == 0 {
+			return nil, false, nil
+		}
+		query = append(query, bson.M{"txstatus": bson.M{"$in": statuses}})
+	}
+	if cursor != nil {
+		query = append(query, bson.M{"$or": []bson.M{
+			{timeField: bson.M{"$lt": cursor.time}},
+			{timeField: cursor.time, "_id": bson.M{"$lt": cursor.id}},
+		}})
+	}
+	return query, true, nil
+}
+
+// utxoHistoryQuery selects txs of the utxo wallet
+func (f TxHistoryFilter) utxoHistoryQuery(userid string) (bson.M, bool, error) {
+	query, ok, err := f.historyQuery(userid, "mempooltime")
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	query = append(query,
+		bson.M{"txaddress.0": bson.M{"$in": f.Addresses}},
+		bson.M{"$or": []bson.M{
+			{"walletsinput.walletindex": f.WalletIndex},
+			{"walletsoutput.walletindex": f.WalletIndex},
+		}},
+	)
+	if f.MinAmount != nil && f.MinAmount.Sign() > 0 {
+		if !f.MinAmount.IsInt64() {
+			return nil, false, nil
+		}
+		query = append(query, bson.M{"txoutamount": bson.M{"$gte": f.MinAmount.Int64()}})
+	}
+	return bson.M{"$and": query}, true, nil
+}
+
+// ethHistoryQuery selects txs of the eth wallet
+func (f TxHistoryFilter) ethHistoryQuery(userid string) (bson.M, bool, error) {
+	query, ok, err := f.historyQuery(userid, "pooltime")
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	query = append(query, bson.M{"walletindex": f.WalletIndex})
+	if f.MinAmount != nil && f.MinAmount.Sign() > 0 {
+		// amounts are decimal strings without leading zeros,
+		// longer string is bigger number and same length ones compare as strings
+		min := f.MinAmount.String()
+		query = append(query, bson.M{"$expr": bson.M{"$or": []bson.M{
+			{"$gt": []interface{}{bson.M{"$strLenCP": "$amount"}, len(min)}},
+			{"$and": []bson.M{
+				{"$eq": []interface{}{bson.M{"$strLenCP": "$amount"}, len(min)}},
+				{"$gte": []interface{}{"$amount", min}},
+			}},
+		}}})
+	}
+	return bson.M{"$and": query}, true, nil
+}
+
+// matchUTXO is utxoHistoryQuery for txs kept in memory
+func (f TxHistoryFilter) matchUTXO(tx MultyTX) bool {
+	if len(tx.TxAddress) == 0 || !containsString(f.Addresses, tx.TxAddress[0]) {
+		return false
+	}
+	inWallet := false
+	for _, w := range append(append([]WalletForTx{}, tx.WalletsInput...), tx.WalletsOutput...) {
+		if w.WalletIndex == f.WalletIndex {
+			inWallet = true
+		}
+	}
+	if !inWallet {
+		return false
+	}
+	if f.MinAmount != nil && f.MinAmount.Sign() > 0 && f.MinAmount.Cmp(big.NewInt(tx.TxOutAmount)) > 0 {
+		return false
+	}
+	return f.match(tx.MempoolTime, tx.TxStatus)
+}
+
+// matchETH is ethHistoryQuery for txs kept in memory
+func (f TxHistoryFilter) matchETH(tx TransactionETH) bool {
+	if tx.WalletIndex != f.WalletIndex {
+		return false
+	}
+	if f.MinAmount != nil && f.MinAmount.Sign() > 0 {
+		amount, ok := new(big.Int).SetString(tx.Amount, 10)
+		if !ok || f.MinAmount.Cmp(amount) > 0 {
+			return false
+		}
+	}
+	return f.match(tx.PoolTime, tx.Status)
+}
+
+func (f TxHistoryFilter) match(t int64, status int) bool {
+	if f.Since != 0 && t < f.Since || f.Until != 0 && t > f.Until {
+		return false
+	}
+	if statuses := f.statuses(); statuses != nil && !containsInt(statuses, status) {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, i int) bool {
+	for _, item := range list {
+		if item == i {
+			return true
+		}
+	}
+	return false
+}
+
+// FindWalletTransactions returns a page of the utxo wallet history and cursor of the next page
+func (mStore *MongoUserStore) FindWalletTransactions(userid string, currencyID, networkID int, filter TxHistoryFilter) ([]MultyTX, string, error) {
+	txs := []MultyTX{}
+	// only utxo chains store MultyTX
+	if _, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]; !ok {
+		return txs, "", nil
+	}
+	query, ok, err := filter.utxoHistoryQuery(userid)
+	if !ok || err != nil {
+		return txs, "", err
+	}
+
+	q := mStore.txsData[chainKey{currencyID, networkID}].Find(query).Sort("-mempooltime", "-_id")
+	limit := filter.limit()
+	if limit > 0 {
+		q = q.Limit(limit + 1)
+	}
+	err = q.All(&txs)
+	if err != nil {
+		return nil, "", fmt.Errorf("FindWalletTransactions: %s", err.Error())
+	}
+	if limit == 0 || len(txs) <= limit {
+		return txs, "", nil
+	}
+	txs = txs[:limit]
+	last := txs[limit-1]
+	return txs, txCursor{last.MempoolTime, last.ID}.String(), nil
+}
+
+// FindWalletEthTransactions returns a page of the eth wallet history and cursor of the next page
+func (mStore *MongoUserStore) FindWalletEthTransactions(userid string, currencyID, networkID int, filter TxHistoryFilter) ([]TransactionETH, string, error) {
+	txs := []TransactionETH{}
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok || !isETH(currencyID, networkID) {
+		return txs, "", nil
+	}
+	query, ok, err := filter.ethHistoryQuery(userid)
+	if !ok || err != nil {
+		return txs, "", err
+	}
+
+	q := txsData.Find(query).Sort("-pooltime", "-_id")
+	limit := filter.limit()
+	if limit > 0 {
+		q = q.Limit(limit + 1)
+	}
+	err = q.All(&txs)
+	if err != nil {
+		return nil, "", fmt.Errorf("FindWalletEthTransactions: %s", err.Error())
+	}
+	if limit == 0 || len(txs) <= limit {
+		return txs, "", nil
+	}
+	txs = txs[:limit]
+	last := txs[limit-1]
+	return txs, txCursor{last.PoolTime, last.ID}.String(), nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+)
+
+func ethHashes(txs []TransactionETH) []string {
+	hashes := []string{}
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	return hashes
+}
+
+func TestFindWalletEthTransactionsPages(t *testing.T) {
+	s := NewMemoryUserStore()
+	txs := []TransactionETH{
+		{Hash: "a", PoolTime: 10, Amount: "100", Status: TxStatusAppearedInBlockIncoming},
+		{Hash: "b", PoolTime: 20, Amount: "2000", Status: TxStatusAppearedInBlockOutcoming},
+		{Hash: "c", PoolTime: 20, Amount: "30", Status: TxStatusAppearedInMempoolIncoming},
+		{Hash: "d", PoolTime: 30, Amount: "1000000000000000000000", Status: TxStatusInBlockConfirmedIncoming},
+		{Hash: "e", PoolTime: 40, Amount: "5", Status: TxStatusAppearedInBlockIncoming, WalletIndex: 1},
+	}
+	for _, tx := range txs {
+		tx.UserID = "user"
+		err := s.SaveEthTransaction(currencies.Ether, currencies.ETHMain, tx)
+		if err != nil {
+			t.Fatalf("SaveEthTransaction: %v", err)
+		}
+	}
+
+	got := []string{}
+	filter := TxHistoryFilter{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("too many pages: %v", got)
+		}
+		page, cursor, err := s.FindWalletEthTransactions("user", currencies.Ether, currencies.ETHMain, filter)
+		if err != nil {
+			t.Fatalf("FindWalletEthTransactions: %v", err)
+		}
+		got = append(got, ethHashes(page)...)
+		if cursor == "" {
+			break
+		}
+		filter.Cursor = cursor
+	}
+	// same time txs go in the order they were saved, newest first
+	want := []string{"d", "c", "b", "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+
+	cases := []struct {
+		name   string
+		filter TxHistoryFilter
+		want   []string
+	}{
+		{"time range", TxHistoryFilter{Since: 15, Until: 25}, []string{"c", "b"}},
+		{"direction", TxHistoryFilter{Direction: TxDirectionIncoming}, []string{"d", "c", "a"}},
+		{"status and direction", TxHistoryFilter{Direction: TxDirectionOutgoing, Statuses: []int{TxStatusAppearedInBlockIncoming}}, []string{}},
+		{"min amount", TxHistoryFilter{MinAmount: big.NewInt(100)}, []string{"d", "b", "a"}},
+		{"other wallet", TxHistoryFilter{WalletIndex: 1}, []string{"e"}},
+	}
+	for _, c := range cases {
+		page, _, err := s.FindWalletEthTransactions("user", currencies.Ether, currencies.ETHMain, c.filter)
+		if err != nil {
+			t.Fatalf("%s: FindWalletEthTransactions: %v", c.name, err)
+		}
+		if got := ethHashes(page); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	_, _, err := s.FindWalletEthTransactions("user", currencies.Ether, currencies.ETHMain, TxHistoryFilter{Cursor: "junk"})
+	if err != ErrBadCursor {
+		t.Errorf("bad cursor err = %v, want ErrBadCursor", err)
+	}
+}
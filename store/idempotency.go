@@ -0,0 +1,63 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ReserveIdempotencyKey marks the request of the user in progress, ErrKeyExists is returned
+// if the key was reserved before. Reservation without response older than the lease is taken
+// over as the request holding it is lost, e.g. the backend crashed while handling it.
+func (mStore *MongoUserStore) ReserveIdempotencyKey(userID, key, requestHash string, lease time.Duration) error {
+	now := time.Now()
+	err := mStore.idempotencyKeys.Insert(IdempotentResponse{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		Created:     now,
+	})
+	if !mgo.IsDup(err) {
+		return err
+	}
+
+	err = mStore.idempotencyKeys.Update(bson.M{
+		"userid":  userID,
+		"key":     key,
+		"code":    0,
+		"created": bson.M{"$lt": now.Add(-lease)},
+	}, bson.M{"$set": bson.M{
+		"requesthash": requestHash,
+		"created":     now,
+	}})
+	if err == mgo.ErrNotFound {
+		return ErrKeyExists
+	}
+	return err
+}
+
+// FindIdempotentResponse returns the record of the key of the user
+func (mStore *MongoUserStore) FindIdempotentResponse(userID, key string) (IdempotentResponse, error) {
+	resp := IdempotentResponse{}
+	err := mStore.idempotencyKeys.Find(bson.M{"userid": userID, "key": key}).One(&resp)
+	return resp, err
+}
+
+// SaveIdempotentResponse keeps the response to the request with the key
+func (mStore *MongoUserStore) SaveIdempotentResponse(userID, key string, code int, body []byte) error {
+	return mStore.idempotencyKeys.Update(bson.M{"userid": userID, "key": key}, bson.M{"$set": bson.M{
+		"code": code,
+		"body": body,
+	}})
+}
+
+// ReleaseIdempotencyKey removes the key, so the request may be repeated
+func (mStore *MongoUserStore) ReleaseIdempotencyKey(userID, key string) error {
+	return mStore.idempotencyKeys.Remove(bson.M{"userid": userID, "key": key})
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyLease(t *testing.T) {
+	s := NewMemoryUserStore()
+	if err := s.ReserveIdempotencyKey("user", "key", "crashed", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReserveIdempotencyKey("user", "key", "retry", time.Minute); err != ErrKeyExists {
+		t.Errorf("key in progress: got %v, want ErrKeyExists", err)
+	}
+
+	// the request holding the key is lost once the lease is over
+	time.Sleep(10 * time.Millisecond)
+	if err := s.ReserveIdempotencyKey("user", "key", "retry", time.Millisecond); err != nil {
+		t.Errorf("key with lease over: got %v, want it taken", err)
+	}
+	if resp, _ := s.FindIdempotentResponse("user", "key"); resp.RequestHash != "retry" {
+		t.Errorf("taken key has request hash %q, want retry", resp.RequestHash)
+	}
+
+	// stored responses are kept regardless of the lease
+	if err := s.SaveIdempotentResponse("user", "key", 200, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := s.ReserveIdempotencyKey("user", "key", "another", time.Millisecond); err != ErrKeyExists {
+		t.Errorf("key with response: got %v, want ErrKeyExists", err)
+	}
+}
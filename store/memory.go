@@ -0,0 +1,1312 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MemoryUserStore keeps everything in process memory.
+// It follows MongoUserStore semantics and is used to run the backend without a database.
+type MemoryUserStore struct {
+	m sync.Mutex
+
+	users     []User
+	addresses map[addressKey]UserAddress
+	rates     []ExchangeRatesRecord
+
+	multyTxs   map[chainKey][]MultyTX
+	ethTxs     map[chainKey][]TransactionETH
+	spendable  map[chainKey][]SpendableOutputs
+	spent      map[chainKey][]SpentOutput
+	lastStates []LastState
+	snapshots  []FeeSnapshot
+	outbound   []OutboundTx
+	responses  map[idempotencyKey]IdempotentResponse
+	tokens     []Token
+	transfers  []TokenTransfer
+	multisigs  []Multisig
+	msigTxs    []MultisigTx
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{
+		addresses: map[addressKey]UserAddress{},
+		multyTxs:  map[chainKey][]MultyTX{},
+		ethTxs:    map[chainKey][]TransactionETH{},
+		spendable: map[chainKey][]SpendableOutputs{},
+		spent:     map[chainKey][]SpentOutput{},
+		responses: map[idempotencyKey]IdempotentResponse{},
+	}
+}
+
+// addressKey identifies address index record, same address may be used on several chains
+type addressKey struct {
+	address    string
+	currencyID int
+	networkID  int
+}
+
+func (s *MemoryUserStore) indexAddress(ua UserAddress) {
+	ua.Address = indexedAddress(ua.CurrencyID, ua.Address)
+	s.addresses[addressKey{ua.Address, ua.CurrencyID, ua.NetworkID}] = ua
+}
+
+func (s *MemoryUserStore) indexUser(user User) {
+	for _, wallet := range user.Wallets {
+		for _, address := range wallet.Adresses {
+			s.indexAddress(userAddress(user.UserID, wallet, address))
+		}
+	}
+}
+
+// utxoCurrencies are bitcoin-like chains memory store keeps outputs of
+var utxoCurrencies = map[int]bool{
+	currencies.Bitcoin:     true,
+	currencies.Litecoin:    true,
+	currencies.Dash:        true,
+	currencies.BitcoinCash: true,
+}
+
+// isUTXO reports whether the chain has spendable outputs collections
+func isUTXO(currencyID, networkID int) bool {
+	return utxoCurrencies[currencyID] && (networkID == currencies.Main || networkID == currencies.Test || networkID == currencies.Regtest)
+}
+
+// evmCurrencies are EVM chains memory store keeps eth transactions of, on any network
+var evmCurrencies = map[int]bool{
+	currencies.Ether:        true,
+	currencies.EtherClassic: true,
+}
+
+// isETH reports whether the chain has eth transactions collection
+func isETH(currencyID, networkID int) bool {
+	return evmCurrencies[currencyID]
+}
+
+// copyUser returns user which shares no slices with the stored one
+func copyUser(user User) User {
+	c := user
+	c.Devices = append([]Device(nil), user.Devices...)
+	c.Wallets = make([]Wallet, len(user.Wallets))
+	for i, wallet := range user.Wallets {
+		c.Wallets[i] = wallet
+		c.Wallets[i].Adresses = append([]Address(nil), wallet.Adresses...)
+	}
+	if user.Wallets == nil {
+		c.Wallets = nil
+	}
+	return c
+}
+
+// userPosition returns index of the first user matched or -1
+func (s *MemoryUserStore) userPosition(match func(User) bool) int {
+	for i, user := range s.users {
+		if match(user) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryUserStore) userByID(userID string) int {
+	return s.userPosition(func(user User) bool {
+		return user.UserID == userID
+	})
+}
+
+func (s *MemoryUserStore) Insert(user User) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.users = append(s.users, copyUser(user))
+	s.indexUser(user)
+	return nil
+}
+
+func (s *MemoryUserStore) UpdateUser(user User) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(user.UserID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.users[i] = copyUser(user)
+	s.indexUser(user)
+	return nil
+}
+
+func (s *MemoryUserStore) FindUserByID(userID string, user *User) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(userID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	*user = copyUser(s.users[i])
+	return nil
+}
+
+func (s *MemoryUserStore) FindUserByToken(token string, user *User) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userPosition(func(u User) bool {
+		for _, device := range u.Devices {
+			if device.JWT == token {
+				return true
+			}
+		}
+		return false
+	})
+	if i < 0 {
+		return ErrNotFound
+	}
+	*user = copyUser(s.users[i])
+	return nil
+}
+
+func (s *MemoryUserStore) FindAddress(currencyID, networkID int, address string, ua *UserAddress) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	found, ok := s.addresses[addressKey{indexedAddress(currencyID, address), currencyID, networkID}]
+	if !ok {
+		return ErrNotFound
+	}
+	*ua = found
+	return nil
+}
+
+func (s *MemoryUserStore) UpdateDeviceToken(userID, oldToken, newToken string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(userID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	for j, device := range s.users[i].Devices {
+		if device.JWT == oldToken {
+			s.users[i].Devices[j].JWT = newToken
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryUserStore) AddWallet(userID string, wallet Wallet) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(userID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	wallet.Adresses = append([]Address(nil), wallet.Adresses...)
+	s.users[i].Wallets = append(s.users[i].Wallets, wallet)
+	for _, address := range wallet.Adresses {
+		s.indexAddress(userAddress(userID, wallet, address))
+	}
+	return nil
+}
+
+func (s *MemoryUserStore) AddAddress(userID string, currencyID, networkID, walletIndex int, address Address) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(userID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	position := walletPosition(s.users[i], currencyID, networkID, walletIndex)
+	if position < 0 {
+		return ErrNotFound
+	}
+	wallet := &s.users[i].Wallets[position]
+	wallet.Adresses = append(wallet.Adresses, address)
+	wallet.LastActionTime = time.Now().Unix()
+	s.indexAddress(userAddress(userID, *wallet, address))
+	return nil
+}
+
+func (s *MemoryUserStore) ChangeWalletName(userID string, currencyID, networkID, walletIndex int, name string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(userID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	position := walletPosition(s.users[i], currencyID, networkID, walletIndex)
+	if position < 0 {
+		return ErrNotFound
+	}
+	s.users[i].Wallets[position].WalletName = name
+	s.users[i].Wallets[position].LastActionTime = time.Now().Unix()
+	return nil
+}
+
+func (s *MemoryUserStore) DeleteWallet(userid string, walletindex, currencyID, networkID int) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(userid)
+	if i < 0 {
+		return ErrNotFound
+	}
+	position := walletPosition(s.users[i], currencyID, networkID, walletindex)
+	if position < 0 {
+		return ErrNotFound
+	}
+	s.users[i].Wallets[position].Status = WalletStatusDeleted
+	s.users[i].Wallets[position].LastActionTime = time.Now().Unix()
+	return nil
+}
+
+func (s *MemoryUserStore) UpdateAddressLastAction(userID, address string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(userID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	w, a := addressPosition(s.users[i], address)
+	if w < 0 {
+		return ErrNotFound
+	}
+	now := time.Now().Unix()
+	wallet := &s.users[i].Wallets[w]
+	wallet.LastActionTime = now
+	wallet.Adresses[a].LastActionTime = now
+	wallet.Status = WalletStatusOK
+	return nil
+}
+
+func (s *MemoryUserStore) FindUserDataChain(CurrencyID, NetworkID int) (map[string]AddressExtended, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	usersData := map[string]AddressExtended{}
+	for _, ua := range s.addresses {
+		if ua.CurrencyID == CurrencyID && ua.NetworkID == NetworkID {
+			usersData[ua.Address] = AddressExtended{
+				UserID:       ua.UserID,
+				WalletIndex:  ua.WalletIndex,
+				AddressIndex: ua.AddressIndex,
+			}
+		}
+	}
+	return usersData, nil
+}
+
+func (s *MemoryUserStore) FethUserAddresses(currencyID, networkID int, userid string, addreses []string) ([]AddressExtended, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := s.userByID(userid)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	return associateAddresses(s.users[i], currencyID, networkID, addreses), nil
+}
+
+func (s *MemoryUserStore) Close() error {
+	return nil
+}
+
+func (s *MemoryUserStore) InsertExchangeRate(eRate ExchangeRates, exchangeStock string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.rates = append(s.rates, ExchangeRatesRecord{
+		Exchanges:     eRate,
+		Timestamp:     time.Now().Unix(),
+		StockExchange: exchangeStock,
+	})
+	return nil
+}
+
+func (s *MemoryUserStore) GetExchangeRatesDay() ([]RatesAPIBitstamp, error) {
+	// not implemented
+	return nil, nil
+}
+
+// latestRate returns the newest record of the stock exchange seen before the time,
+// zero time means no limit
+func (s *MemoryUserStore) latestRate(stockExchange string, before int64) (ExchangeRatesRecord, error) {
+	rates := []ExchangeRatesRecord{}
+	for _, rate := range s.rates {
+		if rate.StockExchange == stockExchange && (before == 0 || rate.Timestamp < before) {
+			rates = append(rates, rate)
+		}
+	}
+	if len(rates) == 0 {
+		return ExchangeRatesRecord{}, ErrNotFound
+	}
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].Timestamp > rates[j].Timestamp
+	})
+	return rates[0], nil
+}
+
+func (s *MemoryUserStore) GetLatestExchangeRate() ([]ExchangeRatesRecord, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	stocksGdax, err := s.latestRate("Gdax", 0)
+	if err != nil {
+		return nil, err
+	}
+	stocksPoloniex, err := s.latestRate("Poloniex", 0)
+	if err != nil {
+		return nil, err
+	}
+	return []ExchangeRatesRecord{stocksPoloniex, stocksGdax}, nil
+}
+
+func (s *MemoryUserStore) GetReSyncExchangeRate(time int64) ([]ExchangeRatesRecord, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	stocksCCCAGG, err := s.latestRate("CCCAGG", time)
+	if err != nil {
+		return nil, err
+	}
+	return []ExchangeRatesRecord{stocksCCCAGG}, nil
+}
+
+func (s *MemoryUserStore) GetAllWalletTransactions(userid string, currencyID, networkID int, walletTxs *[]MultyTX) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return nil
+	}
+	txs := []MultyTX{}
+	for _, tx := range s.multyTxs[chainKey{currencyID, networkID}] {
+		if tx.UserId == userid {
+			txs = append(txs, tx)
+		}
+	}
+	*walletTxs = txs
+	return nil
+}
+
+func (s *MemoryUserStore) GetAllWalletEthTransactions(userid string, currencyID, networkID int, walletTxs *[]TransactionETH) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isETH(currencyID, networkID) {
+		return nil
+	}
+	txs := []TransactionETH{}
+	for _, tx := range s.ethTxs[chainKey{currencyID, networkID}] {
+		if tx.UserID == userid {
+			txs = append(txs, tx)
+		}
+	}
+	*walletTxs = txs
+	return nil
+}
+
+func (s *MemoryUserStore) GetChangedTransactions(userid string, currencyID, networkID int, since int64, txs *[]MultyTX) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return nil
+	}
+	changed := []MultyTX{}
+	for _, tx := range s.multyTxs[chainKey{currencyID, networkID}] {
+		if tx.UserId == userid && (since <= 0 || tx.LastUpdate >= since) {
+			changed = append(changed, tx)
+		}
+	}
+	*txs = changed
+	return nil
+}
+
+func (s *MemoryUserStore) GetChangedEthTransactions(userid string, currencyID, networkID int, since int64, txs *[]TransactionETH) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isETH(currencyID, networkID) {
+		return nil
+	}
+	changed := []TransactionETH{}
+	for _, tx := range s.ethTxs[chainKey{currencyID, networkID}] {
+		if tx.UserID == userid && (since <= 0 || tx.LastUpdate >= since) {
+			changed = append(changed, tx)
+		}
+	}
+	*txs = changed
+	return nil
+}
+
+func (s *MemoryUserStore) FindWalletTransactions(userid string, currencyID, networkID int, filter TxHistoryFilter) ([]MultyTX, string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	txs := []MultyTX{}
+	if !isUTXO(currencyID, networkID) {
+		return txs, "", nil
+	}
+	cursor, err := parseTxCursor(filter.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, tx := range s.multyTxs[chainKey{currencyID, networkID}] {
+		if tx.UserId == userid && filter.matchUTXO(tx) && cursor.after(tx.MempoolTime, tx.ID) {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		return olderTx(txs[j].MempoolTime, txs[j].ID, txs[i].MempoolTime, txs[i].ID)
+	})
+
+	limit := filter.limit()
+	if limit == 0 || len(txs) <= limit {
+		return txs, "", nil
+	}
+	txs = txs[:limit]
+	last := txs[limit-1]
+	return txs, txCursor{last.MempoolTime, last.ID}.String(), nil
+}
+
+func (s *MemoryUserStore) FindWalletEthTransactions(userid string, currencyID, networkID int, filter TxHistoryFilter) ([]TransactionETH, string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	txs := []TransactionETH{}
+	if !isETH(currencyID, networkID) {
+		return txs, "", nil
+	}
+	cursor, err := parseTxCursor(filter.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, tx := range s.ethTxs[chainKey{currencyID, networkID}] {
+		if tx.UserID == userid && filter.matchETH(tx) && cursor.after(tx.PoolTime, tx.ID) {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		return olderTx(txs[j].PoolTime, txs[j].ID, txs[i].PoolTime, txs[i].ID)
+	})
+
+	limit := filter.limit()
+	if limit == 0 || len(txs) <= limit {
+		return txs, "", nil
+	}
+	txs = txs[:limit]
+	last := txs[limit-1]
+	return txs, txCursor{last.PoolTime, last.ID}.String(), nil
+}
+
+// hasWalletOutput reports whether any of tx outputs belongs to the wallet
+func hasWalletOutput(tx MultyTX, walletIndex int) bool {
+	for _, out := range tx.WalletsOutput {
+		if out.WalletIndex == walletIndex {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryUserStore) SaveMultyTransaction(currencyID, networkID int, tx MultyTX) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return errors.New("SaveMultyTransaction: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+	txs := s.multyTxs[key]
+
+	// Doubling txs fix on a asynchronous err
+	duplicate := func(walletIndex int) bool {
+		for _, stored := range txs {
+			if stored.UserId == tx.UserId && stored.TxID == tx.TxID && stored.MempoolTime == tx.MempoolTime && hasWalletOutput(stored, walletIndex) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(tx.WalletsInput) != 0 && duplicate(tx.WalletsInput[0].WalletIndex) {
+		return nil
+	}
+	if len(tx.WalletsOutput) > 0 && duplicate(tx.WalletsOutput[0].WalletIndex) {
+		return nil
+	}
+
+	var userID string
+	switch {
+	case len(tx.WalletsInput) > 0:
+		userID = tx.WalletsInput[0].UserId
+	case len(tx.WalletsOutput) > 0:
+		userID = tx.WalletsOutput[0].UserId
+	default:
+		return nil
+	}
+
+	tx.LastUpdate = time.Now().Unix()
+	for i, stored := range txs {
+		if stored.UserId == userID && stored.TxID == tx.TxID && len(stored.TxAddress) > 0 && stored.TxAddress[0] == tx.TxAddress[0] {
+			txs[i].LastUpdate = tx.LastUpdate
+			txs[i].TxStatus = keepConfirmedStatus(stored.TxStatus, tx.TxStatus)
+			txs[i].BlockHeight = tx.BlockHeight
+			txs[i].Confirmations = tx.Confirmations
+			txs[i].BlockTime = tx.BlockTime
+			txs[i].WalletsOutput = tx.WalletsOutput
+			txs[i].WalletsInput = tx.WalletsInput
+			return nil
+		}
+	}
+	tx.ID = bson.NewObjectId()
+	s.multyTxs[key] = append(txs, tx)
+	return nil
+}
+
+func (s *MemoryUserStore) SaveEthTransaction(currencyID, networkID int, tx TransactionETH) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isETH(currencyID, networkID) {
+		return errors.New("SaveEthTransaction: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+	txs := s.ethTxs[key]
+	tx.LastUpdate = time.Now().Unix()
+	for i, stored := range txs {
+		if stored.UserID == tx.UserID && stored.Hash == tx.Hash && stored.WalletIndex == tx.WalletIndex {
+			txs[i].LastUpdate = tx.LastUpdate
+			txs[i].Status = keepConfirmedStatus(stored.Status, tx.Status)
+			txs[i].BlockHeight = tx.BlockHeight
+			txs[i].BlockTime = tx.BlockTime
+			return nil
+		}
+	}
+	tx.ID = bson.NewObjectId()
+	s.ethTxs[key] = append(txs, tx)
+	return nil
+}
+
+func (s *MemoryUserStore) GetInBlockTransactions(currencyID, networkID int, txs *[]MultyTX) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	inBlock := []MultyTX{}
+	for _, tx := range s.multyTxs[chainKey{currencyID, networkID}] {
+		if isInBlockStatus(tx.TxStatus) {
+			inBlock = append(inBlock, tx)
+		}
+	}
+	*txs = inBlock
+	return nil
+}
+
+func (s *MemoryUserStore) GetInBlockEthTransactions(currencyID, networkID int, txs *[]TransactionETH) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	inBlock := []TransactionETH{}
+	for _, tx := range s.ethTxs[chainKey{currencyID, networkID}] {
+		if isInBlockStatus(tx.Status) {
+			inBlock = append(inBlock, tx)
+		}
+	}
+	*txs = inBlock
+	return nil
+}
+
+func (s *MemoryUserStore) UpdateTransactionConfirmations(currencyID, networkID int, txKey TxKey, status, confirmations int) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	key := chainKey{currencyID, networkID}
+	now := time.Now().Unix()
+	found := false
+	for i, tx := range s.multyTxs[key] {
+		if tx.TxID == txKey.TxID && tx.UserId == txKey.UserID {
+			s.multyTxs[key][i].TxStatus = status
+			s.multyTxs[key][i].Confirmations = confirmations
+			s.multyTxs[key][i].LastUpdate = now
+			found = true
+		}
+	}
+	for i, tx := range s.ethTxs[key] {
+		if tx.Hash == txKey.TxID && tx.UserID == txKey.UserID && tx.WalletIndex == txKey.WalletIndex {
+			s.ethTxs[key][i].Status = status
+			s.ethTxs[key][i].Confirmations = confirmations
+			s.ethTxs[key][i].LastUpdate = now
+			found = true
+		}
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MemoryUserStore) DeleteHistory(CurrencyID, NetworkID int, Address string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(CurrencyID, NetworkID) {
+		return nil
+	}
+	key := chainKey{CurrencyID, NetworkID}
+	txs := s.multyTxs[key]
+	for i, tx := range txs {
+		for _, address := range tx.TxAddress {
+			if address == Address {
+				s.multyTxs[key] = append(txs[:i], txs[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryUserStore) GetAddressSpendableOutputs(address string, currencyID, networkID int) ([]SpendableOutputs, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	spOuts := []SpendableOutputs{}
+	for _, out := range s.spendable[chainKey{currencyID, networkID}] {
+		if out.Address == address {
+			spOuts = append(spOuts, out)
+		}
+	}
+	return spOuts, nil
+}
+
+func (s *MemoryUserStore) FindSpendableOutput(currencyID, networkID int, userID, txID string, txOutID int) (SpendableOutputs, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return SpendableOutputs{}, errors.New("FindSpendableOutput: wrong networkID")
+	}
+	for _, out := range s.spendable[chainKey{currencyID, networkID}] {
+		if out.UserID == userID && out.TxID == txID && out.TxOutID == txOutID {
+			return out, nil
+		}
+	}
+	return SpendableOutputs{}, ErrNotFound
+}
+
+func (s *MemoryUserStore) AddSpendableOutput(currencyID, networkID int, out SpendableOutputs) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return errors.New("AddSpendableOutput: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+	outs := s.spendable[key]
+	for i, stored := range outs {
+		if stored.UserID == out.UserID && stored.TxID == out.TxID && stored.Address == out.Address {
+			outs[i].TxStatus = out.TxStatus
+			return nil
+		}
+	}
+	s.spendable[key] = append(outs, out)
+	return nil
+}
+
+func (s *MemoryUserStore) DeleteSpendableOutput(currencyID, networkID int, userID, txID, address string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return errors.New("DeleteSpendableOutput: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+	outs := s.spendable[key]
+	for i, stored := range outs {
+		if stored.UserID == userID && stored.TxID == txID && stored.Address == address {
+			s.spendable[key] = append(outs[:i], outs[i+1:]...)
+			for j, spent := range s.spent[key] {
+				if spent.UserID == userID && spent.TxID == txID && spent.Address == address {
+					out := stored
+					s.spent[key][j].Output = &out
+					break
+				}
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryUserStore) AddSpentOutput(currencyID, networkID int, out SpentOutput) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return errors.New("AddSpentOutput: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+	s.spent[key] = append(s.spent[key], out)
+	return nil
+}
+
+func (s *MemoryUserStore) IsSpentOutput(currencyID, networkID int, userID, txID, address string) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return false, errors.New("IsSpentOutput: wrong networkID")
+	}
+	for _, out := range s.spent[chainKey{currencyID, networkID}] {
+		if out.UserID == userID && out.TxID == txID && out.Address == address {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryUserStore) FethLastSyncBlockState(networkid, currencyid int) (LastState, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, ls := range s.lastStates {
+		if ls.CurrencyID == currencyid && ls.NetworkID == networkid {
+			return ls, nil
+		}
+	}
+	return LastState{}, ErrNotFound
+}
+
+func (s *MemoryUserStore) SetLastSyncBlockState(networkid, currencyid int, block Block) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for i, ls := range s.lastStates {
+		if ls.CurrencyID == currencyid && ls.NetworkID == networkid {
+			s.lastStates[i].BlockHeight = block.Height
+			s.lastStates[i].Blocks = appendBlock(ls.Blocks, block)
+			return nil
+		}
+	}
+	s.lastStates = append(s.lastStates, LastState{
+		BlockHeight: block.Height,
+		CurrencyID:  currencyid,
+		NetworkID:   networkid,
+		Blocks:      appendBlock(nil, block),
+	})
+	return nil
+}
+
+func (s *MemoryUserStore) RollbackTransactions(currencyID, networkID int, height int64) ([]MultyTX, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return nil, errors.New("RollbackTransactions: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+	now := time.Now().Unix()
+	txs := []MultyTX{}
+	txIDs := []string{}
+	for i, tx := range s.multyTxs[key] {
+		if tx.BlockHeight < height {
+			continue
+		}
+		tx.TxStatus = MempoolStatus(tx.TxStatus)
+		tx.BlockHeight = -1
+		tx.BlockTime = 0
+		tx.Confirmations = 0
+		tx.LastUpdate = now
+		s.multyTxs[key][i] = tx
+		txs = append(txs, tx)
+		if !containsString(txIDs, tx.TxID) {
+			txIDs = append(txIDs, tx.TxID)
+		}
+	}
+
+	for i, out := range s.spendable[key] {
+		if containsString(txIDs, out.TxID) {
+			s.spendable[key][i].TxStatus = MempoolStatus(out.TxStatus)
+		}
+	}
+	return txs, nil
+}
+
+func (s *MemoryUserStore) RestoreSpentOutputs(currencyID, networkID int, spendTxID string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return errors.New("RestoreSpentOutputs: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+	spent := []SpentOutput{}
+	for _, out := range s.spent[key] {
+		if out.SpendTxID != spendTxID {
+			spent = append(spent, out)
+			continue
+		}
+		if out.Output != nil {
+			s.spendable[key] = append(s.spendable[key], *out.Output)
+		}
+	}
+	s.spent[key] = spent
+	return nil
+}
+
+func (s *MemoryUserStore) RollbackEthTransactions(currencyID, networkID int, height int64) ([]TransactionETH, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isETH(currencyID, networkID) {
+		return nil, errors.New("RollbackEthTransactions: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+	now := time.Now().Unix()
+	txs := []TransactionETH{}
+	for i, tx := range s.ethTxs[key] {
+		if tx.BlockHeight < height {
+			continue
+		}
+		tx.Status = MempoolStatus(tx.Status)
+		tx.BlockHeight = -1
+		tx.BlockTime = 0
+		tx.Confirmations = 0
+		tx.LastUpdate = now
+		s.ethTxs[key][i] = tx
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+func (s *MemoryUserStore) SaveFeeSnapshot(snapshot FeeSnapshot) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+	return nil
+}
+
+func (s *MemoryUserStore) GetLastFeeSnapshot(currencyID, networkID int) (FeeSnapshot, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	last := FeeSnapshot{}
+	found := false
+	for _, snapshot := range s.snapshots {
+		if snapshot.CurrencyID == currencyID && snapshot.NetworkID == networkID && !snapshot.Time.Before(last.Time) {
+			last = snapshot
+			found = true
+		}
+	}
+	if !found {
+		return last, ErrNotFound
+	}
+	return last, nil
+}
+
+func (s *MemoryUserStore) GetFeeSnapshots(currencyID, networkID int, since time.Time) ([]FeeSnapshot, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	snapshots := []FeeSnapshot{}
+	for _, snapshot := range s.snapshots {
+		if snapshot.CurrencyID == currencyID && snapshot.NetworkID == networkID && !snapshot.Time.Before(since) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+func (s *MemoryUserStore) FindUserTransaction(userID string, currencyID, networkID int, txID string) (MultyTX, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return MultyTX{}, errors.New("FindUserTransaction: wrong networkID")
+	}
+	for _, tx := range s.multyTxs[chainKey{currencyID, networkID}] {
+		if tx.UserId == userID && tx.TxID == txID {
+			return tx, nil
+		}
+	}
+	return MultyTX{}, ErrNotFound
+}
+
+func (s *MemoryUserStore) GetTxSpendableOutputs(currencyID, networkID int, userID, txID string) ([]SpendableOutputs, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return nil, errors.New("GetTxSpendableOutputs: wrong networkID")
+	}
+	outs := []SpendableOutputs{}
+	for _, out := range s.spendable[chainKey{currencyID, networkID}] {
+		if out.UserID == userID && out.TxID == txID {
+			outs = append(outs, out)
+		}
+	}
+	return outs, nil
+}
+
+func (s *MemoryUserStore) GetTxSpentOutputs(currencyID, networkID int, userID, spendTxID string) ([]SpentOutput, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return nil, errors.New("GetTxSpentOutputs: wrong networkID")
+	}
+	outs := []SpentOutput{}
+	for _, out := range s.spent[chainKey{currencyID, networkID}] {
+		if out.UserID == userID && out.SpendTxID == spendTxID {
+			outs = append(outs, out)
+		}
+	}
+	return outs, nil
+}
+
+func (s *MemoryUserStore) FindSpentOutput(currencyID, networkID int, userID, txID, address string) (SpentOutput, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return SpentOutput{}, errors.New("FindSpentOutput: wrong networkID")
+	}
+	for _, out := range s.spent[chainKey{currencyID, networkID}] {
+		if out.UserID == userID && out.TxID == txID && out.Address == address {
+			return out, nil
+		}
+	}
+	return SpentOutput{}, ErrNotFound
+}
+
+func (s *MemoryUserStore) ReplaceTransaction(currencyID, networkID int, txID, replacedBy string) ([]MultyTX, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !isUTXO(currencyID, networkID) {
+		return nil, errors.New("ReplaceTransaction: wrong networkID")
+	}
+	key := chainKey{currencyID, networkID}
+
+	now := time.Now().Unix()
+	replaced := []MultyTX{}
+	for i, tx := range s.multyTxs[key] {
+		if tx.TxID != txID || !IsMempoolStatus(tx.TxStatus) {
+			continue
+		}
+		s.multyTxs[key][i].TxStatus = TxStatusReplaced
+		s.multyTxs[key][i].ReplacedBy = replacedBy
+		s.multyTxs[key][i].LastUpdate = now
+		replaced = append(replaced, s.multyTxs[key][i])
+	}
+
+	outs := []SpendableOutputs{}
+	for _, out := range s.spendable[key] {
+		if out.TxID != txID {
+			outs = append(outs, out)
+		}
+	}
+	s.spendable[key] = outs
+
+	for i, out := range s.spent[key] {
+		if out.SpendTxID == txID {
+			s.spent[key][i].SpendTxID = replacedBy
+		}
+	}
+	return replaced, nil
+}
+
+func (s *MemoryUserStore) SaveOutboundTx(tx OutboundTx) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, stored := range s.outbound {
+		if stored.CurrencyID == tx.CurrencyID && stored.NetworkID == tx.NetworkID && stored.TxID == tx.TxID {
+			return nil
+		}
+	}
+	s.outbound = append(s.outbound, tx)
+	return nil
+}
+
+func (s *MemoryUserStore) GetOutboundTxs(currencyID, networkID, status int) ([]OutboundTx, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	txs := []OutboundTx{}
+	for _, tx := range s.outbound {
+		if tx.CurrencyID == currencyID && tx.NetworkID == networkID && tx.Status == status {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
+func (s *MemoryUserStore) UpdateOutboundTx(tx OutboundTx) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for i, stored := range s.outbound {
+		if stored.CurrencyID == tx.CurrencyID && stored.NetworkID == tx.NetworkID && stored.TxID == tx.TxID {
+			s.outbound[i].Status = tx.Status
+			s.outbound[i].LastBroadcast = tx.LastBroadcast
+			s.outbound[i].LastSeen = tx.LastSeen
+			s.outbound[i].Broadcasts = tx.Broadcasts
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryUserStore) IsTxInBlock(currencyID, networkID int, txID string) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	key := chainKey{currencyID, networkID}
+	for _, tx := range s.multyTxs[key] {
+		if tx.TxID == txID && tx.BlockHeight > 0 {
+			return true, nil
+		}
+	}
+	for _, tx := range s.ethTxs[key] {
+		if tx.Hash == txID && tx.BlockHeight > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryUserStore) IsTxReplaced(currencyID, networkID int, txID string) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	key := chainKey{currencyID, networkID}
+	for _, tx := range s.multyTxs[key] {
+		if tx.TxID == txID && tx.TxStatus == TxStatusReplaced {
+			return true, nil
+		}
+	}
+	for _, tx := range s.ethTxs[key] {
+		if tx.Hash != txID {
+			continue
+		}
+		for _, other := range s.ethTxs[key] {
+			if other.From == tx.From && other.Nonce == tx.Nonce && other.Hash != txID && other.BlockHeight > 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+type idempotencyKey struct {
+	userID string
+	key    string
+}
+
+func (s *MemoryUserStore) ReserveIdempotencyKey(userID, key, requestHash string, lease time.Duration) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	k := idempotencyKey{userID, key}
+	if resp, ok := s.responses[k]; ok && (resp.Code != 0 || time.Since(resp.Created) < lease) {
+		return ErrKeyExists
+	}
+	s.responses[k] = IdempotentResponse{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		Created:     time.Now(),
+	}
+	return nil
+}
+
+func (s *MemoryUserStore) FindIdempotentResponse(userID, key string) (IdempotentResponse, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	resp, ok := s.responses[idempotencyKey{userID, key}]
+	if !ok {
+		return resp, ErrNotFound
+	}
+	return resp, nil
+}
+
+func (s *MemoryUserStore) SaveIdempotentResponse(userID, key string, code int, body []byte) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	k := idempotencyKey{userID, key}
+	resp, ok := s.responses[k]
+	if !ok {
+		return ErrNotFound
+	}
+	resp.Code = code
+	resp.Body = body
+	s.responses[k] = resp
+	return nil
+}
+
+func (s *MemoryUserStore) ReleaseIdempotencyKey(userID, key string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.responses, idempotencyKey{userID, key})
+	return nil
+}
+
+func (s *MemoryUserStore) GetAddressPendingTxs(currencyID, networkID int, address string) ([]TransactionETH, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	txs := []TransactionETH{}
+	for _, tx := range s.ethTxs[chainKey{currencyID, networkID}] {
+		if tx.From == indexedAddress(currencyID, address) && IsMempoolStatus(tx.Status) {
+			txs = append(txs, tx)
+		}
+	}
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].Nonce < txs[j].Nonce })
+	return txs, nil
+}
+
+func (s *MemoryUserStore) SaveToken(token Token) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for i, t := range s.tokens {
+		if t.CurrencyID == token.CurrencyID && t.NetworkID == token.NetworkID && t.Contract == token.Contract {
+			s.tokens[i] = token
+			return nil
+		}
+	}
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
+func (s *MemoryUserStore) GetTokens(currencyID, networkID int) ([]Token, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	tokens := []Token{}
+	for _, t := range s.tokens {
+		if t.CurrencyID == currencyID && t.NetworkID == networkID {
+			tokens = append(tokens, t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Symbol < tokens[j].Symbol })
+	return tokens, nil
+}
+
+func (s *MemoryUserStore) FindToken(currencyID, networkID int, contract string) (Token, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, t := range s.tokens {
+		if t.CurrencyID == currencyID && t.NetworkID == networkID && t.Contract == contract {
+			return t, nil
+		}
+	}
+	return Token{}, ErrNotFound
+}
+
+func (s *MemoryUserStore) SaveTokenTransfer(transfer TokenTransfer) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	transfer.LastUpdate = time.Now().Unix()
+	for i, t := range s.transfers {
+		if t.CurrencyID == transfer.CurrencyID && t.NetworkID == transfer.NetworkID && t.UserID == transfer.UserID &&
+			t.WalletIndex == transfer.WalletIndex && t.Hash == transfer.Hash && t.LogIndex == transfer.LogIndex {
+			s.transfers[i].Status = transfer.Status
+			s.transfers[i].BlockTime = transfer.BlockTime
+			s.transfers[i].BlockHeight = transfer.BlockHeight
+			s.transfers[i].LastUpdate = transfer.LastUpdate
+			return nil
+		}
+	}
+	s.transfers = append(s.transfers, transfer)
+	return nil
+}
+
+func (s *MemoryUserStore) GetTokenTransfers(currencyID, networkID int, userID string, walletIndex int) ([]TokenTransfer, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	transfers := []TokenTransfer{}
+	for _, t := range s.transfers {
+		if t.CurrencyID == currencyID && t.NetworkID == networkID && t.UserID == userID && t.WalletIndex == walletIndex {
+			transfers = append(transfers, t)
+		}
+	}
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].BlockTime != transfers[j].BlockTime {
+			return transfers[i].BlockTime > transfers[j].BlockTime
+		}
+		return transfers[i].PoolTime > transfers[j].PoolTime
+	})
+	return transfers, nil
+}
+
+func copyMultisig(multisig Multisig) Multisig {
+	multisig.Owners = append([]AddressExtended{}, multisig.Owners...)
+	return multisig
+}
+
+func copyMultisigTx(tx MultisigTx) MultisigTx {
+	tx.Confirmations = append([]MultisigConfirmation{}, tx.Confirmations...)
+	return tx
+}
+
+func (s *MemoryUserStore) SaveMultisig(multisig Multisig) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for i, m := range s.multisigs {
+		if m.CurrencyID == multisig.CurrencyID && m.NetworkID == multisig.NetworkID && m.ContractAddress == multisig.ContractAddress {
+			s.multisigs[i] = copyMultisig(multisig)
+			return nil
+		}
+	}
+	s.multisigs = append(s.multisigs, copyMultisig(multisig))
+	return nil
+}
+
+func (s *MemoryUserStore) FindMultisig(currencyID, networkID int, contract string) (Multisig, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, m := range s.multisigs {
+		if m.CurrencyID == currencyID && m.NetworkID == networkID && m.ContractAddress == contract {
+			return copyMultisig(m), nil
+		}
+	}
+	return Multisig{}, ErrNotFound
+}
+
+func (s *MemoryUserStore) GetMultisigs(currencyID, networkID int) ([]Multisig, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	multisigs := []Multisig{}
+	for _, m := range s.multisigs {
+		if m.CurrencyID == currencyID && m.NetworkID == networkID {
+			multisigs = append(multisigs, copyMultisig(m))
+		}
+	}
+	return multisigs, nil
+}
+
+func (s *MemoryUserStore) GetUserMultisigs(currencyID, networkID int, userID string) ([]Multisig, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	multisigs := []Multisig{}
+	for _, m := range s.multisigs {
+		if m.CurrencyID != currencyID || m.NetworkID != networkID {
+			continue
+		}
+		for _, owner := range m.Owners {
+			if owner.UserID == userID {
+				multisigs = append(multisigs, copyMultisig(m))
+				break
+			}
+		}
+	}
+	sort.SliceStable(multisigs, func(i, j int) bool {
+		return multisigs[i].DateOfCreation < multisigs[j].DateOfCreation
+	})
+	return multisigs, nil
+}
+
+func (s *MemoryUserStore) SaveMultisigTx(tx MultisigTx) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	tx.LastUpdate = time.Now().Unix()
+	for i, t := range s.msigTxs {
+		if t.CurrencyID == tx.CurrencyID && t.NetworkID == tx.NetworkID && t.Contract == tx.Contract && t.TxIndex == tx.TxIndex {
+			s.msigTxs[i] = copyMultisigTx(tx)
+			return nil
+		}
+	}
+	s.msigTxs = append(s.msigTxs, copyMultisigTx(tx))
+	return nil
+}
+
+func (s *MemoryUserStore) FindMultisigTx(currencyID, networkID int, contract string, txIndex int64) (MultisigTx, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, t := range s.msigTxs {
+		if t.CurrencyID == currencyID && t.NetworkID == networkID && t.Contract == contract && t.TxIndex == txIndex {
+			return copyMultisigTx(t), nil
+		}
+	}
+	return MultisigTx{}, ErrNotFound
+}
+
+func (s *MemoryUserStore) GetMultisigTxs(currencyID, networkID int, contract string) ([]MultisigTx, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	txs := []MultisigTx{}
+	for _, t := range s.msigTxs {
+		if t.CurrencyID == currencyID && t.NetworkID == networkID && t.Contract == contract {
+			txs = append(txs, copyMultisigTx(t))
+		}
+	}
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].TxIndex > txs[j].TxIndex
+	})
+	return txs, nil
+}
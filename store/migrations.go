@@ -0,0 +1,690 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/logger"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var log = logger.WithContext("store")
+
+// Migration is a single versioned change of the database schema.
+// Up and Down have to be safe to run again after a partial failure.
+// Up of a per chain migration is run again on every start while the migration
+// is applied, chains may be added to config after it was applied.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       func(mStore *MongoUserStore) error
+	Down     func(mStore *MongoUserStore) error
+	PerChain bool
+}
+
+// MigrationRecord is a migration applied to the database
+type MigrationRecord struct {
+	Version   int    `bson:"version" json:"version"`
+	Name      string `bson:"name" json:"name"`
+	AppliedAt int64  `bson:"appliedat" json:"appliedat"`
+}
+
+// MigrationStatus is a migration known to the backend with its state
+type MigrationStatus struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt int64  `json:"appliedat"`
+}
+
+// feeSnapshotsTTL is how long fee estimation snapshots are kept
+const feeSnapshotsTTL = 30 * 24 * time.Hour
+
+// idempotencyKeysTTL is how long clients may repeat requests with the same key
+const idempotencyKeysTTL = 24 * time.Hour
+
+// migrations are applied in order of versions, never change or reuse
+// a version once it was released, add a new migration instead
+var migrations = []Migration{
+	{
+		Version:  1,
+		Name:     "base indexes",
+		Up:       ensureBaseIndexes,
+		Down:     dropBaseIndexes,
+		PerChain: true,
+	},
+	{
+		Version: 2,
+		Name:    "address index collection",
+		Up:      buildAddressIndex,
+		Down:    dropAddressIndex,
+	},
+	{
+		Version:  3,
+		Name:     "tx history pages indexes",
+		Up:       ensureHistoryIndexes,
+		Down:     dropHistoryIndexes,
+		PerChain: true,
+	},
+	{
+		Version:  4,
+		Name:     "tx changes indexes",
+		Up:       ensureChangesIndexes,
+		Down:     dropChangesIndexes,
+		PerChain: true,
+	},
+	{
+		Version:  5,
+		Name:     "tx status index",
+		Up:       ensureStatusIndexes,
+		Down:     dropStatusIndexes,
+		PerChain: true,
+	},
+	{
+		Version:  6,
+		Name:     "reorg rollback indexes",
+		Up:       ensureReorgIndexes,
+		Down:     dropReorgIndexes,
+		PerChain: true,
+	},
+	{
+		Version: 7,
+		Name:    "fee snapshots indexes",
+		Up:      ensureFeeSnapshotsIndexes,
+		Down:    dropFeeSnapshotsIndexes,
+	},
+	{
+		Version: 8,
+		Name:    "outbound txs indexes",
+		Up:      ensureOutboundIndexes,
+		Down:    dropOutboundIndexes,
+	},
+	{
+		Version: 9,
+		Name:    "idempotency keys indexes",
+		Up:      ensureIdempotencyIndexes,
+		Down:    dropIdempotencyIndexes,
+	},
+	{
+		Version: 10,
+		Name:    "token indexes",
+		Up:      ensureTokenIndexes,
+		Down:    dropTokenIndexes,
+	},
+	{
+		Version: 11,
+		Name:    "multisig indexes",
+		Up:      ensureMultisigIndexes,
+		Down:    dropMultisigIndexes,
+	},
+	{
+		Version:  12,
+		Name:     "evm chains tx indexes",
+		Up:       ensureEVMIndexes,
+		Down:     dropEVMIndexes,
+		PerChain: true,
+	},
+	{
+		Version: 13,
+		Name:    "lowercase evm addresses",
+		Up:      lowercaseEVMAddresses,
+	},
+	{
+		Version: 14,
+		Name:    "token transfers wallet index",
+		Up:      keyTokenTransfersByWallet,
+		Down:    unkeyTokenTransfersByWallet,
+	},
+}
+
+// Migrator applies and rolls back migrations recording them in the users db
+type Migrator struct {
+	mStore     *MongoUserStore
+	migrations []Migration
+}
+
+// NewMigrator makes migrator of all known migrations
+func NewMigrator(mStore *MongoUserStore) *Migrator {
+	return &Migrator{
+		mStore:     mStore,
+		migrations: migrations,
+	}
+}
+
+// OpenMigrator dials the database without applying migrations
+func OpenMigrator(conf Conf) (*Migrator, error) {
+	mStore, err := openUserStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrator(mStore), nil
+}
+
+// Close closes the database session
+func (m *Migrator) Close() error {
+	return m.mStore.Close()
+}
+
+// List returns all known migrations and whether they were applied
+func (m *Migrator) List() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, mg := range m.migrations {
+		rec, ok := applied[mg.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			Applied:   ok,
+			AppliedAt: rec.AppliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// Run applies all pending migrations, returns versions applied
+func (m *Migrator) Run() ([]int, error) {
+	err := m.ensureMigrationsIndex()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	done := []int{}
+	for _, mg := range pendingMigrations(m.migrations, applied) {
+		err = mg.Up(m.mStore)
+		if err != nil {
+			return done, fmt.Errorf("Run: migration %d %q: %s", mg.Version, mg.Name, err.Error())
+		}
+		err = m.mStore.migrations.Insert(MigrationRecord{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			AppliedAt: time.Now().Unix(),
+		})
+		if err != nil {
+			return done, fmt.Errorf("Run: migrations.Insert %d: %s", mg.Version, err.Error())
+		}
+		log.Infof("Migration %d %q applied √", mg.Version, mg.Name)
+		done = append(done, mg.Version)
+	}
+	return done, nil
+}
+
+// Rollback rolls back applied migrations newer than the version,
+// negative version rolls back the last applied migration only
+func (m *Migrator) Rollback(version int) ([]int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	done := []int{}
+	for _, mg := range rollbackMigrations(m.migrations, applied, version) {
+		if mg.Down == nil {
+			return done, fmt.Errorf("Rollback: migration %d %q is irreversible", mg.Version, mg.Name)
+		}
+		err = mg.Down(m.mStore)
+		if err != nil {
+			return done, fmt.Errorf("Rollback: migration %d %q: %s", mg.Version, mg.Name, err.Error())
+		}
+		err = m.mStore.migrations.Remove(bson.M{"version": mg.Version})
+		if err != nil {
+			return done, fmt.Errorf("Rollback: migrations.Remove %d: %s", mg.Version, err.Error())
+		}
+		log.Infof("Migration %d %q rolled back √", mg.Version, mg.Name)
+		done = append(done, mg.Version)
+	}
+	return done, nil
+}
+
+// Reapply runs per chain migrations applied already so new chains get their indexes,
+// rolled back ones are not run
+func (m *Migrator) Reapply() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for _, mg := range reappliedMigrations(m.migrations, applied) {
+		err = mg.Up(m.mStore)
+		if err != nil {
+			return fmt.Errorf("Reapply: migration %d %q: %s", mg.Version, mg.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) applied() (map[int]MigrationRecord, error) {
+	records := []MigrationRecord{}
+	err := m.mStore.migrations.Find(nil).All(&records)
+	if err != nil {
+		return nil, fmt.Errorf("migrations.Find: %s", err.Error())
+	}
+	applied := map[int]MigrationRecord{}
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// ensureMigrationsIndex keeps two backends started at once from recording
+// the same migration twice
+func (m *Migrator) ensureMigrationsIndex() error {
+	return m.mStore.migrations.EnsureIndex(mgo.Index{
+		Key:    []string{"version"},
+		Unique: true,
+	})
+}
+
+// pendingMigrations returns not applied migrations in order of versions
+func pendingMigrations(all []Migration, applied map[int]MigrationRecord) []Migration {
+	pending := []Migration{}
+	for _, mg := range sortedMigrations(all) {
+		if _, ok := applied[mg.Version]; !ok {
+			pending = append(pending, mg)
+		}
+	}
+	return pending
+}
+
+// reappliedMigrations returns applied per chain migrations in order of versions
+func reappliedMigrations(all []Migration, applied map[int]MigrationRecord) []Migration {
+	reapplied := []Migration{}
+	for _, mg := range sortedMigrations(all) {
+		if _, ok := applied[mg.Version]; ok && mg.PerChain {
+			reapplied = append(reapplied, mg)
+		}
+	}
+	return reapplied
+}
+
+// rollbackMigrations returns applied migrations newer than the version
+// starting from the latest one
+func rollbackMigrations(all []Migration, applied map[int]MigrationRecord, version int) []Migration {
+	sorted := sortedMigrations(all)
+	rollback := []Migration{}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		mg := sorted[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		if version < 0 {
+			return []Migration{mg}
+		}
+		if mg.Version <= version {
+			break
+		}
+		rollback = append(rollback, mg)
+	}
+	return rollback
+}
+
+func sortedMigrations(all []Migration) []Migration {
+	sorted := append([]Migration{}, all...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return sorted
+}
+
+// collectionIndex is an index of a collection the queries rely on
+type collectionIndex struct {
+	collection *mgo.Collection
+	index      mgo.Index
+}
+
+// baseIndexes returns indexes on fields the store looks documents up by
+func (mStore *MongoUserStore) baseIndexes() []collectionIndex {
+	indexes := []collectionIndex{
+		{mStore.usersData, mgo.Index{Key: []string{"userID"}, Unique: true}},
+		{mStore.usersData, mgo.Index{Key: []string{"devices.JWT"}}},
+		{mStore.RestoreState, mgo.Index{Key: []string{"currencyid", "networkid"}}},
+	}
+	for key, txs := range mStore.txsData {
+		indexes = append(indexes, collectionIndex{txs, mgo.Index{Key: []string{"userid"}}})
+		if key.currencyID == currencies.Ether {
+			indexes = append(indexes, collectionIndex{txs, mgo.Index{Key: []string{"hash"}}})
+			continue
+		}
+		indexes = append(indexes,
+			collectionIndex{txs, mgo.Index{Key: []string{"txid", "userid"}}},
+			collectionIndex{txs, mgo.Index{Key: []string{"txaddress"}}},
+		)
+	}
+	for _, outs := range mStore.spendableOutputs {
+		indexes = append(indexes,
+			collectionIndex{outs, mgo.Index{Key: []string{"address"}}},
+			collectionIndex{outs, mgo.Index{Key: []string{"userid", "txid", "address"}}},
+		)
+	}
+	for _, outs := range mStore.spentOutputs {
+		indexes = append(indexes, collectionIndex{outs, mgo.Index{Key: []string{"userid", "txid", "address"}}})
+	}
+	return indexes
+}
+
+// historyIndexes returns indexes tx history pages are sorted by
+func (mStore *MongoUserStore) historyIndexes() []collectionIndex {
+	indexes := []collectionIndex{}
+	for key, txs := range mStore.txsData {
+		if key.currencyID == currencies.Ether {
+			indexes = append(indexes, collectionIndex{txs, mgo.Index{Key: []string{"userid", "walletindex", "-pooltime", "-_id"}}})
+			continue
+		}
+		indexes = append(indexes, collectionIndex{txs, mgo.Index{Key: []string{"userid", "-mempooltime", "-_id"}}})
+	}
+	return indexes
+}
+
+// changesIndexes returns indexes of txs changed since the client sync
+func (mStore *MongoUserStore) changesIndexes() []collectionIndex {
+	indexes := []collectionIndex{}
+	for _, txs := range mStore.txsData {
+		indexes = append(indexes, collectionIndex{txs, mgo.Index{Key: []string{"userid", "lastupdate"}}})
+	}
+	return indexes
+}
+
+// statusIndexes returns indexes of txs waiting for confirmations
+func (mStore *MongoUserStore) statusIndexes() []collectionIndex {
+	indexes := []collectionIndex{}
+	for _, txs := range mStore.txsData {
+		indexes = append(indexes, collectionIndex{txs, mgo.Index{Key: []string{"txstatus"}}})
+	}
+	return indexes
+}
+
+// reorgIndexes returns indexes of txs mined in orphaned blocks and outputs they spent
+func (mStore *MongoUserStore) reorgIndexes() []collectionIndex {
+	indexes := []collectionIndex{}
+	for _, txs := range mStore.txsData {
+		indexes = append(indexes, collectionIndex{txs, mgo.Index{Key: []string{"blockheight"}}})
+	}
+	for _, outs := range mStore.spendableOutputs {
+		indexes = append(indexes, collectionIndex{outs, mgo.Index{Key: []string{"txid"}}})
+	}
+	for _, outs := range mStore.spentOutputs {
+		indexes = append(indexes, collectionIndex{outs, mgo.Index{Key: []string{"spendtxid"}}})
+	}
+	return indexes
+}
+
+func ensureBaseIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.baseIndexes())
+}
+
+func dropBaseIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.baseIndexes())
+}
+
+func ensureHistoryIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.historyIndexes())
+}
+
+func dropHistoryIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.historyIndexes())
+}
+
+func ensureChangesIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.changesIndexes())
+}
+
+func dropChangesIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.changesIndexes())
+}
+
+func ensureStatusIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.statusIndexes())
+}
+
+func dropStatusIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.statusIndexes())
+}
+
+// feeSnapshotsIndexes returns indexes of the last snapshot lookup,
+// snapshots expire as only recent ones are of use
+func (mStore *MongoUserStore) feeSnapshotsIndexes() []collectionIndex {
+	return []collectionIndex{
+		{mStore.feeSnapshots, mgo.Index{Key: []string{"currencyid", "networkid", "-time"}}},
+		{mStore.feeSnapshots, mgo.Index{Key: []string{"time"}, ExpireAfter: feeSnapshotsTTL}},
+	}
+}
+
+func ensureFeeSnapshotsIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.feeSnapshotsIndexes())
+}
+
+func dropFeeSnapshotsIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.feeSnapshotsIndexes())
+}
+
+// outboundIndexes returns indexes of the rebroadcast worker lookups
+func (mStore *MongoUserStore) outboundIndexes() []collectionIndex {
+	return []collectionIndex{
+		{mStore.outboundTxs, mgo.Index{Key: []string{"currencyid", "networkid", "txid"}, Unique: true}},
+		{mStore.outboundTxs, mgo.Index{Key: []string{"currencyid", "networkid", "status"}}},
+	}
+}
+
+func ensureOutboundIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.outboundIndexes())
+}
+
+func dropOutboundIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.outboundIndexes())
+}
+
+// idempotencyIndexes returns indexes which keep a key once per user, keys expire
+func (mStore *MongoUserStore) idempotencyIndexes() []collectionIndex {
+	return []collectionIndex{
+		{mStore.idempotencyKeys, mgo.Index{Key: []string{"userid", "key"}, Unique: true}},
+		{mStore.idempotencyKeys, mgo.Index{Key: []string{"created"}, ExpireAfter: idempotencyKeysTTL}},
+	}
+}
+
+func ensureIdempotencyIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.idempotencyIndexes())
+}
+
+func dropIdempotencyIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.idempotencyIndexes())
+}
+
+// tokenIndexes returns indexes of the token registry and of wallet token histories
+func (mStore *MongoUserStore) tokenIndexes() []collectionIndex {
+	return []collectionIndex{
+		{mStore.tokens, mgo.Index{Key: []string{"currencyid", "networkid", "contract"}, Unique: true}},
+		{mStore.tokenTransfers, mgo.Index{Key: []string{"currencyid", "networkid", "userid", "hash", "logindex"}, Unique: true}},
+		{mStore.tokenTransfers, mgo.Index{Key: []string{"currencyid", "networkid", "userid", "walletindex"}}},
+	}
+}
+
+// tokenTransferKeys returns unique indexes of token transfers without and with the wallet index,
+// transfers between wallets of the same user are kept once per wallet since the latter
+func (mStore *MongoUserStore) tokenTransferKeys() (collectionIndex, collectionIndex) {
+	return collectionIndex{mStore.tokenTransfers, mgo.Index{Key: []string{"currencyid", "networkid", "userid", "hash", "logindex"}, Unique: true}},
+		collectionIndex{mStore.tokenTransfers, mgo.Index{Key: []string{"currencyid", "networkid", "userid", "walletindex", "hash", "logindex"}, Unique: true}}
+}
+
+func keyTokenTransfersByWallet(mStore *MongoUserStore) error {
+	byUser, byWallet := mStore.tokenTransferKeys()
+	if err := dropIndexes([]collectionIndex{byUser}); err != nil {
+		return err
+	}
+	return ensureIndexes([]collectionIndex{byWallet})
+}
+
+func unkeyTokenTransfersByWallet(mStore *MongoUserStore) error {
+	byUser, byWallet := mStore.tokenTransferKeys()
+	if err := dropIndexes([]collectionIndex{byWallet}); err != nil {
+		return err
+	}
+	return ensureIndexes([]collectionIndex{byUser})
+}
+
+func ensureTokenIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.tokenIndexes())
+}
+
+func dropTokenIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.tokenIndexes())
+}
+
+// multisigIndexes returns indexes of multisig contracts and of txs submitted to them
+func (mStore *MongoUserStore) multisigIndexes() []collectionIndex {
+	return []collectionIndex{
+		{mStore.multisig, mgo.Index{Key: []string{"currencyID", "networkID", "contractAddress"}, Unique: true}},
+		{mStore.multisig, mgo.Index{Key: []string{"currencyID", "networkID", "owners.userid"}}},
+		{mStore.multisigTxs, mgo.Index{Key: []string{"currencyid", "networkid", "contract", "txindex"}, Unique: true}},
+	}
+}
+
+func ensureMultisigIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.multisigIndexes())
+}
+
+func dropMultisigIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.multisigIndexes())
+}
+
+// evmIndexes returns tx lookup and history indexes of EVM chains other than Ether,
+// those got indexes of UTXO chains from the base and history migrations
+func (mStore *MongoUserStore) evmIndexes() []collectionIndex {
+	indexes := []collectionIndex{}
+	for key, txs := range mStore.txsData {
+		if _, utxo := mStore.spendableOutputs[key]; utxo || key.currencyID == currencies.Ether {
+			continue
+		}
+		indexes = append(indexes,
+			collectionIndex{txs, mgo.Index{Key: []string{"hash"}}},
+			collectionIndex{txs, mgo.Index{Key: []string{"userid", "walletindex", "-pooltime", "-_id"}}},
+		)
+	}
+	return indexes
+}
+
+func ensureEVMIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.evmIndexes())
+}
+
+func dropEVMIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.evmIndexes())
+}
+
+func ensureReorgIndexes(mStore *MongoUserStore) error {
+	return ensureIndexes(mStore.reorgIndexes())
+}
+
+func dropReorgIndexes(mStore *MongoUserStore) error {
+	return dropIndexes(mStore.reorgIndexes())
+}
+
+func ensureIndexes(indexes []collectionIndex) error {
+	for _, ci := range indexes {
+		// chains not set in config have no collection
+		if ci.collection.Name == "" {
+			continue
+		}
+		err := ci.collection.EnsureIndex(ci.index)
+		if err != nil {
+			return fmt.Errorf("%s EnsureIndex %v: %s", ci.collection.FullName, ci.index.Key, err.Error())
+		}
+	}
+	return nil
+}
+
+func dropIndexes(indexes []collectionIndex) error {
+	for _, ci := range indexes {
+		if ci.collection.Name == "" {
+			continue
+		}
+		err := ci.collection.DropIndex(ci.index.Key...)
+		if err != nil && !isDropNotFound(err) {
+			return fmt.Errorf("%s DropIndex %v: %s", ci.collection.FullName, ci.index.Key, err.Error())
+		}
+	}
+	return nil
+}
+
+// buildAddressIndex creates address index collection and fills it from users
+func buildAddressIndex(mStore *MongoUserStore) error {
+	err := mStore.addresses.EnsureIndex(mgo.Index{
+		Key:    []string{"address", "currencyID", "networkID"},
+		Unique: true,
+	})
+	if err != nil {
+		return err
+	}
+	err = mStore.addresses.EnsureIndex(mgo.Index{
+		Key: []string{"currencyID", "networkID"},
+	})
+	if err != nil {
+		return err
+	}
+
+	iter := mStore.usersData.Find(nil).Iter()
+	for user := (User{}); iter.Next(&user); user = (User{}) {
+		err = mStore.indexUser(user)
+		if err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
+
+// lowercaseEVMAddresses rewrites eth addresses indexed before lookups became case insensitive
+func lowercaseEVMAddresses(mStore *MongoUserStore) error {
+	evm := []int{}
+	for currencyID := range evmCurrencies {
+		evm = append(evm, currencyID)
+	}
+	iter := mStore.addresses.Find(bson.M{"currencyID": bson.M{"$in": evm}}).Iter()
+	for ua := (UserAddress{}); iter.Next(&ua); ua = (UserAddress{}) {
+		if ua.Address == strings.ToLower(ua.Address) {
+			continue
+		}
+		err := mStore.addresses.Remove(bson.M{"address": ua.Address, "currencyID": ua.CurrencyID, "networkID": ua.NetworkID})
+		if err != nil && err != mgo.ErrNotFound {
+			iter.Close()
+			return err
+		}
+		err = mStore.indexAddress(ua)
+		if err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
+
+func dropAddressIndex(mStore *MongoUserStore) error {
+	err := mStore.addresses.DropCollection()
+	if err != nil && !isDropNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// isDropNotFound tells that there was nothing to drop,
+// 26 is NamespaceNotFound and 27 is IndexNotFound
+func isDropNotFound(err error) bool {
+	if qErr, ok := err.(*mgo.QueryError); ok {
+		return qErr.Code == 26 || qErr.Code == 27
+	}
+	return err.Error() == "ns not found" || err.Error() == "index not found"
+}
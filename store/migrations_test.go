@@ -0,0 +1,99 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"gopkg.in/mgo.v2"
+)
+
+func versions(mgs []Migration) []int {
+	vs := []int{}
+	for _, mg := range mgs {
+		vs = append(vs, mg.Version)
+	}
+	return vs
+}
+
+func TestMigrationsVersionsUnique(t *testing.T) {
+	seen := map[int]bool{}
+	for _, mg := range migrations {
+		if mg.Version <= 0 || seen[mg.Version] {
+			t.Errorf("migration %q has wrong or duplicated version %d", mg.Name, mg.Version)
+		}
+		seen[mg.Version] = true
+		if mg.Up == nil {
+			t.Errorf("migration %d has no Up", mg.Version)
+		}
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	all := []Migration{{Version: 3}, {Version: 1}, {Version: 2}}
+	applied := map[int]MigrationRecord{2: {Version: 2}}
+
+	got := versions(pendingMigrations(all, applied))
+	if !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("pendingMigrations = %v, want [1 3]", got)
+	}
+}
+
+func TestRollbackMigrations(t *testing.T) {
+	all := []Migration{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}}
+	applied := map[int]MigrationRecord{1: {Version: 1}, 2: {Version: 2}, 3: {Version: 3}}
+
+	cases := []struct {
+		version int
+		want    []int
+	}{
+		{-1, []int{3}},
+		{1, []int{3, 2}},
+		{0, []int{3, 2, 1}},
+		{3, []int{}},
+	}
+	for _, c := range cases {
+		got := versions(rollbackMigrations(all, applied, c.version))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("rollbackMigrations(%d) = %v, want %v", c.version, got, c.want)
+		}
+	}
+}
+
+func TestReappliedMigrations(t *testing.T) {
+	all := []Migration{{Version: 3, PerChain: true}, {Version: 1, PerChain: true}, {Version: 2}, {Version: 4, PerChain: true}}
+	// 3 is rolled back, its indexes must not come back on start
+	applied := map[int]MigrationRecord{1: {Version: 1}, 2: {Version: 2}, 4: {Version: 4}}
+
+	got := versions(reappliedMigrations(all, applied))
+	if !reflect.DeepEqual(got, []int{1, 4}) {
+		t.Errorf("reappliedMigrations = %v, want [1 4]", got)
+	}
+}
+
+func TestEVMIndexes(t *testing.T) {
+	mStore := &MongoUserStore{
+		txsData: map[chainKey]*mgo.Collection{
+			{currencies.Bitcoin, currencies.Main}:         {Name: "btc"},
+			{currencies.Ether, currencies.ETHMain}:        {Name: "eth"},
+			{currencies.EtherClassic, currencies.ETCMain}: {Name: "etc"},
+		},
+		spendableOutputs: map[chainKey]*mgo.Collection{
+			{currencies.Bitcoin, currencies.Main}: {Name: "btc-spendable"},
+		},
+	}
+
+	for _, ci := range mStore.evmIndexes() {
+		if ci.collection.Name != "etc" {
+			t.Errorf("evmIndexes has index %v of %s, want ones of etc only", ci.index.Key, ci.collection.Name)
+		}
+	}
+	if got := len(mStore.evmIndexes()); got != 2 {
+		t.Errorf("evmIndexes returned %d indexes, want 2", got)
+	}
+}
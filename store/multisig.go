@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SaveMultisig registers the multisig contract or updates its owners and confirmations
+func (mStore *MongoUserStore) SaveMultisig(multisig Multisig) error {
+	query := bson.M{"currencyID": multisig.CurrencyID, "networkID": multisig.NetworkID, "contractAddress": multisig.ContractAddress}
+	_, err := mStore.multisig.Upsert(query, multisig)
+	return err
+}
+
+// FindMultisig returns the multisig of the contract, ErrNotFound if it is not registered
+func (mStore *MongoUserStore) FindMultisig(currencyID, networkID int, contract string) (Multisig, error) {
+	multisig := Multisig{}
+	query := bson.M{"currencyID": currencyID, "networkID": networkID, "contractAddress": contract}
+	err := mStore.multisig.Find(query).One(&multisig)
+	return multisig, err
+}
+
+// GetMultisigs returns all multisig contracts of the chain
+func (mStore *MongoUserStore) GetMultisigs(currencyID, networkID int) ([]Multisig, error) {
+	multisigs := []Multisig{}
+	err := mStore.multisig.Find(bson.M{"currencyID": currencyID, "networkID": networkID}).All(&multisigs)
+	return multisigs, err
+}
+
+// GetUserMultisigs returns multisig contracts of the chain owned by wallets of the user
+func (mStore *MongoUserStore) GetUserMultisigs(currencyID, networkID int, userID string) ([]Multisig, error) {
+	multisigs := []Multisig{}
+	query := bson.M{"currencyID": currencyID, "networkID": networkID, "owners.userid": userID}
+	err := mStore.multisig.Find(query).Sort("dateOfCreation").All(&multisigs)
+	return multisigs, err
+}
+
+// SaveMultisigTx inserts the submitted tx or replaces it with confirmations and status changed
+func (mStore *MongoUserStore) SaveMultisigTx(tx MultisigTx) error {
+	tx.LastUpdate = time.Now().Unix()
+	query := bson.M{"currencyid": tx.CurrencyID, "networkid": tx.NetworkID, "contract": tx.Contract, "txindex": tx.TxIndex}
+	_, err := mStore.multisigTxs.Upsert(query, tx)
+	return err
+}
+
+// FindMultisigTx returns the tx of the contract by its index, ErrNotFound if it was not submitted
+func (mStore *MongoUserStore) FindMultisigTx(currencyID, networkID int, contract string, txIndex int64) (MultisigTx, error) {
+	tx := MultisigTx{}
+	query := bson.M{"currencyid": currencyID, "networkid": networkID, "contract": contract, "txindex": txIndex}
+	err := mStore.multisigTxs.Find(query).One(&tx)
+	return tx, err
+}
+
+// GetMultisigTxs returns txs submitted to the contract, newest first
+func (mStore *MongoUserStore) GetMultisigTxs(currencyID, networkID int, contract string) ([]MultisigTx, error) {
+	txs := []MultisigTx{}
+	query := bson.M{"currencyid": currencyID, "networkid": networkID, "contract": contract}
+	err := mStore.multisigTxs.Find(query).Sort("-txindex").All(&txs)
+	return txs, err
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"errors"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func outboundQuery(tx OutboundTx) bson.M {
+	return bson.M{"currencyid": tx.CurrencyID, "networkid": tx.NetworkID, "txid": tx.TxID}
+}
+
+// SaveOutboundTx keeps the tx sent by the user, the same tx sent again is not reset
+func (mStore *MongoUserStore) SaveOutboundTx(tx OutboundTx) error {
+	_, err := mStore.outboundTxs.Upsert(outboundQuery(tx), bson.M{"$setOnInsert": tx})
+	return err
+}
+
+// GetOutboundTxs returns txs sent by users with the status
+func (mStore *MongoUserStore) GetOutboundTxs(currencyID, networkID, status int) ([]OutboundTx, error) {
+	txs := []OutboundTx{}
+	query := bson.M{"currencyid": currencyID, "networkid": networkID, "status": status}
+	err := mStore.outboundTxs.Find(query).Sort("submitted").All(&txs)
+	return txs, err
+}
+
+// UpdateOutboundTx saves status and broadcast progress of the tx
+func (mStore *MongoUserStore) UpdateOutboundTx(tx OutboundTx) error {
+	return mStore.outboundTxs.Update(outboundQuery(tx), bson.M{"$set": bson.M{
+		"status":        tx.Status,
+		"lastbroadcast": tx.LastBroadcast,
+		"lastseen":      tx.LastSeen,
+		"broadcasts":    tx.Broadcasts,
+	}})
+}
+
+// IsTxInBlock reports whether the tx is in a block of the chain according to users history
+func (mStore *MongoUserStore) IsTxInBlock(currencyID, networkID int, txID string) (bool, error) {
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok {
+		return false, errors.New("IsTxInBlock: wrong networkID")
+	}
+	field := "txid"
+	if isETH(currencyID, networkID) {
+		field = "hash"
+	}
+	n, err := txsData.Find(bson.M{field: txID, "blockheight": bson.M{"$gt": 0}}).Count()
+	return n > 0, err
+}
+
+// IsTxReplaced reports whether another tx took place of the tx according to users history:
+// utxo tx was replaced by fee, eth tx with the same nonce was mined
+func (mStore *MongoUserStore) IsTxReplaced(currencyID, networkID int, txID string) (bool, error) {
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok {
+		return false, errors.New("IsTxReplaced: wrong networkID")
+	}
+	if !isETH(currencyID, networkID) {
+		n, err := txsData.Find(bson.M{"txid": txID, "txstatus": TxStatusReplaced}).Count()
+		return n > 0, err
+	}
+
+	tx := TransactionETH{}
+	err := txsData.Find(bson.M{"hash": txID}).One(&tx)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	n, err := txsData.Find(bson.M{
+		"from":        tx.From,
+		"nonce":       tx.Nonce,
+		"hash":        bson.M{"$ne": txID},
+		"blockheight": bson.M{"$gt": 0},
+	}).Count()
+	return n > 0, err
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// recentBlocks is how many last blocks of the chain are kept to find where a reorg forks
+const recentBlocks = 100
+
+// Block is a block seen on the chain
+type Block struct {
+	Height   int64  `bson:"height"`
+	Hash     string `bson:"hash"`
+	PrevHash string `bson:"prevhash"`
+}
+
+// Connects reports whether the block is new and extends recent blocks or forks from one of them.
+// Blocks seen already, replayed from below the recent window or resent by a lagging node service don't.
+func Connects(recent []Block, block Block) bool {
+	if block.Hash == "" || len(recent) == 0 {
+		return true
+	}
+	if block.Height > recent[len(recent)-1].Height {
+		return true
+	}
+	for _, b := range recent {
+		if b.Hash == block.Hash {
+			return false
+		}
+		if block.PrevHash != "" && b.Hash == block.PrevHash {
+			return true
+		}
+	}
+	return block.PrevHash == "" && block.Height >= recent[0].Height
+}
+
+// ForkHeight returns height of the lowest recent block orphaned by the new block,
+// zero means the new block extends the chain, doesn't connect to it or has no hash to check
+func ForkHeight(recent []Block, block Block) int64 {
+	if block.Hash == "" || !Connects(recent, block) {
+		return 0
+	}
+	fork := int64(0)
+	orphan := func(height int64) {
+		if fork == 0 || height < fork {
+			fork = height
+		}
+	}
+	for _, b := range recent {
+		switch {
+		case b.Hash == block.Hash:
+			// seen already, e.g. after the stream reconnect
+			return 0
+		case b.Height >= block.Height:
+			orphan(b.Height)
+		case b.Height == block.Height-1 && block.PrevHash != "" && b.Hash != block.PrevHash:
+			orphan(b.Height)
+		}
+	}
+	return fork
+}
+
+// appendBlock replaces blocks at the height of the new block and above with it
+// and keeps only recentBlocks last ones, blocks which don't connect are skipped
+func appendBlock(recent []Block, block Block) []Block {
+	if block.Hash == "" || !Connects(recent, block) {
+		return recent
+	}
+	blocks := []Block{}
+	for _, b := range recent {
+		if b.Height < block.Height {
+			blocks = append(blocks, b)
+		}
+	}
+	blocks = append(blocks, block)
+	if len(blocks) > recentBlocks {
+		blocks = blocks[len(blocks)-recentBlocks:]
+	}
+	return blocks
+}
+
+// MempoolStatus returns status of the tx whose block was orphaned,
+// the tx goes back to mempool until it is mined again
+func MempoolStatus(status int) int {
+	switch status {
+	case TxStatusAppearedInBlockIncoming, TxStatusInBlockConfirmedIncoming:
+		return TxStatusAppearedInMempoolIncoming
+	case TxStatusAppearedInBlockOutcoming, TxStatusInBlockConfirmedOutcoming:
+		return TxStatusAppearedInMempoolOutcoming
+	}
+	return status
+}
+
+func rollbackUpdate(status int, now int64) bson.M {
+	return bson.M{
+		"$set": bson.M{
+			"txstatus":      status,
+			"blockheight":   -1,
+			"blocktime":     0,
+			"confirmations": 0,
+			"lastupdate":    now,
+		},
+	}
+}
+
+// RollbackTransactions returns utxo txs mined at the height and above back to mempool.
+// Outputs made by the txs get mempool status. Outputs spent by them stay spent while
+// the txs are pending, RestoreSpentOutputs brings them back if a tx is dropped.
+// Txs rolled back are returned with their new status.
+func (mStore *MongoUserStore) RollbackTransactions(currencyID, networkID int, height int64) ([]MultyTX, error) {
+	key := chainKey{currencyID, networkID}
+	spendableOutputs, ok := mStore.spendableOutputs[key]
+	if !ok {
+		return nil, errors.New("RollbackTransactions: wrong networkID")
+	}
+	txsData := mStore.txsData[key]
+
+	txs := []MultyTX{}
+	err := txsData.Find(bson.M{"blockheight": bson.M{"$gte": height}}).All(&txs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	txIDs := []string{}
+	for i, tx := range txs {
+		txs[i].TxStatus = MempoolStatus(tx.TxStatus)
+		txs[i].BlockHeight = -1
+		txs[i].BlockTime = 0
+		txs[i].Confirmations = 0
+		txs[i].LastUpdate = now
+		err = txsData.UpdateId(tx.ID, rollbackUpdate(txs[i].TxStatus, now))
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(txIDs, tx.TxID) {
+			txIDs = append(txIDs, tx.TxID)
+		}
+	}
+	if len(txIDs) == 0 {
+		return txs, nil
+	}
+
+	outs := []SpendableOutputs{}
+	err = spendableOutputs.Find(bson.M{"txid": bson.M{"$in": txIDs}}).All(&outs)
+	if err != nil {
+		return nil, err
+	}
+	for _, out := range outs {
+		query := bson.M{"userid": out.UserID, "txid": out.TxID, "address": out.Address}
+		err = spendableOutputs.Update(query, bson.M{"$set": bson.M{"txstatus": MempoolStatus(out.TxStatus)}})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return txs, nil
+}
+
+// RestoreSpentOutputs makes outputs spent by the tx spendable again and removes their spent records,
+// it's called once the tx is dropped and won't be mined
+func (mStore *MongoUserStore) RestoreSpentOutputs(currencyID, networkID int, spendTxID string) error {
+	key := chainKey{currencyID, networkID}
+	spentOutputs, ok := mStore.spentOutputs[key]
+	if !ok {
+		return errors.New("RestoreSpentOutputs: wrong networkID")
+	}
+
+	spent := []SpentOutput{}
+	err := spentOutputs.Find(bson.M{"spendtxid": spendTxID}).All(&spent)
+	if err != nil {
+		return err
+	}
+	for _, out := range spent {
+		if out.Output != nil {
+			err = mStore.AddSpendableOutput(currencyID, networkID, *out.Output)
+			if err != nil {
+				return err
+			}
+		}
+		err = spentOutputs.Remove(bson.M{"userid": out.UserID, "txid": out.TxID, "address": out.Address, "spendtxid": out.SpendTxID})
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// RollbackEthTransactions returns eth txs mined at the height and above back to mempool,
+// txs rolled back are returned with their new status
+func (mStore *MongoUserStore) RollbackEthTransactions(currencyID, networkID int, height int64) ([]TransactionETH, error) {
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok || !isETH(currencyID, networkID) {
+		return nil, errors.New("RollbackEthTransactions: wrong networkID")
+	}
+
+	txs := []TransactionETH{}
+	err := txsData.Find(bson.M{"blockheight": bson.M{"$gte": height}}).All(&txs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	for i, tx := range txs {
+		txs[i].Status = MempoolStatus(tx.Status)
+		txs[i].BlockHeight = -1
+		txs[i].BlockTime = 0
+		txs[i].Confirmations = 0
+		txs[i].LastUpdate = now
+		err = txsData.UpdateId(tx.ID, rollbackUpdate(txs[i].Status, now))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return txs, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForkHeight(t *testing.T) {
+	recent := []Block{
+		{Height: 10, Hash: "a10", PrevHash: "a9"},
+		{Height: 11, Hash: "a11", PrevHash: "a10"},
+		{Height: 12, Hash: "a12", PrevHash: "a11"},
+	}
+	cases := []struct {
+		name  string
+		block Block
+		want  int64
+	}{
+		{"next block", Block{Height: 13, Hash: "a13", PrevHash: "a12"}, 0},
+		{"seen already", Block{Height: 12, Hash: "a12", PrevHash: "a11"}, 0},
+		{"no hash", Block{Height: 11}, 0},
+		{"tip replaced", Block{Height: 12, Hash: "b12", PrevHash: "a11"}, 12},
+		{"deeper fork", Block{Height: 11, Hash: "b11", PrevHash: "a10"}, 11},
+		{"other parent", Block{Height: 13, Hash: "b13", PrevHash: "b12"}, 12},
+		{"replayed from below recent", Block{Height: 9, Hash: "a9", PrevHash: "a8"}, 0},
+		{"replayed from below recent without parent", Block{Height: 8, Hash: "a8"}, 0},
+		{"lagging node service", Block{Height: 11, Hash: "c11", PrevHash: "c10"}, 0},
+	}
+	for _, c := range cases {
+		if got := ForkHeight(recent, c.block); got != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestAppendBlock(t *testing.T) {
+	blocks := []Block{}
+	for h := int64(1); h <= recentBlocks+5; h++ {
+		blocks = appendBlock(blocks, Block{Height: h, Hash: "a"})
+	}
+	if len(blocks) != recentBlocks || blocks[0].Height != 6 {
+		t.Fatalf("got %d blocks from %d, want %d from 6", len(blocks), blocks[0].Height, recentBlocks)
+	}
+
+	// blocks replayed from below recent ones are skipped
+	if got := appendBlock(blocks, Block{Height: 2, Hash: "b2", PrevHash: "b1"}); !reflect.DeepEqual(got, blocks) {
+		t.Errorf("replayed block: got %d blocks from %d", len(got), got[0].Height)
+	}
+
+	blocks = appendBlock(blocks[:3], Block{Height: 7, Hash: "b7"})
+	want := []Block{{Height: 6, Hash: "a"}, {Height: 7, Hash: "b7"}}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("replaced: got %v, want %v", blocks, want)
+	}
+}
+
+func TestRestoreSpentOutputs(t *testing.T) {
+	s := NewMemoryUserStore()
+	funding := SpendableOutputs{UserID: "user", TxID: "funding", Address: "address", TxOutAmount: 5000}
+	if err := s.AddSpendableOutput(0, 1, funding); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddSpentOutput(0, 1, SpentOutput{UserID: "user", TxID: "funding", Address: "address", SpendTxID: "spend"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteSpendableOutput(0, 1, "user", "funding", "address"); err != nil {
+		t.Fatal(err)
+	}
+	spend := MultyTX{
+		UserId:       "user",
+		TxID:         "spend",
+		TxAddress:    []string{"address"},
+		TxStatus:     TxStatusAppearedInBlockOutcoming,
+		BlockHeight:  10,
+		WalletsInput: []WalletForTx{{UserId: "user", Address: AddressForWallet{Address: "address"}}},
+	}
+	if err := s.SaveMultyTransaction(0, 1, spend); err != nil {
+		t.Fatal(err)
+	}
+
+	// the orphaned spend is pending again, its input stays spent
+	if _, err := s.RollbackTransactions(0, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	if tx, _ := s.FindUserTransaction("user", 0, 1, "spend"); tx.TxStatus != TxStatusAppearedInMempoolOutcoming {
+		t.Errorf("orphaned spend status: got %d", tx.TxStatus)
+	}
+	if outs, _ := s.GetAddressSpendableOutputs("address", 0, 1); len(outs) != 0 {
+		t.Errorf("input of the pending spend is spendable: %+v", outs)
+	}
+
+	// the spend is dropped, its input is spendable again
+	if err := s.RestoreSpentOutputs(0, 1, "spend"); err != nil {
+		t.Fatal(err)
+	}
+	outs, _ := s.GetAddressSpendableOutputs("address", 0, 1)
+	if len(outs) != 1 || outs[0].TxOutAmount != 5000 {
+		t.Errorf("restored outputs: %+v", outs)
+	}
+	if spent, _ := s.IsSpentOutput(0, 1, "user", "funding", "address"); spent {
+		t.Errorf("restored output is still marked spent")
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+var mempoolStatusQuery = bson.M{"$in": []int{TxStatusAppearedInMempoolIncoming, TxStatusAppearedInMempoolOutcoming}}
+
+// IsMempoolStatus tells whether the tx with the status is pending
+func IsMempoolStatus(status int) bool {
+	return status == TxStatusAppearedInMempoolIncoming || status == TxStatusAppearedInMempoolOutcoming
+}
+
+// FindUserTransaction returns the utxo tx from the user history
+func (mStore *MongoUserStore) FindUserTransaction(userID string, currencyID, networkID int, txID string) (MultyTX, error) {
+	tx := MultyTX{}
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok || !isUTXO(currencyID, networkID) {
+		return tx, errors.New("FindUserTransaction: wrong networkID")
+	}
+	err := txsData.Find(bson.M{"userid": userID, "txid": txID}).One(&tx)
+	return tx, err
+}
+
+// GetTxSpendableOutputs returns outputs of the tx the user can spend
+func (mStore *MongoUserStore) GetTxSpendableOutputs(currencyID, networkID int, userID, txID string) ([]SpendableOutputs, error) {
+	outs := []SpendableOutputs{}
+	spendableOutputs, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return outs, errors.New("GetTxSpendableOutputs: wrong networkID")
+	}
+	err := spendableOutputs.Find(bson.M{"userid": userID, "txid": txID}).All(&outs)
+	return outs, err
+}
+
+// GetTxSpentOutputs returns outputs of the user the tx spends
+func (mStore *MongoUserStore) GetTxSpentOutputs(currencyID, networkID int, userID, spendTxID string) ([]SpentOutput, error) {
+	outs := []SpentOutput{}
+	spentOutputs, ok := mStore.spentOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return outs, errors.New("GetTxSpentOutputs: wrong networkID")
+	}
+	err := spentOutputs.Find(bson.M{"userid": userID, "spendtxid": spendTxID}).All(&outs)
+	return outs, err
+}
+
+// FindSpentOutput returns the record of the output spent, ErrNotFound if it is not spent
+func (mStore *MongoUserStore) FindSpentOutput(currencyID, networkID int, userID, txID, address string) (SpentOutput, error) {
+	out := SpentOutput{}
+	spentOutputs, ok := mStore.spentOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return out, errors.New("FindSpentOutput: wrong networkID")
+	}
+	err := spentOutputs.Find(bson.M{"userid": userID, "txid": txID, "address": address}).One(&out)
+	return out, err
+}
+
+// ReplaceTransaction marks pending tx replaced by another one spending the same outputs.
+// Outputs of the replaced tx are removed and outputs it spent are spent by the replacement.
+// Txs marked replaced are returned.
+func (mStore *MongoUserStore) ReplaceTransaction(currencyID, networkID int, txID, replacedBy string) ([]MultyTX, error) {
+	key := chainKey{currencyID, networkID}
+	spendableOutputs, ok := mStore.spendableOutputs[key]
+	if !ok {
+		return nil, errors.New("ReplaceTransaction: wrong networkID")
+	}
+	txsData := mStore.txsData[key]
+
+	txs := []MultyTX{}
+	err := txsData.Find(bson.M{"txid": txID, "txstatus": mempoolStatusQuery}).All(&txs)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	for i, tx := range txs {
+		txs[i].TxStatus = TxStatusReplaced
+		txs[i].ReplacedBy = replacedBy
+		txs[i].LastUpdate = now
+		err = txsData.UpdateId(tx.ID, bson.M{"$set": bson.M{
+			"txstatus":   TxStatusReplaced,
+			"replacedby": replacedBy,
+			"lastupdate": now,
+		}})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = spendableOutputs.RemoveAll(bson.M{"txid": txID})
+	if err != nil {
+		return nil, err
+	}
+	_, err = mStore.spentOutputs[key].UpdateAll(bson.M{"spendtxid": txID}, bson.M{"$set": bson.M{"spendtxid": replacedBy}})
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// GetAddressPendingTxs returns pending eth txs sent from the address ordered by nonce,
+// the tx is returned once for every user it belongs to. Addresses are matched in lowercase.
+func (mStore *MongoUserStore) GetAddressPendingTxs(currencyID, networkID int, address string) ([]TransactionETH, error) {
+	txs := []TransactionETH{}
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok || !isETH(currencyID, networkID) {
+		return txs, errors.New("GetAddressPendingTxs: wrong networkID")
+	}
+	err := txsData.Find(bson.M{"from": indexedAddress(currencyID, address), "txstatus": mempoolStatusQuery}).Sort("nonce").All(&txs)
+	return txs, err
+}
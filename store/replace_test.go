@@ -0,0 +1,44 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import "testing"
+
+func TestKeepReplacedStatus(t *testing.T) {
+	for _, tc := range []struct {
+		stored, status, want int
+	}{
+		{TxStatusReplaced, TxStatusAppearedInMempoolOutcoming, TxStatusReplaced},
+		{TxStatusReplaced, TxStatusAppearedInBlockOutcoming, TxStatusAppearedInBlockOutcoming},
+		{TxStatusAppearedInMempoolOutcoming, TxStatusReplaced, TxStatusReplaced},
+	} {
+		if got := keepConfirmedStatus(tc.stored, tc.status); got != tc.want {
+			t.Errorf("keepConfirmedStatus(%d, %d) = %d, want %d", tc.stored, tc.status, got, tc.want)
+		}
+	}
+}
+
+func TestIsTxReplacedByNonce(t *testing.T) {
+	s := NewMemoryUserStore()
+	pending := TransactionETH{UserID: "user", Hash: "0xpending", From: "0xfrom", Nonce: 7}
+	if err := s.SaveEthTransaction(60, 4, pending); err != nil {
+		t.Fatal(err)
+	}
+	if replaced, _ := s.IsTxReplaced(60, 4, "0xpending"); replaced {
+		t.Errorf("pending tx is replaced before another one with its nonce is mined")
+	}
+
+	mined := TransactionETH{UserID: "user", Hash: "0xmined", From: "0xfrom", Nonce: 7, BlockHeight: 100}
+	if err := s.SaveEthTransaction(60, 4, mined); err != nil {
+		t.Fatal(err)
+	}
+	if replaced, _ := s.IsTxReplaced(60, 4, "0xpending"); !replaced {
+		t.Errorf("tx is not replaced by the mined one with the same nonce")
+	}
+	if replaced, _ := s.IsTxReplaced(60, 4, "0xmined"); replaced {
+		t.Errorf("mined tx is replaced by itself")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/graarh/golang-socketio"
+	"gopkg.in/mgo.v2/bson"
 )
 
 const (
@@ -21,9 +22,23 @@ const (
 	TxStatusInBlockConfirmedIncoming  = 5
 	TxStatusInBlockConfirmedOutcoming = 6
 
+	// tx was replaced in mempool by another one spending the same outputs
+	TxStatusReplaced = 7
+
 	// ws notification topic
-	TopicTransaction = "TransactionUpdate"
-	TopicNewIncoming = "NewIncoming"
+	TopicTransaction  = "TransactionUpdate"
+	TopicNewIncoming  = "NewIncoming"
+	TopicTxDropped    = "TransactionDropped"
+	TopicMultisigSign = "MultisigSignNeeded"
+)
+
+// statuses of txs sent by users
+const (
+	OutboundTxPending    = 1 // not mined yet, rebroadcast while node services don't see it
+	OutboundTxMined      = 2
+	OutboundTxDropped    = 3 // lost after all rebroadcasts
+	OutboundTxReplaced   = 4 // another tx of the user spending the same inputs or nonce took its place
+	OutboundTxConflicted = 5 // node services reject it as its inputs or nonce are used by another tx
 )
 
 // User represents a single app user
@@ -93,16 +108,19 @@ type Wallet struct {
 	Status string `bson:"status"`
 }
 
+// Multisig is a multisig contract wallet, a tx it sends is executed once
+// the required number of owners confirm it
 type Multisig struct {
-	CurrencyID      int               `bson:"currencyID"`
-	NetworkID       int               `bson:"networkID"`
-	WalletName      string            `bson:"walletName"`
-	ContractAddress string            `bson:"walletName"`
-	TxOfCreation    string            `bson:"txofcreation"`
-	LastActionTime  int64             `bson:"lastActionTime"`
-	DateOfCreation  int64             `bson:"dateOfCreation"`
-	Owners          []AddressExtended `bson:"owners"`
-	Status          string            `bson:"status"`
+	CurrencyID      int               `bson:"currencyID" json:"currencyid"`
+	NetworkID       int               `bson:"networkID" json:"networkid"`
+	WalletName      string            `bson:"walletName" json:"walletname"`
+	ContractAddress string            `bson:"contractAddress" json:"contractaddress"` // lowercase hex address
+	TxOfCreation    string            `bson:"txofcreation" json:"txofcreation"`
+	Confirmations   int               `bson:"confirmations" json:"confirmations"` // owners required to execute a tx
+	LastActionTime  int64             `bson:"lastActionTime" json:"lastactiontime"`
+	DateOfCreation  int64             `bson:"dateOfCreation" json:"dateofcreation"`
+	Owners          []AddressExtended `bson:"owners" json:"owners"`
+	Status          string            `bson:"status" json:"status"`
 }
 
 type RatesRecord struct {
@@ -116,6 +134,16 @@ type Address struct {
 	LastActionTime int64  `json:"lastActionTime" bson:"lastActionTime"`
 }
 
+// UserAddress is a record of the address index, it points to the wallet the address belongs to
+type UserAddress struct {
+	Address      string `bson:"address"`
+	UserID       string `bson:"userID"`
+	WalletIndex  int    `bson:"walletIndex"`
+	AddressIndex int    `bson:"addressIndex"`
+	CurrencyID   int    `bson:"currencyID"`
+	NetworkID    int    `bson:"networkID"`
+}
+
 type WalletsSelect struct {
 	Wallets []struct {
 		Addresses []struct {
@@ -139,8 +167,17 @@ type AddressForWallet struct {
 	Amount          int64  `json:"amount"`
 }
 
+// TxKey identifies tx records of the user in history of any chain.
+// WalletIndex is used by eth txs only, utxo tx records of the user share the key.
+type TxKey struct {
+	TxID        string
+	UserID      string
+	WalletIndex int
+}
+
 // the way how user transations store in db
 type MultyTX struct {
+	ID                bson.ObjectId         `json:"-" bson:"_id,omitempty"`
 	UserId            string                `json:"userid"`
 	TxID              string                `json:"txid"`
 	TxHash            string                `json:"txhash"`
@@ -156,8 +193,11 @@ type MultyTX struct {
 	StockExchangeRate []ExchangeRatesRecord `json:"stockexchangerate"`
 	TxInputs          []AddresAmount        `json:"txinputs"`
 	TxOutputs         []AddresAmount        `json:"txoutputs"`
-	WalletsInput      []WalletForTx         `json:"walletsinput"`  //here we storing all wallets and addresses that took part in Inputs of the transaction
-	WalletsOutput     []WalletForTx         `json:"walletsoutput"` //here we storing all wallets and addresses that took part in Outputs of the transaction
+	WalletsInput      []WalletForTx         `json:"walletsinput"`         //here we storing all wallets and addresses that took part in Inputs of the transaction
+	WalletsOutput     []WalletForTx         `json:"walletsoutput"`        //here we storing all wallets and addresses that took part in Outputs of the transaction
+	LastUpdate        int64                 `json:"lastupdate"`           // unix time the record was inserted or changed
+	RBF               bool                  `json:"rbf"`                  // tx signals it may be replaced by fee
+	ReplacedBy        string                `json:"replacedby,omitempty"` // txid of the tx which replaced this one
 }
 
 type BTCResync struct {
@@ -179,6 +219,11 @@ type WsTxNotify struct {
 	WalletIndex     int    `json:"walletindex"`
 	From            string `json:"from"`
 	To              string `json:"to"`
+
+	// set for token transfers only, amount is in the smallest units of the token
+	Contract string `json:"contract,omitempty"`
+	Symbol   string `json:"symbol,omitempty"`
+	Decimals int    `json:"decimals,omitempty"`
 }
 
 type TransactionWithUserID struct {
@@ -234,6 +279,16 @@ type SpendableOutputs struct {
 	StockExchangeRate []ExchangeRatesRecord `json:"stockexchangerate"`
 }
 
+// SpentOutput is an output spent by a tx seen before the output itself,
+// a copy of the removed output is kept to restore it if the spending tx is orphaned
+type SpentOutput struct {
+	UserID    string            `json:"userid"`
+	TxID      string            `json:"txid"`
+	Address   string            `json:"address"`
+	SpendTxID string            `json:"spendtxid"`
+	Output    *SpendableOutputs `json:"output,omitempty"`
+}
+
 type WalletETH struct {
 	// Currency of wallet.
 	CurrencyID int `bson:"currencyID"`
@@ -264,6 +319,7 @@ type WalletETH struct {
 }
 
 type TransactionETH struct {
+	ID                bson.ObjectId         `json:"-" bson:"_id,omitempty"`
 	UserID            string                `json:"userid"`
 	WalletIndex       int                   `json:"walletindex"`
 	AddressIndex      int                   `json:"addressindex"`
@@ -280,12 +336,272 @@ type TransactionETH struct {
 	BlockHeight       int64                 `json:"blockheight"`
 	Confirmations     int                   `json:"confirmations"`
 	StockExchangeRate []ExchangeRatesRecord `json:"stockexchangerate"`
+	LastUpdate        int64                 `json:"lastupdate"` // unix time the record was inserted or changed
 }
 
 type CoinType struct {
 	СurrencyID int `bson:"currencyID"`
 	NetworkID  int `bson:"networkID"`
 	GRPCUrl    string
+	// GRPCUrls are backup node services of the network, traffic fails over to them in order
+	GRPCUrls []string
+	// MaxLag is how many blocks node service may be behind the others before traffic
+	// fails over, zero is the chain default
+	MaxLag int
+	// ProbeInterval is seconds between health probes of node services, zero is 30 seconds
+	ProbeInterval int
+	// Confirmations is a depth after which txs are confirmed, zero is the chain default
+	Confirmations int
+	// MinGasPrice is the lowest gas price in wei suggested on EVM chains, zero is the chain default
+	MinGasPrice int
+	// TLS secures connections to node services of the network, they are plaintext if it's not set
+	TLS *NodeTLS
+}
+
+// NodeTLS describes TLS of node service connections, it's mutual if client certificate is set.
+// Files are re-read on SIGHUP.
+type NodeTLS struct {
+	// CAFile is a PEM bundle node service certificates are checked against, system roots if empty
+	CAFile string
+	// CertFile and KeyFile are PEM client certificate and its key for mutual TLS
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name node service certificates are checked for, host of the url if empty
+	ServerName string
+}
+
+// Endpoints returns urls of all node services of the network in failover order
+func (ct CoinType) Endpoints() []string {
+	return append([]string{ct.GRPCUrl}, ct.GRPCUrls...)
+}
+
+// FeeRates is a fee estimation for different confirmation speeds
+type FeeRates struct {
+	VerySlow int
+	Slow     int
+	Medium   int
+	Fast     int
+	VeryFast int
+}
+
+// FeeSnapshot is a fee estimation of the chain with block statistics it was made from
+type FeeSnapshot struct {
+	CurrencyID  int       `bson:"currencyid" json:"currencyid"`
+	NetworkID   int       `bson:"networkid" json:"networkid"`
+	Time        time.Time `bson:"time" json:"time"`
+	MempoolSize int       `bson:"mempoolsize" json:"mempoolsize"`
+	Blocks      [][]int   `bson:"blocks" json:"blocks"`         // fee rate percentiles of recent blocks, oldest first
+	BlockSizes  []int     `bson:"blocksizes" json:"blocksizes"` // mempool txs taken by recent blocks
+	Strategy    string    `bson:"strategy" json:"strategy"`
+	Rates       FeeRates  `bson:"rates" json:"rates"`
+}
+
+// MempoolStats is the mempool state of the chain shown to the clients
+type MempoolStats struct {
+	CurrencyID   int              `json:"currencyid"`
+	NetworkID    int              `json:"networkid"`
+	Size         int              `json:"size"`
+	Histogram    []FeeBucket      `json:"histogram"`
+	Trend        []FeeTrendPoint  `json:"trend"`
+	Confirmation *FeeConfirmation `json:"confirmation,omitempty"`
+}
+
+// FeeBucket is a fee rate range of the mempool histogram, zero To is no upper bound
+type FeeBucket struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
+
+// FeeTrendPoint is the mempool state at some block
+type FeeTrendPoint struct {
+	Time        int64    `json:"time"`
+	MempoolSize int      `json:"mempoolsize"`
+	Rates       FeeRates `json:"rates"`
+}
+
+// FeeConfirmation is an estimated wait of a tx paying the fee rate
+type FeeConfirmation struct {
+	FeeRate int   `json:"feerate"`
+	Blocks  int   `json:"blocks"`
+	Seconds int64 `json:"seconds"`
+}
+
+// OutboundTx is a raw tx sent by a user, it is kept until it is mined
+// so it can be broadcast again if node service loses it
+type OutboundTx struct {
+	CurrencyID    int    `json:"currencyid"`
+	NetworkID     int    `json:"networkid"`
+	UserID        string `json:"userid"`
+	TxID          string `json:"txid"`
+	RawTx         string `json:"rawtx"`
+	Status        int    `json:"status"`
+	Submitted     int64  `json:"submitted"`     // unix time the tx was sent first
+	LastBroadcast int64  `json:"lastbroadcast"` // unix time of the last broadcast
+	LastSeen      int64  `json:"lastseen"`      // unix time node service had the tx
+	Broadcasts    int    `json:"broadcasts"`
+}
+
+// events of multisig contracts reported by node services
+const (
+	MultisigEventSubmission       = "submission"
+	MultisigEventConfirmation     = "confirmation"
+	MultisigEventRevocation       = "revocation"
+	MultisigEventExecution        = "execution"
+	MultisigEventExecutionFailure = "execution_failure"
+	MultisigEventOwnerAddition    = "owner_addition"
+	MultisigEventOwnerRemoval     = "owner_removal"
+	MultisigEventRequirement      = "requirement_change"
+)
+
+// statuses of txs submitted to multisig contracts
+const (
+	MultisigTxPending  = 1 // waiting for confirmations of the owners
+	MultisigTxExecuted = 2
+	MultisigTxFailed   = 3 // confirmed but the contract call failed
+)
+
+// MultisigTx is a tx submitted to a multisig contract by one of its owners
+type MultisigTx struct {
+	CurrencyID    int                    `json:"currencyid"`
+	NetworkID     int                    `json:"networkid"`
+	Contract      string                 `json:"contract"`
+	TxIndex       int64                  `json:"txindex"` // id of the tx in the contract
+	Hash          string                 `json:"txhash"`  // hash of the submission
+	Submitter     string                 `json:"submitter"`
+	To            string                 `json:"to"`
+	Amount        string                 `json:"amount"`
+	Confirmations []MultisigConfirmation `json:"confirmations"`
+	Status        int                    `json:"status"`
+	Submitted     int64                  `json:"submitted"`
+	LastUpdate    int64                  `json:"lastupdate"`
+}
+
+// MultisigConfirmation is a confirmation of the tx by the owner
+type MultisigConfirmation struct {
+	Owner string `json:"owner"`
+	Hash  string `json:"txhash"`
+	Time  int64  `json:"time"`
+}
+
+// MultisigSignNeeded is sent to NSQ for every owner who has not confirmed the pending tx yet
+type MultisigSignNeeded struct {
+	UserID      string `json:"userid"`
+	CurrencyID  int    `json:"currencyid"`
+	NetworkID   int    `json:"networkid"`
+	Contract    string `json:"contract"`
+	WalletName  string `json:"walletname"`
+	Owner       string `json:"owner"`
+	TxIndex     int64  `json:"txindex"`
+	To          string `json:"to"`
+	Amount      string `json:"amount"`
+	Confirmed   int    `json:"confirmed"`
+	Required    int    `json:"required"`
+	WalletIndex int    `json:"walletindex"`
+}
+
+// TxDropped is sent to NSQ when a tx of the user is lost for good
+type TxDropped struct {
+	UserID     string `json:"userid"`
+	CurrencyID int    `json:"currencyid"`
+	NetworkID  int    `json:"networkid"`
+	TxID       string `json:"txid"`
+}
+
+// FeeBump describes how a pending utxo tx can be sped up,
+// sizes are estimated in bytes and fee rates are in satoshi per byte
+type FeeBump struct {
+	TxID        string     `json:"txid"`
+	RBF         bool       `json:"rbf"`
+	Size        int        `json:"size"`
+	Fee         int64      `json:"fee"`
+	FeeRate     int        `json:"feerate"`
+	TargetRate  int        `json:"targetrate"`
+	Replacement *FeeBumpTx `json:"replacement,omitempty"` // only if the tx signals rbf
+	CPFP        *FeeBumpTx `json:"cpfp,omitempty"`        // only if the tx has outputs of the user
+}
+
+// FeeBumpTx is a suggested tx to be signed by the client
+type FeeBumpTx struct {
+	Inputs  []SpendableOutputs `json:"inputs"`
+	Outputs []AddresAmount     `json:"outputs"`
+	Size    int                `json:"size"`
+	Fee     int64              `json:"fee"`
+	FeeRate int                `json:"feerate"`
+}
+
+// AddressNonces is the nonce state of an ethereum address, Confirmed is the nonce of the next tx
+// to be mined and Next is the nonce the client should sign the new tx with, the first gap if any
+type AddressNonces struct {
+	Address   string       `json:"address"`
+	Confirmed int64        `json:"confirmed"`
+	Next      int64        `json:"next"`
+	Pending   []PendingETH `json:"pending"`
+	Gaps      []int64      `json:"gaps"` // missing nonces pending txs wait for
+}
+
+// PendingETH is a pending tx of the address, stuck txs have suggestions for the same nonce
+type PendingETH struct {
+	Hash     string          `json:"txhash"`
+	Nonce    int64           `json:"nonce"`
+	GasPrice int64           `json:"gasprice"`
+	PoolTime int64           `json:"mempooltime"`
+	Stuck    string          `json:"stuck,omitempty"` // why the tx is stuck
+	SpeedUp  *SuggestedTxETH `json:"speedup,omitempty"`
+	Cancel   *SuggestedTxETH `json:"cancel,omitempty"`
+}
+
+// SuggestedTxETH is a tx to be signed by the client, it replaces the pending tx with the same nonce
+type SuggestedTxETH struct {
+	Nonce    int64  `json:"nonce"`
+	To       string `json:"to"`
+	Amount   string `json:"amount"`
+	GasPrice int64  `json:"gasprice"`
+	GasLimit int64  `json:"gaslimit"`
+}
+
+// Token is an ERC-20 contract known to the backend, transfers of unknown contracts are ignored
+type Token struct {
+	CurrencyID int    `bson:"currencyid" json:"currencyid"`
+	NetworkID  int    `bson:"networkid" json:"networkid"`
+	Contract   string `bson:"contract" json:"contract"` // lowercase hex address
+	Symbol     string `bson:"symbol" json:"symbol"`
+	Name       string `bson:"name" json:"name"`
+	Decimals   int    `bson:"decimals" json:"decimals"`
+}
+
+// TokenTransfer is a Transfer event of the token from or to an address of the user
+type TokenTransfer struct {
+	ID           bson.ObjectId `json:"-" bson:"_id,omitempty"`
+	CurrencyID   int           `json:"currencyid"`
+	NetworkID    int           `json:"networkid"`
+	UserID       string        `json:"userid"`
+	WalletIndex  int           `json:"walletindex"`
+	AddressIndex int           `json:"addressindex"`
+	Hash         string        `json:"txhash"`
+	LogIndex     int           `json:"logindex"`
+	Contract     string        `json:"contract"`
+	Symbol       string        `json:"symbol"`
+	Decimals     int           `json:"decimals"`
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	Amount       string        `json:"amount"` // in the smallest units of the token
+	Status       int           `json:"txstatus" bson:"txstatus"`
+	BlockTime    int64         `json:"blocktime"`
+	PoolTime     int64         `json:"mempooltime"`
+	BlockHeight  int64         `json:"blockheight"`
+	LastUpdate   int64         `json:"lastupdate"`
+}
+
+// TokenBalance is a balance of the address in the smallest units of the token
+type TokenBalance struct {
+	Contract       string `json:"contract"`
+	Symbol         string `json:"symbol"`
+	Decimals       int    `json:"decimals"`
+	Balance        string `json:"balance"`
+	PendingBalance string `json:"pendingbalance"`
+	// Unknown is set when node service failed to reply the balance
+	Unknown bool `json:"unknown,omitempty"`
 }
 
 type MempoolRecord struct {
@@ -305,11 +621,11 @@ type DonationInfo struct {
 }
 
 type AddressExtended struct {
-	UserID       string
-	Address      string // etereum asociated to contract address
-	Associated   bool   // is associated
-	WalletIndex  int
-	AddressIndex int
+	UserID       string `json:"-"`
+	Address      string `json:"address"`    // etereum asociated to contract address
+	Associated   bool   `json:"associated"` // is associated
+	WalletIndex  int    `json:"walletindex"`
+	AddressIndex int    `json:"addressindex"`
 }
 
 type ServerConfig struct {
@@ -406,9 +722,31 @@ type Payload struct {
 }
 
 type LastState struct {
-	BlockHeight int64 `bson:"blockheight"`
-	CurrencyID  int   `bson:"currencyid"`
-	NetworkID   int   `bson:"networkid"`
+	BlockHeight int64   `bson:"blockheight"`
+	CurrencyID  int     `bson:"currencyid"`
+	NetworkID   int     `bson:"networkid"`
+	Blocks      []Block `bson:"blocks"` // recent blocks, oldest first
+}
+
+// SyncProgress is a catch-up state of the chain after the backend restart
+type SyncProgress struct {
+	CurrencyID    int   `json:"currencyid"`
+	NetworkID     int   `json:"networkid"`
+	FromHeight    int64 `json:"fromheight"`
+	TargetHeight  int64 `json:"targetheight"`
+	CurrentHeight int64 `json:"currentheight"`
+	Synced        bool  `json:"synced"`
+}
+
+// IdempotentResponse is the response to the request of the user with the idempotency key,
+// Code is zero while the request is in progress
+type IdempotentResponse struct {
+	UserID      string    `bson:"userid" json:"userid"`
+	Key         string    `bson:"key" json:"key"`
+	RequestHash string    `bson:"requesthash" json:"requesthash"`
+	Code        int       `bson:"code" json:"code"`
+	Body        []byte    `bson:"body" json:"body"`
+	Created     time.Time `bson:"created" json:"created"`
 }
 
 type NodeVersion struct {
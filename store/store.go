@@ -7,7 +7,9 @@ package store
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Multy-io/Multy-back/currencies"
@@ -23,7 +25,16 @@ var (
 // Default table names
 const (
 	TableUsers             = "UserCollection"
+	TableAddresses         = "AddressCollection"
+	TableMigrations        = "Migrations"
 	TableStockExchangeRate = "TableStockExchangeRate"
+	TableFeeSnapshots      = "FeeSnapshots"
+	TableOutboundTxs       = "OutboundTxs"
+	TableIdempotencyKeys   = "IdempotencyKeys"
+	TableTokens            = "Tokens"
+	TableTokenTransfers    = "TokenTransfers"
+	TableMultisig          = "Multisig"
+	TableMultisigTxs       = "MultisigTxs"
 )
 
 // Conf is a struct for database configuration
@@ -53,6 +64,12 @@ type Conf struct {
 	TableMempoolRatesETHTest string
 	TableTxsDataETHTest      string
 
+	// other bitcoin-like chains
+	UTXOChains []UTXOTables
+
+	// other EVM chains and networks
+	EVMChains []EVMTables
+
 	//RestoreState
 	DBRestoreState string
 	TableState     string
@@ -62,70 +79,195 @@ type Conf struct {
 	Password string
 }
 
+// UTXOTables names collections of a single network of a bitcoin-like chain
+type UTXOTables struct {
+	CurrencyID int
+	NetworkID  int
+
+	TableTxsData          string
+	TableSpendableOutputs string
+	TableSpentOutputs     string
+}
+
+// EVMTables names collections of a single network of an EVM chain
+type EVMTables struct {
+	CurrencyID int
+	NetworkID  int
+
+	TableTxsData string
+}
+
+// ErrNotFound is returned by lookups which found nothing
+var ErrNotFound = mgo.ErrNotFound
+
+// ErrKeyExists is returned when the idempotency key of the user is already reserved
+var ErrKeyExists = errors.New("idempotency key exists")
+
 type UserStore interface {
-	GetUserByDevice(device bson.M, user *User)
-	Update(sel, update bson.M) error
+	// users
 	Insert(user User) error
+	UpdateUser(user User) error
+	FindUserByID(userID string, user *User) error
+	FindUserByToken(token string, user *User) error
+	FindAddress(currencyID, networkID int, address string, ua *UserAddress) error
+	UpdateDeviceToken(userID, oldToken, newToken string) error
+	AddWallet(userID string, wallet Wallet) error
+	AddAddress(userID string, currencyID, networkID, walletIndex int, address Address) error
+	ChangeWalletName(userID string, currencyID, networkID, walletIndex int, name string) error
+	DeleteWallet(userid string, walletindex, currencyID, networkID int) error
+	UpdateAddressLastAction(userID, address string) error
+	FindUserDataChain(CurrencyID, NetworkID int) (map[string]AddressExtended, error)
+	FethUserAddresses(currencyID, networkID int, userid string, addreses []string) ([]AddressExtended, error)
 	Close() error
-	FindUser(query bson.M, user *User) error
-	UpdateUser(sel bson.M, user *User) error
-	// FindUserTxs(query bson.M, userTxs *TxRecord) error
-	// InsertTxStore(userTxs TxRecord) error
-	FindUserErr(query bson.M) error
-	FindUserAddresses(query bson.M, sel bson.M, ws *WalletsSelect) error
+
+	// exchange rates
 	InsertExchangeRate(ExchangeRates, string) error
 	GetExchangeRatesDay() ([]RatesAPIBitstamp, error)
+	GetLatestExchangeRate() ([]ExchangeRatesRecord, error)
+	GetReSyncExchangeRate(time int64) ([]ExchangeRatesRecord, error)
 
-	//TODo update this method by eth
+	// tx history
 	GetAllWalletTransactions(userid string, currencyID, networkID int, walletTxs *[]MultyTX) error
 	GetAllWalletEthTransactions(userid string, currencyID, networkID int, walletTxs *[]TransactionETH) error
-	// GetAllSpendableOutputs(query bson.M) (error, []SpendableOutputs)
-	GetAddressSpendableOutputs(address string, currencyID, networkID int) ([]SpendableOutputs, error)
-	DeleteWallet(userid string, walletindex, currencyID, networkID int) error
-	// DropTest()
-
-	FindAllUserETHTransactions(sel bson.M) ([]TransactionETH, error)
-	FindUserDataChain(CurrencyID, NetworkID int) (map[string]AddressExtended, error)
-
-	FethUserAddresses(currencyID, networkID int, userid string, addreses []string) (AddressExtended, error)
-	// FindAllMultisigContracts( NetworkID int)
-
+	FindWalletTransactions(userid string, currencyID, networkID int, filter TxHistoryFilter) ([]MultyTX, string, error)
+	FindWalletEthTransactions(userid string, currencyID, networkID int, filter TxHistoryFilter) ([]TransactionETH, string, error)
+	GetChangedTransactions(userid string, currencyID, networkID int, since int64, txs *[]MultyTX) error
+	GetChangedEthTransactions(userid string, currencyID, networkID int, since int64, txs *[]TransactionETH) error
+	GetInBlockTransactions(currencyID, networkID int, txs *[]MultyTX) error
+	GetInBlockEthTransactions(currencyID, networkID int, txs *[]TransactionETH) error
+	UpdateTransactionConfirmations(currencyID, networkID int, key TxKey, status, confirmations int) error
+	SaveMultyTransaction(currencyID, networkID int, tx MultyTX) error
+	SaveEthTransaction(currencyID, networkID int, tx TransactionETH) error
 	DeleteHistory(CurrencyID, NetworkID int, Address string) error
 
-	FethLastSyncBlockState(networkid, currencyid int) ([]LastState, error)
+	// spendable outputs of utxo chains
+	GetAddressSpendableOutputs(address string, currencyID, networkID int) ([]SpendableOutputs, error)
+	FindSpendableOutput(currencyID, networkID int, userID, txID string, txOutID int) (SpendableOutputs, error)
+	AddSpendableOutput(currencyID, networkID int, out SpendableOutputs) error
+	DeleteSpendableOutput(currencyID, networkID int, userID, txID, address string) error
+	AddSpentOutput(currencyID, networkID int, out SpentOutput) error
+	IsSpentOutput(currencyID, networkID int, userID, txID, address string) (bool, error)
+
+	// last block seen on the chain
+	FethLastSyncBlockState(networkid, currencyid int) (LastState, error)
+	SetLastSyncBlockState(networkid, currencyid int, block Block) error
+
+	// fee estimation snapshots
+	SaveFeeSnapshot(snapshot FeeSnapshot) error
+	GetLastFeeSnapshot(currencyID, networkID int) (FeeSnapshot, error)
+	GetFeeSnapshots(currencyID, networkID int, since time.Time) ([]FeeSnapshot, error)
+
+	// reorgs
+	RollbackTransactions(currencyID, networkID int, height int64) ([]MultyTX, error)
+	RestoreSpentOutputs(currencyID, networkID int, spendTxID string) error
+	RollbackEthTransactions(currencyID, networkID int, height int64) ([]TransactionETH, error)
+
+	// fee bumps of pending utxo txs
+	FindUserTransaction(userID string, currencyID, networkID int, txID string) (MultyTX, error)
+	GetTxSpendableOutputs(currencyID, networkID int, userID, txID string) ([]SpendableOutputs, error)
+	GetTxSpentOutputs(currencyID, networkID int, userID, spendTxID string) ([]SpentOutput, error)
+	FindSpentOutput(currencyID, networkID int, userID, txID, address string) (SpentOutput, error)
+	ReplaceTransaction(currencyID, networkID int, txID, replacedBy string) ([]MultyTX, error)
+
+	// nonces of pending eth txs
+	GetAddressPendingTxs(currencyID, networkID int, address string) ([]TransactionETH, error)
+
+	// txs sent by users
+	SaveOutboundTx(tx OutboundTx) error
+	GetOutboundTxs(currencyID, networkID, status int) ([]OutboundTx, error)
+	UpdateOutboundTx(tx OutboundTx) error
+	IsTxInBlock(currencyID, networkID int, txID string) (bool, error)
+	IsTxReplaced(currencyID, networkID int, txID string) (bool, error)
+
+	// erc-20 tokens
+	SaveToken(token Token) error
+	GetTokens(currencyID, networkID int) ([]Token, error)
+	FindToken(currencyID, networkID int, contract string) (Token, error)
+	SaveTokenTransfer(transfer TokenTransfer) error
+	GetTokenTransfers(currencyID, networkID int, userID string, walletIndex int) ([]TokenTransfer, error)
+
+	// multisig contract wallets
+	SaveMultisig(multisig Multisig) error
+	FindMultisig(currencyID, networkID int, contract string) (Multisig, error)
+	GetMultisigs(currencyID, networkID int) ([]Multisig, error)
+	GetUserMultisigs(currencyID, networkID int, userID string) ([]Multisig, error)
+	SaveMultisigTx(tx MultisigTx) error
+	FindMultisigTx(currencyID, networkID int, contract string, txIndex int64) (MultisigTx, error)
+	GetMultisigTxs(currencyID, networkID int, contract string) ([]MultisigTx, error)
+
+	// responses to requests with idempotency keys
+	ReserveIdempotencyKey(userID, key, requestHash string, lease time.Duration) error
+	FindIdempotentResponse(userID, key string) (IdempotentResponse, error)
+	SaveIdempotentResponse(userID, key string, code int, body []byte) error
+	ReleaseIdempotencyKey(userID, key string) error
+}
 
-	CheckTx(tx string) bool
+// chainKey identifies collections of a single currency and network
+type chainKey struct {
+	currencyID int
+	networkID  int
 }
 
 type MongoUserStore struct {
 	config    *Conf
 	session   *mgo.Session
 	usersData *mgo.Collection
+	addresses *mgo.Collection // address index of all users wallets
 
-	// btc main
-	BTCMainTxsData          *mgo.Collection
-	BTCMainSpendableOutputs *mgo.Collection
+	migrations *mgo.Collection // applied schema migrations
 
-	// btc test
-	BTCTestTxsData          *mgo.Collection
-	BTCTestSpendableOutputs *mgo.Collection
+	idempotencyKeys *mgo.Collection // responses to repeated requests
 
-	//eth main
-	ETHMainRatesData *mgo.Collection
-	ETHMainTxsData   *mgo.Collection
+	// per chain collections
+	txsData          map[chainKey]*mgo.Collection
+	spendableOutputs map[chainKey]*mgo.Collection
+	spentOutputs     map[chainKey]*mgo.Collection
 
-	//eth test
+	//eth rates
+	ETHMainRatesData *mgo.Collection
 	ETHTestRatesData *mgo.Collection
-	ETHTestTxsData   *mgo.Collection
+
+	feeSnapshots *mgo.Collection // fee estimations of all chains
+	outboundTxs  *mgo.Collection // raw txs sent by users of all chains
+
+	tokens         *mgo.Collection // erc-20 registry of all eth chains
+	tokenTransfers *mgo.Collection // erc-20 transfers of users of all eth chains
+
+	multisig    *mgo.Collection // multisig contracts of all eth chains
+	multisigTxs *mgo.Collection // txs submitted to multisig contracts
 
 	stockExchangeRate *mgo.Collection
-	ethTxHistory      *mgo.Collection
-	ETHTest           *mgo.Collection
 
 	RestoreState *mgo.Collection
 }
 
+// InitUserStore opens the database, applies pending migrations
+// and ensures indexes of all configured chains
 func InitUserStore(conf Conf) (UserStore, error) {
+	uStore, err := openUserStore(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := NewMigrator(uStore).Run()
+	if err != nil {
+		uStore.Close()
+		return nil, err
+	}
+	if len(applied) > 0 {
+		log.Infof("InitUserStore: applied migrations %v", applied)
+	}
+
+	err = NewMigrator(uStore).Reapply()
+	if err != nil {
+		uStore.Close()
+		return nil, fmt.Errorf("InitUserStore: chain indexes: %s", err.Error())
+	}
+	return uStore, nil
+}
+
+// openUserStore dials the database and opens collections only
+func openUserStore(conf Conf) (*MongoUserStore, error) {
 	uStore := &MongoUserStore{
 		config: &conf,
 	}
@@ -145,132 +287,114 @@ func InitUserStore(conf Conf) (UserStore, error) {
 
 	uStore.session = session
 	uStore.usersData = uStore.session.DB(conf.DBUsers).C(TableUsers)
+	uStore.addresses = uStore.session.DB(conf.DBUsers).C(TableAddresses)
+	uStore.migrations = uStore.session.DB(conf.DBUsers).C(TableMigrations)
+	uStore.idempotencyKeys = uStore.session.DB(conf.DBUsers).C(TableIdempotencyKeys)
+	uStore.multisig = uStore.session.DB(conf.DBUsers).C(TableMultisig)
 	uStore.stockExchangeRate = uStore.session.DB(conf.DBStockExchangeRate).C(TableStockExchangeRate)
 
-	// BTC main
-	uStore.BTCMainTxsData = uStore.session.DB(conf.DBTx).C(conf.TableTxsDataBTCMain)
-	uStore.BTCMainSpendableOutputs = uStore.session.DB(conf.DBTx).C(conf.TableSpendableOutputsBTCMain)
-
-	// BTC test
-	uStore.BTCTestTxsData = uStore.session.DB(conf.DBTx).C(conf.TableTxsDataBTCTest)
-	uStore.BTCTestSpendableOutputs = uStore.session.DB(conf.DBTx).C(conf.TableSpendableOutputsBTCTest)
+	db := uStore.session.DB(conf.DBTx)
+	uStore.txsData = map[chainKey]*mgo.Collection{
+		{currencies.Bitcoin, currencies.Main}:  db.C(conf.TableTxsDataBTCMain),
+		{currencies.Bitcoin, currencies.Test}:  db.C(conf.TableTxsDataBTCTest),
+		{currencies.Ether, currencies.ETHMain}: db.C(conf.TableTxsDataETHMain),
+		{currencies.Ether, currencies.ETHTest}: db.C(conf.TableTxsDataETHTest),
+	}
+	uStore.spendableOutputs = map[chainKey]*mgo.Collection{
+		{currencies.Bitcoin, currencies.Main}: db.C(conf.TableSpendableOutputsBTCMain),
+		{currencies.Bitcoin, currencies.Test}: db.C(conf.TableSpendableOutputsBTCTest),
+	}
+	uStore.spentOutputs = map[chainKey]*mgo.Collection{
+		{currencies.Bitcoin, currencies.Main}: db.C(conf.TableSpentOutputsBTCMain),
+		{currencies.Bitcoin, currencies.Test}: db.C(conf.TableSpentOutputsBTCTest),
+	}
+	for _, tables := range conf.UTXOChains {
+		key := chainKey{tables.CurrencyID, tables.NetworkID}
+		uStore.txsData[key] = db.C(tables.TableTxsData)
+		uStore.spendableOutputs[key] = db.C(tables.TableSpendableOutputs)
+		uStore.spentOutputs[key] = db.C(tables.TableSpentOutputs)
+	}
+	for _, tables := range conf.EVMChains {
+		uStore.txsData[chainKey{tables.CurrencyID, tables.NetworkID}] = db.C(tables.TableTxsData)
+	}
+	uStore.outboundTxs = db.C(TableOutboundTxs)
+	uStore.tokens = db.C(TableTokens)
+	uStore.tokenTransfers = db.C(TableTokenTransfers)
+	uStore.multisigTxs = db.C(TableMultisigTxs)
 
-	// ETH main
+	// ETH rates
 	uStore.ETHMainRatesData = uStore.session.DB(conf.DBFeeRates).C(conf.TableMempoolRatesETHMain)
-	uStore.ETHMainTxsData = uStore.session.DB(conf.DBTx).C(conf.TableTxsDataETHMain)
-
-	// ETH test
 	uStore.ETHTestRatesData = uStore.session.DB(conf.DBFeeRates).C(conf.TableMempoolRatesETHTest)
-	uStore.ETHTestTxsData = uStore.session.DB(conf.DBTx).C(conf.TableTxsDataETHTest)
+	uStore.feeSnapshots = uStore.session.DB(conf.DBFeeRates).C(TableFeeSnapshots)
 
 	uStore.RestoreState = uStore.session.DB(conf.DBRestoreState).C(conf.TableState)
 
 	return uStore, nil
 }
 
-func (mStore *MongoUserStore) CheckTx(tx string) bool {
-	query := bson.M{"txid": tx}
-	// sp := SpendableOutputs{}
-	err := mStore.usersData.Find(query).One(nil)
-	if err != nil {
-		return true
-	}
-	return false
-}
-
 func (mStore *MongoUserStore) FindUserDataChain(CurrencyID, NetworkID int) (map[string]AddressExtended, error) {
-	users := []User{}
 	usersData := map[string]AddressExtended{} // addres -> userid
-	err := mStore.usersData.Find(nil).All(&users)
-	if err != nil {
-		return usersData, err
+	iter := mStore.addresses.Find(bson.M{"currencyID": CurrencyID, "networkID": NetworkID}).Iter()
+	for ua := (UserAddress{}); iter.Next(&ua); ua = (UserAddress{}) {
+		usersData[ua.Address] = AddressExtended{
+			UserID:       ua.UserID,
+			WalletIndex:  ua.WalletIndex,
+			AddressIndex: ua.AddressIndex,
+		}
 	}
-	for _, user := range users {
-		for _, wallet := range user.Wallets {
-			if wallet.CurrencyID == CurrencyID && wallet.NetworkID == NetworkID {
-				for _, address := range wallet.Adresses {
-					usersData[address.Address] = AddressExtended{
-						UserID:       user.UserID,
-						WalletIndex:  wallet.WalletIndex,
-						AddressIndex: address.AddressIndex,
-					}
-				}
+	return usersData, iter.Close()
+}
+
+// indexAddress upserts the address to the address index
+func (mStore *MongoUserStore) indexAddress(ua UserAddress) error {
+	ua.Address = indexedAddress(ua.CurrencyID, ua.Address)
+	sel := bson.M{"address": ua.Address, "currencyID": ua.CurrencyID, "networkID": ua.NetworkID}
+	_, err := mStore.addresses.Upsert(sel, ua)
+	return err
+}
+
+func (mStore *MongoUserStore) indexUser(user User) error {
+	for _, wallet := range user.Wallets {
+		for _, address := range wallet.Adresses {
+			err := mStore.indexAddress(userAddress(user.UserID, wallet, address))
+			if err != nil {
+				return err
 			}
 		}
 	}
-	return usersData, nil
+	return nil
 }
 
-func (mStore *MongoUserStore) FethUserAddresses(currencyID, networkID int, userid string, addreses []string) (AddressExtended, error) {
+// FethUserAddresses returns the addresses associated with wallets of the user,
+// addresses not found in the wallets are returned not associated
+func (mStore *MongoUserStore) FethUserAddresses(currencyID, networkID int, userid string, addreses []string) ([]AddressExtended, error) {
 	user := User{}
 	err := mStore.usersData.Find(bson.M{"userID": userid}).One(&user)
 	if err != nil {
-		return AddressExtended{}, err
-	}
-	addresses := []AddressExtended{}
-
-	for _, wallet := range user.Wallets {
-		for _, addres := range wallet.Adresses {
-			if wallet.CurrencyID == currencyID && wallet.NetworkID == networkID {
-				for _, fethAddr := range addreses {
-
-					ae := AddressExtended{
-						Address:    fethAddr,
-						Associated: false,
-					}
-					if fethAddr == addres.Address {
-						ae.Associated = true
-						ae.WalletIndex = wallet.WalletIndex
-						ae.AddressIndex = addres.AddressIndex
-						ae.UserID = userid
-					}
-					addresses = append(addresses, ae)
-				}
-
-			}
-		}
+		return nil, err
 	}
-	return AddressExtended{}, nil
+	return associateAddresses(user, currencyID, networkID, addreses), nil
 }
 
 func (mStore *MongoUserStore) DeleteHistory(CurrencyID, NetworkID int, Address string) error {
-
-	sel := bson.M{"txaddress": Address}
-	switch CurrencyID {
-	case currencies.Bitcoin:
-		if NetworkID == currencies.Main {
-			return mStore.BTCMainTxsData.Remove(sel)
-		}
-		if NetworkID == currencies.Test {
-			return mStore.BTCTestTxsData.Remove(sel)
-		}
-	case currencies.Ether:
-		if NetworkID == currencies.ETHMain {
-
-		}
-		if NetworkID == currencies.ETHTest {
-
-		}
+	key := chainKey{CurrencyID, NetworkID}
+	// history is rebuilt on resync only for utxo chains
+	if _, ok := mStore.spendableOutputs[key]; !ok {
+		return nil
 	}
-	return nil
+	sel := bson.M{"txaddress": Address}
+	return mStore.txsData[key].Remove(sel)
 }
 
-func (mStore *MongoUserStore) FethLastSyncBlockState(networkid, currencyid int) ([]LastState, error) {
-	ls := []LastState{}
-	err := mStore.RestoreState.Find(nil).All(&ls)
+// FethLastSyncBlockState returns the last block height seen by the backend on the chain.
+// Zero height with mgo.ErrNotFound means the chain was never synced.
+func (mStore *MongoUserStore) FethLastSyncBlockState(networkid, currencyid int) (LastState, error) {
+	ls := LastState{}
+	query := bson.M{"currencyid": currencyid, "networkid": networkid}
+	err := mStore.RestoreState.Find(query).One(&ls)
 	return ls, err
 }
 
-func (mStore *MongoUserStore) FindAllUserETHTransactions(sel bson.M) ([]TransactionETH, error) {
-	allTxs := []TransactionETH{}
-	err := mStore.ethTxHistory.Find(sel).All(&allTxs)
-	return allTxs, err
-}
-func (mStore *MongoUserStore) FindETHTransaction(sel bson.M) error {
-	err := mStore.ethTxHistory.Find(sel).One(nil)
-	return err
-}
-
 func (mStore *MongoUserStore) DeleteWallet(userid string, walletindex, currencyID, networkID int) error {
 	user := User{}
 	sel := bson.M{"userID": userid, "wallets.networkID": networkID, "wallets.currencyID": currencyID, "wallets.walletIndex": walletindex}
@@ -285,7 +409,8 @@ func (mStore *MongoUserStore) DeleteWallet(userid string, walletindex, currencyI
 		}
 		update := bson.M{
 			"$set": bson.M{
-				"wallets." + strconv.Itoa(position) + ".status": WalletStatusDeleted,
+				"wallets." + strconv.Itoa(position) + ".status":         WalletStatusDeleted,
+				"wallets." + strconv.Itoa(position) + ".lastActionTime": time.Now().Unix(),
 			},
 		}
 		return mStore.usersData.Update(sel, update)
@@ -302,56 +427,136 @@ func (mStore *MongoUserStore) DeleteWallet(userid string, walletindex, currencyI
 // }
 func (mStore *MongoUserStore) GetAddressSpendableOutputs(address string, currencyID, networkID int) ([]SpendableOutputs, error) {
 	spOuts := []SpendableOutputs{}
-	var err error
-
+	spendableOutputs, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return spOuts, nil
+	}
 	query := bson.M{"address": address}
+	err := spendableOutputs.Find(query).All(&spOuts)
+	return spOuts, err
+}
 
-	switch currencyID {
-	case currencies.Bitcoin:
-		if networkID == currencies.Main {
-			err = mStore.BTCMainSpendableOutputs.Find(query).All(&spOuts)
-		}
-		if networkID == currencies.Test {
-			err = mStore.BTCTestSpendableOutputs.Find(query).All(&spOuts)
-		}
-	case currencies.Litecoin:
-		if networkID == currencies.Main {
-
-		}
-		if networkID == currencies.Test {
+// FindSpendableOutput returns the output of the user, ErrNotFound if it is spent or unknown
+func (mStore *MongoUserStore) FindSpendableOutput(currencyID, networkID int, userID, txID string, txOutID int) (SpendableOutputs, error) {
+	out := SpendableOutputs{}
+	spendableOutputs, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return out, errors.New("FindSpendableOutput: wrong networkID")
+	}
+	query := bson.M{"userid": userID, "txid": txID, "txoutid": txOutID}
+	err := spendableOutputs.Find(query).One(&out)
+	return out, err
+}
 
-		}
+func (mStore *MongoUserStore) UpdateUser(user User) error {
+	err := mStore.usersData.Update(bson.M{"userID": user.UserID}, user)
+	if err != nil {
+		return err
 	}
+	return mStore.indexUser(user)
+}
 
-	return spOuts, err
+func (mStore *MongoUserStore) FindUserByID(userID string, user *User) error {
+	return mStore.usersData.Find(bson.M{"userID": userID}).One(user)
 }
 
-func (mStore *MongoUserStore) UpdateUser(sel bson.M, user *User) error {
-	return mStore.usersData.Update(sel, user)
+func (mStore *MongoUserStore) FindUserByToken(token string, user *User) error {
+	return mStore.usersData.Find(bson.M{"devices.JWT": token}).One(user)
 }
 
-func (mStore *MongoUserStore) GetUserByDevice(device bson.M, user *User) { // rename GetUserByToken
-	mStore.usersData.Find(device).One(user)
-	return // why?
+func (mStore *MongoUserStore) FindAddress(currencyID, networkID int, address string, ua *UserAddress) error {
+	sel := bson.M{"address": indexedAddress(currencyID, address), "currencyID": currencyID, "networkID": networkID}
+	return mStore.addresses.Find(sel).One(ua)
 }
 
-func (mStore *MongoUserStore) Update(sel, update bson.M) error {
+func (mStore *MongoUserStore) UpdateDeviceToken(userID, oldToken, newToken string) error {
+	sel := bson.M{"userID": userID, "devices.JWT": oldToken}
+	update := bson.M{"$set": bson.M{"devices.$.JWT": newToken}}
 	return mStore.usersData.Update(sel, update)
 }
 
-func (mStore *MongoUserStore) FindUser(query bson.M, user *User) error {
-	return mStore.usersData.Find(query).One(user)
+func (mStore *MongoUserStore) AddWallet(userID string, wallet Wallet) error {
+	update := bson.M{"$push": bson.M{"wallets": wallet}}
+	err := mStore.usersData.Update(bson.M{"userID": userID}, update)
+	if err != nil {
+		return err
+	}
+	for _, address := range wallet.Adresses {
+		err = mStore.indexAddress(userAddress(userID, wallet, address))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
-func (mStore *MongoUserStore) FindUserErr(query bson.M) error {
-	return mStore.usersData.Find(query).One(nil)
+
+func (mStore *MongoUserStore) AddAddress(userID string, currencyID, networkID, walletIndex int, address Address) error {
+	user := User{}
+	if err := mStore.FindUserByID(userID, &user); err != nil {
+		return err
+	}
+	position := walletPosition(user, currencyID, networkID, walletIndex)
+	if position < 0 {
+		return ErrNotFound
+	}
+	update := bson.M{
+		"$push": bson.M{"wallets." + strconv.Itoa(position) + ".addresses": address},
+		"$set":  bson.M{"wallets." + strconv.Itoa(position) + ".lastActionTime": time.Now().Unix()},
+	}
+	err := mStore.usersData.Update(bson.M{"userID": userID}, update)
+	if err != nil {
+		return err
+	}
+	return mStore.indexAddress(userAddress(userID, user.Wallets[position], address))
 }
 
-func (mStore *MongoUserStore) FindUserAddresses(query bson.M, sel bson.M, ws *WalletsSelect) error {
-	return mStore.usersData.Find(query).Select(sel).One(ws)
+func (mStore *MongoUserStore) ChangeWalletName(userID string, currencyID, networkID, walletIndex int, name string) error {
+	user := User{}
+	if err := mStore.FindUserByID(userID, &user); err != nil {
+		return err
+	}
+	position := walletPosition(user, currencyID, networkID, walletIndex)
+	if position < 0 {
+		return ErrNotFound
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"wallets." + strconv.Itoa(position) + ".walletName":     name,
+			"wallets." + strconv.Itoa(position) + ".lastActionTime": time.Now().Unix(),
+		},
+	}
+	return mStore.usersData.Update(bson.M{"userID": userID}, update)
+}
+
+// UpdateAddressLastAction marks the address and its wallet as recently used
+func (mStore *MongoUserStore) UpdateAddressLastAction(userID, address string) error {
+	sel := bson.M{"userID": userID, "wallets.addresses.address": address}
+	user := User{}
+	err := mStore.usersData.Find(sel).One(&user)
+	if err != nil {
+		return err
+	}
+
+	i, j := addressPosition(user, address)
+	if i < 0 {
+		return ErrNotFound
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"wallets." + strconv.Itoa(i) + ".lastActionTime":                                   time.Now().Unix(),
+			"wallets." + strconv.Itoa(i) + ".addresses." + strconv.Itoa(j) + ".lastActionTime": time.Now().Unix(),
+			"wallets." + strconv.Itoa(i) + ".status":                                           WalletStatusOK,
+		},
+	}
+	return mStore.usersData.Update(sel, update)
 }
 
 func (mStore *MongoUserStore) Insert(user User) error {
-	return mStore.usersData.Insert(user)
+	err := mStore.usersData.Insert(user)
+	if err != nil {
+		return err
+	}
+	return mStore.indexUser(user)
 }
 
 func (mStore *MongoUserStore) InsertExchangeRate(eRate ExchangeRates, exchangeStock string) error {
@@ -371,36 +576,368 @@ func (mStore *MongoUserStore) GetExchangeRatesDay() ([]RatesAPIBitstamp, error)
 }
 
 func (mStore *MongoUserStore) GetAllWalletTransactions(userid string, currencyID, networkID int, walletTxs *[]MultyTX) error {
-	switch currencyID {
-	case currencies.Bitcoin:
-		query := bson.M{"userid": userid}
-		if networkID == currencies.Main {
-			return mStore.BTCMainTxsData.Find(query).All(walletTxs)
-		}
-		if networkID == currencies.Test {
-			return mStore.BTCTestTxsData.Find(query).All(walletTxs)
-		}
+	// only utxo chains store MultyTX
+	if _, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]; !ok {
+		return nil
 	}
-	return nil
+	query := bson.M{"userid": userid}
+	return mStore.txsData[chainKey{currencyID, networkID}].Find(query).All(walletTxs)
 }
 
 func (mStore *MongoUserStore) GetAllWalletEthTransactions(userid string, currencyID, networkID int, walletTxs *[]TransactionETH) error {
-	switch currencyID {
-	case currencies.Ether:
-		query := bson.M{"userid": userid}
-		if networkID == currencies.ETHMain {
-			return mStore.ETHMainTxsData.Find(query).All(walletTxs)
-		}
-		if networkID == currencies.ETHTest {
-			err := mStore.ETHTestTxsData.Find(query).All(walletTxs)
-			return err
-		}
+	// utxo chains store MultyTX
+	if _, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]; ok {
+		return nil
+	}
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok {
+		return nil
+	}
+	query := bson.M{"userid": userid}
+	return txsData.Find(query).All(walletTxs)
+}
 
+// GetChangedTransactions returns utxo txs of the user inserted or changed since the time,
+// zero time returns all of them
+func (mStore *MongoUserStore) GetChangedTransactions(userid string, currencyID, networkID int, since int64, txs *[]MultyTX) error {
+	if _, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]; !ok {
+		return nil
 	}
-	return nil
+	query := bson.M{"userid": userid}
+	if since > 0 {
+		query["lastupdate"] = bson.M{"$gte": since}
+	}
+	return mStore.txsData[chainKey{currencyID, networkID}].Find(query).All(txs)
+}
+
+// GetChangedEthTransactions is GetChangedTransactions for eth txs
+func (mStore *MongoUserStore) GetChangedEthTransactions(userid string, currencyID, networkID int, since int64, txs *[]TransactionETH) error {
+	txsData, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok || !isETH(currencyID, networkID) {
+		return nil
+	}
+	query := bson.M{"userid": userid}
+	if since > 0 {
+		query["lastupdate"] = bson.M{"$gte": since}
+	}
+	return txsData.Find(query).All(txs)
 }
 
 func (mStore *MongoUserStore) Close() error {
 	mStore.session.Close()
 	return nil
 }
+
+// GetLatestExchangeRate returns the latest Poloniex and Gdax rates
+func (mStore *MongoUserStore) GetLatestExchangeRate() ([]ExchangeRatesRecord, error) {
+	selGdax := bson.M{
+		"stockexchange": "Gdax",
+	}
+	selPoloniex := bson.M{
+		"stockexchange": "Poloniex",
+	}
+	stocksGdax := ExchangeRatesRecord{}
+	err := mStore.stockExchangeRate.Find(selGdax).Sort("-timestamp").One(&stocksGdax)
+	if err != nil {
+		return nil, err
+	}
+
+	stocksPoloniex := ExchangeRatesRecord{}
+	err = mStore.stockExchangeRate.Find(selPoloniex).Sort("-timestamp").One(&stocksPoloniex)
+	if err != nil {
+		return nil, err
+	}
+	return []ExchangeRatesRecord{stocksPoloniex, stocksGdax}, nil
+}
+
+// GetReSyncExchangeRate returns CCCAGG rates seen before the given time
+func (mStore *MongoUserStore) GetReSyncExchangeRate(time int64) ([]ExchangeRatesRecord, error) {
+	selCCCAGG := bson.M{
+		"stockexchange": "CCCAGG",
+		"timestamp":     bson.M{"$lt": time},
+	}
+	stocksCCCAGG := ExchangeRatesRecord{}
+	err := mStore.stockExchangeRate.Find(selCCCAGG).Sort("-timestamp").One(&stocksCCCAGG)
+	if err != nil {
+		return nil, err
+	}
+	return []ExchangeRatesRecord{stocksCCCAGG}, nil
+}
+
+// SaveMultyTransaction inserts utxo tx to the history or updates its status if the tx is already there
+func (mStore *MongoUserStore) SaveMultyTransaction(currencyID, networkID int, tx MultyTX) error {
+	txStore, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok {
+		return errors.New("SaveMultyTransaction: wrong networkID")
+	}
+
+	// Doubling txs fix on a asynchronous err
+	if len(tx.WalletsInput) != 0 {
+		sel := bson.M{"userid": tx.UserId, "txid": tx.TxID, "walletsoutput.walletindex": tx.WalletsInput[0].WalletIndex, "mempooltime": tx.MempoolTime}
+		err := txStore.Find(sel).One(nil)
+		if err == nil {
+			return nil
+		}
+	}
+	if len(tx.WalletsOutput) > 0 {
+		sel := bson.M{"userid": tx.UserId, "txid": tx.TxID, "walletsoutput.walletindex": tx.WalletsOutput[0].WalletIndex, "mempooltime": tx.MempoolTime}
+		err := txStore.Find(sel).One(nil)
+		if err == nil {
+			return nil
+		}
+	}
+
+	var sel bson.M
+	switch {
+	case len(tx.WalletsInput) > 0:
+		// outgoing transaction for exact wallet
+		sel = bson.M{"userid": tx.WalletsInput[0].UserId, "txid": tx.TxID, "txaddress": tx.TxAddress[0]}
+	case len(tx.WalletsOutput) > 0:
+		sel = bson.M{"userid": tx.WalletsOutput[0].UserId, "txid": tx.TxID, "txaddress": tx.TxAddress[0]}
+	default:
+		return nil
+	}
+
+	tx.LastUpdate = time.Now().Unix()
+	stored := MultyTX{}
+	err := txStore.Find(sel).One(&stored)
+	if err == mgo.ErrNotFound {
+		// initial insertion
+		return txStore.Insert(tx)
+	}
+	if err != nil {
+		return err
+	}
+	tx.TxStatus = keepConfirmedStatus(stored.TxStatus, tx.TxStatus)
+
+	update := bson.M{
+		"$set": bson.M{
+			"lastupdate":    tx.LastUpdate,
+			"txstatus":      tx.TxStatus,
+			"blockheight":   tx.BlockHeight,
+			"confirmations": tx.Confirmations,
+			"blocktime":     tx.BlockTime,
+			"walletsoutput": tx.WalletsOutput,
+			"walletsinput":  tx.WalletsInput,
+		},
+	}
+	return txStore.Update(sel, update)
+}
+
+// SaveEthTransaction inserts eth tx to the wallet history or updates its status if the tx is already there
+func (mStore *MongoUserStore) SaveEthTransaction(currencyID, networkID int, tx TransactionETH) error {
+	txStore, ok := mStore.txsData[chainKey{currencyID, networkID}]
+	if !ok {
+		return errors.New("SaveEthTransaction: wrong networkID")
+	}
+
+	sel := bson.M{"userid": tx.UserID, "hash": tx.Hash, "walletindex": tx.WalletIndex}
+	tx.LastUpdate = time.Now().Unix()
+	stored := TransactionETH{}
+	err := txStore.Find(sel).One(&stored)
+	if err == mgo.ErrNotFound {
+		// initial insertion
+		return txStore.Insert(tx)
+	}
+	if err != nil {
+		return err
+	}
+	tx.Status = keepConfirmedStatus(stored.Status, tx.Status)
+
+	update := bson.M{
+		"$set": bson.M{
+			"lastupdate":  tx.LastUpdate,
+			"txstatus":    tx.Status,
+			"blockheight": tx.BlockHeight,
+			"blocktime":   tx.BlockTime,
+		},
+	}
+	return txStore.Update(sel, update)
+}
+
+// AddSpendableOutput inserts the output or updates its status if the output is already there
+func (mStore *MongoUserStore) AddSpendableOutput(currencyID, networkID int, out SpendableOutputs) error {
+	spendableOutputs, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return errors.New("AddSpendableOutput: wrong networkID")
+	}
+
+	query := bson.M{"userid": out.UserID, "txid": out.TxID, "address": out.Address}
+	err := spendableOutputs.Find(query).One(nil)
+	if err == mgo.ErrNotFound {
+		return spendableOutputs.Insert(out)
+	}
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"txstatus": out.TxStatus,
+		},
+	}
+	return spendableOutputs.Update(query, update)
+}
+
+func (mStore *MongoUserStore) DeleteSpendableOutput(currencyID, networkID int, userID, txID, address string) error {
+	spendableOutputs, ok := mStore.spendableOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return errors.New("DeleteSpendableOutput: wrong networkID")
+	}
+	query := bson.M{"userid": userID, "txid": txID, "address": address}
+	out := SpendableOutputs{}
+	err := spendableOutputs.Find(query).One(&out)
+	if err != nil {
+		return err
+	}
+	err = spendableOutputs.Remove(query)
+	if err != nil {
+		return err
+	}
+
+	// keep the output in its spent record, a reorg may bring it back
+	err = mStore.spentOutputs[chainKey{currencyID, networkID}].Update(query, bson.M{"$set": bson.M{"output": out}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (mStore *MongoUserStore) AddSpentOutput(currencyID, networkID int, out SpentOutput) error {
+	spentOutputs, ok := mStore.spentOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return errors.New("AddSpentOutput: wrong networkID")
+	}
+	return spentOutputs.Insert(out)
+}
+
+// IsSpentOutput reports whether the output was already spent,
+// outputs of a mined tx may come after its spending tx
+func (mStore *MongoUserStore) IsSpentOutput(currencyID, networkID int, userID, txID, address string) (bool, error) {
+	spentOutputs, ok := mStore.spentOutputs[chainKey{currencyID, networkID}]
+	if !ok {
+		return false, errors.New("IsSpentOutput: wrong networkID")
+	}
+	query := bson.M{"userid": userID, "txid": txID, "address": address}
+	err := spentOutputs.Find(query).One(nil)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SetLastSyncBlockState saves the last block seen on the chain,
+// the block replaces recent blocks at its height and above
+func (mStore *MongoUserStore) SetLastSyncBlockState(networkid, currencyid int, block Block) error {
+	query := bson.M{"currencyid": currencyid, "networkid": networkid}
+	ls := LastState{}
+	err := mStore.RestoreState.Find(query).One(&ls)
+	if err == mgo.ErrNotFound {
+		return mStore.RestoreState.Insert(LastState{
+			BlockHeight: block.Height,
+			CurrencyID:  currencyid,
+			NetworkID:   networkid,
+			Blocks:      appendBlock(nil, block),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"blockheight": block.Height,
+			"blocks":      appendBlock(ls.Blocks, block),
+		},
+	}
+	return mStore.RestoreState.Update(query, update)
+}
+
+// walletPosition returns index of the wallet in user wallets or -1
+func walletPosition(user User, currencyID, networkID, walletIndex int) int {
+	for i, wallet := range user.Wallets {
+		if wallet.CurrencyID == currencyID && wallet.NetworkID == networkID && wallet.WalletIndex == walletIndex {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexedAddress returns the address as it is kept in the address index,
+// eth addresses are case insensitive and kept in lowercase
+func indexedAddress(currencyID int, address string) string {
+	if evmCurrencies[currencyID] {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+func userAddress(userID string, wallet Wallet, address Address) UserAddress {
+	return UserAddress{
+		Address:      address.Address,
+		UserID:       userID,
+		WalletIndex:  wallet.WalletIndex,
+		AddressIndex: address.AddressIndex,
+		CurrencyID:   wallet.CurrencyID,
+		NetworkID:    wallet.NetworkID,
+	}
+}
+
+// addressPosition returns indexes of the wallet and the address in it or -1, -1
+func addressPosition(user User, address string) (int, int) {
+	for i, wallet := range user.Wallets {
+		for j, addr := range wallet.Adresses {
+			if addr.Address == address {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// associateAddresses marks the addresses found in wallets of the user on the chain,
+// eth addresses are compared case insensitive
+func associateAddresses(user User, currencyID, networkID int, addresses []string) []AddressExtended {
+	associated := make([]AddressExtended, 0, len(addresses))
+	for _, address := range addresses {
+		ae := AddressExtended{
+			Address: address,
+		}
+		for _, wallet := range user.Wallets {
+			if wallet.CurrencyID != currencyID || wallet.NetworkID != networkID {
+				continue
+			}
+			for _, addr := range wallet.Adresses {
+				if strings.EqualFold(addr.Address, address) {
+					ae.Associated = true
+					ae.UserID = user.UserID
+					ae.WalletIndex = wallet.WalletIndex
+					ae.AddressIndex = addr.AddressIndex
+				}
+			}
+		}
+		associated = append(associated, ae)
+	}
+	return associated
+}
+
+// SaveFeeSnapshot saves fee estimation of the chain
+func (mStore *MongoUserStore) SaveFeeSnapshot(snapshot FeeSnapshot) error {
+	return mStore.feeSnapshots.Insert(snapshot)
+}
+
+// GetLastFeeSnapshot returns the latest fee estimation saved for the chain
+func (mStore *MongoUserStore) GetLastFeeSnapshot(currencyID, networkID int) (FeeSnapshot, error) {
+	snapshot := FeeSnapshot{}
+	query := bson.M{"currencyid": currencyID, "networkid": networkID}
+	err := mStore.feeSnapshots.Find(query).Sort("-time").One(&snapshot)
+	return snapshot, err
+}
+
+// GetFeeSnapshots returns fee estimations of the chain saved since the time, oldest first
+func (mStore *MongoUserStore) GetFeeSnapshots(currencyID, networkID int, since time.Time) ([]FeeSnapshot, error) {
+	snapshots := []FeeSnapshot{}
+	query := bson.M{"currencyid": currencyID, "networkid": networkID, "time": bson.M{"$gte": since}}
+	err := mStore.feeSnapshots.Find(query).Sort("time").All(&snapshots)
+	return snapshots, err
+}
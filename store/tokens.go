@@ -0,0 +1,68 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package store
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SaveToken adds the token to the registry or updates its metadata
+func (mStore *MongoUserStore) SaveToken(token Token) error {
+	query := bson.M{"currencyid": token.CurrencyID, "networkid": token.NetworkID, "contract": token.Contract}
+	_, err := mStore.tokens.Upsert(query, token)
+	return err
+}
+
+// GetTokens returns tokens of the chain
+func (mStore *MongoUserStore) GetTokens(currencyID, networkID int) ([]Token, error) {
+	tokens := []Token{}
+	err := mStore.tokens.Find(bson.M{"currencyid": currencyID, "networkid": networkID}).Sort("symbol").All(&tokens)
+	return tokens, err
+}
+
+// FindToken returns the token of the contract, ErrNotFound if it is not in the registry
+func (mStore *MongoUserStore) FindToken(currencyID, networkID int, contract string) (Token, error) {
+	token := Token{}
+	err := mStore.tokens.Find(bson.M{"currencyid": currencyID, "networkid": networkID, "contract": contract}).One(&token)
+	return token, err
+}
+
+// SaveTokenTransfer inserts the transfer or updates its status once it is mined,
+// a transfer between wallets of the user is kept in both of them
+func (mStore *MongoUserStore) SaveTokenTransfer(transfer TokenTransfer) error {
+	transfer.LastUpdate = time.Now().Unix()
+	query := bson.M{
+		"currencyid":  transfer.CurrencyID,
+		"networkid":   transfer.NetworkID,
+		"userid":      transfer.UserID,
+		"walletindex": transfer.WalletIndex,
+		"hash":        transfer.Hash,
+		"logindex":    transfer.LogIndex,
+	}
+	n, err := mStore.tokenTransfers.Find(query).Count()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return mStore.tokenTransfers.Insert(transfer)
+	}
+	return mStore.tokenTransfers.Update(query, bson.M{"$set": bson.M{
+		"txstatus":    transfer.Status,
+		"blocktime":   transfer.BlockTime,
+		"blockheight": transfer.BlockHeight,
+		"lastupdate":  transfer.LastUpdate,
+	}})
+}
+
+// GetTokenTransfers returns token history of the wallet of the user, newest first
+func (mStore *MongoUserStore) GetTokenTransfers(currencyID, networkID int, userID string, walletIndex int) ([]TokenTransfer, error) {
+	transfers := []TokenTransfer{}
+	query := bson.M{"currencyid": currencyID, "networkid": networkID, "userid": userID, "walletindex": walletIndex}
+	err := mStore.tokenTransfers.Find(query).Sort("-blocktime", "-pooltime").All(&transfers)
+	return transfers, err
+}
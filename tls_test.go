@@ -0,0 +1,72 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+)
+
+func TestNodeServiceMutualTLS(t *testing.T) {
+	h := newHarness(t, walletUser("tls-user", currencies.Litecoin, currencies.Main, "ltc-address"))
+	defer h.Close()
+
+	if _, ok := h.ltcMain.UsersData()["ltc-address"]; !ok {
+		t.Fatalf("ltc main UsersData: ltc-address is not sent over TLS")
+	}
+
+	issuer := func() string {
+		certs := h.ltcMain.ClientCertificates()
+		if len(certs) == 0 {
+			return ""
+		}
+		return certs[len(certs)-1].Issuer.CommonName
+	}
+	if got := issuer(); got != "client ca" {
+		t.Errorf("client certificate issued by %q, want client ca", got)
+	}
+
+	// client certificates are rotated to a new CA, the node service accepts both
+	rotatedCA, err := newTestCA("rotated client ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTLS, err := h.nodeCA.serverTLS(h.clientCA, rotatedCA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.ltcMain.SetTLS(serverTLS)
+	if err := rotatedCA.writeClientCert(h.certDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.multy.ReloadCertificates(); err != nil {
+		t.Fatalf("ReloadCertificates: %s", err.Error())
+	}
+
+	// connections dialled after the reload present the rotated certificate
+	handshakes := len(h.ltcMain.ClientCertificates())
+	if err := h.ltcMain.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	h.waitFor("users data sent after the restart", func() bool {
+		_, ok := h.ltcMain.UsersData()["ltc-address"]
+		return ok && len(h.ltcMain.ClientCertificates()) > handshakes
+	})
+	if got := issuer(); got != "rotated client ca" {
+		t.Errorf("client certificate after the reload issued by %q, want rotated client ca", got)
+	}
+
+	// broken files keep certificates loaded before
+	if err := ioutil.WriteFile(filepath.Join(h.certDir, "cert.pem"), []byte("broken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.multy.ReloadCertificates(); err == nil {
+		t.Errorf("ReloadCertificates: broken certificate is accepted")
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	ethpb "github.com/Multy-io/Multy-back/node-streamer/eth"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestTokenTransfers(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	dai := store.Token{
+		CurrencyID: currencies.Ether,
+		NetworkID:  currencies.ETHTest,
+		Contract:   "0xdai",
+		Symbol:     "DAI",
+		Decimals:   18,
+	}
+	if err := h.userStore.SaveToken(dai); err != nil {
+		t.Fatal(err)
+	}
+
+	token := h.login("token-user")
+	h.addWallet(token, currencies.Ether, currencies.ETHTest, 0, "0xholder")
+
+	// the contract is matched case insensitive, unknown tokens are ignored
+	h.ethTest.AddTokenTransfer(&ethpb.TokenTransfer{
+		UserID:     "token-user",
+		Hash:       "0xunknown",
+		Contract:   "0xscam",
+		From:       "0xsender",
+		To:         "0xholder",
+		Amount:     "1",
+		Status:     store.TxStatusAppearedInMempoolIncoming,
+		TxpoolTime: time.Now().Unix(),
+	})
+	h.ethTest.AddTokenTransfer(&ethpb.TokenTransfer{
+		UserID:     "token-user",
+		Hash:       "0xtransfer",
+		Contract:   "0xDAI",
+		From:       "0xsender",
+		To:         "0xholder",
+		Amount:     "5000000000000000000",
+		Status:     store.TxStatusAppearedInMempoolIncoming,
+		TxpoolTime: time.Now().Unix(),
+	})
+
+	notify := h.waitForTxNotification("token notification", "0xtransfer", nil)
+	if m := notify.NotificationMsg; m.Contract != "0xdai" || m.Symbol != "DAI" || m.Decimals != 18 || m.Address != "0xholder" {
+		t.Errorf("token notification: %+v", m)
+	}
+
+	w := h.do(http.MethodGet, "/api/v1/wallets/tokens/60/4/0", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("token history: %d %s", w.Code, w.Body.String())
+	}
+	history := struct {
+		History []store.TokenTransfer `json:"history"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.History) != 1 {
+		t.Fatalf("token history: %+v", history.History)
+	}
+	if tr := history.History[0]; tr.Hash != "0xtransfer" || tr.Symbol != "DAI" || tr.Amount != "5000000000000000000" {
+		t.Errorf("token transfer: %+v", tr)
+	}
+
+	// balance of another token can't be checked, the DAI one is returned anyway
+	usdt := store.Token{
+		CurrencyID: currencies.Ether,
+		NetworkID:  currencies.ETHTest,
+		Contract:   "0xusdt",
+		Symbol:     "USDT",
+		Decimals:   6,
+	}
+	if err := h.userStore.SaveToken(usdt); err != nil {
+		t.Fatal(err)
+	}
+	h.ethTest.FailTokenBalance("0xusdt", errors.New("contract call failed"))
+	h.ethTest.SetTokenBalance("0xholder", "0xdai", "5000000000000000000", "0")
+	w = h.do(http.MethodGet, "/api/v1/wallet/0/verbose/60/4", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("wallet verbose: %d %s", w.Code, w.Body.String())
+	}
+	verbose := struct {
+		Wallet []struct {
+			Addresses []struct {
+				Tokens []store.TokenBalance `json:"tokens"`
+			} `json:"addresses"`
+		} `json:"wallet"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &verbose); err != nil {
+		t.Fatal(err)
+	}
+	if len(verbose.Wallet) != 1 || len(verbose.Wallet[0].Addresses) != 1 {
+		t.Fatalf("wallet verbose: %s", w.Body.String())
+	}
+	balances := map[string]store.TokenBalance{}
+	for _, tb := range verbose.Wallet[0].Addresses[0].Tokens {
+		balances[tb.Symbol] = tb
+	}
+	if dai := balances["DAI"]; len(balances) != 2 || dai.Balance != "5000000000000000000" || dai.Unknown {
+		t.Errorf("token balances: %+v", balances)
+	}
+	if usdt := balances["USDT"]; !usdt.Unknown {
+		t.Errorf("failed token balance is not marked unknown: %+v", usdt)
+	}
+}
+
+func TestTokenTransferBetweenWallets(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	err := h.userStore.SaveToken(store.Token{
+		CurrencyID: currencies.Ether,
+		NetworkID:  currencies.ETHTest,
+		Contract:   "0xdai",
+		Symbol:     "DAI",
+		Decimals:   18,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := h.login("token-user")
+	for i, address := range []string{"0xspender", "0xsaver"} {
+		h.addWallet(token, currencies.Ether, currencies.ETHTest, i, address)
+	}
+
+	// node service reports the transfer once for the sending and once for the receiving wallet
+	for i, status := range []int{store.TxStatusAppearedInMempoolOutcoming, store.TxStatusAppearedInMempoolIncoming} {
+		h.ethTest.AddTokenTransfer(&ethpb.TokenTransfer{
+			UserID:      "token-user",
+			WalletIndex: int32(i),
+			Hash:        "0xinternal",
+			Contract:    "0xdai",
+			From:        "0xspender",
+			To:          "0xsaver",
+			Amount:      "1000000000000000000",
+			Status:      int32(status),
+			TxpoolTime:  time.Now().Unix(),
+		})
+	}
+
+	history := func(walletIndex int) []store.TokenTransfer {
+		w := h.do(http.MethodGet, fmt.Sprintf("/api/v1/wallets/tokens/60/4/%d", walletIndex), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("token history: %d %s", w.Code, w.Body.String())
+		}
+		resp := struct {
+			History []store.TokenTransfer `json:"history"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.History
+	}
+	h.waitFor("transfer in the receiving wallet", func() bool {
+		return len(history(1)) == 1
+	})
+	if got := history(1)[0]; got.Hash != "0xinternal" || got.Status != store.TxStatusAppearedInMempoolIncoming {
+		t.Errorf("receiving wallet transfer: %+v", got)
+	}
+	if got := history(0); len(got) != 1 || got[0].Status != store.TxStatusAppearedInMempoolOutcoming {
+		t.Errorf("sending wallet transfers: %+v", got)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/chain"
+	"github.com/Multy-io/Multy-back/currencies"
+)
+
+func TestSendRawTxValidation(t *testing.T) {
+	h := newHarness(t)
+	defer h.Close()
+
+	token := h.login("send-user")
+	prevTxID := strings.Repeat("ab", 32)
+	h.addOutput(currencies.Bitcoin, currencies.Test, "send-user", "send-address", prevTxID, 100000)
+
+	send := func(currencyID, networkID int, rawTx string) (int, *chain.TxError) {
+		w := h.sendTx(token, nil, currencyID, networkID, "send-change", rawTx)
+		resp := struct {
+			Error *chain.TxError `json:"error"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("json.Unmarshal: %s", err.Error())
+		}
+		return w.Code, resp.Error
+	}
+
+	for _, tc := range []struct {
+		name, rawTx string
+		want        string
+	}{
+		{"not hex", "zz", chain.TxErrDecode},
+		{"unknown input", rawBTCTx(t, strings.Repeat("cd", 32), 99000), chain.TxErrUnknownInput},
+		{"dust", rawBTCTx(t, prevTxID, 98000, 100), chain.TxErrDustOutput},
+		{"overspend", rawBTCTx(t, prevTxID, 200000), chain.TxErrFeeTooLow},
+		{"absurd fee", rawBTCTx(t, prevTxID, 10000), chain.TxErrFeeTooHigh},
+	} {
+		code, txErr := send(currencies.Bitcoin, currencies.Test, tc.rawTx)
+		if code != http.StatusBadRequest || txErr == nil || txErr.Code != tc.want {
+			t.Errorf("%s: got %d %+v, want %s", tc.name, code, txErr, tc.want)
+		}
+	}
+	if len(h.btcTest.RawTxs()) != 0 {
+		t.Errorf("rejected txs are broadcast: %v", h.btcTest.RawTxs())
+	}
+
+	valid := rawBTCTx(t, prevTxID, 99000)
+	if code, txErr := send(currencies.Bitcoin, currencies.Test, valid); code != http.StatusOK || txErr != nil {
+		t.Errorf("valid tx: got %d %+v", code, txErr)
+	}
+	if raw := h.btcTest.RawTxs(); len(raw) != 1 || raw[0] != valid {
+		t.Errorf("broadcast txs: %v", raw)
+	}
+
+	// litecoin nodes relay outputs ten times bigger than bitcoin ones
+	h.addOutput(currencies.Litecoin, currencies.Main, "send-user", "send-ltc-address", prevTxID, 100000)
+	if code, txErr := send(currencies.Litecoin, currencies.Main, rawBTCTx(t, prevTxID, 90000, 1000)); code != http.StatusBadRequest || txErr == nil || txErr.Code != chain.TxErrDustOutput {
+		t.Errorf("ltc dust: got %d %+v, want %s", code, txErr, chain.TxErrDustOutput)
+	}
+
+	// legacy tx signed for chain 3 with 1 gwei gas price
+	ethTx := "e3" + "80" + "843b9aca00" + "825208" + "94" + strings.Repeat("11", 20) + "80" + "80" + "29" + "01" + "01"
+	if code, txErr := send(currencies.Ether, currencies.ETHTest, ethTx); code != http.StatusBadRequest || txErr == nil || txErr.Code != chain.TxErrWrongNetwork {
+		t.Errorf("eth wrong network: got %d %+v", code, txErr)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2018 Idealnaya rabota LLC
+Licensed under Multy.io license.
+See LICENSE for details
+*/
+package multyback
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Multy-io/Multy-back/currencies"
+	"github.com/Multy-io/Multy-back/store"
+)
+
+func TestDeleteWalletOfUnknownBalance(t *testing.T) {
+	h := newHarness(t, walletUser("delete-user", currencies.Ether, currencies.ETHTest, "0xdelete"))
+	defer h.Close()
+	token := h.login("delete-user")
+	wallets := func() int {
+		user := store.User{}
+		if err := h.userStore.FindUserByID("delete-user", &user); err != nil {
+			t.Fatal(err)
+		}
+		return len(user.Wallets)
+	}
+
+	// the wallet is kept while the node service can't tell its balance
+	h.ethTest.FailBalances(errors.New("node is down"))
+	if w := h.do(http.MethodDelete, "/api/v1/wallet/60/4/0", token, nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("DELETE with unknown balance: %d %s", w.Code, w.Body.String())
+	}
+	if wallets() != 1 {
+		t.Fatalf("wallet of unknown balance is deleted")
+	}
+
+	h.ethTest.FailBalances(nil)
+	h.ethTest.SetBalance("0xdelete", "1", "0")
+	if w := h.do(http.MethodDelete, "/api/v1/wallet/60/4/0", token, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("DELETE with balance: %d %s", w.Code, w.Body.String())
+	}
+
+	h.ethTest.SetBalance("0xdelete", "0", "0")
+	if w := h.do(http.MethodDelete, "/api/v1/wallet/60/4/0", token, nil); w.Code != http.StatusOK {
+		t.Errorf("DELETE with zero balance: %d %s", w.Code, w.Body.String())
+	}
+}